@@ -1,28 +1,106 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// ModeManage creates, updates and deletes the declared AWX resources
+	ModeManage = "Manage"
+	// ModeObserve only reports the state and drift of the declared AWX resources
+	ModeObserve = "Observe"
+
+	// SCMBranchPolicyEnforce reverts branches changed in AWX to the declared one
+	SCMBranchPolicyEnforce = "Enforce"
+	// SCMBranchPolicyIgnore keeps branches changed in AWX, e.g. for a hotfix
+	SCMBranchPolicyIgnore = "Ignore"
+
+	// VariablesMergePolicyReplace replaces the variables in AWX with the
+	// declared ones
+	VariablesMergePolicyReplace = "Replace"
+	// VariablesMergePolicyMerge deep-merges the declared variables over those
+	// in AWX, keeping keys set by other automation
+	VariablesMergePolicyMerge = "Merge"
+)
+
+// RetainedKind is a kind of declared objects, named like its list in the spec
+// +kubebuilder:validation:Enum=credentials;organizations;projects;inventories;jobTemplates;workflowJobTemplates;schedules
+type RetainedKind string
+
+// Kinds of declared objects that can be retained on deletion. Organizations
+// are not deleted, they lose their declared Galaxy credentials. Schedules are
+// the schedules of job templates and the update schedules of projects and
+// inventory sources.
+const (
+	RetainCredentials          RetainedKind = "credentials"
+	RetainOrganizations        RetainedKind = "organizations"
+	RetainProjects             RetainedKind = "projects"
+	RetainInventories          RetainedKind = "inventories"
+	RetainJobTemplates         RetainedKind = "jobTemplates"
+	RetainWorkflowJobTemplates RetainedKind = "workflowJobTemplates"
+	RetainSchedules            RetainedKind = "schedules"
+)
+
 // AWXInstanceSpec defines the desired state of AWXInstance
+// +kubebuilder:validation:XValidation:rule="!has(self.port) || (has(self.hostname) && !self.hostname.matches(':[0-9]+$'))",message="port requires a hostname without a port"
 type AWXInstanceSpec struct {
-	// AdminUser is the AWX admin username
-	// +kubebuilder:validation:Required
-	AdminUser string `json:"adminUser"`
+	// AdminUser is the AWX admin username. Defaults to "admin" when discovered.
+	// +optional
+	AdminUser string `json:"adminUser,omitempty"`
 
-	// AdminPassword is the AWX admin password
-	// +kubebuilder:validation:Required
+	// AdminPassword is the AWX admin password. Required unless discovered.
 	// +kubebuilder:validation:MinLength=5
-	AdminPassword string `json:"adminPassword"`
+	// +optional
+	AdminPassword string `json:"adminPassword,omitempty"`
+
+	// AdminPasswordSecretRef selects a key of a Secret holding the AWX admin
+	// password, instead of AdminPassword. A changed password is verified
+	// against AWX before the operator switches to it.
+	// +optional
+	AdminPasswordSecretRef *corev1.SecretKeySelector `json:"adminPasswordSecretRef,omitempty"`
 
 	// AdminEmail is the AWX admin email
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Format=email
 	AdminEmail string `json:"adminEmail"`
 
-	// Hostname is the hostname to access AWX UI
-	// +kubebuilder:validation:Required
-	Hostname string `json:"hostname"`
+	// AuthMethod selects how the operator authenticates against AWX. Basic sends
+	// the admin credentials with every request, Token exchanges them once for a
+	// short-lived OAuth2 token that is refreshed before it expires.
+	// +kubebuilder:validation:Enum=Basic;Token
+	// +kubebuilder:default=Basic
+	// +optional
+	AuthMethod string `json:"authMethod,omitempty"`
+
+	// Hostname is the hostname to access AWX UI. Required unless discovered.
+	// It may end with a port, e.g. "awx.example.com:8443" or "[fd00::1]:8443",
+	// but holds neither a scheme nor a path, which are set with Protocol and
+	// APIPathPrefix.
+	// +kubebuilder:validation:MaxLength=261
+	// +kubebuilder:validation:XValidation:rule="!self.contains('://')",message="hostname must not contain a scheme, set protocol instead"
+	// +kubebuilder:validation:XValidation:rule="!self.contains('/')",message="hostname must not contain a path, set apiPathPrefix instead"
+	// +kubebuilder:validation:XValidation:rule="!self.contains(':') || (self.startsWith('[') ? self.matches('^[^/]+](:[0-9]{1,5})?$') : self.matches('^[^:]+:[0-9]{1,5}$'))",message="hostname must be a host name, IPv4 address or bracketed IPv6 address, optionally followed by a numeric port"
+	// +kubebuilder:validation:XValidation:rule="!self.matches(':[0-9]{1,5}$') || (int(self.substring(self.lastIndexOf(':') + 1)) >= 1 && int(self.substring(self.lastIndexOf(':') + 1)) <= 65535)",message="hostname port must be between 1 and 65535"
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// Port is the port of Hostname, for hostnames that don't include one.
+	// Defaults to the port of Protocol.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// ServiceRef connects to AWX through the ClusterIP of an in-cluster
+	// Service, resolved at every reconcile, instead of Hostname
+	// +optional
+	ServiceRef *ServiceRef `json:"serviceRef,omitempty"`
+
+	// DiscoverFrom fills in missing connection settings from an AWX deployed by
+	// the upstream awx-operator in the same namespace
+	// +optional
+	DiscoverFrom *DiscoverySpec `json:"discoverFrom,omitempty"`
 
 	// Protocol is the protocol to use for the AWX connection (http or https)
 	// +kubebuilder:validation:Enum=http;https
@@ -30,26 +108,422 @@ type AWXInstanceSpec struct {
 	// +optional
 	Protocol string `json:"protocol,omitempty"`
 
+	// APIPathPrefix is the path of the AWX API relative to the hostname, e.g.
+	// "api/v2" for AWX or "api/controller/v2" for AAP gateway deployments.
+	// When empty, the prefix is detected by probing the known locations.
+	// +optional
+	APIPathPrefix string `json:"apiPathPrefix,omitempty"`
+
+	// Mode selects whether the operator manages the declared AWX resources or
+	// only observes them. In Observe mode the operator reports the actual state
+	// and drift of the resources without writing anything to AWX.
+	// +kubebuilder:validation:Enum=Manage;Observe
+	// +kubebuilder:default=Manage
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// AcceptNotifications lets the notification receiver of the operator
+	// record AWX job notifications addressed to this instance in
+	// status.lastJobs. Notifications for other instances are refused.
+	// +optional
+	AcceptNotifications bool `json:"acceptNotifications,omitempty"`
+
+	// APIRateLimit bounds the rate of the requests sent to AWX for the
+	// instance, e.g. to reconcile a sensitive production AWX more gently than
+	// a lab instance
+	// +optional
+	APIRateLimit *APIRateLimit `json:"apiRateLimit,omitempty"`
+
+	// MaxParallelRequests bounds the requests sent to AWX for the instance at
+	// the same time, including the parallel host requests of inventories
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxParallelRequests int32 `json:"maxParallelRequests,omitempty"`
+
+	// ReconcileTimeouts bounds how long a reconcile of the instance and its
+	// phases may take. Objects left when a deadline passed are reconciled by
+	// the next pass.
+	// +optional
+	ReconcileTimeouts *ReconcileTimeouts `json:"reconcileTimeouts,omitempty"`
+
 	// ExternalInstance indicates this is an existing AWX instance that should be managed but not created
 	// +optional
 	ExternalInstance bool `json:"externalInstance,omitempty"`
 
+	// Cascade deletes the job templates that are not declared but still use a
+	// declared project or inventory when the AWXInstance is deleted. Without
+	// it, the deletion waits until they are removed.
+	// +optional
+	Cascade bool `json:"cascade,omitempty"`
+
+	// RetainOnDeletion lists the kinds of declared objects that are left in
+	// AWX when the AWXInstance is deleted. The kinds the retained objects use
+	// are retained as well, e.g. jobTemplates retains the projects,
+	// inventories and credentials, and schedules retains the job templates,
+	// projects and inventories they belong to.
+	// +optional
+	// +listType=set
+	RetainOnDeletion []RetainedKind `json:"retainOnDeletion,omitempty"`
+
 	// Replicas is the number of AWX workers to deploy
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:default=1
 	Replicas int32 `json:"replicas,omitempty"`
 
+	// TemplateValuesFrom lists ConfigMaps and Secrets in the instance namespace
+	// whose keys are available as ${{ .Values.<key> }} in inventory and host
+	// variables and job template extra vars. Templates are only rendered when
+	// at least one source is configured.
+	// +optional
+	TemplateValuesFrom []TemplateValuesSource `json:"templateValuesFrom,omitempty"`
+
+	// NamingPolicy prefixes and suffixes the AWX names of all declared
+	// objects, so that several clusters can share one AWX with identical
+	// AWXInstances. References between declared objects follow the policy.
+	// +optional
+	NamingPolicy *NamingPolicy `json:"namingPolicy,omitempty"`
+
+	// Targets lists other AWXInstances in the same namespace whose AWX receives
+	// a copy of the resources declared here, e.g. a disaster recovery instance.
+	// The connection settings of the targets are used, their own resources are
+	// not affected.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Targets []InstanceRef `json:"targets,omitempty"`
+
+	// Analytics configures the Automation Analytics settings of AWX, which
+	// upload usage data to Red Hat Insights. The settings are left alone when
+	// not configured.
+	// +optional
+	Analytics *AnalyticsSpec `json:"analytics,omitempty"`
+
+	// MeshInstances registers execution and hop nodes of the receptor mesh
+	// with AWX. Nodes that are not listed are left alone.
+	// +optional
+	// +listType=map
+	// +listMapKey=hostname
+	MeshInstances []MeshInstanceSpec `json:"meshInstances,omitempty"`
+
+	// JobCleanup configures the schedules of the AWX system jobs that delete
+	// old jobs and activity stream entries. The schedules are left alone when
+	// not configured.
+	// +optional
+	JobCleanup *JobCleanupSpec `json:"jobCleanup,omitempty"`
+
+	// HostQuotaWarningPercent is the share of the max hosts of an AWX
+	// organization in use at which the QuotaNearLimit condition and a Warning
+	// Event are raised. Organizations without max hosts are not checked.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=90
+	// +optional
+	HostQuotaWarningPercent int32 `json:"hostQuotaWarningPercent,omitempty"`
+
+	// Organizations configures existing AWX organizations. Organizations are
+	// not created or deleted, and those not listed are left alone.
+	// +optional
+	Organizations []OrganizationSpec `json:"organizations,omitempty"`
+
+	// Credentials defines the AWX credentials to create. They are reconciled
+	// before the projects and job templates that may reference them.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Credentials []CredentialSpec `json:"credentials,omitempty"`
+
 	// Projects defines the AWX projects to create
 	// +optional
+	// +listType=map
+	// +listMapKey=name
 	Projects []ProjectSpec `json:"projects,omitempty"`
 
 	// Inventories defines the AWX inventories to create
 	// +optional
+	// +listType=map
+	// +listMapKey=name
 	Inventories []InventorySpec `json:"inventories,omitempty"`
 
 	// JobTemplates defines the AWX job templates to create
 	// +optional
+	// +listType=map
+	// +listMapKey=name
 	JobTemplates []JobTemplateSpec `json:"jobTemplates,omitempty"`
+
+	// JobTemplateDefaults are merged into every job template that doesn't
+	// set the field itself
+	// +optional
+	JobTemplateDefaults *JobTemplateDefaults `json:"jobTemplateDefaults,omitempty"`
+
+	// WorkflowJobTemplates defines the AWX workflow job templates to create.
+	// They are reconciled after the job templates they run.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	WorkflowJobTemplates []WorkflowJobTemplateSpec `json:"workflowJobTemplates,omitempty"`
+}
+
+// ServiceRef references the Service in front of AWX
+type ServiceRef struct {
+	// Name is the name of the Service
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of the Service, defaults to the namespace of the AWXInstance
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Port of the Service to connect to, defaults to its first port. The
+	// protocol is https for a port named "https" or port 443, http otherwise.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// InstanceRef references another AWXInstance in the same namespace
+type InstanceRef struct {
+	// Name is the name of the AWXInstance
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// DiscoverySpec references an AWX deployed by the upstream awx-operator
+type DiscoverySpec struct {
+	// Name is the name of the upstream AWX resource. The admin password is read
+	// from the "<name>-admin-password" Secret and, when Hostname is empty, the
+	// API is reached through the "<name>-service" Service.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// APIRateLimit is a token bucket limit on the requests sent to AWX
+type APIRateLimit struct {
+	// RequestsPerSecond is the sustained request rate
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	RequestsPerSecond int32 `json:"requestsPerSecond"`
+
+	// Burst is the number of requests sent at once before the rate applies,
+	// RequestsPerSecond when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// ReconcileTimeouts are the deadlines of a reconcile and its phases. Requests
+// to AWX still running at a deadline are canceled.
+type ReconcileTimeouts struct {
+	// TotalSeconds bounds the whole reconcile, the --reconcile-timeout of the
+	// operator when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TotalSeconds int32 `json:"totalSeconds,omitempty"`
+
+	// ConnectionTestSeconds bounds the connection test at the start of a
+	// reconcile
+	// +kubebuilder:default=30
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConnectionTestSeconds int32 `json:"connectionTestSeconds,omitempty"`
+
+	// DriftScanSeconds bounds the check of the declared objects for changes
+	// made in AWX, the remaining total when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DriftScanSeconds int32 `json:"driftScanSeconds,omitempty"`
+
+	// MutationSeconds bounds the creation and update of the declared
+	// objects, the remaining total when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MutationSeconds int32 `json:"mutationSeconds,omitempty"`
+}
+
+// TemplateValuesSource references a ConfigMap or a Secret providing template values
+type TemplateValuesSource struct {
+	// ConfigMapRef references a ConfigMap whose data keys become template values
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// SecretRef references a Secret whose data keys become template values
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// NamingPolicy defines how the AWX names of declared objects are derived from
+// their names in the spec. Prefix and suffix are templates that can use
+// ${{ .ClusterName }}, the cluster name the operator is started with,
+// ${{ .Namespace }} and, with TemplateValuesFrom, ${{ .Values.<key> }}.
+type NamingPolicy struct {
+	// Prefix is prepended to the name of each declared object, e.g.
+	// "${{ .ClusterName }}-"
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Suffix is appended to the name of each declared object
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// AnalyticsSpec defines the Automation Analytics settings of AWX
+type AnalyticsSpec struct {
+	// Enabled gathers data for Automation Analytics and uploads it to Red Hat Insights
+	Enabled bool `json:"enabled"`
+
+	// URL is the Automation Analytics upload URL. AWX uses the Red Hat
+	// console when empty.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// GatherIntervalSeconds is the interval between two data uploads
+	// +kubebuilder:validation:Minimum=1800
+	// +optional
+	GatherIntervalSeconds int32 `json:"gatherIntervalSeconds,omitempty"`
+
+	// CredentialsSecretRef references a Secret with the username and password
+	// keys of the Red Hat customer account used for the upload
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// OrganizationSpec configures an existing AWX organization
+type OrganizationSpec struct {
+	// Name is the name of the organization
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// GalaxyCredentials are the names of the Ansible Galaxy/Automation Hub
+	// credentials the organization installs collections and roles with, in
+	// the order they are tried
+	// +listType=atomic
+	// +optional
+	GalaxyCredentials []string `json:"galaxyCredentials,omitempty"`
+}
+
+// JobCleanupSpec defines how long AWX keeps jobs and activity stream entries
+type JobCleanupSpec struct {
+	// Jobs configures the cleanup of the details and output of jobs
+	// +optional
+	Jobs *CleanupScheduleSpec `json:"jobs,omitempty"`
+
+	// ActivityStream configures the cleanup of the activity stream
+	// +optional
+	ActivityStream *CleanupScheduleSpec `json:"activityStream,omitempty"`
+}
+
+// CleanupScheduleSpec defines when a cleanup system job runs and how much
+// data it keeps
+type CleanupScheduleSpec struct {
+	// RetentionDays is the number of days of data the cleanup keeps
+	// +kubebuilder:validation:Minimum=0
+	RetentionDays int32 `json:"retentionDays"`
+
+	// Recurrence is the iCalendar RRULE without DTSTART and UNTIL,
+	// e.g. "FREQ=WEEKLY;BYDAY=SU;INTERVAL=1"
+	// +kubebuilder:validation:Required
+	Recurrence string `json:"recurrence"`
+
+	// Start is the local date and time of the first run in Timezone, e.g. "2024-01-01T03:00:00"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`
+	Start string `json:"start"`
+
+	// Timezone is the IANA time zone of Start, e.g. "Europe/Berlin"
+	// +kubebuilder:default=UTC
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// Enabled controls whether the cleanup runs
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ReconcileCursor points to the next batch of declared objects to reconcile.
+// Objects are counted in the order they are reconciled: credentials,
+// projects, inventories, job templates and workflow job templates.
+type ReconcileCursor struct {
+	// Position is the index of the next object to reconcile
+	Position int `json:"position"`
+
+	// Total is the number of declared objects
+	Total int `json:"total"`
+
+	// Generation is the spec generation the batches belong to. A changed spec
+	// starts over with the first batch.
+	Generation int64 `json:"generation"`
+}
+
+// RequeueRecord describes why reconciles of the instance were requeued
+type RequeueRecord struct {
+	// Reason is the cause of the requeue, e.g. "DriftCorrected",
+	// "ConnectionFailed" or "StatusConflict"
+	Reason string `json:"reason"`
+
+	// Message gives details of the first requeue with this reason
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Time is when reconciles started to be requeued with this reason
+	Time metav1.Time `json:"time"`
+}
+
+// MeshInstanceSpec defines an execution or hop node of the receptor mesh
+type MeshInstanceSpec struct {
+	// Hostname is the hostname the node is registered and reached with
+	// +kubebuilder:validation:Required
+	Hostname string `json:"hostname"`
+
+	// NodeType is the role of the node in the mesh. It can't be changed
+	// after the node is registered.
+	// +kubebuilder:validation:Enum=execution;hop
+	// +kubebuilder:default=execution
+	// +optional
+	NodeType string `json:"nodeType,omitempty"`
+
+	// ListenerPort is the port receptor listens on for peer connections.
+	// Hop nodes need one.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	ListenerPort int32 `json:"listenerPort,omitempty"`
+
+	// PeersFromControlNodes makes the control nodes connect to this node
+	// +optional
+	PeersFromControlNodes bool `json:"peersFromControlNodes,omitempty"`
+
+	// Peers lists the hostnames of the nodes this node connects to
+	// +optional
+	// +listType=set
+	Peers []string `json:"peers,omitempty"`
+
+	// Enabled lets AWX schedule jobs on the node, defaults to true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// CredentialSpec defines an AWX Credential
+type CredentialSpec struct {
+	// Name is the credential name
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Description of the credential
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Kind is the credential type, given by its namespace (e.g. "ssh", "scm")
+	// or its name (e.g. "Machine", "Source Control")
+	// +kubebuilder:validation:Required
+	Kind string `json:"kind"`
+
+	// Inputs are the non-sensitive inputs of the credential type, e.g. username
+	// +optional
+	Inputs map[string]string `json:"inputs,omitempty"`
+
+	// InputsSecretRef references a Secret whose keys are added to the inputs,
+	// for sensitive inputs such as password or ssh_key_data
+	// +optional
+	InputsSecretRef *corev1.LocalObjectReference `json:"inputsSecretRef,omitempty"`
 }
 
 // ProjectSpec defines an AWX Project
@@ -62,8 +536,9 @@ type ProjectSpec struct {
 	// +optional
 	Description string `json:"description,omitempty"`
 
-	// SCMType is the source control type (git, svn, etc)
-	// +kubebuilder:validation:Enum=git;svn;manual
+	// SCMType is the source control type (git, svn, etc). Insights projects
+	// use SCMCredential as their Red Hat Insights credential.
+	// +kubebuilder:validation:Enum=git;svn;manual;insights
 	// +kubebuilder:default=git
 	SCMType string `json:"scmType,omitempty"`
 
@@ -76,9 +551,32 @@ type ProjectSpec struct {
 	// +kubebuilder:default=main
 	SCMBranch string `json:"scmBranch,omitempty"`
 
+	// SCMBranchPolicy selects whether a branch changed in AWX is reverted to
+	// SCMBranch. With Ignore, SCMBranch is only set when the project is
+	// created, so AWX admins can switch branches temporarily, e.g. for a hotfix.
+	// +kubebuilder:validation:Enum=Enforce;Ignore
+	// +kubebuilder:default=Enforce
+	// +optional
+	SCMBranchPolicy string `json:"scmBranchPolicy,omitempty"`
+
 	// SCMCredential is the name of the credential to use for SCM
 	// +optional
 	SCMCredential string `json:"scmCredential,omitempty"`
+
+	// SCMRefspec is an additional refspec to fetch, e.g. "refs/pull/*:refs/remotes/origin/pull/*"
+	// +optional
+	SCMRefspec string `json:"scmRefspec,omitempty"`
+
+	// SignatureValidationCredential is the name of the GPG public key
+	// credential used to verify the content signature of the project
+	// +optional
+	SignatureValidationCredential string `json:"signatureValidationCredential,omitempty"`
+
+	// UpdateSchedule updates the project from SCM periodically. Launch
+	// overrides don't apply to project updates and are rejected. Without
+	// it, schedules of the project created in AWX are left alone.
+	// +optional
+	UpdateSchedule *ScheduleSpec `json:"updateSchedule,omitempty"`
 }
 
 // InventorySpec defines an AWX Inventory
@@ -95,9 +593,126 @@ type InventorySpec struct {
 	// +optional
 	Variables string `json:"variables,omitempty"`
 
+	// CopyFrom is the name of an existing inventory, including its hosts, to
+	// copy when the inventory is created. The fields in this spec are applied
+	// on top of the copy. Hosts of the copy source are kept in the copy even
+	// when they aren't declared in Hosts.
+	// +optional
+	CopyFrom string `json:"copyFrom,omitempty"`
+
 	// Hosts defines the hosts in this inventory
 	// +optional
+	// +listType=map
+	// +listMapKey=name
 	Hosts []HostSpec `json:"hosts,omitempty"`
+
+	// HostDefaults are merged into each host of the inventory
+	// +optional
+	HostDefaults *HostDefaults `json:"hostDefaults,omitempty"`
+
+	// VariablesMergePolicy selects how the variables of the inventory and its
+	// hosts are written. Replace writes the declared variables as the whole
+	// document. Merge deep-merges them over the variables in AWX, so keys set
+	// by other automation are kept.
+	// +kubebuilder:validation:Enum=Replace;Merge
+	// +kubebuilder:default=Replace
+	// +optional
+	VariablesMergePolicy string `json:"variablesMergePolicy,omitempty"`
+
+	// MaxHostDeletionPercent is the largest share of the existing hosts that a
+	// single reconcile may delete before the operator refuses and reports the
+	// inventory as Degraded. Deleting up to five hosts is always allowed.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=50
+	// +optional
+	MaxHostDeletionPercent int32 `json:"maxHostDeletionPercent,omitempty"`
+
+	// AllowMassDeletion acknowledges that more hosts than MaxHostDeletionPercent
+	// may be deleted from the inventory
+	// +optional
+	AllowMassDeletion bool `json:"allowMassDeletion,omitempty"`
+
+	// Facts publishes selected Ansible facts of the declared hosts, as
+	// gathered by AWX jobs, in status.inventoryFacts
+	// +optional
+	Facts *HostFactsSpec `json:"facts,omitempty"`
+
+	// Sources are the dynamic inventory sources that fill the inventory with
+	// hosts, e.g. from a cloud provider or an inventory file in a project.
+	// Sources of the inventory that are not listed are removed when at least
+	// one is declared.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Sources []InventorySourceSpec `json:"sources,omitempty"`
+}
+
+// InventorySourceSpec defines a dynamic inventory source
+// +kubebuilder:validation:XValidation:rule="self.source != 'scm' || has(self.sourceProject)",message="sourceProject is required for scm sources"
+type InventorySourceSpec struct {
+	// Name is the inventory source name
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Description of the inventory source
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Source is the kind of source, e.g. "scm" for an inventory file in a
+	// project or a cloud provider such as "ec2", "azure_rm" or "gce"
+	// +kubebuilder:validation:Required
+	Source string `json:"source"`
+
+	// SourceProject is the project holding the inventory file of scm sources
+	// +optional
+	SourceProject string `json:"sourceProject,omitempty"`
+
+	// SourcePath is the path of the inventory file in the project
+	// +optional
+	SourcePath string `json:"sourcePath,omitempty"`
+
+	// Credential is the name of the cloud credential of the source
+	// +optional
+	Credential string `json:"credential,omitempty"`
+
+	// SourceVars are the variables of the inventory plugin in YAML format
+	// +optional
+	SourceVars string `json:"sourceVars,omitempty"`
+
+	// Overwrite removes the hosts and groups the source no longer reports
+	// +optional
+	Overwrite bool `json:"overwrite,omitempty"`
+
+	// OverwriteVars replaces the variables of hosts and groups with those
+	// reported by the source instead of merging them
+	// +optional
+	OverwriteVars bool `json:"overwriteVars,omitempty"`
+
+	// UpdateOnLaunch updates the source before jobs using the inventory run
+	// +optional
+	UpdateOnLaunch bool `json:"updateOnLaunch,omitempty"`
+
+	// UpdateCacheTimeout is how many seconds an update is current, so jobs
+	// launched within that time don't update the source again
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	UpdateCacheTimeout int32 `json:"updateCacheTimeout,omitempty"`
+
+	// UpdateSchedule updates the source periodically. Launch overrides don't
+	// apply to inventory updates and are rejected.
+	// +optional
+	UpdateSchedule *ScheduleSpec `json:"updateSchedule,omitempty"`
+}
+
+// HostFactsSpec configures how often the facts of the hosts of an inventory
+// are read from AWX
+type HostFactsSpec struct {
+	// IntervalSeconds is the interval between two collections
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:default=3600
+	// +optional
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
 }
 
 // HostSpec defines a host in an inventory
@@ -115,6 +730,21 @@ type HostSpec struct {
 	Variables string `json:"variables,omitempty"`
 }
 
+// HostDefaults are shared by the hosts of an inventory. Fields a host sets
+// itself take precedence.
+type HostDefaults struct {
+	// Description is the description of hosts that declare none. It is a Go
+	// template with the host name as .Name and the inventory name as
+	// .Inventory, e.g. "Web server {{ .Name }}".
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Variables are host variables in YAML format shared by all hosts. They
+	// are merged with the variables of each host, whose own keys win.
+	// +optional
+	Variables string `json:"variables,omitempty"`
+}
+
 // JobTemplateSpec defines an AWX Job Template
 type JobTemplateSpec struct {
 	// Name is the job template name
@@ -137,17 +767,399 @@ type JobTemplateSpec struct {
 	// +kubebuilder:validation:Required
 	Playbook string `json:"playbook"`
 
+	// ValidatePlaybook verifies that the playbook is available in the project
+	// before the job template is created or updated
+	// +optional
+	ValidatePlaybook bool `json:"validatePlaybook,omitempty"`
+
 	// ExtraVars is the extra variables for the job template in YAML format
 	// +optional
 	ExtraVars string `json:"extraVars,omitempty"`
+
+	// CopyFrom is the name of an existing job template to copy when the job
+	// template is created. The fields in this spec are applied on top of the copy.
+	// +optional
+	CopyFrom string `json:"copyFrom,omitempty"`
+
+	// JobTags is a comma-separated list of playbook tags to run
+	// +optional
+	JobTags string `json:"jobTags,omitempty"`
+
+	// SkipTags is a comma-separated list of playbook tags to skip
+	// +optional
+	SkipTags string `json:"skipTags,omitempty"`
+
+	// AskLimitOnLaunch prompts for the host limit when the job template is launched
+	// +optional
+	AskLimitOnLaunch bool `json:"askLimitOnLaunch,omitempty"`
+
+	// AskInventoryOnLaunch prompts for the inventory when the job template is launched
+	// +optional
+	AskInventoryOnLaunch bool `json:"askInventoryOnLaunch,omitempty"`
+
+	// AskCredentialOnLaunch prompts for credentials when the job template is launched
+	// +optional
+	AskCredentialOnLaunch bool `json:"askCredentialOnLaunch,omitempty"`
+
+	// AskVariablesOnLaunch prompts for extra variables when the job template is launched
+	// +optional
+	AskVariablesOnLaunch bool `json:"askVariablesOnLaunch,omitempty"`
+
+	// AskVerbosityOnLaunch prompts for the verbosity when the job template is launched
+	// +optional
+	AskVerbosityOnLaunch bool `json:"askVerbosityOnLaunch,omitempty"`
+
+	// AskTagsOnLaunch prompts for job tags when the job template is launched
+	// +optional
+	AskTagsOnLaunch bool `json:"askTagsOnLaunch,omitempty"`
+
+	// AskDiffModeOnLaunch prompts for the diff mode when the job template is launched
+	// +optional
+	AskDiffModeOnLaunch bool `json:"askDiffModeOnLaunch,omitempty"`
+
+	// Forks is the number of parallel processes used by the playbook run.
+	// Zero uses the Ansible default of 5.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Forks int32 `json:"forks,omitempty"`
+
+	// JobSliceCount splits the job into this many slices that run in parallel
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	JobSliceCount int32 `json:"jobSliceCount,omitempty"`
+
+	// ExecutionEnvironment is the name of the execution environment the job
+	// template runs in. When empty, AWX uses the default environment of the
+	// project or organization.
+	// +optional
+	ExecutionEnvironment string `json:"executionEnvironment,omitempty"`
+
+	// Verbosity of the playbook run, from 0 (normal) to 5 (WinRM debug)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// Timeout cancels jobs running longer than this many seconds, 0 never
+	// cancels them. The timeout of the job template is left alone when this
+	// is not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout *int32 `json:"timeout,omitempty"`
+
+	// InstanceGroups are the names of the instance groups the job template
+	// runs on, in order of preference. The instance groups of the job
+	// template are left alone when none are listed.
+	// +listType=atomic
+	// +optional
+	InstanceGroups []string `json:"instanceGroups,omitempty"`
+
+	// BecomeEnabled runs the playbook with privilege escalation
+	// +optional
+	BecomeEnabled bool `json:"becomeEnabled,omitempty"`
+
+	// Credentials are the names of the credentials attached to the job
+	// template, e.g. a machine credential with become_method and
+	// become_password for privilege escalation. Credentials that are not
+	// listed are detached when at least one is declared.
+	// +listType=set
+	// +optional
+	Credentials []string `json:"credentials,omitempty"`
+
+	// Schedules launch the job template periodically. Schedules of the job
+	// template that are not listed are removed when at least one is declared.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Schedules []ScheduleSpec `json:"schedules,omitempty"`
+
+	// Survey prompts for extra variables when the job template is launched.
+	// The survey of the job template is left alone when this is not set.
+	// +optional
+	Survey *SurveySpec `json:"survey,omitempty"`
+
+	// WaitFor lists the syncs the job template waits for before it is Ready
+	// +optional
+	WaitFor *WaitForSpec `json:"waitFor,omitempty"`
+}
+
+// JobTemplateDefaults are the instance level defaults of job templates
+type JobTemplateDefaults struct {
+	// Verbosity of the playbook run, from 0 (normal) to 5 (WinRM debug)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// ExecutionEnvironment is the name of the execution environment job
+	// templates run in
+	// +optional
+	ExecutionEnvironment string `json:"executionEnvironment,omitempty"`
+
+	// InstanceGroups are the names of the instance groups job templates run
+	// on, in order of preference. Job templates listing an empty list don't
+	// inherit them.
+	// +listType=atomic
+	// +optional
+	InstanceGroups []string `json:"instanceGroups,omitempty"`
+
+	// Timeout cancels jobs running longer than this many seconds, 0 never
+	// cancels them
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout *int32 `json:"timeout,omitempty"`
+}
+
+// WaitForSpec defines the syncs of its dependencies a job template waits for
+type WaitForSpec struct {
+	// ProjectSync requires the last sync of the project to have succeeded
+	// +optional
+	ProjectSync bool `json:"projectSync,omitempty"`
+
+	// InventorySources requires the last update of every source of the
+	// inventory to have succeeded
+	// +optional
+	InventorySources bool `json:"inventorySources,omitempty"`
+}
+
+// SurveySpec defines the survey of a job template
+type SurveySpec struct {
+	// Enabled shows the survey when the job template is launched, defaults to true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Name of the survey
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Description of the survey
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Questions are asked in the listed order
+	// +listType=map
+	// +listMapKey=variable
+	// +optional
+	Questions []SurveyQuestionSpec `json:"questions,omitempty"`
+}
+
+// SurveyQuestionSpec defines a question of a survey
+type SurveyQuestionSpec struct {
+	// Variable is the extra variable the answer is stored in
+	// +kubebuilder:validation:Required
+	Variable string `json:"variable"`
+
+	// Question is the text shown for the question
+	// +kubebuilder:validation:Required
+	Question string `json:"question"`
+
+	// Description is shown below the question
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Type of the answer
+	// +kubebuilder:validation:Enum=text;textarea;password;integer;float;multiplechoice;multiselect
+	// +kubebuilder:default=text
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Required makes an answer mandatory
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// Default is the default answer. Password questions take their default
+	// from DefaultSecretRef instead.
+	// +optional
+	Default string `json:"default,omitempty"`
+
+	// DefaultSecretRef selects a key of a Secret holding the default answer
+	// of a password question
+	// +optional
+	DefaultSecretRef *corev1.SecretKeySelector `json:"defaultSecretRef,omitempty"`
+
+	// Choices are the answers of multiplechoice and multiselect questions
+	// +listType=atomic
+	// +optional
+	Choices []string `json:"choices,omitempty"`
+
+	// Min is the minimum value of numbers or the minimum length of text
+	// +optional
+	Min *int32 `json:"min,omitempty"`
+
+	// Max is the maximum value of numbers or the maximum length of text
+	// +optional
+	Max *int32 `json:"max,omitempty"`
+}
+
+// ScheduleSpec defines a schedule of a job template, project or inventory source
+type ScheduleSpec struct {
+	// Name is the schedule name
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Description of the schedule
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Recurrence is the iCalendar RRULE without DTSTART and UNTIL,
+	// e.g. "FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=1"
+	// +kubebuilder:validation:Required
+	Recurrence string `json:"recurrence"`
+
+	// Start is the local date and time of the first run in Timezone, e.g. "2024-01-01T09:00:00"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`
+	Start string `json:"start"`
+
+	// End is the local date and time after which the schedule no longer runs
+	// +kubebuilder:validation:Pattern=`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`
+	// +optional
+	End string `json:"end,omitempty"`
+
+	// Timezone is the IANA time zone of Start and End, e.g. "Europe/Berlin"
+	// +kubebuilder:default=UTC
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// Enabled controls whether the schedule launches jobs
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Verbosity overrides the verbosity of the jobs the schedule launches,
+	// from 0 (normal) to 5 (WinRM debug). Requires askVerbosityOnLaunch on
+	// the job template.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// DiffMode overrides whether the jobs the schedule launches show the
+	// changes made by tasks. Requires askDiffModeOnLaunch on the job template.
+	// +optional
+	DiffMode *bool `json:"diffMode,omitempty"`
+}
+
+// WorkflowJobTemplateSpec defines an AWX workflow job template
+type WorkflowJobTemplateSpec struct {
+	// Name is the workflow job template name
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Description of the workflow job template
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Nodes are the steps of the workflow. Nodes are matched with AWX by their
+	// identifier, so reordering them does not recreate them. Nodes that are
+	// not the child of another node start the workflow.
+	// +optional
+	// +listType=map
+	// +listMapKey=identifier
+	Nodes []WorkflowNodeSpec `json:"nodes,omitempty"`
+
+	// Survey prompts for extra variables when the workflow is launched. The
+	// survey of the workflow job template is left alone when this is not set.
+	// +optional
+	Survey *SurveySpec `json:"survey,omitempty"`
+
+	// Webhook lets GitHub or GitLab launch the workflow. The receiver URL and
+	// the key the webhooks are signed with are published in a Secret.
+	// +optional
+	Webhook *WebhookSpec `json:"webhook,omitempty"`
+}
+
+// WebhookSpec configures the webhook that launches a template
+type WebhookSpec struct {
+	// Service is the service sending the webhooks
+	// +kubebuilder:validation:Enum=github;gitlab
+	// +kubebuilder:validation:Required
+	Service string `json:"service"`
+
+	// CredentialName is the name of a GitHub or GitLab personal access token
+	// credential AWX uses to report the status of launched jobs back to the
+	// commit
+	// +optional
+	CredentialName string `json:"credentialName,omitempty"`
+}
+
+// WorkflowNodeSpec defines a node of a workflow job template
+type WorkflowNodeSpec struct {
+	// Identifier uniquely identifies the node within the workflow
+	// +kubebuilder:validation:Required
+	Identifier string `json:"identifier"`
+
+	// JobTemplateName is the name of the job template the node runs
+	// +kubebuilder:validation:Required
+	JobTemplateName string `json:"jobTemplateName"`
+
+	// SuccessNodes are the identifiers of the nodes run when this node succeeds
+	// +optional
+	SuccessNodes []string `json:"successNodes,omitempty"`
+
+	// FailureNodes are the identifiers of the nodes run when this node fails
+	// +optional
+	FailureNodes []string `json:"failureNodes,omitempty"`
+
+	// AlwaysNodes are the identifiers of the nodes run when this node finishes
+	// +optional
+	AlwaysNodes []string `json:"alwaysNodes,omitempty"`
+
+	// AllParentsMustConverge makes a node with several parents run only once
+	// all of them have finished with the outcome leading to it, instead of
+	// after the first one
+	// +optional
+	AllParentsMustConverge bool `json:"allParentsMustConverge,omitempty"`
 }
 
 // AWXInstanceStatus defines the observed state of AWXInstance
 type AWXInstanceStatus struct {
-	// Conditions represent the latest available observations of the AWXInstance's state
+	// ObservedGeneration is the generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// SchemaVersion is the version of the status layout. An operator upgrade
+	// migrates the status of older versions in place.
+	// +optional
+	SchemaVersion int32 `json:"schemaVersion,omitempty"`
+
+	// ReconcileCursor records the progress of a spec that is reconciled in
+	// batches. It is cleared once all declared objects were reconciled.
+	// +optional
+	ReconcileCursor *ReconcileCursor `json:"reconcileCursor,omitempty"`
+
+	// LastRequeueReason is why the last reconcile was requeued, e.g.
+	// "DriftCorrected", "ConnectionFailed", "StatusConflict" or "Resync" for
+	// the periodic check of an instance in sync
+	// +optional
+	LastRequeueReason string `json:"lastRequeueReason,omitempty"`
+
+	// RequeueHistory lists the last requeue reasons, oldest first. A reason is
+	// only added when it differs from the previous one, so an instance that
+	// alternates between two reasons is looping.
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	RequeueHistory []RequeueRecord `json:"requeueHistory,omitempty"`
+
+	// Phase is the readiness of an AWX deployed in the cluster: Provisioning
+	// until its web pods are ready and the API answers, Migrating while its
+	// database migrations run and Ready once /ping reports its version.
+	// Resources are only reconciled in the Ready phase.
+	// +kubebuilder:validation:Enum=Provisioning;Migrating;Ready
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions represent the latest available observations of the AWXInstance's state.
+	// Ready aggregates the CredentialsSynced, ProjectsSynced, InventoriesSynced, JobTemplatesSynced and
+	// WorkflowJobTemplatesSynced conditions.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
+	// CredentialStatuses contains the reconciliation status of each credential
+	// +optional
+	CredentialStatuses map[string]string `json:"credentialStatuses,omitempty"`
+
 	// ProjectStatuses contains the reconciliation status of each project
 	// +optional
 	ProjectStatuses map[string]string `json:"projectStatuses,omitempty"`
@@ -160,6 +1172,55 @@ type AWXInstanceStatus struct {
 	// +optional
 	JobTemplateStatuses map[string]string `json:"jobTemplateStatuses,omitempty"`
 
+	// WorkflowJobTemplateStatuses contains the reconciliation status of each workflow job template
+	// +optional
+	WorkflowJobTemplateStatuses map[string]string `json:"workflowJobTemplateStatuses,omitempty"`
+
+	// JobTemplateExecutionEnvironments contains the execution environment each
+	// job template effectively runs in and where it is inherited from,
+	// e.g. "organization: AWX EE (latest)"
+	// +optional
+	JobTemplateExecutionEnvironments map[string]string `json:"jobTemplateExecutionEnvironments,omitempty"`
+
+	// ObjectIDs contains the AWX IDs of the reconciled objects keyed by
+	// "<endpoint>/<name>", e.g. "projects/web". They allow renaming an object
+	// in the spec without creating a new AWX object.
+	// +optional
+	ObjectIDs map[string]int `json:"objectIDs,omitempty"`
+
+	// SpecHashes contains a keyed hash of the spec each object was last
+	// reconciled from, keyed like ObjectIDs. Objects whose hash is unchanged are not read
+	// from AWX again until the next inventory resync.
+	// +optional
+	SpecHashes map[string]string `json:"specHashes,omitempty"`
+
+	// TargetStatuses contains the reconciliation status of the resources on each target
+	// +optional
+	TargetStatuses map[string]string `json:"targetStatuses,omitempty"`
+
+	// AnalyticsStatus contains the reconciliation status of the Automation Analytics settings
+	// +optional
+	AnalyticsStatus string `json:"analyticsStatus,omitempty"`
+
+	// JobCleanupStatus contains the reconciliation status of the cleanup schedules
+	// +optional
+	JobCleanupStatus string `json:"jobCleanupStatus,omitempty"`
+
+	// OrganizationsStatus contains the reconciliation status of the organizations
+	// +optional
+	OrganizationsStatus string `json:"organizationsStatus,omitempty"`
+
+	// MeshInstanceStatuses contains the health of each mesh instance as
+	// reported by AWX, e.g. "ready" or "unavailable: <errors>", or why it
+	// couldn't be registered
+	// +optional
+	MeshInstanceStatuses map[string]string `json:"meshInstanceStatuses,omitempty"`
+
+	// LastJobs contains the last job of each declared job template and
+	// workflow job template as reported by AWX notifications
+	// +optional
+	LastJobs map[string]JobRunStatus `json:"lastJobs,omitempty"`
+
 	// LastConnectionCheck is the timestamp of the last connection check
 	// +optional
 	LastConnectionCheck metav1.Time `json:"lastConnectionCheck,omitempty"`
@@ -167,11 +1228,164 @@ type AWXInstanceStatus struct {
 	// ConnectionStatus represents the current connection status to the AWX instance
 	// +optional
 	ConnectionStatus string `json:"connectionStatus,omitempty"`
+
+	// TemplateValuesHash is a keyed hash of the template values used in the last reconcile
+	// +optional
+	TemplateValuesHash string `json:"templateValuesHash,omitempty"`
+
+	// APIPathPrefix is the API path prefix detected for the AWX instance
+	// +optional
+	APIPathPrefix string `json:"apiPathPrefix,omitempty"`
+
+	// BaseURL is the AWX URL the operator reconciles through. A changed
+	// hostname, port or protocol is only switched to once AWX answers on it.
+	// +optional
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// LastHostnameCutover records the last switch to a changed AWX URL
+	// +optional
+	LastHostnameCutover *HostnameCutover `json:"lastHostnameCutover,omitempty"`
+
+	// License is the subscription status reported by the AWX instance
+	// +optional
+	License *LicenseStatus `json:"license,omitempty"`
+
+	// TLS describes the TLS connection last negotiated with AWX
+	// +optional
+	TLS *TLSStatus `json:"tls,omitempty"`
+
+	// SessionTokenIDs are the IDs of the AWX session tokens the operator
+	// created for the instance. Tokens no longer in use are revoked, and all
+	// of them when the instance is deleted.
+	// +optional
+	SessionTokenIDs []int `json:"sessionTokenIDs,omitempty"`
+
+	// AdminPasswordRotatedAt is when the operator last switched to a changed
+	// admin password after verifying it against AWX
+	// +optional
+	AdminPasswordRotatedAt *metav1.Time `json:"adminPasswordRotatedAt,omitempty"`
+
+	// InventoryFacts contains the facts of the hosts of the inventories with
+	// facts enabled, keyed by inventory name
+	// +optional
+	InventoryFacts map[string]InventoryFactsStatus `json:"inventoryFacts,omitempty"`
+
+	// WebhookSecrets contains the name of the Secret with the webhook URL and
+	// key of each workflow job template with a webhook
+	// +optional
+	WebhookSecrets map[string]string `json:"webhookSecrets,omitempty"`
+}
+
+// InventoryFactsStatus contains the facts of the hosts of an inventory
+type InventoryFactsStatus struct {
+	// CollectedAt is when the facts were last read from AWX
+	CollectedAt metav1.Time `json:"collectedAt"`
+
+	// Hosts contains the facts per host. Hosts for which AWX never gathered
+	// facts are left out.
+	// +optional
+	Hosts map[string]HostFactsStatus `json:"hosts,omitempty"`
+
+	// Message explains why the facts couldn't be read
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// HostFactsStatus contains selected Ansible facts of a host
+type HostFactsStatus struct {
+	// OS is the distribution and its version, e.g. "Ubuntu 22.04"
+	// +optional
+	OS string `json:"os,omitempty"`
+
+	// Kernel is the kernel release
+	// +optional
+	Kernel string `json:"kernel,omitempty"`
+
+	// Architecture is the machine architecture, e.g. "x86_64"
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// Addresses are the IPv4 and IPv6 addresses of the host
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// GatheredAt is when AWX last stored facts of the host
+	// +optional
+	GatheredAt *metav1.Time `json:"gatheredAt,omitempty"`
+}
+
+// JobRunStatus describes a job reported by an AWX notification
+type JobRunStatus struct {
+	// JobID is the ID of the job in AWX
+	JobID int `json:"jobID"`
+
+	// Status is the job status, e.g. "successful", "failed" or "running"
+	Status string `json:"status"`
+
+	// Finished is when the job finished, unset while it runs
+	// +optional
+	Finished *metav1.Time `json:"finished,omitempty"`
+
+	// URL links to the job in the AWX UI
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// HostnameCutover describes a switch of the operator to a changed AWX URL
+type HostnameCutover struct {
+	// From is the URL the operator reconciled through before
+	From string `json:"from"`
+
+	// To is the URL the operator switched to
+	To string `json:"to"`
+
+	// Time is when the new URL was verified and switched to
+	Time metav1.Time `json:"time"`
+}
+
+// LicenseStatus describes the subscription of an AWX instance
+type LicenseStatus struct {
+	// Type is the license type, e.g. "open" for AWX or "enterprise" for AAP
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Compliant reports whether the managed hosts are within the subscription
+	Compliant bool `json:"compliant"`
+
+	// ExpiresAt is the expiry date of the subscription, unset if it never expires
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// HostsUsed is the number of hosts counted against the subscription
+	// +optional
+	HostsUsed int32 `json:"hostsUsed,omitempty"`
+
+	// HostLimit is the number of hosts allowed by the subscription
+	// +optional
+	HostLimit int32 `json:"hostLimit,omitempty"`
+}
+
+// TLSStatus describes the TLS connection negotiated with AWX
+type TLSStatus struct {
+	// Version is the negotiated TLS version, e.g. "TLS 1.3"
+	Version string `json:"version"`
+
+	// CipherSuite is the negotiated cipher suite
+	CipherSuite string `json:"cipherSuite"`
+
+	// Restricted reports whether the operator restricts the TLS version or
+	// cipher suites with --awx-tls-min-version or --awx-tls-cipher-suites
+	Restricted bool `json:"restricted"`
+
+	// FIPSApproved reports whether the negotiated cipher suite is approved
+	// for FIPS 140
+	FIPSApproved bool `json:"fipsApproved"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Hostname",type="string",JSONPath=".spec.hostname"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 //+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
@@ -0,0 +1,197 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Phases of an AWXJob
+	JobPending    = "Pending"
+	JobRunning    = "Running"
+	JobSuccessful = "Successful"
+	JobFailed     = "Failed"
+)
+
+// AWXJobSpec requests a single launch of an AWX job template
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable, create a new AWXJob to launch again"
+type AWXJobSpec struct {
+	// InstanceRef references the AWXInstance in the same namespace whose AWX
+	// holds the job template
+	// +kubebuilder:validation:Required
+	InstanceRef InstanceRef `json:"instanceRef"`
+
+	// JobTemplate is the name of the AWX job template to launch
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	JobTemplate string `json:"jobTemplate"`
+
+	// CredentialPasswords supply the passwords of the credentials of the job
+	// template that prompt for them on launch. They are only sent to the
+	// launch endpoint of AWX.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	CredentialPasswords []CredentialPasswordSource `json:"credentialPasswords,omitempty"`
+
+	// SurveyAnswers answer questions of the survey of the job template from
+	// Secrets, e.g. those of password type. They are only sent to the launch
+	// endpoint of AWX. Requires surveyEnabled on the job template.
+	// +listType=map
+	// +listMapKey=variable
+	// +optional
+	SurveyAnswers []SurveyAnswerSource `json:"surveyAnswers,omitempty"`
+
+	// Verbosity overrides the verbosity of the job, from 0 (normal) to 5
+	// (WinRM debug). Requires askVerbosityOnLaunch on the job template.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// DiffMode overrides whether the job shows the changes made by tasks.
+	// Requires askDiffModeOnLaunch on the job template.
+	// +optional
+	DiffMode *bool `json:"diffMode,omitempty"`
+
+	// JobTags overrides the comma-separated list of playbook tags to run.
+	// Requires askTagsOnLaunch on the job template.
+	// +optional
+	JobTags *string `json:"jobTags,omitempty"`
+
+	// SkipTags overrides the comma-separated list of playbook tags to skip.
+	// Requires ask_skip_tags_on_launch on the job template in AWX.
+	// +optional
+	SkipTags *string `json:"skipTags,omitempty"`
+
+	// TTLSecondsAfterFinished deletes the AWXJob this many seconds after the
+	// job finished. It is kept when unset.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// CredentialPasswordSource selects the Secret key holding a password AWX asks
+// for on launch
+type CredentialPasswordSource struct {
+	// Name is the password as listed in passwords_needed_to_start of the
+	// job template, e.g. "ssh_password", "become_password", "ssh_key_unlock"
+	// or "vault_password.<vault id>"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// SecretKeyRef selects the key of a Secret holding the password
+	// +kubebuilder:validation:Required
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
+}
+
+// SurveyAnswerSource selects the Secret key holding the answer to a survey
+// question
+type SurveyAnswerSource struct {
+	// Variable is the variable of the survey question
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Variable string `json:"variable"`
+
+	// SecretKeyRef selects the key of a Secret holding the answer
+	// +kubebuilder:validation:Required
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
+}
+
+// JobLaunchParameters are the launch parameters a job ran with, either
+// overridden by the AWXJob or taken from the job template
+type JobLaunchParameters struct {
+	// Verbosity of the job, from 0 (normal) to 5 (WinRM debug)
+	Verbosity int32 `json:"verbosity"`
+
+	// DiffMode reports whether the job shows the changes made by tasks
+	DiffMode bool `json:"diffMode"`
+
+	// JobTags are the playbook tags the job runs
+	// +optional
+	JobTags string `json:"jobTags,omitempty"`
+
+	// SkipTags are the playbook tags the job skips
+	// +optional
+	SkipTags string `json:"skipTags,omitempty"`
+}
+
+// AWXJobStatus reports the progress of the job. The credential passwords and
+// survey answers are never recorded.
+type AWXJobStatus struct {
+	// Phase is Pending until the job is launched in AWX, Running while it
+	// runs, and Successful or Failed once it finished
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// JobID is the ID of the job in AWX
+	// +optional
+	JobID int `json:"jobID,omitempty"`
+
+	// LaunchRequestedAt is when the launch was requested. It is recorded
+	// before the launch, so a job launched without its ID being recorded is
+	// found again instead of being launched twice.
+	// +optional
+	LaunchRequestedAt *metav1.Time `json:"launchRequestedAt,omitempty"`
+
+	// AWXStatus is the status of the job reported by AWX, e.g. "running" or
+	// "canceled"
+	// +optional
+	AWXStatus string `json:"awxStatus,omitempty"`
+
+	// EffectiveLaunch records the launch parameters of the job as reported
+	// by AWX
+	// +optional
+	EffectiveLaunch *JobLaunchParameters `json:"effectiveLaunch,omitempty"`
+
+	// StartedAt is when the job started in AWX
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// FinishedAt is when the job finished in AWX
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// Stdout is the end of the output of the job
+	// +optional
+	Stdout string `json:"stdout,omitempty"`
+
+	// StdoutRef references the complete output of the job kept by the
+	// artifact store of the operator, e.g. "configmap/<name>-job-stdout"
+	// +optional
+	StdoutRef string `json:"stdoutRef,omitempty"`
+
+	// Message explains the phase, e.g. why the job could not be launched yet
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Job Template",type="string",JSONPath=".spec.jobTemplate"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// AWXJob is the Schema for the awxjobs API. It launches an AWX job template
+// once, e.g. to run a playbook whose credentials prompt for passwords.
+type AWXJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWXJobSpec   `json:"spec,omitempty"`
+	Status AWXJobStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AWXJobList contains a list of AWXJob
+type AWXJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWXJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AWXJob{}, &AWXJobList{})
+}
@@ -0,0 +1,100 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Phases of an AWXProjectSync
+	ProjectSyncPending    = "Pending"
+	ProjectSyncRunning    = "Running"
+	ProjectSyncSuccessful = "Successful"
+	ProjectSyncFailed     = "Failed"
+)
+
+// AWXProjectSyncSpec requests a single sync of an AWX project
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable, create a new AWXProjectSync to sync again"
+type AWXProjectSyncSpec struct {
+	// InstanceRef references the AWXInstance in the same namespace whose AWX
+	// holds the project
+	// +kubebuilder:validation:Required
+	InstanceRef InstanceRef `json:"instanceRef"`
+
+	// Project is the name of the AWX project to sync
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Project string `json:"project"`
+
+	// TTLSecondsAfterFinished deletes the AWXProjectSync this many seconds
+	// after the sync finished. It is kept when unset.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// AWXProjectSyncStatus reports the progress of the sync
+type AWXProjectSyncStatus struct {
+	// Phase is Pending until the sync is started in AWX, Running while it
+	// runs, and Successful or Failed once it finished
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// ProjectUpdateID is the ID of the project update in AWX
+	// +optional
+	ProjectUpdateID int `json:"projectUpdateID,omitempty"`
+
+	// AWXStatus is the status of the project update reported by AWX, e.g.
+	// "running" or "canceled"
+	// +optional
+	AWXStatus string `json:"awxStatus,omitempty"`
+
+	// StartedAt is when the project update started in AWX
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// FinishedAt is when the project update finished in AWX
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// Stdout is the end of the output of the project update
+	// +optional
+	Stdout string `json:"stdout,omitempty"`
+
+	// StdoutRef references the complete output of the project update kept
+	// by the artifact store of the operator, e.g. "configmap/<name>"
+	// +optional
+	StdoutRef string `json:"stdoutRef,omitempty"`
+
+	// Message explains the phase, e.g. why the sync could not be started yet
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Project",type="string",JSONPath=".spec.project"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// AWXProjectSync is the Schema for the awxprojectsyncs API. It syncs an AWX
+// project once, e.g. to pull a new commit from a GitOps repository.
+type AWXProjectSync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWXProjectSyncSpec   `json:"spec,omitempty"`
+	Status AWXProjectSyncStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AWXProjectSyncList contains a list of AWXProjectSync
+type AWXProjectSyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWXProjectSync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AWXProjectSync{}, &AWXProjectSyncList{})
+}
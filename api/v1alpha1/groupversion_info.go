@@ -20,8 +20,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"sigs.k8s.io/controller-runtime/pkg/scheme"
 )
 
 var (
@@ -29,8 +30,31 @@ var (
 	GroupVersion = schema.GroupVersion{Group: "awx.ansible.com", Version: "v1alpha1"}
 
 	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
-	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+	SchemeBuilder = &schemeBuilder{GroupVersion: GroupVersion}
 
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
 )
+
+// schemeBuilder registers the types of a group-version with a scheme. It does
+// what the controller-runtime scheme builder does with apimachinery alone, so
+// that pkg/awx can import this package without pulling in controller-runtime.
+type schemeBuilder struct {
+	GroupVersion schema.GroupVersion
+	runtime.SchemeBuilder
+}
+
+// Register adds the objects to the types registered with the group-version
+func (b *schemeBuilder) Register(objects ...runtime.Object) *schemeBuilder {
+	b.SchemeBuilder.Register(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(b.GroupVersion, objects...)
+		metav1.AddToGroupVersion(scheme, b.GroupVersion)
+		return nil
+	})
+	return b
+}
+
+// AddToScheme adds all the registered types to the scheme
+func (b *schemeBuilder) AddToScheme(scheme *runtime.Scheme) error {
+	return b.SchemeBuilder.AddToScheme(scheme)
+}
@@ -22,10 +22,26 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIRateLimit) DeepCopyInto(out *APIRateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIRateLimit.
+func (in *APIRateLimit) DeepCopy() *APIRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(APIRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWXInstance) DeepCopyInto(out *AWXInstance) {
 	*out = *in
@@ -88,10 +104,90 @@ func (in *AWXInstanceList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWXInstanceSpec) DeepCopyInto(out *AWXInstanceSpec) {
 	*out = *in
+	if in.AdminPasswordSecretRef != nil {
+		in, out := &in.AdminPasswordSecretRef, &out.AdminPasswordSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceRef != nil {
+		in, out := &in.ServiceRef, &out.ServiceRef
+		*out = new(ServiceRef)
+		**out = **in
+	}
+	if in.DiscoverFrom != nil {
+		in, out := &in.DiscoverFrom, &out.DiscoverFrom
+		*out = new(DiscoverySpec)
+		**out = **in
+	}
+	if in.APIRateLimit != nil {
+		in, out := &in.APIRateLimit, &out.APIRateLimit
+		*out = new(APIRateLimit)
+		**out = **in
+	}
+	if in.ReconcileTimeouts != nil {
+		in, out := &in.ReconcileTimeouts, &out.ReconcileTimeouts
+		*out = new(ReconcileTimeouts)
+		**out = **in
+	}
+	if in.RetainOnDeletion != nil {
+		in, out := &in.RetainOnDeletion, &out.RetainOnDeletion
+		*out = make([]RetainedKind, len(*in))
+		copy(*out, *in)
+	}
+	if in.TemplateValuesFrom != nil {
+		in, out := &in.TemplateValuesFrom, &out.TemplateValuesFrom
+		*out = make([]TemplateValuesSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NamingPolicy != nil {
+		in, out := &in.NamingPolicy, &out.NamingPolicy
+		*out = new(NamingPolicy)
+		**out = **in
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]InstanceRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Analytics != nil {
+		in, out := &in.Analytics, &out.Analytics
+		*out = new(AnalyticsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MeshInstances != nil {
+		in, out := &in.MeshInstances, &out.MeshInstances
+		*out = make([]MeshInstanceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.JobCleanup != nil {
+		in, out := &in.JobCleanup, &out.JobCleanup
+		*out = new(JobCleanupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Organizations != nil {
+		in, out := &in.Organizations, &out.Organizations
+		*out = make([]OrganizationSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make([]CredentialSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Projects != nil {
 		in, out := &in.Projects, &out.Projects
 		*out = make([]ProjectSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Inventories != nil {
 		in, out := &in.Inventories, &out.Inventories
@@ -103,13 +199,39 @@ func (in *AWXInstanceSpec) DeepCopyInto(out *AWXInstanceSpec) {
 	if in.JobTemplates != nil {
 		in, out := &in.JobTemplates, &out.JobTemplates
 		*out = make([]JobTemplateSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.JobTemplateDefaults != nil {
+		in, out := &in.JobTemplateDefaults, &out.JobTemplateDefaults
+		*out = new(JobTemplateDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkflowJobTemplates != nil {
+		in, out := &in.WorkflowJobTemplates, &out.WorkflowJobTemplates
+		*out = make([]WorkflowJobTemplateSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWXInstanceStatus) DeepCopyInto(out *AWXInstanceStatus) {
 	*out = *in
+	if in.ReconcileCursor != nil {
+		in, out := &in.ReconcileCursor, &out.ReconcileCursor
+		*out = new(ReconcileCursor)
+		**out = **in
+	}
+	if in.RequeueHistory != nil {
+		in, out := &in.RequeueHistory, &out.RequeueHistory
+		*out = make([]RequeueRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]metav1.Condition, len(*in))
@@ -117,6 +239,13 @@ func (in *AWXInstanceStatus) DeepCopyInto(out *AWXInstanceStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CredentialStatuses != nil {
+		in, out := &in.CredentialStatuses, &out.CredentialStatuses
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.ProjectStatuses != nil {
 		in, out := &in.ProjectStatuses, &out.ProjectStatuses
 		*out = make(map[string]string, len(*in))
@@ -138,69 +267,1159 @@ func (in *AWXInstanceStatus) DeepCopyInto(out *AWXInstanceStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.WorkflowJobTemplateStatuses != nil {
+		in, out := &in.WorkflowJobTemplateStatuses, &out.WorkflowJobTemplateStatuses
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.JobTemplateExecutionEnvironments != nil {
+		in, out := &in.JobTemplateExecutionEnvironments, &out.JobTemplateExecutionEnvironments
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ObjectIDs != nil {
+		in, out := &in.ObjectIDs, &out.ObjectIDs
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SpecHashes != nil {
+		in, out := &in.SpecHashes, &out.SpecHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TargetStatuses != nil {
+		in, out := &in.TargetStatuses, &out.TargetStatuses
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MeshInstanceStatuses != nil {
+		in, out := &in.MeshInstanceStatuses, &out.MeshInstanceStatuses
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastJobs != nil {
+		in, out := &in.LastJobs, &out.LastJobs
+		*out = make(map[string]JobRunStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.LastHostnameCutover != nil {
+		in, out := &in.LastHostnameCutover, &out.LastHostnameCutover
+		*out = new(HostnameCutover)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.License != nil {
+		in, out := &in.License, &out.License
+		*out = new(LicenseStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSStatus)
+		**out = **in
+	}
+	if in.SessionTokenIDs != nil {
+		in, out := &in.SessionTokenIDs, &out.SessionTokenIDs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdminPasswordRotatedAt != nil {
+		in, out := &in.AdminPasswordRotatedAt, &out.AdminPasswordRotatedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.InventoryFacts != nil {
+		in, out := &in.InventoryFacts, &out.InventoryFacts
+		*out = make(map[string]InventoryFactsStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.WebhookSecrets != nil {
+		in, out := &in.WebhookSecrets, &out.WebhookSecrets
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HostSpec) DeepCopyInto(out *HostSpec) {
+func (in *AWXJob) DeepCopyInto(out *AWXJob) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostSpec.
-func (in *HostSpec) DeepCopy() *HostSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXJob.
+func (in *AWXJob) DeepCopy() *AWXJob {
 	if in == nil {
 		return nil
 	}
-	out := new(HostSpec)
+	out := new(AWXJob)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWXJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InventorySpec) DeepCopyInto(out *InventorySpec) {
+func (in *AWXJobList) DeepCopyInto(out *AWXJobList) {
 	*out = *in
-	if in.Hosts != nil {
-		in, out := &in.Hosts, &out.Hosts
-		*out = make([]HostSpec, len(*in))
-		copy(*out, *in)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AWXJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventorySpec.
-func (in *InventorySpec) DeepCopy() *InventorySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXJobList.
+func (in *AWXJobList) DeepCopy() *AWXJobList {
 	if in == nil {
 		return nil
 	}
-	out := new(InventorySpec)
+	out := new(AWXJobList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWXJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobTemplateSpec) DeepCopyInto(out *JobTemplateSpec) {
+func (in *AWXJobSpec) DeepCopyInto(out *AWXJobSpec) {
 	*out = *in
+	out.InstanceRef = in.InstanceRef
+	if in.CredentialPasswords != nil {
+		in, out := &in.CredentialPasswords, &out.CredentialPasswords
+		*out = make([]CredentialPasswordSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SurveyAnswers != nil {
+		in, out := &in.SurveyAnswers, &out.SurveyAnswers
+		*out = make([]SurveyAnswerSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DiffMode != nil {
+		in, out := &in.DiffMode, &out.DiffMode
+		*out = new(bool)
+		**out = **in
+	}
+	if in.JobTags != nil {
+		in, out := &in.JobTags, &out.JobTags
+		*out = new(string)
+		**out = **in
+	}
+	if in.SkipTags != nil {
+		in, out := &in.SkipTags, &out.SkipTags
+		*out = new(string)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTemplateSpec.
-func (in *JobTemplateSpec) DeepCopy() *JobTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXJobSpec.
+func (in *AWXJobSpec) DeepCopy() *AWXJobSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(JobTemplateSpec)
+	out := new(AWXJobSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ProjectSpec) DeepCopyInto(out *ProjectSpec) {
+func (in *AWXJobStatus) DeepCopyInto(out *AWXJobStatus) {
 	*out = *in
+	if in.LaunchRequestedAt != nil {
+		in, out := &in.LaunchRequestedAt, &out.LaunchRequestedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.EffectiveLaunch != nil {
+		in, out := &in.EffectiveLaunch, &out.EffectiveLaunch
+		*out = new(JobLaunchParameters)
+		**out = **in
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectSpec.
-func (in *ProjectSpec) DeepCopy() *ProjectSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXJobStatus.
+func (in *AWXJobStatus) DeepCopy() *AWXJobStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ProjectSpec)
+	out := new(AWXJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWXProjectSync) DeepCopyInto(out *AWXProjectSync) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXProjectSync.
+func (in *AWXProjectSync) DeepCopy() *AWXProjectSync {
+	if in == nil {
+		return nil
+	}
+	out := new(AWXProjectSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWXProjectSync) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWXProjectSyncList) DeepCopyInto(out *AWXProjectSyncList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AWXProjectSync, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXProjectSyncList.
+func (in *AWXProjectSyncList) DeepCopy() *AWXProjectSyncList {
+	if in == nil {
+		return nil
+	}
+	out := new(AWXProjectSyncList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWXProjectSyncList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWXProjectSyncSpec) DeepCopyInto(out *AWXProjectSyncSpec) {
+	*out = *in
+	out.InstanceRef = in.InstanceRef
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXProjectSyncSpec.
+func (in *AWXProjectSyncSpec) DeepCopy() *AWXProjectSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWXProjectSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWXProjectSyncStatus) DeepCopyInto(out *AWXProjectSyncStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWXProjectSyncStatus.
+func (in *AWXProjectSyncStatus) DeepCopy() *AWXProjectSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWXProjectSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalyticsSpec) DeepCopyInto(out *AnalyticsSpec) {
+	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalyticsSpec.
+func (in *AnalyticsSpec) DeepCopy() *AnalyticsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalyticsSpec)
 	in.DeepCopyInto(out)
 	return out
-} 
\ No newline at end of file
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CleanupScheduleSpec) DeepCopyInto(out *CleanupScheduleSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CleanupScheduleSpec.
+func (in *CleanupScheduleSpec) DeepCopy() *CleanupScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CleanupScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialPasswordSource) DeepCopyInto(out *CredentialPasswordSource) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialPasswordSource.
+func (in *CredentialPasswordSource) DeepCopy() *CredentialPasswordSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialPasswordSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialSpec) DeepCopyInto(out *CredentialSpec) {
+	*out = *in
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InputsSecretRef != nil {
+		in, out := &in.InputsSecretRef, &out.InputsSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialSpec.
+func (in *CredentialSpec) DeepCopy() *CredentialSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoverySpec) DeepCopyInto(out *DiscoverySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoverySpec.
+func (in *DiscoverySpec) DeepCopy() *DiscoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostDefaults) DeepCopyInto(out *HostDefaults) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostDefaults.
+func (in *HostDefaults) DeepCopy() *HostDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(HostDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostFactsSpec) DeepCopyInto(out *HostFactsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostFactsSpec.
+func (in *HostFactsSpec) DeepCopy() *HostFactsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostFactsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostFactsStatus) DeepCopyInto(out *HostFactsStatus) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GatheredAt != nil {
+		in, out := &in.GatheredAt, &out.GatheredAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostFactsStatus.
+func (in *HostFactsStatus) DeepCopy() *HostFactsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostFactsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostSpec) DeepCopyInto(out *HostSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostSpec.
+func (in *HostSpec) DeepCopy() *HostSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostnameCutover) DeepCopyInto(out *HostnameCutover) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostnameCutover.
+func (in *HostnameCutover) DeepCopy() *HostnameCutover {
+	if in == nil {
+		return nil
+	}
+	out := new(HostnameCutover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceRef) DeepCopyInto(out *InstanceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceRef.
+func (in *InstanceRef) DeepCopy() *InstanceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryFactsStatus) DeepCopyInto(out *InventoryFactsStatus) {
+	*out = *in
+	in.CollectedAt.DeepCopyInto(&out.CollectedAt)
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make(map[string]HostFactsStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryFactsStatus.
+func (in *InventoryFactsStatus) DeepCopy() *InventoryFactsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryFactsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventorySourceSpec) DeepCopyInto(out *InventorySourceSpec) {
+	*out = *in
+	if in.UpdateSchedule != nil {
+		in, out := &in.UpdateSchedule, &out.UpdateSchedule
+		*out = new(ScheduleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventorySourceSpec.
+func (in *InventorySourceSpec) DeepCopy() *InventorySourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InventorySourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventorySpec) DeepCopyInto(out *InventorySpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]HostSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.HostDefaults != nil {
+		in, out := &in.HostDefaults, &out.HostDefaults
+		*out = new(HostDefaults)
+		**out = **in
+	}
+	if in.Facts != nil {
+		in, out := &in.Facts, &out.Facts
+		*out = new(HostFactsSpec)
+		**out = **in
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]InventorySourceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventorySpec.
+func (in *InventorySpec) DeepCopy() *InventorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InventorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobCleanupSpec) DeepCopyInto(out *JobCleanupSpec) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(CleanupScheduleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActivityStream != nil {
+		in, out := &in.ActivityStream, &out.ActivityStream
+		*out = new(CleanupScheduleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobCleanupSpec.
+func (in *JobCleanupSpec) DeepCopy() *JobCleanupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobCleanupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobLaunchParameters) DeepCopyInto(out *JobLaunchParameters) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobLaunchParameters.
+func (in *JobLaunchParameters) DeepCopy() *JobLaunchParameters {
+	if in == nil {
+		return nil
+	}
+	out := new(JobLaunchParameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobRunStatus) DeepCopyInto(out *JobRunStatus) {
+	*out = *in
+	if in.Finished != nil {
+		in, out := &in.Finished, &out.Finished
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobRunStatus.
+func (in *JobRunStatus) DeepCopy() *JobRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobTemplateDefaults) DeepCopyInto(out *JobTemplateDefaults) {
+	*out = *in
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTemplateDefaults.
+func (in *JobTemplateDefaults) DeepCopy() *JobTemplateDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(JobTemplateDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobTemplateSpec) DeepCopyInto(out *JobTemplateSpec) {
+	*out = *in
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]ScheduleSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Survey != nil {
+		in, out := &in.Survey, &out.Survey
+		*out = new(SurveySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WaitFor != nil {
+		in, out := &in.WaitFor, &out.WaitFor
+		*out = new(WaitForSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTemplateSpec.
+func (in *JobTemplateSpec) DeepCopy() *JobTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LicenseStatus) DeepCopyInto(out *LicenseStatus) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LicenseStatus.
+func (in *LicenseStatus) DeepCopy() *LicenseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LicenseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeshInstanceSpec) DeepCopyInto(out *MeshInstanceSpec) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshInstanceSpec.
+func (in *MeshInstanceSpec) DeepCopy() *MeshInstanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MeshInstanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamingPolicy) DeepCopyInto(out *NamingPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamingPolicy.
+func (in *NamingPolicy) DeepCopy() *NamingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NamingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationSpec) DeepCopyInto(out *OrganizationSpec) {
+	*out = *in
+	if in.GalaxyCredentials != nil {
+		in, out := &in.GalaxyCredentials, &out.GalaxyCredentials
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationSpec.
+func (in *OrganizationSpec) DeepCopy() *OrganizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectSpec) DeepCopyInto(out *ProjectSpec) {
+	*out = *in
+	if in.UpdateSchedule != nil {
+		in, out := &in.UpdateSchedule, &out.UpdateSchedule
+		*out = new(ScheduleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectSpec.
+func (in *ProjectSpec) DeepCopy() *ProjectSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectSpec)
+	in.DeepCopyInto(out)
+	return out
+} 
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileCursor) DeepCopyInto(out *ReconcileCursor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileCursor.
+func (in *ReconcileCursor) DeepCopy() *ReconcileCursor {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileCursor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileTimeouts) DeepCopyInto(out *ReconcileTimeouts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileTimeouts.
+func (in *ReconcileTimeouts) DeepCopy() *ReconcileTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequeueRecord) DeepCopyInto(out *RequeueRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequeueRecord.
+func (in *RequeueRecord) DeepCopy() *RequeueRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(RequeueRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleSpec) DeepCopyInto(out *ScheduleSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DiffMode != nil {
+		in, out := &in.DiffMode, &out.DiffMode
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleSpec.
+func (in *ScheduleSpec) DeepCopy() *ScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceRef) DeepCopyInto(out *ServiceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceRef.
+func (in *ServiceRef) DeepCopy() *ServiceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SurveyAnswerSource) DeepCopyInto(out *SurveyAnswerSource) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SurveyAnswerSource.
+func (in *SurveyAnswerSource) DeepCopy() *SurveyAnswerSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SurveyAnswerSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SurveyQuestionSpec) DeepCopyInto(out *SurveyQuestionSpec) {
+	*out = *in
+	if in.DefaultSecretRef != nil {
+		in, out := &in.DefaultSecretRef, &out.DefaultSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Choices != nil {
+		in, out := &in.Choices, &out.Choices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SurveyQuestionSpec.
+func (in *SurveyQuestionSpec) DeepCopy() *SurveyQuestionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SurveyQuestionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SurveySpec) DeepCopyInto(out *SurveySpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Questions != nil {
+		in, out := &in.Questions, &out.Questions
+		*out = make([]SurveyQuestionSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SurveySpec.
+func (in *SurveySpec) DeepCopy() *SurveySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SurveySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSStatus) DeepCopyInto(out *TLSStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSStatus.
+func (in *TLSStatus) DeepCopy() *TLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateValuesSource) DeepCopyInto(out *TemplateValuesSource) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateValuesSource.
+func (in *TemplateValuesSource) DeepCopy() *TemplateValuesSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateValuesSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaitForSpec) DeepCopyInto(out *WaitForSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaitForSpec.
+func (in *WaitForSpec) DeepCopy() *WaitForSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WaitForSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSpec) DeepCopyInto(out *WebhookSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSpec.
+func (in *WebhookSpec) DeepCopy() *WebhookSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowJobTemplateSpec) DeepCopyInto(out *WorkflowJobTemplateSpec) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]WorkflowNodeSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Survey != nil {
+		in, out := &in.Survey, &out.Survey
+		*out = new(SurveySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowJobTemplateSpec.
+func (in *WorkflowJobTemplateSpec) DeepCopy() *WorkflowJobTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowJobTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowNodeSpec) DeepCopyInto(out *WorkflowNodeSpec) {
+	*out = *in
+	if in.SuccessNodes != nil {
+		in, out := &in.SuccessNodes, &out.SuccessNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailureNodes != nil {
+		in, out := &in.FailureNodes, &out.FailureNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AlwaysNodes != nil {
+		in, out := &in.AlwaysNodes, &out.AlwaysNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowNodeSpec.
+func (in *WorkflowNodeSpec) DeepCopy() *WorkflowNodeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowNodeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
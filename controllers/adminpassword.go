@@ -0,0 +1,109 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=update;patch
+
+// applyAdminPasswordSecret reads the admin password from the referenced
+// Secret into the spec of instance. The spec is never written back with the
+// password, finalization resolves it on a copy.
+func (r *AWXInstanceReconciler) applyAdminPasswordSecret(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	ref := instance.Spec.AdminPasswordSecretRef
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: ref.Name}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("failed to read admin password Secret %s: %w", key.Name,
+			missingReference(err, "Secret", key.Name))
+	}
+	password, ok := secret.Data[ref.Key]
+	if !ok || len(password) == 0 {
+		return fmt.Errorf("admin password %w",
+			&awx.ReferenceNotFoundError{Kind: "Secret key", Name: key.Name + "/" + ref.Key})
+	}
+	instance.Spec.AdminPassword = string(password)
+	return nil
+}
+
+// rotateAdminPassword switches to a changed admin password once it is
+// verified against AWX. The cached clients of the instance, which are also
+// used by the instances pushing to it as a target, are dropped, and an AWX
+// deployed by the upstream awx-operator gets the new password in its admin
+// password Secret. A password that AWX rejects is not used.
+func (r *AWXInstanceReconciler) rotateAdminPassword(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	logger := log.FromContext(ctx)
+	config := clientConfigFor(instance)
+	cached, ok := r.cachedAWXClientConfig(instance)
+	if !ok || cached.password == config.password {
+		return nil
+	}
+
+	logger.Info("AWX admin password changed, verifying it", "instance", instance.Name)
+	if err := newAWXClient(ctx, instance, config).VerifyCredentials(); err != nil {
+		return fmt.Errorf("the changed admin password was not accepted by AWX: %w", err)
+	}
+
+	if instance.Spec.DiscoverFrom != nil && !instance.Spec.ExternalInstance {
+		if err := r.syncDiscoveredAdminPassword(ctx, instance); err != nil {
+			return err
+		}
+	}
+
+	r.forgetAWXClient(instance)
+	now := metav1.Now()
+	instance.Status.AdminPasswordRotatedAt = &now
+	r.recordEvent(ctx, instance, corev1.EventTypeNormal, "AdminPasswordRotated",
+		"Switched to the changed admin password after AWX accepted it")
+	logger.Info("Rotated AWX admin password", "instance", instance.Name)
+	return nil
+}
+
+// syncDiscoveredAdminPassword writes the verified admin password to the admin
+// password Secret of the upstream awx-operator deployment, so the deployment
+// and the operator agree on it. It is left alone when it is already current.
+func (r *AWXInstanceReconciler) syncDiscoveredAdminPassword(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.DiscoverFrom.Name + adminPasswordSecretSuffix}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("failed to read admin password Secret %s: %w", key.Name, err)
+	}
+	if string(secret.Data[adminPasswordKey]) == instance.Spec.AdminPassword {
+		return nil
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[adminPasswordKey] = []byte(instance.Spec.AdminPassword)
+	if err := r.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to update admin password Secret %s: %w", key.Name, err)
+	}
+	log.FromContext(ctx).Info("Updated admin password Secret of the AWX deployment", "secret", key.Name)
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+//+kubebuilder:webhook:path=/validate-awx-ansible-com-v1alpha1-awxinstance,mutating=false,failurePolicy=ignore,sideEffects=None,groups=awx.ansible.com,resources=awxinstances,verbs=create;update,versions=v1alpha1,name=vawxinstance.awx.ansible.com,admissionReviewVersions=v1
+
+// CredentialInputsValidator rejects AWXInstances at admission whose credential
+// inputs don't match their credential type. It only uses the credential type
+// catalog cached by the clients of the reconciler and never contacts AWX, so
+// instances without a cached catalog, e.g. new ones, are admitted and their
+// inputs are validated by the reconcile.
+type CredentialInputsValidator struct {
+	// Client reads the Secrets holding credential inputs
+	Client client.Reader
+	// Instances caches the AWX clients and their catalogs
+	Instances *AWXInstanceReconciler
+}
+
+// SetupWebhookWithManager registers the validating webhook with the manager
+func (v *CredentialInputsValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&awxv1alpha1.AWXInstance{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates the credential inputs of a created instance
+func (v *CredentialInputsValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, obj)
+}
+
+// ValidateUpdate validates the credential inputs of an updated instance
+func (v *CredentialInputsValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, newObj)
+}
+
+// ValidateDelete admits every deletion
+func (v *CredentialInputsValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the inputs of every credential whose type is in the cached
+// catalog, including the inputs from its Secret. A credential whose Secret
+// can't be read is admitted with a warning, the Secret may be created later.
+func (v *CredentialInputsValidator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	instance, ok := obj.(*awxv1alpha1.AWXInstance)
+	if !ok {
+		return nil, fmt.Errorf("expected an AWXInstance but got %T", obj)
+	}
+
+	var warnings admission.Warnings
+	var problems []string
+	for _, credentialSpec := range instance.Spec.Credentials {
+		credentialType, cached := v.Instances.cachedCredentialType(instance, credentialSpec.Kind)
+		if !cached {
+			continue
+		}
+		if credentialType == nil {
+			problems = append(problems, fmt.Sprintf("credential %s: unknown credential kind %s", credentialSpec.Name, credentialSpec.Kind))
+			continue
+		}
+
+		inputs := make(map[string]string, len(credentialSpec.Inputs))
+		for field, value := range credentialSpec.Inputs {
+			inputs[field] = value
+		}
+		if ref := credentialSpec.InputsSecretRef; ref != nil {
+			secret := &corev1.Secret{}
+			if err := v.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: ref.Name}, secret); err != nil {
+				warnings = append(warnings, fmt.Sprintf("credential %s: inputs not validated, Secret %s could not be read: %v",
+					credentialSpec.Name, ref.Name, err))
+				continue
+			}
+			for field, value := range secret.Data {
+				inputs[field] = string(value)
+			}
+		}
+		if err := credentialType.ValidateInputs(credentialSpec.Kind, inputs); err != nil {
+			problems = append(problems, fmt.Sprintf("credential %s: %v", credentialSpec.Name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return warnings, errors.New(strings.Join(problems, "; "))
+	}
+	return warnings, nil
+}
+
+// cachedCredentialType returns the credential type named by kind from the
+// catalog cached by the clients of the instance, preferring the tenant client
+// the credentials are reconciled with. It reports false when neither client
+// has a current catalog.
+func (r *AWXInstanceReconciler) cachedCredentialType(instance *awxv1alpha1.AWXInstance, kind string) (*awx.CredentialType, bool) {
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	for _, cached := range []*cachedAWXClient{r.tenantClients[key], r.clients[key]} {
+		if cached == nil {
+			continue
+		}
+		if credentialType, ok := cached.client.CachedCredentialType(kind); ok {
+			return credentialType, true
+		}
+	}
+	return nil, false
+}
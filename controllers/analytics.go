@@ -0,0 +1,61 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// analyticsSettingsFor returns the desired Automation Analytics settings of
+// the instance, reading the Red Hat account from its Secret
+func (r *AWXInstanceReconciler) analyticsSettingsFor(ctx context.Context, instance *awxv1alpha1.AWXInstance) (awx.AnalyticsSettings, error) {
+	analytics := instance.Spec.Analytics
+	settings := awx.AnalyticsSettings{
+		Enabled:               analytics.Enabled,
+		URL:                   analytics.URL,
+		GatherIntervalSeconds: analytics.GatherIntervalSeconds,
+	}
+	if analytics.CredentialsSecretRef == nil {
+		return settings, nil
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: analytics.CredentialsSecretRef.Name}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return settings, fmt.Errorf("failed to read Secret %s for analytics: %w", key.Name,
+			missingReference(err, "Secret", key.Name))
+	}
+	settings.Username = string(secret.Data["username"])
+	settings.Password = string(secret.Data["password"])
+	return settings, nil
+}
+
+// reconcileAnalytics applies the Automation Analytics settings of the instance
+func (r *AWXInstanceReconciler) reconcileAnalytics(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	settings, err := r.analyticsSettingsFor(ctx, instance)
+	if err != nil {
+		return err
+	}
+	return awx.NewSettingsManager(awxClient).EnsureAnalytics(settings)
+}
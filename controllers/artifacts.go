@@ -0,0 +1,332 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;delete
+
+// Artifact store types selected with --artifact-store
+const (
+	ArtifactStoreConfigMap = "configmap"
+	ArtifactStoreFile      = "file"
+	ArtifactStoreS3        = "s3"
+)
+
+// defaultS3Region is the region artifacts are signed for when AWS_REGION is
+// not set, which S3-compatible stores like MinIO accept by default
+const defaultS3Region = "us-east-1"
+
+// maxConfigMapArtifactBytes is the size of the end of an artifact kept in a
+// ConfigMap, below the 1 MiB etcd accepts for an object
+const maxConfigMapArtifactBytes = 900 * 1024
+
+// ArtifactStore keeps large artifacts of a resource, e.g. the full output of
+// a project update, outside of its status, whose size etcd limits
+type ArtifactStore interface {
+	// Save stores the artifact of the owner under name and returns a
+	// reference to it for the status
+	Save(ctx context.Context, owner client.Object, name string, data []byte) (string, error)
+
+	// Delete removes the artifact of the owner, if there is one
+	Delete(ctx context.Context, owner client.Object, name string) error
+}
+
+// NewArtifactStore creates the artifact store of the given type, or returns
+// nil when storeType is empty and artifacts are not kept. path is the
+// directory of the file store and the bucket URL of the S3 store. The
+// ConfigMap store reads through reader, so that no ConfigMap informer is
+// started; pass an uncached reader such as the API reader of the manager.
+func NewArtifactStore(storeType, path string, c client.Client, reader client.Reader, scheme *runtime.Scheme) (ArtifactStore, error) {
+	switch storeType {
+	case "":
+		return nil, nil
+	case ArtifactStoreConfigMap:
+		return &configMapArtifactStore{client: c, reader: reader, scheme: scheme}, nil
+	case ArtifactStoreFile:
+		if path == "" {
+			return nil, fmt.Errorf("the file artifact store requires a path")
+		}
+		return &fileArtifactStore{dir: path}, nil
+	case ArtifactStoreS3:
+		return newS3ArtifactStore(path)
+	default:
+		return nil, fmt.Errorf("unknown artifact store %q, expected %s, %s or %s",
+			storeType, ArtifactStoreConfigMap, ArtifactStoreFile, ArtifactStoreS3)
+	}
+}
+
+// configMapArtifactStore keeps artifacts in ConfigMaps owned by their
+// resource, so they are garbage collected together with it. Only the end of
+// artifacts larger than a ConfigMap can hold is kept.
+type configMapArtifactStore struct {
+	client client.Client
+	reader client.Reader
+	scheme *runtime.Scheme
+}
+
+// artifactConfigMapName returns the name of the ConfigMap of an artifact
+func artifactConfigMapName(owner client.Object, name string) string {
+	return owner.GetName() + "-" + name
+}
+
+// Save implements ArtifactStore
+func (s *configMapArtifactStore) Save(ctx context.Context, owner client.Object, name string, data []byte) (string, error) {
+	if len(data) > maxConfigMapArtifactBytes {
+		data = data[len(data)-maxConfigMapArtifactBytes:]
+	}
+	key := types.NamespacedName{Namespace: owner.GetNamespace(), Name: artifactConfigMapName(owner, name)}
+	configMap := &corev1.ConfigMap{}
+	err := s.reader.Get(ctx, key, configMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to read ConfigMap %s: %w", key.Name, err)
+	}
+	exists := err == nil
+	if !exists {
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	}
+
+	configMap.Data = map[string]string{name: string(data)}
+	if err := controllerutil.SetOwnerReference(owner, configMap, s.scheme); err != nil {
+		return "", err
+	}
+	if exists {
+		err = s.client.Update(ctx, configMap)
+	} else {
+		err = s.client.Create(ctx, configMap)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save %s in ConfigMap %s: %w", name, key.Name, err)
+	}
+	return "configmap/" + key.Name, nil
+}
+
+// Delete implements ArtifactStore
+func (s *configMapArtifactStore) Delete(ctx context.Context, owner client.Object, name string) error {
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      artifactConfigMapName(owner, name),
+		Namespace: owner.GetNamespace(),
+	}}
+	if err := s.client.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ConfigMap %s: %w", configMap.Name, err)
+	}
+	return nil
+}
+
+// fileArtifactStore keeps artifacts as files below a directory, typically
+// on a PersistentVolume, one directory per namespace and resource
+type fileArtifactStore struct {
+	dir string
+}
+
+// artifactPath returns the file of an artifact
+func (s *fileArtifactStore) artifactPath(owner client.Object, name string) string {
+	return filepath.Join(s.dir, owner.GetNamespace(), owner.GetName(), name)
+}
+
+// Save implements ArtifactStore
+func (s *fileArtifactStore) Save(_ context.Context, owner client.Object, name string, data []byte) (string, error) {
+	path := s.artifactPath(owner, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	// Write a temporary file first, so readers never see a partial artifact
+	if err := os.WriteFile(path+".tmp", data, 0o640); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return "file:" + path, nil
+}
+
+// Delete implements ArtifactStore
+func (s *fileArtifactStore) Delete(_ context.Context, owner client.Object, name string) error {
+	path := s.artifactPath(owner, name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	// Drop the directory of the resource once its last artifact is gone
+	_ = os.Remove(filepath.Dir(path))
+	return nil
+}
+
+// s3ArtifactStore keeps artifacts as objects of a bucket of S3 or an
+// S3-compatible store such as MinIO, one prefix per namespace and resource.
+// The bucket is addressed path-style and requests are signed with AWS
+// Signature Version 4.
+type s3ArtifactStore struct {
+	bucketURL    *url.URL
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	httpClient   *http.Client
+}
+
+// newS3ArtifactStore creates the S3 store of the bucket at bucketURL, e.g.
+// https://minio.example.com/artifacts. The credentials and the region are
+// read from the usual AWS environment variables.
+func newS3ArtifactStore(bucketURL string) (*s3ArtifactStore, error) {
+	if bucketURL == "" {
+		return nil, fmt.Errorf("the s3 artifact store requires the bucket URL as path")
+	}
+	parsed, err := url.Parse(strings.TrimSuffix(bucketURL, "/"))
+	if err != nil || parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid bucket URL %q, expected e.g. https://s3.example.com/bucket", bucketURL)
+	}
+	store := &s3ArtifactStore{
+		bucketURL:    parsed,
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		httpClient:   &http.Client{Timeout: time.Minute},
+	}
+	if store.accessKey == "" || store.secretKey == "" {
+		return nil, fmt.Errorf("the s3 artifact store requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if store.region == "" {
+		store.region = defaultS3Region
+	}
+	return store, nil
+}
+
+// objectURL returns the URL of the object of an artifact
+func (s *s3ArtifactStore) objectURL(owner client.Object, name string) string {
+	objectURL := *s.bucketURL
+	objectURL.Path = path.Join(s.bucketURL.Path, owner.GetNamespace(), owner.GetName(), name)
+	return objectURL.String()
+}
+
+// Save implements ArtifactStore
+func (s *s3ArtifactStore) Save(ctx context.Context, owner client.Object, name string, data []byte) (string, error) {
+	objectURL := s.objectURL(owner, name)
+	if err := s.do(ctx, http.MethodPut, objectURL, data); err != nil {
+		return "", fmt.Errorf("failed to save %s in %s: %w", name, objectURL, err)
+	}
+	return objectURL, nil
+}
+
+// Delete implements ArtifactStore. S3 answers the deletion of a missing
+// object with success as well.
+func (s *s3ArtifactStore) Delete(ctx context.Context, owner client.Object, name string) error {
+	objectURL := s.objectURL(owner, name)
+	if err := s.do(ctx, http.MethodDelete, objectURL, nil); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", objectURL, err)
+	}
+	return nil
+}
+
+// do sends a signed request for an object
+func (s *s3ArtifactStore) do(ctx context.Context, method, objectURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	signS3Request(req, body, s.region, s.accessKey, s.secretKey, time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && !(method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", method, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// signS3Request signs a request to S3 with AWS Signature Version 4. The host
+// and all headers set on the request are signed.
+func signS3Request(req *http.Request, body []byte, region, accessKey, secretKey string, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// awxClientConfig holds the settings an AWX client was built from
+type awxClientConfig struct {
+	baseURL    string
+	username   string
+	password   string
+	apiPath    string
+	authMethod string
+}
+
+// cachedAWXClient is an AWX client together with the settings it was built from
+type cachedAWXClient struct {
+	config awxClientConfig
+	client *awx.Client
+}
+
+// instanceProtocol returns the protocol for the AWX connection, defaulting to https
+func instanceProtocol(instance *awxv1alpha1.AWXInstance) string {
+	if instance.Spec.Protocol != "" {
+		return instance.Spec.Protocol
+	}
+	return "https"
+}
+
+// splitHostname splits a hostname into the host and its port, which is 0
+// when the hostname has none. Schemes, paths, unbracketed IPv6 addresses and
+// ports out of range are rejected, mirroring the validation rules of the CRD
+// for clusters that don't enforce them.
+func splitHostname(hostname string) (string, int32, error) {
+	switch {
+	case strings.Contains(hostname, "://"):
+		return "", 0, fmt.Errorf("hostname %s must not contain a scheme, set protocol instead", hostname)
+	case strings.Contains(hostname, "/"):
+		return "", 0, fmt.Errorf("hostname %s must not contain a path, set apiPathPrefix instead", hostname)
+	}
+
+	host := hostname
+	port := ""
+	if strings.HasPrefix(hostname, "[") || strings.Count(hostname, ":") == 1 {
+		if strings.HasPrefix(hostname, "[") && strings.HasSuffix(hostname, "]") {
+			host = strings.TrimSuffix(strings.TrimPrefix(hostname, "["), "]")
+		} else {
+			var err error
+			if host, port, err = net.SplitHostPort(hostname); err != nil {
+				return "", 0, fmt.Errorf("invalid hostname %s: %w", hostname, err)
+			}
+			if port == "" {
+				return "", 0, fmt.Errorf("hostname %s has an empty port", hostname)
+			}
+		}
+	} else if strings.Contains(hostname, ":") {
+		return "", 0, fmt.Errorf("IPv6 address %s must be enclosed in brackets", hostname)
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("hostname %s has no host", hostname)
+	}
+	if port == "" {
+		return host, 0, nil
+	}
+
+	number, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || number == 0 {
+		return "", 0, fmt.Errorf("hostname %s has invalid port %s, must be between 1 and 65535", hostname, port)
+	}
+	return host, int32(number), nil
+}
+
+// instanceAddress returns the host and port to connect to, joined from the
+// hostname and spec.port. The port is left out when neither sets one, so
+// the default port of the protocol is used. Hostnames that can't be split
+// are used as they are, validateSpec reports them.
+func instanceAddress(instance *awxv1alpha1.AWXInstance) string {
+	host, port, err := splitHostname(instance.Spec.Hostname)
+	if err != nil {
+		return instance.Spec.Hostname
+	}
+	if port == 0 {
+		port = instance.Spec.Port
+	}
+	if port == 0 {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// clientConfigFor derives the AWX client settings from the instance
+func clientConfigFor(instance *awxv1alpha1.AWXInstance) awxClientConfig {
+	config := awxClientConfig{
+		baseURL:    fmt.Sprintf("%s://%s", instanceProtocol(instance), instanceAddress(instance)),
+		username:   instance.Spec.AdminUser,
+		password:   instance.Spec.AdminPassword,
+		authMethod: instance.Spec.AuthMethod,
+	}
+
+	// An explicitly configured API path prefix wins over a detected one
+	if instance.Spec.APIPathPrefix != "" {
+		config.apiPath = instance.Spec.APIPathPrefix
+	} else {
+		config.apiPath = instance.Status.APIPathPrefix
+	}
+
+	return config
+}
+
+// resolveConnection fills in the connection settings of the instance read
+// from its admin password Secret, its Service and the discovered AWX, which
+// are needed to reach AWX outside of a reconcile
+func (r *AWXInstanceReconciler) resolveConnection(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	if instance.Spec.AdminPasswordSecretRef != nil {
+		if err := r.applyAdminPasswordSecret(ctx, instance); err != nil {
+			return err
+		}
+	}
+	if instance.Spec.ServiceRef != nil {
+		if err := r.applyServiceRef(ctx, instance); err != nil {
+			return err
+		}
+	}
+	if instance.Spec.DiscoverFrom != nil {
+		if err := r.applyDiscoveredConnection(ctx, instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// awxClientFor returns the cached AWX client for the instance, creating a new
+// one when the connection settings have changed. The requests of the client
+// carry the correlation ID of the reconcile in ctx and are sent within ctx.
+func (r *AWXInstanceReconciler) awxClientFor(ctx context.Context, instance *awxv1alpha1.AWXInstance) *awx.Client {
+	config := clientConfigFor(instance)
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+
+	if cached, ok := r.clients[key]; ok && cached.config == config {
+		cached.client.SetCorrelationID(correlationIDFrom(ctx))
+		cached.client.SetRequestLimiter(r.requestLimiterLocked(instance))
+		cached.client.SetTokenCreation(instance.Spec.Mode != awxv1alpha1.ModeObserve)
+		return cached.client.WithContext(ctx)
+	}
+
+	awxClient := newAWXClient(ctx, instance, config)
+	awxClient.SetRequestLimiter(r.requestLimiterLocked(instance))
+	if r.clients == nil {
+		r.clients = make(map[types.NamespacedName]*cachedAWXClient)
+	}
+	r.clients[key] = &cachedAWXClient{config: config, client: awxClient}
+	return awxClient.WithContext(ctx)
+}
+
+// newAWXClient builds an AWX client for the instance from the settings. The
+// client of an observed instance never creates session tokens in AWX.
+func newAWXClient(ctx context.Context, instance *awxv1alpha1.AWXInstance, config awxClientConfig) *awx.Client {
+	awxClient := awx.NewClient(config.baseURL, config.username, config.password)
+	if config.apiPath != "" {
+		awxClient.SetAPIPath(config.apiPath)
+	}
+	if config.authMethod != "" {
+		awxClient.SetAuthMethod(config.authMethod)
+	}
+	awxClient.SetManagedBy(fmt.Sprintf("awxinstance/%s/%s", instance.Namespace, instance.Name))
+	awxClient.SetLogger(awxClient.Logger().WithValues("instance", instance.Name, "namespace", instance.Namespace))
+	awxClient.SetCorrelationID(correlationIDFrom(ctx))
+	awxClient.SetTokenCreation(instance.Spec.Mode != awxv1alpha1.ModeObserve)
+	return awxClient
+}
+
+// cachedAWXClientConfig returns the settings of the cached AWX client of the
+// instance, if there is one
+func (r *AWXInstanceReconciler) cachedAWXClientConfig(instance *awxv1alpha1.AWXInstance) (awxClientConfig, bool) {
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	cached, ok := r.clients[types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}]
+	if !ok {
+		return awxClientConfig{}, false
+	}
+	return cached.config, true
+}
+
+// forgetAWXClient drops the cached AWX clients for the instance
+func (r *AWXInstanceReconciler) forgetAWXClient(instance *awxv1alpha1.AWXInstance) {
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	delete(r.clients, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+	delete(r.tenantClients, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+	delete(r.limiters, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+}
+
+// requestLimits derives the AWX request limits from the instance spec
+func requestLimits(spec *awxv1alpha1.AWXInstanceSpec) awx.RequestLimits {
+	limits := awx.RequestLimits{MaxParallel: int(spec.MaxParallelRequests)}
+	if spec.APIRateLimit != nil {
+		limits.RequestsPerSecond = float64(spec.APIRateLimit.RequestsPerSecond)
+		limits.Burst = int(spec.APIRateLimit.Burst)
+		if limits.Burst == 0 {
+			limits.Burst = int(spec.APIRateLimit.RequestsPerSecond)
+		}
+	}
+	return limits
+}
+
+// requestLimiterLocked returns the request limiter shared by the clients of
+// the instance, replacing it when the limits in the spec have changed, or nil
+// without limits. r.clientsMu must be held.
+func (r *AWXInstanceReconciler) requestLimiterLocked(instance *awxv1alpha1.AWXInstance) *awx.RequestLimiter {
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	limits := requestLimits(&instance.Spec)
+	if limits == (awx.RequestLimits{}) {
+		delete(r.limiters, key)
+		return nil
+	}
+	if limiter, ok := r.limiters[key]; ok && limiter.Limits() == limits {
+		return limiter
+	}
+
+	limiter := awx.NewRequestLimiter(limits)
+	if r.limiters == nil {
+		r.limiters = make(map[types.NamespacedName]*awx.RequestLimiter)
+	}
+	r.limiters[key] = limiter
+	return limiter
+}
+
+// tlsStatus reports the TLS connection last negotiated by the client, or nil
+// for plain HTTP connections
+func tlsStatus(awxClient *awx.Client) *awxv1alpha1.TLSStatus {
+	state := awxClient.TLSState()
+	if state == nil {
+		return nil
+	}
+	return &awxv1alpha1.TLSStatus{
+		Version:      state.Version,
+		CipherSuite:  state.CipherSuite,
+		Restricted:   state.Restricted,
+		FIPSApproved: state.FIPSApproved,
+	}
+}
@@ -18,18 +18,29 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
 	"github.com/derzufall/awx-k8s-operator/pkg/awx"
@@ -38,18 +49,104 @@ import (
 // AWXInstanceReconciler reconciles a AWXInstance object
 type AWXInstanceReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// APIReader reads objects the operator doesn't watch directly from the
+	// API server, so the client doesn't start a cluster-wide informer for
+	// them. The client is used when it is not set.
+	APIReader client.Reader
+
+	// ProxyAWXMetrics re-exposes the metrics of the managed AWX instances on
+	// the operator metrics endpoint
+	ProxyAWXMetrics bool
+
+	// FleetMetrics exposes metrics summarizing the health of all instances
+	// of the cluster on the operator metrics endpoint
+	FleetMetrics bool
+
+	// APIBudget sets the AWX API usage above which an instance is warned
+	APIBudget APIBudget
+
+	// MaxObjectsPerReconcile bounds the declared objects reconciled in one
+	// pass. Larger specs are reconciled in batches, zero disables batching.
+	MaxObjectsPerReconcile int
+
+	// ReconcileTimeout bounds a reconcile of instances that don't set their
+	// own timeout, zero leaves it unbounded
+	ReconcileTimeout time.Duration
+
+	// NotificationAddress is the address AWX webhook notifications are
+	// received on, empty disables the receiver
+	NotificationAddress string
+
+	// NotificationToken is the bearer token AWX notifications must carry. It is
+	// required when NotificationAddress is set.
+	NotificationToken string
+
+	// ClusterName identifies the cluster in the naming policy of instances
+	// that share an AWX with other clusters
+	ClusterName string
+
+	// TenantCredentialsSecret names the Secret that holds the organization
+	// scoped AWX user the resources of its namespace are reconciled as, empty
+	// reconciles all resources as the instance admin
+	TenantCredentialsSecret string
+
+	// AdminNamespaces lists the namespaces that are reconciled as the instance
+	// admin when they don't hold the tenant credentials Secret. Any other
+	// namespace without it is not reconciled.
+	AdminNamespaces []string
+
+	// clients caches AWX clients per instance so that session tokens and
+	// other client state survive between reconciles
+	clientsMu     sync.Mutex
+	clients       map[types.NamespacedName]*cachedAWXClient
+	tenantClients map[types.NamespacedName]*cachedAWXClient
+
+	// limiters holds the request limiter shared by the clients of an instance
+	limiters map[types.NamespacedName]*awx.RequestLimiter
+
+	// apiUsage tracks the AWX API requests per instance in one-hour windows
+	apiUsageMu sync.Mutex
+	apiUsage   map[types.NamespacedName]*apiUsageWindow
+
+	// driftEvents records when reconciles reverted changes made in AWX, per
+	// instance, for the fleet metrics
+	driftEventsMu sync.Mutex
+	driftEvents   map[types.NamespacedName][]time.Time
+
+	// specHashKeys holds the keys the spec hashes of each instance are
+	// computed with, loaded from their Secrets at every reconcile
+	specHashKeysMu sync.Mutex
+	specHashKeys   map[types.NamespacedName][]byte
+
+	// resync orders the initial resync after the operator became the leader
+	resync initialResync
 }
 
 //+kubebuilder:rbac:groups=awx.ansible.com,resources=awxinstances,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=awx.ansible.com,resources=awxinstances/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=awx.ansible.com,resources=awxinstances/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 // For more details, check Reconcile and its Result here:
 // https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.16.0/pkg/reconcile
 func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = withCorrelationID(ctx)
+	ctx, trace := withRequeueTrace(ctx)
+	result, err := r.reconcile(ctx, req)
+	r.resync.criticalDone(req.NamespacedName, time.Now())
+	if err != nil || result.Requeue || result.RequeueAfter > 0 {
+		r.recordRequeueReason(ctx, req.NamespacedName, trace, err)
+	}
+	return result, err
+}
+
+// reconcile reconciles the instance once, see Reconcile
+func (r *AWXInstanceReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	// Fetch the AWXInstance resource
@@ -65,6 +162,9 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// Initialize status maps if they don't exist
+	if instance.Status.CredentialStatuses == nil {
+		instance.Status.CredentialStatuses = make(map[string]string)
+	}
 	if instance.Status.ProjectStatuses == nil {
 		instance.Status.ProjectStatuses = make(map[string]string)
 	}
@@ -74,11 +174,25 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if instance.Status.JobTemplateStatuses == nil {
 		instance.Status.JobTemplateStatuses = make(map[string]string)
 	}
+	if instance.Status.WorkflowJobTemplateStatuses == nil {
+		instance.Status.WorkflowJobTemplateStatuses = make(map[string]string)
+	}
+	if instance.Status.ObjectIDs == nil {
+		instance.Status.ObjectIDs = make(map[string]int)
+	}
+
+	// Upgrade a status written by an older operator
+	if migrateStatus(ctx, instance) {
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to migrate AWXInstance status")
+			return ctrl.Result{}, err
+		}
+	}
 
 	// Initialize or update the LastConnectionCheck timestamp if needed
 	if instance.Status.LastConnectionCheck.IsZero() {
 		instance.Status.LastConnectionCheck = metav1.Now()
-		if err := r.Status().Update(ctx, instance); err != nil {
+		if err := r.updateStatus(ctx, instance); err != nil {
 			logger.Error(err, "Failed to update LastConnectionCheck timestamp")
 			return ctrl.Result{}, err
 		}
@@ -94,10 +208,12 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			if err := r.finalizeAWXInstance(ctx, instance); err != nil {
 				return ctrl.Result{}, err
 			}
+			r.forgetAPIUsage(instance)
+			r.forgetDriftEvents(instance)
+			forgetLicense(instance)
 
 			// Remove finalizer once cleanup is done
-			controllerutil.RemoveFinalizer(instance, awxFinalizer)
-			if err := r.Update(ctx, instance); err != nil {
+			if err := r.removeFinalizer(ctx, instance, awxFinalizer); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
@@ -112,17 +228,223 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	// Set the protocol, defaulting to https if not specified
-	protocol := "https"
-	if instance.Spec.Protocol != "" {
-		protocol = instance.Spec.Protocol
+	// Refuse to reconcile specs that would silently overwrite their own objects
+	// or lack connection settings
+	if err := validateSpec(&instance.Spec); err != nil {
+		logger.Error(err, "AWXInstance spec is invalid", "instance", instance.Name)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "InvalidSpec",
+			Message:            err.Error(),
+		})
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+			return ctrl.Result{}, err
+		}
+		// Nothing to retry until the spec is changed
+		return ctrl.Result{}, nil
+	}
+
+	// Read the admin password from its Secret
+	if instance.Spec.AdminPasswordSecretRef != nil {
+		if err := r.applyAdminPasswordSecret(ctx, instance); err != nil {
+			logger.Error(err, "Failed to read admin password", "instance", instance.Name)
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionReady,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             "AdminPasswordUnavailable",
+				Message:            err.Error(),
+			})
+			setReferencesResolved(instance, err)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+			}
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+	}
+
+	// Connect through the ClusterIP of the referenced Service
+	if instance.Spec.ServiceRef != nil {
+		if err := r.applyServiceRef(ctx, instance); err != nil {
+			logger.Error(err, "Failed to resolve AWX Service", "instance", instance.Name)
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionReady,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             "ServiceResolutionFailed",
+				Message:            err.Error(),
+			})
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+			}
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+	}
+
+	// Fill in connection settings from an AWX deployed by the upstream awx-operator
+	if instance.Spec.DiscoverFrom != nil {
+		if err := r.applyDiscoveredConnection(ctx, instance); err != nil {
+			logger.Error(err, "Failed to discover AWX connection settings", "instance", instance.Name)
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionReady,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             "DiscoveryFailed",
+				Message:            err.Error(),
+			})
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+			}
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+	}
+
+	// Verify a changed admin password before switching to it
+	if err := r.rotateAdminPassword(ctx, instance); err != nil {
+		logger.Error(err, "Failed to rotate admin password", "instance", instance.Name)
+		r.recordEvent(ctx, instance, corev1.EventTypeWarning, "AdminPasswordRotationFailed", err.Error())
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "AdminPasswordRotationFailed",
+			Message:            err.Error(),
+		})
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// Merge the job template defaults into the job templates. Only the copy of
+	// the spec read for this reconcile is changed, never the stored resource.
+	applyJobTemplateDefaults(&instance.Spec)
+
+	// Allow the mass deletion of hosts if acknowledged for this generation
+	applyMassDeletionAcknowledgment(instance)
+
+	// Load the key of the spec hashes. Without it, unchanged objects are
+	// reconciled again instead of being skipped.
+	if err := r.loadSpecHashKey(ctx, instance); err != nil {
+		logger.Error(err, "Failed to load spec hash key", "instance", instance.Name)
+	}
+
+	// Render variables and extra vars with values from ConfigMaps and Secrets.
+	// The Secret values are masked in the request logs of the AWX clients.
+	var redactedValues []string
+	if len(instance.Spec.TemplateValuesFrom) > 0 {
+		values, secretValues, err := r.loadTemplateValues(ctx, instance)
+		if err == nil {
+			err = renderSpecTemplates(&instance.Spec, values)
+		}
+		if err != nil {
+			logger.Error(err, "Failed to render spec templates", "instance", instance.Name)
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionReady,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             "TemplateRenderFailed",
+				Message:            err.Error(),
+			})
+			setReferencesResolved(instance, err)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+			}
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+
+		redactedValues = secretValues
+		if valuesHash := r.specHash(instance, values); valuesHash != instance.Status.TemplateValuesHash {
+			logger.Info("Template values changed", "instance", instance.Name)
+			instance.Status.TemplateValuesHash = valuesHash
+		}
+	}
+
+	// Prefix and suffix the AWX names of the declared objects
+	if err := r.applyNamingPolicy(ctx, instance); err != nil {
+		logger.Error(err, "Failed to apply naming policy", "instance", instance.Name)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "TemplateRenderFailed",
+			Message:            err.Error(),
+		})
+		setReferencesResolved(instance, err)
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// Add sensitive credential inputs from their Secrets
+	if err := r.resolveCredentialInputs(ctx, instance); err != nil {
+		logger.Error(err, "Failed to resolve credential inputs", "instance", instance.Name)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "CredentialInputsUnavailable",
+			Message:            err.Error(),
+		})
+		setReferencesResolved(instance, err)
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// Add the password defaults of surveys from their Secrets
+	if err := r.resolveSurveyDefaults(ctx, instance); err != nil {
+		logger.Error(err, "Failed to resolve survey defaults", "instance", instance.Name)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "SurveyDefaultsUnavailable",
+			Message:            err.Error(),
+		})
+		setReferencesResolved(instance, err)
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// Switch to a changed AWX URL only once AWX answers on it
+	if result := r.verifyHostnameCutover(ctx, instance); result != nil {
+		return *result, nil
 	}
 
+	protocol := instanceProtocol(instance)
+
 	// Create AWX client
-	baseURL := fmt.Sprintf("%s://%s", protocol, instance.Spec.Hostname)
-	awxClient := awx.NewClient(baseURL, instance.Spec.AdminUser, instance.Spec.AdminPassword)
+	awxClient := r.awxClientFor(ctx, instance)
+	awxClient.SetRedactedValues(redactedValues)
+	defer r.recordAPIUsage(ctx, instance, awxClient, awxClient.RequestCount())
+
+	// Bound the requests of the reconcile by its deadline
+	deadline := r.reconcileDeadlineFor(ctx, instance, time.Now())
+	deadline.bound(&awxClient)
+	defer deadline.release()
+
+	// Detect the API path prefix once when it isn't configured explicitly
+	if instance.Spec.APIPathPrefix == "" && instance.Status.APIPathPrefix == "" {
+		if apiPath, err := awxClient.DetectAPIPath(); err != nil {
+			logger.Info("Could not detect AWX API path prefix, using default",
+				"instance", instance.Name,
+				"apiPath", awxClient.APIPath(),
+				"error", err.Error())
+		} else {
+			instance.Status.APIPathPrefix = apiPath
+		}
+	}
 
 	// Check if we need to perform a periodic connection test (every 30 seconds)
+	deadline.startPhase(deadline.connectionTest)
 	now := metav1.Now()
 	timeSinceLastCheck := now.Time.Sub(instance.Status.LastConnectionCheck.Time)
 	if timeSinceLastCheck >= 30*time.Second {
@@ -150,10 +472,14 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			logger.Info("Periodic connection test successful",
 				"instance", instance.Name,
 				"hostname", instance.Spec.Hostname)
+
+			// Refresh the subscription status along with the connection check
+			r.updateLicenseStatus(ctx, instance, awxClient)
+			instance.Status.TLS = tlsStatus(awxClient)
 		}
 
 		// Update status with new connection information
-		if err := r.Status().Update(ctx, instance); err != nil {
+		if err := r.updateStatus(ctx, instance); err != nil {
 			logger.Error(err, "Failed to update connection status")
 			return ctrl.Result{}, err
 		}
@@ -161,18 +487,18 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		// If this is an external instance and connection failed, don't proceed with reconciliation
 		if connectionErr != nil && instance.Spec.ExternalInstance {
 			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-				Type:               "Ready",
+				Type:               conditionReady,
 				Status:             metav1.ConditionFalse,
 				LastTransitionTime: metav1.Now(),
 				Reason:             "ConnectionFailed",
 				Message:            fmt.Sprintf("Failed to connect to external AWX instance: %v", connectionErr),
 			})
 
-			if err := r.Status().Update(ctx, instance); err != nil {
+			if err := r.updateStatus(ctx, instance); err != nil {
 				logger.Error(err, "Failed to update AWXInstance status")
 			}
 
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, connectionErr
+			return requeueAfterError(connectionErr, 30*time.Second)
 		}
 	} else {
 		// Test connection to AWX if we're not doing a periodic check
@@ -186,120 +512,478 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// If this is an external instance, we expect it to exist
 			if instance.Spec.ExternalInstance {
 				meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-					Type:               "Ready",
+					Type:               conditionReady,
 					Status:             metav1.ConditionFalse,
 					LastTransitionTime: metav1.Now(),
 					Reason:             "ConnectionFailed",
 					Message:            fmt.Sprintf("Failed to connect to external AWX instance: %v", err),
 				})
 
-				if err := r.Status().Update(ctx, instance); err != nil {
+				if err := r.updateStatus(ctx, instance); err != nil {
 					logger.Error(err, "Failed to update AWXInstance status")
 				}
 
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
+				return requeueAfterError(err, 30*time.Second)
 			}
 
 			// For non-external instances, this may be expected during initial setup
 			logger.Info("AWX instance not available yet, will retry")
 		}
 	}
+	deadline.startPhase(0)
+
+	// Gate the reconcile of an AWX deployed in the cluster on its readiness
+	if !instance.Spec.ExternalInstance {
+		phase, message := r.readinessPhase(ctx, instance, awxClient)
+		if phase != phaseReady {
+			return r.waitForReadiness(ctx, instance, phase, message)
+		}
+		if instance.Status.Phase != phaseReady {
+			logger.Info("AWX is ready", "instance", instance.Name, "message", message)
+		}
+		instance.Status.Phase = phaseReady
+	}
+
+	// In Observe mode only report the actual state of the declared resources
+	if instance.Spec.Mode == awxv1alpha1.ModeObserve {
+		if err := r.observeResources(ctx, instance, awxClient); err != nil {
+			logger.Error(err, "Failed to observe AWX resources", "instance", instance.Name)
+			return requeueAfterError(err, time.Minute)
+		}
+
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "ObservationSucceeded",
+			Message:            "AWXInstance resources have been observed successfully",
+		})
+		r.trackSessionTokens(ctx, instance, awxClient)
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// Reconcile the declared resources as the AWX user of the tenant, if any
+	adminClient := awxClient
+	awxClient, err = r.tenantClientFor(ctx, instance, adminClient)
+	if err != nil {
+		logger.Error(err, "Failed to create tenant AWX client", "instance", instance.Name)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReady,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "TenantCredentialsUnavailable",
+			Message:            err.Error(),
+		})
+		if err := r.updateStatus(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update AWXInstance status")
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+	if awxClient != adminClient {
+		awxClient.SetRedactedValues(redactedValues)
+		defer r.recordAPIUsage(ctx, instance, awxClient, awxClient.RequestCount())
+	}
+	deadline.bound(&awxClient)
+	deadline.bound(&adminClient)
+
+	// Stop with a single condition when the user may not write a declared kind
+	if result := r.checkPermissions(ctx, instance, awxClient); result != nil {
+		return *result, nil
+	}
 
-	// Check and reconcile any differences from AWX internal state to the desired state
-	if changed, err := r.reconcileInternalChanges(ctx, instance, awxClient); err != nil {
+	// Manage objects created by an unfinished reconcile by their recorded IDs
+	if err := r.restoreObjectIDs(ctx, instance, awxClient); err != nil {
+		logger.Error(err, "Failed to remove AWX object IDs of undeclared objects", "instance", instance.Name)
+		return ctrl.Result{}, err
+	}
+
+	// Rename objects whose name changed in the spec before they are looked up by name
+	if err := r.renameObjects(ctx, instance, awxClient); err != nil {
+		if conflictErr, ok := awx.AsConflictError(err); ok {
+			return r.waitForUnlock(ctx, instance, conflictErr)
+		}
+		logger.Error(err, "Failed to rename AWX objects", "instance", instance.Name)
+		return requeueAfterError(err, time.Minute)
+	}
+
+	// Large specs are reconciled in batches over several passes
+	batch := r.nextBatch(instance)
+
+	// Check and reconcile any differences from AWX internal state to the
+	// desired state. Objects left when the deadline passed are checked by the
+	// next pass, as are the objects after them.
+	batch.deadline = deadline.startPhase(deadline.driftScan)
+	changed, err := r.reconcileInternalChanges(ctx, instance, awxClient, batch)
+	if awx.IsDeadlineExceeded(err) && batch.expire() {
+		logger.Info("Drift scan reached its deadline, continuing with the objects checked so far",
+			"instance", instance.Name)
+		err = nil
+	}
+	if err != nil {
+		if massErr, ok := awx.AsMassDeletionError(err); ok {
+			return r.refuseMassDeletion(ctx, instance, massErr)
+		}
+		if conflictErr, ok := awx.AsConflictError(err); ok {
+			return r.waitForUnlock(ctx, instance, conflictErr)
+		}
 		logger.Error(err, "Failed to reconcile internal AWX changes",
 			"instance", instance.Name,
 			"details", err.Error())
-		return ctrl.Result{RequeueAfter: time.Minute}, err
+		return requeueAfterError(err, time.Minute)
 	} else if changed {
 		logger.Info("Detected and corrected internal AWX changes", "instance", instance.Name)
+		noteRequeueReason(ctx, requeueReasonDrift, "Changes made in AWX were reverted to the spec")
+		r.noteDriftEvent(instance)
 		// If changes were detected and corrected, update the status
-		if err := r.Status().Update(ctx, instance); err != nil {
+		if err := r.updateStatus(ctx, instance); err != nil {
 			logger.Error(err, "Failed to update AWXInstance status")
 			return ctrl.Result{}, err
 		}
 	}
 
+	// Reconcile Credentials (before the projects that may use them)
+	batch.deadline = deadline.startPhase(deadline.mutation)
+	credentialManager := awx.NewCredentialManager(awxClient)
+	for i, credentialSpec := range instance.Spec.Credentials {
+		if !batch.includes(batchCredentials, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "credentials", credentialSpec.Name, credentialSpec) {
+			continue
+		}
+		logger.Info("Reconciling credential", "name", credentialSpec.Name, "instance", instance.Name)
+		rotated := r.credentialRotated(instance, credentialSpec)
+		credential, err := credentialManager.EnsureCredential(credentialSpec)
+		if err != nil {
+			if awx.IsDeadlineExceeded(err) && batch.expire() {
+				break
+			}
+			if conflictErr, ok := awx.AsConflictError(err); ok {
+				instance.Status.CredentialStatuses[credentialSpec.Name] = fmt.Sprintf("Locked: %v", conflictErr)
+				return r.waitForUnlock(ctx, instance, conflictErr)
+			}
+			logger.Error(err, "Failed to reconcile credential",
+				"name", credentialSpec.Name,
+				"instance", instance.Name,
+				"details", err.Error())
+			instance.Status.CredentialStatuses[credentialSpec.Name] = "Failed: " + awx.StatusMessage("credential", credentialSpec.Name, err)
+			setReferencesResolved(instance, fmt.Errorf("credential %s: %w", credentialSpec.Name, err))
+
+			// Update reconciliation status
+			setSyncedConditions(instance)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+				return ctrl.Result{}, err
+			}
+
+			return requeueAfterError(err, time.Minute)
+		}
+		instance.Status.CredentialStatuses[credentialSpec.Name] = "Reconciled"
+		if rotated {
+			r.recordEvent(ctx, instance, corev1.EventTypeNormal, "CredentialRotated",
+				fmt.Sprintf("Credential %s was updated with the changed inputs of Secret %s",
+					credentialSpec.Name, credentialSpec.InputsSecretRef.Name))
+		}
+		r.recordObjectID(ctx, instance, "credentials", credentialSpec.Name, credential)
+		r.recordSpecHash(instance, awxClient, "credentials", credentialSpec.Name, credentialSpec)
+	}
+
 	// Reconcile Projects
 	projectManager := awx.NewProjectManager(awxClient)
-	for _, projectSpec := range instance.Spec.Projects {
+	for i, projectSpec := range instance.Spec.Projects {
+		if !batch.includes(batchProjects, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "projects", projectSpec.Name, projectSpec) {
+			continue
+		}
 		logger.Info("Reconciling project", "name", projectSpec.Name, "instance", instance.Name)
-		_, err := projectManager.EnsureProject(projectSpec)
+		project, err := projectManager.EnsureProject(projectSpec)
 		if err != nil {
+			if awx.IsDeadlineExceeded(err) && batch.expire() {
+				break
+			}
+			if conflictErr, ok := awx.AsConflictError(err); ok {
+				instance.Status.ProjectStatuses[projectSpec.Name] = fmt.Sprintf("Locked: %v", conflictErr)
+				return r.waitForUnlock(ctx, instance, conflictErr)
+			}
 			logger.Error(err, "Failed to reconcile project",
 				"name", projectSpec.Name,
 				"instance", instance.Name,
 				"details", err.Error())
-			instance.Status.ProjectStatuses[projectSpec.Name] = fmt.Sprintf("Failed: %v", err)
+			instance.Status.ProjectStatuses[projectSpec.Name] = "Failed: " + awx.StatusMessage("project", projectSpec.Name, err)
+			setReferencesResolved(instance, fmt.Errorf("project %s: %w", projectSpec.Name, err))
 
 			// Update reconciliation status
-			if err := r.Status().Update(ctx, instance); err != nil {
+			setSyncedConditions(instance)
+			if err := r.updateStatus(ctx, instance); err != nil {
 				logger.Error(err, "Failed to update AWXInstance status")
 				return ctrl.Result{}, err
 			}
 
-			return ctrl.Result{RequeueAfter: time.Minute}, err
+			return requeueAfterError(err, time.Minute)
 		}
 		instance.Status.ProjectStatuses[projectSpec.Name] = "Reconciled"
+		r.recordObjectID(ctx, instance, "projects", projectSpec.Name, project)
+		r.recordSpecHash(instance, awxClient, "projects", projectSpec.Name, projectSpec)
 	}
 
 	// Reconcile Inventories
 	inventoryManager := awx.NewInventoryManager(awxClient)
-	for _, inventorySpec := range instance.Spec.Inventories {
+	for i, inventorySpec := range instance.Spec.Inventories {
+		if !batch.includes(batchInventories, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "inventories", inventorySpec.Name, inventorySpec) {
+			continue
+		}
 		logger.Info("Reconciling inventory", "name", inventorySpec.Name, "instance", instance.Name)
-		_, err := inventoryManager.EnsureInventory(inventorySpec)
+		inventory, err := inventoryManager.EnsureInventory(inventorySpec)
 		if err != nil {
+			if awx.IsDeadlineExceeded(err) && batch.expire() {
+				break
+			}
+			if massErr, ok := awx.AsMassDeletionError(err); ok {
+				return r.refuseMassDeletion(ctx, instance, massErr)
+			}
+			if conflictErr, ok := awx.AsConflictError(err); ok {
+				instance.Status.InventoryStatuses[inventorySpec.Name] = fmt.Sprintf("Locked: %v", conflictErr)
+				return r.waitForUnlock(ctx, instance, conflictErr)
+			}
 			logger.Error(err, "Failed to reconcile inventory",
 				"name", inventorySpec.Name,
 				"instance", instance.Name,
 				"details", err.Error())
-			instance.Status.InventoryStatuses[inventorySpec.Name] = fmt.Sprintf("Failed: %v", err)
+			instance.Status.InventoryStatuses[inventorySpec.Name] = "Failed: " + awx.StatusMessage("inventory", inventorySpec.Name, err)
+			setReferencesResolved(instance, fmt.Errorf("inventory %s: %w", inventorySpec.Name, err))
 
 			// Update reconciliation status
-			if err := r.Status().Update(ctx, instance); err != nil {
+			setSyncedConditions(instance)
+			if err := r.updateStatus(ctx, instance); err != nil {
 				logger.Error(err, "Failed to update AWXInstance status")
 				return ctrl.Result{}, err
 			}
 
-			return ctrl.Result{RequeueAfter: time.Minute}, err
+			return requeueAfterError(err, time.Minute)
 		}
 		instance.Status.InventoryStatuses[inventorySpec.Name] = "Reconciled"
+		r.recordObjectID(ctx, instance, "inventories", inventorySpec.Name, inventory)
+		r.recordSpecHash(instance, awxClient, "inventories", inventorySpec.Name, inventorySpec)
 	}
 
 	// Reconcile Job Templates (after projects and inventories)
 	jobTemplateManager := awx.NewJobTemplateManager(awxClient)
-	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+	for i, jobTemplateSpec := range instance.Spec.JobTemplates {
+		if !batch.includes(batchJobTemplates, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "job_templates", jobTemplateSpec.Name, jobTemplateSpec) {
+			continue
+		}
 		logger.Info("Reconciling job template", "name", jobTemplateSpec.Name, "instance", instance.Name)
-		_, err := jobTemplateManager.EnsureJobTemplate(jobTemplateSpec)
+		jobTemplate, err := jobTemplateManager.EnsureJobTemplate(jobTemplateSpec)
 		if err != nil {
+			if awx.IsDeadlineExceeded(err) && batch.expire() {
+				break
+			}
+			if conflictErr, ok := awx.AsConflictError(err); ok {
+				instance.Status.JobTemplateStatuses[jobTemplateSpec.Name] = fmt.Sprintf("Locked: %v", conflictErr)
+				return r.waitForUnlock(ctx, instance, conflictErr)
+			}
 			logger.Error(err, "Failed to reconcile job template",
 				"name", jobTemplateSpec.Name,
 				"instance", instance.Name,
 				"details", err.Error())
-			instance.Status.JobTemplateStatuses[jobTemplateSpec.Name] = fmt.Sprintf("Failed: %v", err)
+			instance.Status.JobTemplateStatuses[jobTemplateSpec.Name] = failedStatus(err) + awx.StatusMessage("job template", jobTemplateSpec.Name, err)
+			setReferencesResolved(instance, fmt.Errorf("job template %s: %w", jobTemplateSpec.Name, err))
 
 			// Update reconciliation status
-			if err := r.Status().Update(ctx, instance); err != nil {
+			setSyncedConditions(instance)
+			if err := r.updateStatus(ctx, instance); err != nil {
 				logger.Error(err, "Failed to update AWXInstance status")
 				return ctrl.Result{}, err
 			}
 
-			return ctrl.Result{RequeueAfter: time.Minute}, err
+			return requeueAfterError(err, time.Minute)
 		}
 		instance.Status.JobTemplateStatuses[jobTemplateSpec.Name] = "Reconciled"
+		r.recordObjectID(ctx, instance, "job_templates", jobTemplateSpec.Name, jobTemplate)
+		r.recordExecutionEnvironment(ctx, instance, jobTemplateManager, jobTemplateSpec.Name, jobTemplate)
+		// The spec hash is only recorded once the dependencies are ready, so
+		// waiting job templates are checked again on the next reconcile
+		if status := waitingStatus(jobTemplateManager, jobTemplateSpec); status != "" {
+			instance.Status.JobTemplateStatuses[jobTemplateSpec.Name] = status
+			continue
+		}
+		r.recordSpecHash(instance, awxClient, "job_templates", jobTemplateSpec.Name, jobTemplateSpec)
+	}
+
+	// Reconcile Workflow Job Templates (after the job templates their nodes run)
+	workflowManager := awx.NewWorkflowJobTemplateManager(awxClient)
+	for i, workflowSpec := range instance.Spec.WorkflowJobTemplates {
+		if !batch.includes(batchWorkflowJobTemplates, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "workflow_job_templates", workflowSpec.Name, workflowSpec) {
+			continue
+		}
+		logger.Info("Reconciling workflow job template", "name", workflowSpec.Name, "instance", instance.Name)
+		workflow, err := workflowManager.EnsureWorkflowJobTemplate(workflowSpec)
+		if err == nil && workflowSpec.Webhook != nil {
+			err = r.publishWebhookSecret(ctx, instance, workflowManager, workflowSpec, workflow)
+		}
+		if err != nil {
+			if awx.IsDeadlineExceeded(err) && batch.expire() {
+				break
+			}
+			if conflictErr, ok := awx.AsConflictError(err); ok {
+				instance.Status.WorkflowJobTemplateStatuses[workflowSpec.Name] = fmt.Sprintf("Locked: %v", conflictErr)
+				return r.waitForUnlock(ctx, instance, conflictErr)
+			}
+			logger.Error(err, "Failed to reconcile workflow job template",
+				"name", workflowSpec.Name,
+				"instance", instance.Name,
+				"details", err.Error())
+			instance.Status.WorkflowJobTemplateStatuses[workflowSpec.Name] = "Failed: " + awx.StatusMessage("workflow job template", workflowSpec.Name, err)
+			setReferencesResolved(instance, fmt.Errorf("workflow job template %s: %w", workflowSpec.Name, err))
+
+			// Update reconciliation status
+			setSyncedConditions(instance)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+				return ctrl.Result{}, err
+			}
+
+			return requeueAfterError(err, time.Minute)
+		}
+		instance.Status.WorkflowJobTemplateStatuses[workflowSpec.Name] = "Reconciled"
+		r.recordObjectID(ctx, instance, "workflow_job_templates", workflowSpec.Name, workflow)
+		r.recordSpecHash(instance, awxClient, "workflow_job_templates", workflowSpec.Name, workflowSpec)
+	}
+
+	// Continue with the next batch before the instance-wide settings are applied
+	if !batch.complete() {
+		return r.continueWithNextBatch(ctx, instance, batch)
+	}
+	deadline.startPhase(0)
+	instance.Status.ReconcileCursor = nil
+	pruneSpecHashes(instance)
+
+	// Remove the webhook keys of workflows that no longer declare a webhook
+	if err := r.pruneWebhookSecrets(ctx, instance); err != nil {
+		logger.Error(err, "Failed to remove webhook Secrets", "instance", instance.Name)
+	}
+
+	// Apply the Automation Analytics settings
+	if instance.Spec.Analytics != nil {
+		if err := r.reconcileAnalytics(ctx, instance, adminClient); err != nil {
+			logger.Error(err, "Failed to reconcile analytics settings", "instance", instance.Name)
+			instance.Status.AnalyticsStatus = fmt.Sprintf("Failed: %v", err)
+			setReferencesResolved(instance, err)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+				return ctrl.Result{}, err
+			}
+			return requeueAfterError(err, time.Minute)
+		}
+		instance.Status.AnalyticsStatus = "Reconciled"
+	}
+
+	// Apply the retention of jobs and activity stream entries
+	if instance.Spec.JobCleanup != nil {
+		if err := awx.NewCleanupManager(adminClient).EnsureJobCleanup(instance.Spec.JobCleanup); err != nil {
+			logger.Error(err, "Failed to reconcile cleanup schedules", "instance", instance.Name)
+			instance.Status.JobCleanupStatus = fmt.Sprintf("Failed: %v", err)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+				return ctrl.Result{}, err
+			}
+			return requeueAfterError(err, time.Minute)
+		}
+		instance.Status.JobCleanupStatus = "Reconciled"
+	} else {
+		instance.Status.JobCleanupStatus = ""
 	}
 
-	// Update Ready condition
+	// Apply the settings of the declared organizations, after the Galaxy
+	// credentials they refer to were reconciled
+	if len(instance.Spec.Organizations) > 0 {
+		if err := awx.NewOrganizationManager(adminClient).EnsureOrganizations(instance.Spec.Organizations); err != nil {
+			logger.Error(err, "Failed to reconcile organizations", "instance", instance.Name)
+			instance.Status.OrganizationsStatus = fmt.Sprintf("Failed: %v", err)
+			setReferencesResolved(instance, err)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+				return ctrl.Result{}, err
+			}
+			return requeueAfterError(err, time.Minute)
+		}
+		instance.Status.OrganizationsStatus = "Reconciled"
+	} else {
+		instance.Status.OrganizationsStatus = ""
+	}
+
+	// Register the execution and hop nodes of the mesh
+	if len(instance.Spec.MeshInstances) > 0 {
+		if err := r.reconcileMeshInstances(ctx, instance, adminClient); err != nil {
+			logger.Error(err, "Failed to reconcile mesh instances", "instance", instance.Name)
+			if err := r.updateStatus(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update AWXInstance status")
+				return ctrl.Result{}, err
+			}
+			return requeueAfterError(err, time.Minute)
+		}
+	} else {
+		instance.Status.MeshInstanceStatuses = nil
+	}
+
+	// Publish the facts AWX gathered about the declared hosts
+	r.collectHostFacts(ctx, instance, inventoryManager)
+
+	// Warn when job templates request more parallelism than AWX can provide
+	r.checkJobTemplateCapacity(ctx, instance, jobTemplateManager)
+
+	// Warn when organizations approach their max hosts
+	r.checkHostQuotas(ctx, instance, awxClient)
+
+	// Record the session tokens of the clients and revoke those replaced
+	r.trackSessionTokens(ctx, instance, adminClient, awxClient)
+
+	// Push the same resources to the target AWX instances
+	r.pushToTargets(ctx, instance)
+
+	// Update the per kind Synced conditions and the aggregated Ready condition
+	setSyncedConditions(instance)
+	setReferencesResolved(instance, nil)
+	meta.RemoveStatusCondition(&instance.Status.Conditions, conditionReconciling)
+	if waiting := waitingJobTemplates(instance); len(waiting) > 0 {
+		return r.waitForDependencies(ctx, instance, waiting)
+	}
 	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-		Type:               "Ready",
+		Type:               conditionReady,
 		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
 		LastTransitionTime: metav1.Now(),
 		Reason:             "ReconciliationSucceeded",
 		Message:            "AWXInstance resources have been reconciled successfully",
 	})
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               "Degraded",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "ReconciliationSucceeded",
+		Message:            "AWXInstance resources have been reconciled successfully",
+	})
+
+	instance.Status.ObservedGeneration = instance.Generation
 
 	// Update status
-	if err := r.Status().Update(ctx, instance); err != nil {
+	if err := r.updateStatus(ctx, instance); err != nil {
 		logger.Error(err, "Failed to update AWXInstance status")
 		return ctrl.Result{}, err
 	}
@@ -308,15 +992,79 @@ func (r *AWXInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
 }
 
+// refuseMassDeletion marks the instance as Degraded because reconciling an
+// inventory would delete more hosts than its threshold allows
+func (r *AWXInstanceReconciler) refuseMassDeletion(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, massErr *awx.MassDeletionError) (ctrl.Result, error) {
+
+	logger := log.FromContext(ctx)
+	logger.Info("Refusing to mass-delete inventory hosts",
+		"instance", instance.Name,
+		"inventory", massErr.Inventory,
+		"existing", massErr.ExistingHosts,
+		"toDelete", massErr.HostsToDelete)
+
+	instance.Status.InventoryStatuses[massErr.Inventory] = fmt.Sprintf("Blocked: %v", massErr)
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               "Degraded",
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "MassDeletionRefused",
+		Message:            massDeletionMessage(instance, massErr),
+	})
+	setSyncedConditions(instance)
+
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+		return ctrl.Result{}, err
+	}
+
+	// Keep checking in case the hosts are removed from AWX by other means
+	return ctrl.Result{RequeueAfter: time.Minute}, nil
+}
+
+// waitForUnlock marks the instance as Reconciling because AWX kept an object
+// locked by a running sync or job after the manager retried the change
+func (r *AWXInstanceReconciler) waitForUnlock(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, conflictErr *awx.ConflictError) (ctrl.Result, error) {
+
+	logger := log.FromContext(ctx)
+	logger.Info("AWX object is still locked, retrying later",
+		"instance", instance.Name,
+		"operation", conflictErr.Operation,
+		"attempts", conflictErr.Attempts)
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReconciling,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "AWXObjectLocked",
+		Message:            conflictErr.Error(),
+	})
+	setSyncedConditions(instance)
+
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+		return ctrl.Result{}, err
+	}
+
+	// Locks are released when the sync or job finishes
+	return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+}
+
 // reconcileInternalChanges checks if AWX's internal state matches the desired state
 // and corrects any differences found. Returns true if changes were detected and corrected.
 func (r *AWXInstanceReconciler) reconcileInternalChanges(ctx context.Context,
-	instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) (bool, error) {
+	instance *awxv1alpha1.AWXInstance, awxClient *awx.Client, batch *reconcileBatch) (bool, error) {
 
 	logger := log.FromContext(ctx)
 	changesDetected := false
 
 	// Ensure status maps are initialized
+	if instance.Status.CredentialStatuses == nil {
+		instance.Status.CredentialStatuses = make(map[string]string)
+	}
 	if instance.Status.ProjectStatuses == nil {
 		instance.Status.ProjectStatuses = make(map[string]string)
 	}
@@ -326,14 +1074,51 @@ func (r *AWXInstanceReconciler) reconcileInternalChanges(ctx context.Context,
 	if instance.Status.JobTemplateStatuses == nil {
 		instance.Status.JobTemplateStatuses = make(map[string]string)
 	}
+	if instance.Status.WorkflowJobTemplateStatuses == nil {
+		instance.Status.WorkflowJobTemplateStatuses = make(map[string]string)
+	}
 
 	// Create managers for each resource type
+	credentialManager := awx.NewCredentialManager(awxClient)
 	projectManager := awx.NewProjectManager(awxClient)
 	inventoryManager := awx.NewInventoryManager(awxClient)
 	jobTemplateManager := awx.NewJobTemplateManager(awxClient)
+	workflowManager := awx.NewWorkflowJobTemplateManager(awxClient)
+
+	// Check Credentials
+	for i, credentialSpec := range instance.Spec.Credentials {
+		if !batch.includes(batchCredentials, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "credentials", credentialSpec.Name, credentialSpec) {
+			continue
+		}
+		logger.Info("Checking credential state", "name", credentialSpec.Name)
+		credential, err := credentialManager.GetCredential(credentialSpec.Name)
+		if err != nil {
+			return false, fmt.Errorf("failed to get credential %s: %w", credentialSpec.Name, err)
+		}
+
+		// If credential doesn't exist or its configuration doesn't match the spec, reconcile it
+		if credential == nil || !credentialManager.IsCredentialInDesiredState(credential, credentialSpec) {
+			logger.Info("Credential needs reconciliation", "name", credentialSpec.Name)
+			_, err := credentialManager.EnsureCredential(credentialSpec)
+			if err != nil {
+				return false, fmt.Errorf("failed to reconcile credential %s: %w", credentialSpec.Name, err)
+			}
+			instance.Status.CredentialStatuses[credentialSpec.Name] = "Reconciled (corrected internal changes)"
+			changesDetected = true
+		}
+	}
 
 	// Check Projects
-	for _, projectSpec := range instance.Spec.Projects {
+	for i, projectSpec := range instance.Spec.Projects {
+		if !batch.includes(batchProjects, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "projects", projectSpec.Name, projectSpec) {
+			continue
+		}
 		logger.Info("Checking project state", "name", projectSpec.Name)
 		project, err := projectManager.GetProject(projectSpec.Name)
 		if err != nil {
@@ -353,7 +1138,13 @@ func (r *AWXInstanceReconciler) reconcileInternalChanges(ctx context.Context,
 	}
 
 	// Check Inventories
-	for _, inventorySpec := range instance.Spec.Inventories {
+	for i, inventorySpec := range instance.Spec.Inventories {
+		if !batch.includes(batchInventories, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "inventories", inventorySpec.Name, inventorySpec) {
+			continue
+		}
 		logger.Info("Checking inventory state", "name", inventorySpec.Name)
 		inventory, err := inventoryManager.GetInventory(inventorySpec.Name)
 		if err != nil {
@@ -373,7 +1164,13 @@ func (r *AWXInstanceReconciler) reconcileInternalChanges(ctx context.Context,
 	}
 
 	// Check Job Templates
-	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+	for i, jobTemplateSpec := range instance.Spec.JobTemplates {
+		if !batch.includes(batchJobTemplates, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "job_templates", jobTemplateSpec.Name, jobTemplateSpec) {
+			continue
+		}
 		logger.Info("Checking job template state", "name", jobTemplateSpec.Name)
 		jobTemplate, err := jobTemplateManager.GetJobTemplate(jobTemplateSpec.Name)
 		if err != nil {
@@ -392,61 +1189,114 @@ func (r *AWXInstanceReconciler) reconcileInternalChanges(ctx context.Context,
 		}
 	}
 
+	// Check Workflow Job Templates
+	for i, workflowSpec := range instance.Spec.WorkflowJobTemplates {
+		if !batch.includes(batchWorkflowJobTemplates, i) {
+			continue
+		}
+		if r.specUnchanged(instance, awxClient, "workflow_job_templates", workflowSpec.Name, workflowSpec) {
+			continue
+		}
+		logger.Info("Checking workflow job template state", "name", workflowSpec.Name)
+		workflow, err := workflowManager.GetWorkflowJobTemplate(workflowSpec.Name)
+		if err != nil {
+			return false, fmt.Errorf("failed to get workflow job template %s: %w", workflowSpec.Name, err)
+		}
+
+		// If the workflow doesn't exist or its node graph doesn't match the spec, reconcile it
+		if workflow == nil || !workflowManager.IsWorkflowJobTemplateInDesiredState(workflow, workflowSpec) {
+			logger.Info("Workflow job template needs reconciliation", "name", workflowSpec.Name)
+			_, err := workflowManager.EnsureWorkflowJobTemplate(workflowSpec)
+			if err != nil {
+				return false, fmt.Errorf("failed to reconcile workflow job template %s: %w", workflowSpec.Name, err)
+			}
+			instance.Status.WorkflowJobTemplateStatuses[workflowSpec.Name] = "Reconciled (corrected internal changes)"
+			changesDetected = true
+		}
+	}
+
 	return changesDetected, nil
 }
 
-// finalizeAWXInstance performs cleanup when the instance is being deleted
+// finalizeAWXInstance performs cleanup when the instance is being deleted.
+// The connection settings and AWX names are resolved on a copy of the
+// instance, so the admin password never ends up in a written spec.
 func (r *AWXInstanceReconciler) finalizeAWXInstance(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Finalizing AWXInstance", "name", instance.Name)
+	instance = instance.DeepCopy()
+	defer r.forgetSpecHashKey(instance)
+
+	// Observed resources were never managed, so they are left in place. Only
+	// session tokens from before the instance was observed are revoked.
+	if instance.Spec.Mode == awxv1alpha1.ModeObserve {
+		logger.Info("Instance is in Observe mode, leaving AWX resources in place", "name", instance.Name)
+		defer r.forgetAWXClient(instance)
+		return r.revokeObservedSessionTokens(ctx, instance)
+	}
 
-	// Set the protocol, defaulting to https if not specified
-	protocol := "https"
-	if instance.Spec.Protocol != "" {
-		protocol = instance.Spec.Protocol
+	// Resolved and discovered connection settings are needed to reach AWX for the cleanup
+	if err := r.resolveConnection(ctx, instance); err != nil {
+		return err
 	}
 
-	// Create AWX client
-	baseURL := fmt.Sprintf("%s://%s", protocol, instance.Spec.Hostname)
-	awxClient := awx.NewClient(baseURL, instance.Spec.AdminUser, instance.Spec.AdminPassword)
+	// The objects were created under their AWX names
+	if err := r.applyNamingPolicy(ctx, instance); err != nil {
+		return err
+	}
 
-	// Delete job templates first (as they depend on projects and inventories)
-	jobTemplateManager := awx.NewJobTemplateManager(awxClient)
-	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
-		logger.Info("Deleting job template", "name", jobTemplateSpec.Name)
-		err := jobTemplateManager.DeleteJobTemplate(jobTemplateSpec.Name)
-		if err != nil {
-			logger.Error(err, "Failed to delete job template", "name", jobTemplateSpec.Name)
-			return err
-		}
+	// Create AWX client, deleting the declared resources as the tenant's AWX user
+	adminClient := r.awxClientFor(ctx, instance)
+	defer r.forgetAWXClient(instance)
+	awxClient, err := r.tenantClientFor(ctx, instance, adminClient)
+	if err != nil {
+		return err
 	}
 
-	// Delete inventories
-	inventoryManager := awx.NewInventoryManager(awxClient)
-	for _, inventorySpec := range instance.Spec.Inventories {
-		logger.Info("Deleting inventory", "name", inventorySpec.Name)
-		err := inventoryManager.DeleteInventory(inventorySpec.Name)
-		if err != nil {
-			logger.Error(err, "Failed to delete inventory", "name", inventorySpec.Name)
-			return err
-		}
+	// Delete the declared objects, leaving the retained kinds in place
+	if err := r.deleteManagedObjects(ctx, instance, awxClient); err != nil {
+		return err
 	}
 
-	// Delete projects
-	projectManager := awx.NewProjectManager(awxClient)
-	for _, projectSpec := range instance.Spec.Projects {
-		logger.Info("Deleting project", "name", projectSpec.Name)
-		err := projectManager.DeleteProject(projectSpec.Name)
-		if err != nil {
-			logger.Error(err, "Failed to delete project", "name", projectSpec.Name)
-			return err
-		}
+	// Deprovision the mesh instances
+	if err := r.deprovisionMeshInstances(ctx, instance, adminClient); err != nil {
+		logger.Error(err, "Failed to deprovision mesh instances", "name", instance.Name)
+		return err
+	}
+
+	// Remove the resources pushed to target AWX instances
+	if err := r.deleteFromTargets(ctx, instance); err != nil {
+		logger.Error(err, "Failed to delete resources on targets", "name", instance.Name)
+		return err
+	}
+
+	// Revoke the session tokens last, the clients are not used afterwards
+	if err := r.revokeSessionTokens(ctx, instance, adminClient, awxClient); err != nil {
+		logger.Error(err, "Failed to revoke AWX session tokens", "name", instance.Name)
+		return err
 	}
 
 	logger.Info("Successfully finalized AWXInstance", "name", instance.Name)
 	return nil
 }
 
+// removeFinalizer removes a finalizer from the AWXInstance. Only the
+// finalizers are patched, guarded by the resource version, so nothing else
+// held in memory is written back.
+func (r *AWXInstanceReconciler) removeFinalizer(ctx context.Context, instance *awxv1alpha1.AWXInstance, finalizer string) error {
+	finalizers := slices.DeleteFunc(slices.Clone(instance.Finalizers), func(name string) bool { return name == finalizer })
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": instance.ResourceVersion,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return r.Patch(ctx, instance.DeepCopy(), client.RawPatch(types.MergePatchType, data))
+}
+
 // testConnection tests connectivity to the AWX instance
 func (r *AWXInstanceReconciler) testConnection(ctx context.Context, awxClient *awx.Client) error {
 	logger := log.FromContext(ctx)
@@ -457,7 +1307,9 @@ func (r *AWXInstanceReconciler) testConnection(ctx context.Context, awxClient *a
 	if err != nil {
 		// Parse the error message to provide more context
 		var errorDetails string
-		if strings.Contains(err.Error(), "failed to connect") {
+		if awx.IsCircuitOpen(err) {
+			errorDetails = "AWX API is failing repeatedly - requests are paused by the circuit breaker"
+		} else if strings.Contains(err.Error(), "failed to connect") {
 			errorDetails = "Network connectivity issue - check network routes and firewall rules"
 		} else if strings.Contains(err.Error(), "unexpected status code: 401") {
 			errorDetails = "Authentication failed - check username and password"
@@ -481,7 +1333,52 @@ func (r *AWXInstanceReconciler) testConnection(ctx context.Context, awxClient *a
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *AWXInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&awxv1alpha1.AWXInstance{}).
-		Complete(r)
+	if r.ProxyAWXMetrics {
+		collector := newAWXMetricsCollector(r)
+		if err := metrics.Registry.Register(collector); err != nil {
+			return fmt.Errorf("failed to register AWX metrics proxy: %w", err)
+		}
+		if err := mgr.Add(collector); err != nil {
+			return fmt.Errorf("failed to start AWX metrics proxy: %w", err)
+		}
+	}
+	if r.FleetMetrics {
+		if err := metrics.Registry.Register(&awxFleetCollector{reconciler: r}); err != nil {
+			return fmt.Errorf("failed to register AWX fleet metrics: %w", err)
+		}
+	}
+
+	if err := setupIndexes(context.Background(), mgr); err != nil {
+		return fmt.Errorf("failed to register AWXInstance indexes: %w", err)
+	}
+
+	// The initial resync starts once this replica leads, not when it starts
+	go func() {
+		<-mgr.Elected()
+		r.resync.start(time.Now())
+	}()
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&awxv1alpha1.AWXInstance{}, builder.WithPredicates(specOrReconcileNowChanged)).
+		Watches(&awxv1alpha1.AWXInstance{}, r.resync.createHandler()).
+		Watches(&awxv1alpha1.AWXInstance{}, handler.EnqueueRequestsFromMapFunc(r.instancesForTarget),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.instancesForConfigMap)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.instancesForSecret),
+			builder.WithPredicates(secretDataChanged))
+
+	// Requeue instances when AWX notifies about their jobs
+	if r.NotificationAddress != "" {
+		receiver, err := newNotificationReceiver(r, r.NotificationAddress, r.NotificationToken)
+		if err != nil {
+			return err
+		}
+		if err := mgr.Add(receiver); err != nil {
+			return fmt.Errorf("failed to add AWX notification receiver: %w", err)
+		}
+		controllerBuilder = controllerBuilder.WatchesRawSource(&source.Channel{Source: receiver.events},
+			&handler.EnqueueRequestForObject{})
+	}
+
+	return controllerBuilder.Complete(r)
 }
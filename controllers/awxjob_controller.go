@@ -0,0 +1,355 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// jobPollInterval is how often a running job is checked
+const jobPollInterval = 10 * time.Second
+
+// launchClockSkew is how much earlier than the recorded launch request a job
+// may be created in AWX and still be found as the job of the AWXJob
+const launchClockSkew = time.Minute
+
+const (
+	// maxJobStdoutLines is the number of output lines of a job kept in the
+	// AWXJob status
+	maxJobStdoutLines = 20
+
+	// jobStdoutArtifact is the name of the stored output of a job, distinct
+	// from the output of an AWXProjectSync with the same name
+	jobStdoutArtifact = "job-stdout"
+)
+
+// AWXJobReconciler launches the AWX job template of an AWXJob once and tracks
+// the job to completion
+type AWXJobReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Instances builds the AWX clients of the referenced AWXInstances, sharing
+	// their cached clients and session tokens
+	Instances *AWXInstanceReconciler
+
+	// Artifacts keeps the complete output of jobs, if set. The status only
+	// holds its last lines.
+	Artifacts ArtifactStore
+}
+
+//+kubebuilder:rbac:groups=awx.ansible.com,resources=awxjobs,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=awx.ansible.com,resources=awxjobs/status,verbs=get;update;patch
+
+// Reconcile launches the job of a new AWXJob, reports its progress in the
+// status and deletes the AWXJob once its TTL expired
+func (r *AWXJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	job := &awxv1alpha1.AWXJob{}
+	if err := r.Get(ctx, req.NamespacedName, job); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if jobFinished(job) {
+		return r.expire(ctx, job, time.Now())
+	}
+
+	awxClient, err := r.Instances.referencedClient(ctx, job.Namespace, job.Spec.InstanceRef)
+	if err != nil {
+		return r.setPending(ctx, job, err.Error())
+	}
+	jtm := awx.NewJobTemplateManager(awxClient)
+
+	// A launch was requested but its job never recorded, e.g. because the
+	// operator stopped right after the launch. The job is taken over instead
+	// of launching another one.
+	if job.Status.JobID == 0 && job.Status.LaunchRequestedAt != nil {
+		since := job.Status.LaunchRequestedAt.Add(-launchClockSkew)
+		id, err := jtm.FindLaunchedJob(job.Spec.JobTemplate, since)
+		if err != nil {
+			logger.Error(err, "Failed to look up launched job", "jobTemplate", job.Spec.JobTemplate)
+			return requeueAfterError(err, 30*time.Second)
+		}
+		if id != 0 {
+			logger.Info("Found job of an unrecorded launch", "job", id, "jobTemplate", job.Spec.JobTemplate)
+			if err := r.recordLaunch(ctx, job, id); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Launch the job once; its ID marks the job as launched. The launch
+	// request is recorded first. The passwords and survey answers are read
+	// right before the launch and only kept in memory.
+	if job.Status.JobID == 0 {
+		passwords, err := r.credentialPasswords(ctx, job)
+		if err != nil {
+			return r.setPending(ctx, job, err.Error())
+		}
+		answers, err := r.surveyAnswers(ctx, job)
+		if err != nil {
+			return r.setPending(ctx, job, err.Error())
+		}
+		now := metav1.Now()
+		job.Status.LaunchRequestedAt = &now
+		if err := r.updateStatus(ctx, job); err != nil {
+			logger.Error(err, "Failed to record launch request")
+			return ctrl.Result{}, err
+		}
+		id, err := jtm.LaunchJobTemplate(job.Spec.JobTemplate, awx.LaunchOptions{
+			CredentialPasswords: passwords,
+			SurveyAnswers:       answers,
+			Verbosity:           job.Spec.Verbosity,
+			DiffMode:            job.Spec.DiffMode,
+			JobTags:             job.Spec.JobTags,
+			SkipTags:            job.Spec.SkipTags,
+		})
+		if err != nil {
+			logger.Error(err, "Failed to launch job template", "jobTemplate", job.Spec.JobTemplate)
+			if !launchUncertain(err) {
+				job.Status.LaunchRequestedAt = nil
+			}
+			return r.setPending(ctx, job, err.Error())
+		}
+		if err := r.recordLaunch(ctx, job, id); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	awxJob, err := jtm.GetJob(job.Status.JobID)
+	if err != nil {
+		logger.Error(err, "Failed to read job", "job", job.Status.JobID)
+		return requeueAfterError(err, 30*time.Second)
+	}
+	job.Status.AWXStatus = awxJob.Status
+	job.Status.EffectiveLaunch = &awxv1alpha1.JobLaunchParameters{
+		Verbosity: int32(awxJob.Verbosity),
+		DiffMode:  awxJob.DiffMode,
+		JobTags:   awxJob.JobTags,
+		SkipTags:  awxJob.SkipTags,
+	}
+	if !awxJob.Started.IsZero() {
+		job.Status.StartedAt = &metav1.Time{Time: awxJob.Started}
+	}
+	if !awxJob.Done() {
+		if err := r.updateStatus(ctx, job); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: jobPollInterval}, nil
+	}
+
+	// The output is best effort, the result of the job is in the phase
+	if output, err := jtm.JobOutput(awxJob.ID); err != nil {
+		logger.Error(err, "Failed to read output of job", "job", awxJob.ID)
+	} else {
+		job.Status.Stdout = awx.LastLines(output, maxJobStdoutLines)
+		if r.Artifacts != nil {
+			if ref, err := r.Artifacts.Save(ctx, job, jobStdoutArtifact, []byte(output)); err != nil {
+				logger.Error(err, "Failed to store output of job", "job", awxJob.ID)
+			} else {
+				job.Status.StdoutRef = ref
+			}
+		}
+	}
+	finished := awxJob.Finished
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	job.Status.FinishedAt = &metav1.Time{Time: finished}
+	if awxJob.Succeeded() {
+		job.Status.Phase = awxv1alpha1.JobSuccessful
+		r.Recorder.Eventf(job, corev1.EventTypeNormal, "JobSucceeded",
+			"Job %d of job template %s succeeded", awxJob.ID, job.Spec.JobTemplate)
+	} else {
+		job.Status.Phase = awxv1alpha1.JobFailed
+		job.Status.Message = "job " + awxJob.Status
+		r.Recorder.Eventf(job, corev1.EventTypeWarning, "JobFailed",
+			"Job %d of job template %s is %s", awxJob.ID, job.Spec.JobTemplate, awxJob.Status)
+	}
+	if err := r.updateStatus(ctx, job); err != nil {
+		logger.Error(err, "Failed to update AWXJob status")
+		return ctrl.Result{}, err
+	}
+	return r.expire(ctx, job, time.Now())
+}
+
+// launchUncertain reports whether a failed launch may have started a job
+// anyway, because it was sent but the answer of AWX never arrived. Launches
+// refused by AWX or before they were sent started no job.
+func launchUncertain(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, awx.ErrDeadlineExceeded)
+}
+
+// recordLaunch records the launched job in the status
+func (r *AWXJobReconciler) recordLaunch(ctx context.Context, job *awxv1alpha1.AWXJob, id int) error {
+	job.Status.JobID = id
+	job.Status.Phase = awxv1alpha1.JobRunning
+	job.Status.Message = ""
+	r.Recorder.Eventf(job, corev1.EventTypeNormal, "JobLaunched",
+		"Launched job %d of job template %s", id, job.Spec.JobTemplate)
+	if err := r.updateStatus(ctx, job); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to record launched job", "job", id)
+		return err
+	}
+	return nil
+}
+
+// updateStatus writes the status of the job, retrying with the latest
+// resource version on conflicts. Only this controller writes the status and
+// the spec is immutable, so the status is never outdated by the AWXJob read.
+func (r *AWXJobReconciler) updateStatus(ctx context.Context, job *awxv1alpha1.AWXJob) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := r.Status().Update(ctx, job)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		latest := &awxv1alpha1.AWXJob{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(job), latest); err != nil {
+			return err
+		}
+		job.ResourceVersion = latest.ResourceVersion
+		return err
+	})
+}
+
+// credentialPasswords reads the credential passwords of the job from their
+// Secrets, keyed as AWX expects them in credential_passwords
+func (r *AWXJobReconciler) credentialPasswords(ctx context.Context, job *awxv1alpha1.AWXJob) (map[string]string, error) {
+	passwords := make(map[string]string, len(job.Spec.CredentialPasswords))
+	for _, source := range job.Spec.CredentialPasswords {
+		if err := r.readSecretValue(ctx, job, "credential password "+source.Name, source.SecretKeyRef,
+			passwords, source.Name); err != nil {
+			return nil, err
+		}
+	}
+	return passwords, nil
+}
+
+// surveyAnswers reads the survey answers of the job from their Secrets, keyed
+// by the variable of their question
+func (r *AWXJobReconciler) surveyAnswers(ctx context.Context, job *awxv1alpha1.AWXJob) (map[string]string, error) {
+	answers := make(map[string]string, len(job.Spec.SurveyAnswers))
+	for _, source := range job.Spec.SurveyAnswers {
+		if err := r.readSecretValue(ctx, job, "survey answer "+source.Variable, source.SecretKeyRef,
+			answers, source.Variable); err != nil {
+			return nil, err
+		}
+	}
+	return answers, nil
+}
+
+// readSecretValue reads the selected Secret key into values under name. A
+// missing optional Secret or key is left out; what is missing otherwise is
+// reported for the described value.
+func (r *AWXJobReconciler) readSecretValue(ctx context.Context, job *awxv1alpha1.AWXJob, description string,
+	ref corev1.SecretKeySelector, values map[string]string, name string) error {
+
+	optional := ref.Optional != nil && *ref.Optional
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: job.Namespace, Name: ref.Name}
+	if err := r.Get(ctx, key, secret); err != nil {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("failed to read Secret %s for %s: %w",
+			key.Name, description, missingReference(err, "Secret", key.Name))
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok || len(value) == 0 {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", description,
+			&awx.ReferenceNotFoundError{Kind: "Secret key", Name: key.Name + "/" + ref.Key})
+	}
+	values[name] = string(value)
+	return nil
+}
+
+// jobFinished reports whether the job finished
+func jobFinished(job *awxv1alpha1.AWXJob) bool {
+	return job.Status.Phase == awxv1alpha1.JobSuccessful ||
+		job.Status.Phase == awxv1alpha1.JobFailed
+}
+
+// setPending reports why the job could not be launched or checked yet and
+// retries later, e.g. once the job template or the Secret of a password exists
+func (r *AWXJobReconciler) setPending(ctx context.Context, job *awxv1alpha1.AWXJob, message string) (ctrl.Result, error) {
+	if job.Status.JobID == 0 {
+		job.Status.Phase = awxv1alpha1.JobPending
+	}
+	job.Status.Message = message
+	if err := r.updateStatus(ctx, job); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update AWXJob status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// expire deletes a finished job once TTLSecondsAfterFinished passed, and
+// requeues it for then otherwise
+func (r *AWXJobReconciler) expire(ctx context.Context, job *awxv1alpha1.AWXJob, now time.Time) (ctrl.Result, error) {
+	ttl := job.Spec.TTLSecondsAfterFinished
+	if ttl == nil || job.Status.FinishedAt == nil {
+		return ctrl.Result{}, nil
+	}
+	remaining := job.Status.FinishedAt.Add(time.Duration(*ttl) * time.Second).Sub(now)
+	if remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.FromContext(ctx).Info("Deleting finished AWXJob", "name", job.Name)
+	// ConfigMaps are garbage collected with the job, files and objects are not
+	if r.Artifacts != nil && job.Status.StdoutRef != "" {
+		if err := r.Artifacts.Delete(ctx, job, jobStdoutArtifact); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if err := r.Delete(ctx, job); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The spec is
+// immutable, so only new jobs and the requeues of running ones are reconciled.
+func (r *AWXJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awxv1alpha1.AWXJob{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+const (
+	// maxProjectSyncStdoutLines is the number of output lines of a project
+	// update kept in the AWXProjectSync status
+	maxProjectSyncStdoutLines = 20
+
+	// projectSyncStdoutArtifact is the name of the stored output of a
+	// project update
+	projectSyncStdoutArtifact = "stdout"
+
+	// projectSyncPollInterval is how often a running project update is checked
+	projectSyncPollInterval = 10 * time.Second
+)
+
+// AWXProjectSyncReconciler syncs the AWX project of an AWXProjectSync once
+// and tracks the project update to completion
+type AWXProjectSyncReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Instances builds the AWX clients of the referenced AWXInstances, sharing
+	// their cached clients and session tokens
+	Instances *AWXInstanceReconciler
+
+	// Artifacts keeps the complete output of project updates, if set. The
+	// status only holds its last lines.
+	Artifacts ArtifactStore
+}
+
+//+kubebuilder:rbac:groups=awx.ansible.com,resources=awxprojectsyncs,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=awx.ansible.com,resources=awxprojectsyncs/status,verbs=get;update;patch
+
+// Reconcile starts the project update of a new AWXProjectSync, reports its
+// progress in the status and deletes the AWXProjectSync once its TTL expired
+func (r *AWXProjectSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	projectSync := &awxv1alpha1.AWXProjectSync{}
+	if err := r.Get(ctx, req.NamespacedName, projectSync); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if projectSyncFinished(projectSync) {
+		return r.expire(ctx, projectSync, time.Now())
+	}
+
+	awxClient, err := r.Instances.referencedClient(ctx, projectSync.Namespace, projectSync.Spec.InstanceRef)
+	if err != nil {
+		return r.setPending(ctx, projectSync, err.Error())
+	}
+	pm := awx.NewProjectManager(awxClient)
+
+	// Start the project update once; its ID marks the sync as started
+	if projectSync.Status.ProjectUpdateID == 0 {
+		id, err := pm.StartProjectUpdate(projectSync.Spec.Project)
+		if err != nil {
+			logger.Error(err, "Failed to start project update", "project", projectSync.Spec.Project)
+			return r.setPending(ctx, projectSync, err.Error())
+		}
+		projectSync.Status.ProjectUpdateID = id
+		projectSync.Status.Phase = awxv1alpha1.ProjectSyncRunning
+		projectSync.Status.Message = ""
+		r.Recorder.Eventf(projectSync, corev1.EventTypeNormal, "SyncStarted",
+			"Started update %d of project %s", id, projectSync.Spec.Project)
+		if err := r.Status().Update(ctx, projectSync); err != nil {
+			logger.Error(err, "Failed to record started project update", "projectUpdate", id)
+			return ctrl.Result{}, err
+		}
+	}
+
+	update, err := pm.GetProjectUpdate(projectSync.Status.ProjectUpdateID)
+	if err != nil {
+		logger.Error(err, "Failed to read project update", "projectUpdate", projectSync.Status.ProjectUpdateID)
+		return requeueAfterError(err, 30*time.Second)
+	}
+	projectSync.Status.AWXStatus = update.Status
+	if !update.Started.IsZero() {
+		projectSync.Status.StartedAt = &metav1.Time{Time: update.Started}
+	}
+	if !update.Done() {
+		if err := r.Status().Update(ctx, projectSync); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: projectSyncPollInterval}, nil
+	}
+
+	// The output is best effort, the result of the sync is in the phase
+	if output, err := pm.ProjectUpdateOutput(update.ID); err != nil {
+		logger.Error(err, "Failed to read output of project update", "projectUpdate", update.ID)
+	} else {
+		projectSync.Status.Stdout = awx.LastLines(output, maxProjectSyncStdoutLines)
+		if r.Artifacts != nil {
+			if ref, err := r.Artifacts.Save(ctx, projectSync, projectSyncStdoutArtifact, []byte(output)); err != nil {
+				logger.Error(err, "Failed to store output of project update", "projectUpdate", update.ID)
+			} else {
+				projectSync.Status.StdoutRef = ref
+			}
+		}
+	}
+	finished := update.Finished
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	projectSync.Status.FinishedAt = &metav1.Time{Time: finished}
+	if update.Succeeded() {
+		projectSync.Status.Phase = awxv1alpha1.ProjectSyncSuccessful
+		r.Recorder.Eventf(projectSync, corev1.EventTypeNormal, "SyncSucceeded",
+			"Project %s was synced", projectSync.Spec.Project)
+	} else {
+		projectSync.Status.Phase = awxv1alpha1.ProjectSyncFailed
+		projectSync.Status.Message = "project update " + update.Status
+		r.Recorder.Eventf(projectSync, corev1.EventTypeWarning, "SyncFailed",
+			"Update %d of project %s is %s", update.ID, projectSync.Spec.Project, update.Status)
+	}
+	if err := r.Status().Update(ctx, projectSync); err != nil {
+		logger.Error(err, "Failed to update AWXProjectSync status")
+		return ctrl.Result{}, err
+	}
+	return r.expire(ctx, projectSync, time.Now())
+}
+
+// projectSyncFinished reports whether the project update of the sync finished
+func projectSyncFinished(projectSync *awxv1alpha1.AWXProjectSync) bool {
+	return projectSync.Status.Phase == awxv1alpha1.ProjectSyncSuccessful ||
+		projectSync.Status.Phase == awxv1alpha1.ProjectSyncFailed
+}
+
+// setPending reports why the sync could not be started or checked yet and
+// retries later, e.g. once the AWXInstance or the project exists
+func (r *AWXProjectSyncReconciler) setPending(ctx context.Context, projectSync *awxv1alpha1.AWXProjectSync, message string) (ctrl.Result, error) {
+	if projectSync.Status.ProjectUpdateID == 0 {
+		projectSync.Status.Phase = awxv1alpha1.ProjectSyncPending
+	}
+	projectSync.Status.Message = message
+	if err := r.Status().Update(ctx, projectSync); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update AWXProjectSync status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// expire deletes a finished sync once TTLSecondsAfterFinished passed, and
+// requeues it for then otherwise
+func (r *AWXProjectSyncReconciler) expire(ctx context.Context, projectSync *awxv1alpha1.AWXProjectSync, now time.Time) (ctrl.Result, error) {
+	ttl := projectSync.Spec.TTLSecondsAfterFinished
+	if ttl == nil || projectSync.Status.FinishedAt == nil {
+		return ctrl.Result{}, nil
+	}
+	remaining := projectSync.Status.FinishedAt.Add(time.Duration(*ttl) * time.Second).Sub(now)
+	if remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.FromContext(ctx).Info("Deleting finished AWXProjectSync", "name", projectSync.Name)
+	// ConfigMaps are garbage collected with the sync, files and objects are not
+	if r.Artifacts != nil && projectSync.Status.StdoutRef != "" {
+		if err := r.Artifacts.Delete(ctx, projectSync, projectSyncStdoutArtifact); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if err := r.Delete(ctx, projectSync); err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The spec is
+// immutable, so only new syncs and the requeues of running ones are reconciled.
+func (r *AWXProjectSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awxv1alpha1.AWXProjectSync{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
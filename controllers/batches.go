@@ -0,0 +1,200 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// DefaultMaxObjectsPerReconcile is the number of declared objects reconciled
+// in one pass by default, which keeps a pass well within the reconcile
+// timeout even when every object needs several AWX requests
+const DefaultMaxObjectsPerReconcile = 500
+
+// batchRequeueDelay is the delay before the next batch. RequeueAfter is used
+// instead of Requeue, as the rate limiter would back off further with every
+// batch of a large spec.
+const batchRequeueDelay = time.Second
+
+// Kinds of declared objects in the order they are reconciled, which is also
+// the order in which the reconcile cursor counts them
+const (
+	batchCredentials = iota
+	batchProjects
+	batchInventories
+	batchJobTemplates
+	batchWorkflowJobTemplates
+	batchKinds
+)
+
+// reconcileBatch is the range of declared objects reconciled in this pass
+type reconcileBatch struct {
+	// offsets are the positions of the first object of each kind
+	offsets [batchKinds]int
+	start   int
+	end     int
+	total   int
+
+	// deadline is the deadline of the current phase of the reconcile
+	deadline time.Time
+	// current is the position of the object being reconciled
+	current int
+	// expired is set when a deadline ended the batch early
+	expired bool
+	// deferred is set when the batch ends before the job templates during
+	// the initial resync
+	deferred bool
+}
+
+// nextBatch returns the objects to reconcile in this pass. Specs within the
+// limit are reconciled in one pass. The batch starts at the cursor recorded
+// by the previous pass, which was cut short by the limit or a deadline, or
+// over at the first object when the spec changed since. During the initial
+// resync the batch ends before the job templates, which only follow once the
+// critical kinds of the other instances are reconciled.
+func (r *AWXInstanceReconciler) nextBatch(instance *awxv1alpha1.AWXInstance) *reconcileBatch {
+	counts := [batchKinds]int{
+		batchCredentials:          len(instance.Spec.Credentials),
+		batchProjects:             len(instance.Spec.Projects),
+		batchInventories:          len(instance.Spec.Inventories),
+		batchJobTemplates:         len(instance.Spec.JobTemplates),
+		batchWorkflowJobTemplates: len(instance.Spec.WorkflowJobTemplates),
+	}
+	batch := &reconcileBatch{}
+	for kind, count := range counts {
+		batch.offsets[kind] = batch.total
+		batch.total += count
+	}
+	batch.end = batch.total
+
+	if cursor := instance.Status.ReconcileCursor; cursor != nil &&
+		cursor.Generation == instance.Generation && cursor.Position < batch.total {
+		batch.start = cursor.Position
+	}
+	if r.MaxObjectsPerReconcile > 0 {
+		batch.end = min(batch.start+r.MaxObjectsPerReconcile, batch.total)
+	}
+
+	now := time.Now()
+	deferredFrom := batch.offsets[batchJobTemplates]
+	if r.resync.active(now) && deferredFrom < batch.total {
+		key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+		switch {
+		case batch.start < deferredFrom && deferredFrom < batch.end:
+			batch.end = deferredFrom
+			batch.deferred = true
+		case batch.start == deferredFrom && r.resync.waiting(key, now):
+			batch.end = batch.start
+			batch.deferred = true
+		}
+	}
+	return batch
+}
+
+// includes reports whether the object at index of its kind is part of the
+// batch. Once the deadline of the phase passed, the batch ends before the
+// object. The first object of the batch is always included, so that every
+// pass makes progress.
+func (b *reconcileBatch) includes(kind, index int) bool {
+	position := b.offsets[kind] + index
+	if position < b.start || position >= b.end {
+		return false
+	}
+	if position > b.start && !b.deadline.IsZero() && !time.Now().Before(b.deadline) {
+		b.end = position
+		b.expired = true
+		return false
+	}
+	b.current = position
+	return true
+}
+
+// expire ends the batch before the current object, whose requests ran into
+// the deadline. It reports false when that object is the first of the batch,
+// which then has to fail.
+func (b *reconcileBatch) expire() bool {
+	if b.current <= b.start {
+		return false
+	}
+	b.end = b.current
+	b.expired = true
+	return true
+}
+
+// complete reports whether the batch reaches the last declared object
+func (b *reconcileBatch) complete() bool {
+	return b.end == b.total
+}
+
+// continueWithNextBatch records where the next pass starts and requeues the
+// instance for it. Ready is left alone until the last batch is done.
+func (r *AWXInstanceReconciler) continueWithNextBatch(ctx context.Context, instance *awxv1alpha1.AWXInstance, batch *reconcileBatch) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciled a batch of objects, continuing with the next one",
+		"instance", instance.Name,
+		"from", batch.start,
+		"to", batch.end,
+		"total", batch.total,
+		"deadlineExceeded", batch.expired,
+		"deferred", batch.deferred)
+
+	reason := "ReconcilingInBatches"
+	message := fmt.Sprintf("Reconciled %d of %d declared objects", batch.end, batch.total)
+	delay := batchRequeueDelay
+	switch {
+	case batch.expired:
+		reason = "DeadlineExceeded"
+		message += " before the reconcile deadline"
+	case batch.deferred:
+		reason = "DeferredByInitialResync"
+		message += ", the job templates follow the credentials, projects and inventories of all instances"
+		if batch.end == batch.start {
+			delay = deferredKindsDelay
+		}
+	}
+
+	instance.Status.ReconcileCursor = &awxv1alpha1.ReconcileCursor{
+		Position:   batch.end,
+		Total:      batch.total,
+		Generation: instance.Generation,
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReconciling,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	setSyncedConditions(instance)
+
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: delay}, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// conditionAPIBudgetExceeded warns when an instance sends more AWX API
+// requests than its budget allows, which usually points at a spec that
+// makes the operator hammer AWX
+const conditionAPIBudgetExceeded = "APIBudgetExceeded"
+
+// apiBudgetWindow is the length of the window of the hourly budget
+const apiBudgetWindow = time.Hour
+
+// APIBudget holds the AWX API usage per instance above which the
+// APIBudgetExceeded condition is set. Zero disables a limit.
+type APIBudget struct {
+	// PerReconcile limits the requests of a single reconcile
+	PerReconcile int64
+	// PerHour limits the requests within a one-hour window
+	PerHour int64
+}
+
+// apiUsageWindow counts the requests of an instance since windowStart
+type apiUsageWindow struct {
+	windowStart time.Time
+	calls       int64
+}
+
+// add counts calls at now, starting a new window when the current one is over,
+// and returns the calls of the current window
+func (w *apiUsageWindow) add(now time.Time, calls int64) int64 {
+	if now.Sub(w.windowStart) >= apiBudgetWindow {
+		w.windowStart = now
+		w.calls = 0
+	}
+	w.calls += calls
+	return w.calls
+}
+
+// exceeded returns why the usage is above the budget, or an empty string
+func (b APIBudget) exceeded(perReconcile, perHour int64) string {
+	switch {
+	case b.PerReconcile > 0 && perReconcile > b.PerReconcile:
+		return fmt.Sprintf("The last reconcile sent %d AWX API requests, more than the budget of %d", perReconcile, b.PerReconcile)
+	case b.PerHour > 0 && perHour > b.PerHour:
+		return fmt.Sprintf("%d AWX API requests were sent within the hour, more than the budget of %d", perHour, b.PerHour)
+	}
+	return ""
+}
+
+// recordAPIUsage exposes the AWX API requests sent since before as metrics
+// and updates the APIBudgetExceeded condition when the budget was exceeded
+// or is met again
+func (r *AWXInstanceReconciler) recordAPIUsage(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client, before int64) {
+	calls := awxClient.RequestCount() - before
+	apiCallsPerReconcileHistogram.WithLabelValues(instance.Namespace, instance.Name).Observe(float64(calls))
+	apiCallsTotal.WithLabelValues(instance.Namespace, instance.Name).Add(float64(calls))
+
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	r.apiUsageMu.Lock()
+	if r.apiUsage == nil {
+		r.apiUsage = make(map[types.NamespacedName]*apiUsageWindow)
+	}
+	window, ok := r.apiUsage[key]
+	if !ok {
+		window = &apiUsageWindow{}
+		r.apiUsage[key] = window
+	}
+	hourly := window.add(time.Now(), calls)
+	r.apiUsageMu.Unlock()
+	apiCallsCurrentHourGauge.WithLabelValues(instance.Namespace, instance.Name).Set(float64(hourly))
+
+	condition := metav1.Condition{
+		Type:               conditionAPIBudgetExceeded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             "WithinBudget",
+		Message:            "AWX API usage is within the budget",
+	}
+	if message := r.APIBudget.exceeded(calls, hourly); message != "" {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "BudgetExceeded"
+		condition.Message = message
+	}
+
+	// Only write the status when the condition changes, not after every reconcile
+	current := meta.FindStatusCondition(instance.Status.Conditions, conditionAPIBudgetExceeded)
+	if current == nil && condition.Status == metav1.ConditionFalse {
+		return
+	}
+	if current != nil && current.Status == condition.Status && current.Message == condition.Message {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+	if condition.Status == metav1.ConditionTrue {
+		logger.Info("AWX API budget exceeded", "instance", instance.Name, "message", condition.Message)
+	}
+	condition.LastTransitionTime = metav1.Now()
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+	}
+}
+
+// forgetAPIUsage drops the API usage window and metrics of a deleted instance
+func (r *AWXInstanceReconciler) forgetAPIUsage(instance *awxv1alpha1.AWXInstance) {
+	r.apiUsageMu.Lock()
+	delete(r.apiUsage, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+	r.apiUsageMu.Unlock()
+
+	apiCallsPerReconcileHistogram.DeleteLabelValues(instance.Namespace, instance.Name)
+	apiCallsTotal.DeleteLabelValues(instance.Namespace, instance.Name)
+	apiCallsCurrentHourGauge.DeleteLabelValues(instance.Namespace, instance.Name)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// checkJobTemplateCapacity compares the parallelism requested by job templates
+// with forks or job slicing against the capacity of their instance groups and
+// warns through the CapacitySufficient condition and an Event when it exceeds it
+func (r *AWXInstanceReconciler) checkJobTemplateCapacity(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, jobTemplateManager *awx.JobTemplateManager) {
+
+	logger := log.FromContext(ctx)
+	var exceeded []string
+
+	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+		if jobTemplateSpec.Forks == 0 && jobTemplateSpec.JobSliceCount <= 1 {
+			continue
+		}
+
+		capacity, err := jobTemplateManager.AvailableCapacity(jobTemplateSpec.Name)
+		if err != nil {
+			// The capacity check is advisory, so it never fails the reconcile
+			logger.Info("Could not determine instance group capacity",
+				"jobTemplate", jobTemplateSpec.Name,
+				"error", err.Error())
+			continue
+		}
+
+		requested := awx.RequestedParallelism(jobTemplateSpec)
+		if requested > capacity {
+			message := fmt.Sprintf("job template %s requests %d (forks x slices) but its instance groups have a capacity of %d",
+				jobTemplateSpec.Name, requested, capacity)
+			exceeded = append(exceeded, message)
+			r.recordEvent(ctx, instance, corev1.EventTypeWarning, "InsufficientCapacity", message)
+		}
+	}
+
+	if len(exceeded) > 0 {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionCapacitySufficient,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "ParallelismExceedsCapacity",
+			Message:            strings.Join(exceeded, "; "),
+		})
+		return
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionCapacitySufficient,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "CapacitySufficient",
+		Message:            "Instance groups can run the requested parallelism",
+	})
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+const (
+	// conditionReady aggregates the state of the whole instance
+	conditionReady = "Ready"
+	// conditionCapacitySufficient reports whether the instance groups can run
+	// the parallelism requested by the job templates
+	conditionCapacitySufficient = "CapacitySufficient"
+	// conditionLicenseValid reports whether the AWX subscription is valid
+	conditionLicenseValid = "LicenseValid"
+	// conditionQuotaNearLimit reports whether an AWX organization uses most
+	// of its max hosts
+	conditionQuotaNearLimit = "QuotaNearLimit"
+	// conditionTargetsSynced reports whether the resources were pushed to all
+	// target AWX instances
+	conditionTargetsSynced = "TargetsSynced"
+	// conditionReconciling is set while AWX objects are locked and their
+	// changes are retried, or while a large spec is reconciled in batches
+	conditionReconciling = "Reconciling"
+	// Per resource kind conditions
+	conditionCredentialsSynced          = "CredentialsSynced"
+	conditionProjectsSynced             = "ProjectsSynced"
+	conditionInventoriesSynced          = "InventoriesSynced"
+	conditionJobTemplatesSynced         = "JobTemplatesSynced"
+	conditionWorkflowJobTemplatesSynced = "WorkflowJobTemplatesSynced"
+)
+
+// syncedKind describes the resources of one kind for the Synced conditions
+type syncedKind struct {
+	conditionType string
+	kind          string
+	reasonPrefix  string
+	names         []string
+	statuses      map[string]string
+}
+
+// setSyncedConditions derives a Synced condition per resource kind from the
+// status maps and, if any kind failed to sync, marks the instance not Ready
+// with a reason naming the first failed kind
+func setSyncedConditions(instance *awxv1alpha1.AWXInstance) {
+	kinds := []syncedKind{
+		{conditionType: conditionCredentialsSynced, kind: "credentials", reasonPrefix: "Credentials", statuses: instance.Status.CredentialStatuses},
+		{conditionType: conditionProjectsSynced, kind: "projects", reasonPrefix: "Projects", statuses: instance.Status.ProjectStatuses},
+		{conditionType: conditionInventoriesSynced, kind: "inventories", reasonPrefix: "Inventories", statuses: instance.Status.InventoryStatuses},
+		{conditionType: conditionJobTemplatesSynced, kind: "job templates", reasonPrefix: "JobTemplates", statuses: instance.Status.JobTemplateStatuses},
+		{conditionType: conditionWorkflowJobTemplatesSynced, kind: "workflow job templates", reasonPrefix: "WorkflowJobTemplates", statuses: instance.Status.WorkflowJobTemplateStatuses},
+	}
+	for _, credential := range instance.Spec.Credentials {
+		kinds[0].names = append(kinds[0].names, credential.Name)
+	}
+	for _, project := range instance.Spec.Projects {
+		kinds[1].names = append(kinds[1].names, project.Name)
+	}
+	for _, inventory := range instance.Spec.Inventories {
+		kinds[2].names = append(kinds[2].names, inventory.Name)
+	}
+	for _, jobTemplate := range instance.Spec.JobTemplates {
+		kinds[3].names = append(kinds[3].names, jobTemplate.Name)
+	}
+	for _, workflow := range instance.Spec.WorkflowJobTemplates {
+		kinds[4].names = append(kinds[4].names, workflow.Name)
+	}
+
+	var notReady *metav1.Condition
+	for _, k := range kinds {
+		var failed, pending []string
+		for _, name := range k.names {
+			status, ok := k.statuses[name]
+			switch {
+			case !ok || strings.HasPrefix(status, "Locked") || strings.HasPrefix(status, statusWaiting):
+				pending = append(pending, name)
+			case strings.HasPrefix(status, "Failed") || strings.HasPrefix(status, "Blocked"):
+				failed = append(failed, name)
+			}
+		}
+
+		condition := metav1.Condition{
+			Type:               k.conditionType,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "Synced",
+			Message:            fmt.Sprintf("All %d %s are synced", len(k.names), k.kind),
+		}
+		if len(failed) > 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "SyncFailed"
+			condition.Message = fmt.Sprintf("Failed to sync %s: %s", k.kind, strings.Join(failed, ", "))
+		} else if len(pending) > 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "SyncPending"
+			condition.Message = fmt.Sprintf("Waiting to sync %s: %s", k.kind, strings.Join(pending, ", "))
+		}
+		meta.SetStatusCondition(&instance.Status.Conditions, condition)
+
+		if condition.Status == metav1.ConditionFalse && notReady == nil {
+			notReady = &metav1.Condition{
+				Type:               conditionReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				LastTransitionTime: metav1.Now(),
+				Reason:             k.reasonPrefix + condition.Reason,
+				Message:            condition.Message,
+			}
+		}
+	}
+
+	if notReady != nil {
+		meta.SetStatusCondition(&instance.Status.Conditions, *notReady)
+	}
+}
+
+// failedStatus returns the prefix of the status of an object that failed to
+// reconcile. Changes that were rolled back are marked, so the status tells
+// whether AWX was left as before or half updated.
+func failedStatus(err error) string {
+	rolledBack, ok := awx.AsRolledBackError(err)
+	switch {
+	case !ok:
+		return "Failed: "
+	case rolledBack.RollbackErr != nil:
+		return "Failed (rollback incomplete): "
+	default:
+		return "Failed (rolled back): "
+	}
+}
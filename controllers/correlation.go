@@ -0,0 +1,57 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// annotationCorrelationID carries the correlation ID of the reconcile that
+// emitted an Event
+const annotationCorrelationID = "awx.ansible.com/correlation-id"
+
+// correlationIDKey is the context key of the correlation ID of a reconcile
+type correlationIDKey struct{}
+
+// withCorrelationID starts a reconcile with a new correlation ID. It is added
+// to the logger of the context and sent to AWX with every request, so all
+// activity of a reconcile can be found with one key.
+func withCorrelationID(ctx context.Context) context.Context {
+	correlationID := string(uuid.NewUUID())
+	ctx = context.WithValue(ctx, correlationIDKey{}, correlationID)
+	return log.IntoContext(ctx, log.FromContext(ctx).WithValues("correlationID", correlationID))
+}
+
+// correlationIDFrom returns the correlation ID of the reconcile, if any
+func correlationIDFrom(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
+
+// recordEvent emits an Event annotated with the correlation ID of the reconcile
+func (r *AWXInstanceReconciler) recordEvent(ctx context.Context, object runtime.Object, eventType, reason, message string) {
+	correlationID := correlationIDFrom(ctx)
+	if correlationID == "" {
+		r.Recorder.Event(object, eventType, reason, message)
+		return
+	}
+	r.Recorder.AnnotatedEventf(object, map[string]string{annotationCorrelationID: correlationID}, eventType, reason, "%s", message)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// resolveCredentialInputs adds the keys of the Secrets referenced by the
+// credentials to their inputs. Secret keys override inputs of the same name.
+func (r *AWXInstanceReconciler) resolveCredentialInputs(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	for i := range instance.Spec.Credentials {
+		credential := &instance.Spec.Credentials[i]
+		if credential.InputsSecretRef == nil {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Namespace: instance.Namespace, Name: credential.InputsSecretRef.Name}
+		if err := r.Get(ctx, key, secret); err != nil {
+			return fmt.Errorf("failed to read Secret %s for credential %s: %w", key.Name, credential.Name,
+				missingReference(err, "Secret", key.Name))
+		}
+
+		inputs := make(map[string]string, len(credential.Inputs)+len(secret.Data))
+		for field, value := range credential.Inputs {
+			inputs[field] = value
+		}
+		for field, value := range secret.Data {
+			inputs[field] = string(value)
+		}
+		credential.Inputs = inputs
+	}
+	return nil
+}
+
+// credentialRotated reports whether a credential is reconciled again because
+// the Secret with its inputs changed, i.e. its spec hash changed while the
+// spec of the instance did not
+func (r *AWXInstanceReconciler) credentialRotated(instance *awxv1alpha1.AWXInstance, credentialSpec awxv1alpha1.CredentialSpec) bool {
+	if credentialSpec.InputsSecretRef == nil || instance.Status.ObservedGeneration != instance.Generation {
+		return false
+	}
+	previous, ok := instance.Status.SpecHashes[objectIDKey("credentials", credentialSpec.Name)]
+	return ok && previous != r.specHash(instance, credentialSpec)
+}
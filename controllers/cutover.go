@@ -0,0 +1,266 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+var (
+	// upstreamAWXKind is the resource of an AWX deployed by the upstream
+	// awx-operator, which manages the Ingress or Route of the deployment
+	upstreamAWXKind = schema.GroupVersionKind{Group: "awx.ansible.com", Version: "v1beta1", Kind: "AWX"}
+	// certificateKind is the cert-manager resource issuing a TLS Secret
+	certificateKind = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+)
+
+// annotationCertificateName names the cert-manager Certificate that issued a Secret
+const annotationCertificateName = "cert-manager.io/certificate-name"
+
+//+kubebuilder:rbac:groups=awx.ansible.com,resources=awxs,verbs=get;patch
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;patch
+
+// verifyHostnameCutover switches the instance to a changed AWX URL once AWX
+// answers on it. AWX deployed by the upstream awx-operator is moved to the
+// new hostname first, along with its Ingress or Route and its certificate.
+// Until AWX answers the reconcile is held back with the returned result, so
+// objects are never written through an endpoint that doesn't work yet. Nil
+// lets the reconcile continue.
+func (r *AWXInstanceReconciler) verifyHostnameCutover(ctx context.Context, instance *awxv1alpha1.AWXInstance) *ctrl.Result {
+	config := clientConfigFor(instance)
+	previous := instance.Status.BaseURL
+	if previous == config.baseURL {
+		return nil
+	}
+	if previous == "" {
+		// First connection, there is nothing to cut over from
+		instance.Status.BaseURL = config.baseURL
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	if instance.Spec.DiscoverFrom != nil && !instance.Spec.ExternalInstance {
+		if err := r.moveManagedHostname(ctx, instance, urlHost(previous), urlHost(config.baseURL)); err != nil {
+			logger.Error(err, "Failed to move AWX to the new hostname", "instance", instance.Name)
+			return r.holdHostnameCutover(ctx, instance, "HostnameCutoverFailed", err.Error())
+		}
+	}
+
+	logger.Info("AWX URL changed, verifying the new endpoint",
+		"instance", instance.Name, "from", previous, "to", config.baseURL)
+	if err := newAWXClient(ctx, instance, config).TestConnection(); err != nil {
+		logger.Info("New AWX endpoint is not reachable yet, keeping the reconcile on hold",
+			"instance", instance.Name, "to", config.baseURL, "error", err.Error())
+		return r.holdHostnameCutover(ctx, instance, "HostnameCutoverPending",
+			fmt.Sprintf("AWX is not reachable on %s yet: %v", config.baseURL, err))
+	}
+
+	instance.Status.BaseURL = config.baseURL
+	instance.Status.LastHostnameCutover = &awxv1alpha1.HostnameCutover{
+		From: previous,
+		To:   config.baseURL,
+		Time: metav1.Now(),
+	}
+	r.recordEvent(ctx, instance, corev1.EventTypeNormal, "HostnameCutover",
+		fmt.Sprintf("Switched from %s to %s after verifying the new endpoint", previous, config.baseURL))
+	return nil
+}
+
+// holdHostnameCutover reports why the cutover to a changed AWX URL can't
+// happen yet and requeues the instance to check again
+func (r *AWXInstanceReconciler) holdHostnameCutover(ctx context.Context, instance *awxv1alpha1.AWXInstance, reason, message string) *ctrl.Result {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update AWXInstance status")
+	}
+	return &ctrl.Result{RequeueAfter: 30 * time.Second}
+}
+
+// urlHost returns the host of an AWX URL without its port
+func urlHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
+// serviceHost reports whether host is the address of a Service or an IP,
+// which is reached without the Ingress or Route
+func serviceHost(host string) bool {
+	return strings.HasSuffix(host, ".svc") || net.ParseIP(host) != nil
+}
+
+// moveManagedHostname moves an AWX deployed by the upstream awx-operator from
+// one hostname to another: the hostname of its Ingress or Route is changed in
+// the AWX resource, which the upstream operator rolls out, and the
+// cert-manager Certificate of its TLS Secret is re-issued for the new
+// hostname. Both are only changed while they still point at the old one, so
+// repeated calls during a pending cutover are harmless. The AWX resource and
+// the Certificate are read as unstructured objects, which bypass the cache.
+func (r *AWXInstanceReconciler) moveManagedHostname(ctx context.Context, instance *awxv1alpha1.AWXInstance, from, to string) error {
+	if from == "" || to == "" || from == to || serviceHost(from) || serviceHost(to) {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	upstream := &unstructured.Unstructured{}
+	upstream.SetGroupVersionKind(upstreamAWXKind)
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.DiscoverFrom.Name}
+	if err := r.Get(ctx, key, upstream); err != nil {
+		return fmt.Errorf("failed to read AWX %s: %w", key.Name, err)
+	}
+
+	changes, tlsSecret := movedHostnameSpec(upstream, from, to)
+	if changes != nil {
+		patch, err := json.Marshal(map[string]interface{}{"spec": changes})
+		if err != nil {
+			return err
+		}
+		if err := r.Patch(ctx, upstream, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return fmt.Errorf("failed to move AWX %s to %s: %w", key.Name, to, err)
+		}
+		logger.Info("Moved the Ingress or Route of AWX to the new hostname", "awx", key.Name, "from", from, "to", to)
+		r.recordEvent(ctx, instance, corev1.EventTypeNormal, "HostnameMoved",
+			fmt.Sprintf("Moved the Ingress or Route of AWX %s from %s to %s", key.Name, from, to))
+	}
+
+	if tlsSecret == "" {
+		return nil
+	}
+	return r.reissueCertificate(ctx, instance, tlsSecret, from, to)
+}
+
+// movedHostnameSpec returns the changes to the spec of an upstream AWX
+// resource that move its Ingress or Route from one hostname to another, nil
+// when it doesn't use the old hostname, and the TLS Secret served for the
+// hostname
+func movedHostnameSpec(upstream *unstructured.Unstructured, from, to string) (map[string]interface{}, string) {
+	ingressType, _, _ := unstructured.NestedString(upstream.Object, "spec", "ingress_type")
+	switch strings.ToLower(ingressType) {
+	case "route":
+		tlsSecret, _, _ := unstructured.NestedString(upstream.Object, "spec", "route_tls_secret")
+		host, _, _ := unstructured.NestedString(upstream.Object, "spec", "route_host")
+		if host != from {
+			return nil, tlsSecret
+		}
+		return map[string]interface{}{"route_host": to}, tlsSecret
+
+	case "ingress":
+		hosts, found, _ := unstructured.NestedSlice(upstream.Object, "spec", "ingress_hosts")
+		if !found {
+			// awx-operator before 2.5 serves a single hostname
+			tlsSecret, _, _ := unstructured.NestedString(upstream.Object, "spec", "ingress_tls_secret")
+			host, _, _ := unstructured.NestedString(upstream.Object, "spec", "hostname")
+			if host != from {
+				return nil, tlsSecret
+			}
+			return map[string]interface{}{"hostname": to}, tlsSecret
+		}
+
+		tlsSecret := ""
+		moved := false
+		for _, entry := range hosts {
+			host, ok := entry.(map[string]interface{})
+			if !ok || (host["hostname"] != from && host["hostname"] != to) {
+				continue
+			}
+			if secret, ok := host["tls_secret"].(string); ok {
+				tlsSecret = secret
+			}
+			if host["hostname"] == from {
+				host["hostname"] = to
+				moved = true
+			}
+		}
+		if !moved {
+			return nil, tlsSecret
+		}
+		return map[string]interface{}{"ingress_hosts": hosts}, tlsSecret
+	}
+	return nil, ""
+}
+
+// reissueCertificate replaces the old hostname by the new one in the DNS
+// names of the cert-manager Certificate that issued the TLS Secret, which
+// makes cert-manager issue a certificate for the new hostname. Secrets that
+// don't exist yet or weren't issued by cert-manager are left alone.
+func (r *AWXInstanceReconciler) reissueCertificate(ctx context.Context, instance *awxv1alpha1.AWXInstance, secretName, from, to string) error {
+	logger := log.FromContext(ctx)
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: secretName}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read TLS Secret %s: %w", secretName, err)
+	}
+	name := secret.Annotations[annotationCertificateName]
+	if name == "" {
+		logger.Info("TLS Secret was not issued by cert-manager, leaving its certificate alone", "secret", secretName)
+		return nil
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateKind)
+	if err := r.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: name}, certificate); err != nil {
+		return fmt.Errorf("failed to read Certificate %s: %w", name, err)
+	}
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	if slices.Contains(dnsNames, to) {
+		return nil
+	}
+	dnsNames = slices.DeleteFunc(dnsNames, func(dnsName string) bool { return dnsName == from })
+	dnsNames = append(dnsNames, to)
+
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"dnsNames": dnsNames}})
+	if err != nil {
+		return err
+	}
+	if err := r.Patch(ctx, certificate, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("failed to re-issue Certificate %s for %s: %w", name, to, err)
+	}
+	logger.Info("Re-issuing the AWX certificate for the new hostname", "certificate", name, "to", to)
+	r.recordEvent(ctx, instance, corev1.EventTypeNormal, "CertificateReissued",
+		fmt.Sprintf("Re-issuing Certificate %s for %s", name, to))
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// DefaultReconcileTimeout bounds a reconcile of instances that don't set
+// their own timeout, so that a hanging AWX doesn't hold a worker for long
+const DefaultReconcileTimeout = 5 * time.Minute
+
+// defaultConnectionTestTimeout bounds the connection test of instances that
+// don't set their own timeout
+const defaultConnectionTestTimeout = 30 * time.Second
+
+// reconcileDeadline bounds the requests of the AWX clients of a reconcile by
+// the deadline of the reconcile and of its current phase. The deadlines are
+// carried in the contexts of the clients of the reconcile, derived with
+// WithContext, so the cached clients shared with other reconcilers are never
+// changed.
+type reconcileDeadline struct {
+	// ctx is the context of the reconcile
+	ctx context.Context
+	// total is the deadline of the whole reconcile, zero for none
+	total time.Time
+
+	connectionTest time.Duration
+	driftScan      time.Duration
+	mutation       time.Duration
+
+	// clients are the variables holding the clients of the reconcile, bound
+	// to the context of the current phase
+	clients []**awx.Client
+	current context.Context
+	cancels []context.CancelFunc
+}
+
+// reconcileDeadlineFor returns the deadline of a reconcile of the instance
+// starting now
+func (r *AWXInstanceReconciler) reconcileDeadlineFor(ctx context.Context, instance *awxv1alpha1.AWXInstance, now time.Time) *reconcileDeadline {
+	deadline := &reconcileDeadline{ctx: ctx, connectionTest: defaultConnectionTestTimeout}
+	total := r.ReconcileTimeout
+	if timeouts := instance.Spec.ReconcileTimeouts; timeouts != nil {
+		if timeouts.TotalSeconds > 0 {
+			total = time.Duration(timeouts.TotalSeconds) * time.Second
+		}
+		if timeouts.ConnectionTestSeconds > 0 {
+			deadline.connectionTest = time.Duration(timeouts.ConnectionTestSeconds) * time.Second
+		}
+		deadline.driftScan = time.Duration(timeouts.DriftScanSeconds) * time.Second
+		deadline.mutation = time.Duration(timeouts.MutationSeconds) * time.Second
+	}
+	if total > 0 {
+		deadline.total = now.Add(total)
+	}
+	deadline.startPhase(0)
+	return deadline
+}
+
+// bound binds the client held by the variable to the current phase, and to
+// every later phase started with startPhase
+func (d *reconcileDeadline) bound(awxClient **awx.Client) {
+	if !slices.Contains(d.clients, awxClient) {
+		d.clients = append(d.clients, awxClient)
+	}
+	*awxClient = (*awxClient).WithContext(d.current)
+}
+
+// startPhase starts a phase that may take up to timeout, or the rest of the
+// reconcile for a zero timeout, rebinds the clients to it and returns the
+// deadline of the phase
+func (d *reconcileDeadline) startPhase(timeout time.Duration) time.Time {
+	end := d.total
+	if timeout > 0 {
+		if phaseEnd := time.Now().Add(timeout); end.IsZero() || phaseEnd.Before(end) {
+			end = phaseEnd
+		}
+	}
+
+	d.current = d.ctx
+	if !end.IsZero() {
+		var cancel context.CancelFunc
+		d.current, cancel = context.WithDeadline(d.ctx, end)
+		d.cancels = append(d.cancels, cancel)
+	}
+	for _, awxClient := range d.clients {
+		*awxClient = (*awxClient).WithContext(d.current)
+	}
+	return end
+}
+
+// release cancels the contexts of the phases once the reconcile is done
+func (d *reconcileDeadline) release() {
+	for _, cancel := range d.cancels {
+		cancel()
+	}
+	d.cancels = nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"slices"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// applyJobTemplateDefaults merges the instance level job template defaults
+// into each job template. Fields a job template sets itself are kept; an
+// explicitly empty list of instance groups opts out of the default groups.
+// The defaults are copied, so job templates never share them.
+func applyJobTemplateDefaults(spec *awxv1alpha1.AWXInstanceSpec) {
+	defaults := spec.JobTemplateDefaults
+	if defaults == nil {
+		return
+	}
+
+	for i := range spec.JobTemplates {
+		jobTemplate := &spec.JobTemplates[i]
+		if jobTemplate.Verbosity == nil && defaults.Verbosity != nil {
+			verbosity := *defaults.Verbosity
+			jobTemplate.Verbosity = &verbosity
+		}
+		if jobTemplate.Timeout == nil && defaults.Timeout != nil {
+			timeout := *defaults.Timeout
+			jobTemplate.Timeout = &timeout
+		}
+		if jobTemplate.ExecutionEnvironment == "" {
+			jobTemplate.ExecutionEnvironment = defaults.ExecutionEnvironment
+		}
+		if jobTemplate.InstanceGroups == nil {
+			jobTemplate.InstanceGroups = slices.Clone(defaults.InstanceGroups)
+		}
+	}
+}
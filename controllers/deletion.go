@@ -0,0 +1,315 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// conditionDeletionBlocked is set while AWX objects outside the spec keep the
+// managed objects of a deleted instance from being removed
+const conditionDeletionBlocked = "DeletionBlocked"
+
+// deletionDependencies maps each kind of declared objects to the kinds its
+// objects use. Objects are deleted before the objects they use, so a kind
+// retained on deletion retains the kinds it depends on.
+var deletionDependencies = map[awxv1alpha1.RetainedKind][]awxv1alpha1.RetainedKind{
+	awxv1alpha1.RetainSchedules:            {awxv1alpha1.RetainJobTemplates, awxv1alpha1.RetainInventories, awxv1alpha1.RetainProjects},
+	awxv1alpha1.RetainWorkflowJobTemplates: {awxv1alpha1.RetainJobTemplates, awxv1alpha1.RetainInventories, awxv1alpha1.RetainCredentials},
+	awxv1alpha1.RetainJobTemplates:         {awxv1alpha1.RetainProjects, awxv1alpha1.RetainInventories, awxv1alpha1.RetainCredentials},
+	awxv1alpha1.RetainInventories:          {awxv1alpha1.RetainProjects, awxv1alpha1.RetainCredentials},
+	awxv1alpha1.RetainProjects:             {awxv1alpha1.RetainCredentials},
+	awxv1alpha1.RetainOrganizations:        {awxv1alpha1.RetainCredentials},
+	awxv1alpha1.RetainCredentials:          nil,
+}
+
+// deletionOrder is the order in which the finalization deletes the kinds of
+// declared objects, each before the kinds it depends on. Schedules go first,
+// so nothing is launched while the objects they run are deleted.
+var deletionOrder = []awxv1alpha1.RetainedKind{
+	awxv1alpha1.RetainSchedules,
+	awxv1alpha1.RetainWorkflowJobTemplates,
+	awxv1alpha1.RetainJobTemplates,
+	awxv1alpha1.RetainInventories,
+	awxv1alpha1.RetainProjects,
+	awxv1alpha1.RetainOrganizations,
+	awxv1alpha1.RetainCredentials,
+}
+
+// retainedKinds returns the kinds of declared objects left in AWX when the
+// instance is deleted: those listed in the spec and the kinds they depend on
+func retainedKinds(spec *awxv1alpha1.AWXInstanceSpec) map[awxv1alpha1.RetainedKind]bool {
+	retained := make(map[awxv1alpha1.RetainedKind]bool)
+	var retain func(kind awxv1alpha1.RetainedKind)
+	retain = func(kind awxv1alpha1.RetainedKind) {
+		if retained[kind] {
+			return
+		}
+		retained[kind] = true
+		for _, dependency := range deletionDependencies[kind] {
+			retain(dependency)
+		}
+	}
+	for _, kind := range spec.RetainOnDeletion {
+		retain(kind)
+	}
+	return retained
+}
+
+// blockedObject is a managed project or inventory that job templates outside
+// the spec still use
+type blockedObject struct {
+	kind         string
+	name         string
+	jobTemplates []string
+}
+
+// dependencyError describes the blocked object as returned by AWX clients
+func (b blockedObject) dependencyError() *awx.DependencyError {
+	blockers := make([]string, 0, len(b.jobTemplates))
+	for _, jobTemplate := range b.jobTemplates {
+		blockers = append(blockers, "job template "+jobTemplate)
+	}
+	return &awx.DependencyError{Kind: b.kind, Name: b.name, Blockers: blockers}
+}
+
+// findDeletionBlockers returns the managed projects and inventories that job
+// templates outside the spec still use. Retained kinds are not deleted and
+// can't be blocked.
+func findDeletionBlockers(instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) ([]blockedObject, error) {
+	retained := retainedKinds(&instance.Spec)
+	declared := make([]string, 0, len(instance.Spec.JobTemplates))
+	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+		declared = append(declared, jobTemplateSpec.Name)
+	}
+
+	var blocked []blockedObject
+	check := func(kind, endpoint, name string) error {
+		dependents, err := awxClient.DependentJobTemplates(endpoint, name)
+		if err != nil {
+			return err
+		}
+		object := blockedObject{kind: kind, name: name}
+		for _, dependent := range dependents {
+			if !slices.Contains(declared, dependent) {
+				object.jobTemplates = append(object.jobTemplates, dependent)
+			}
+		}
+		if len(object.jobTemplates) > 0 {
+			blocked = append(blocked, object)
+		}
+		return nil
+	}
+
+	if !retained[awxv1alpha1.RetainInventories] {
+		for _, inventorySpec := range instance.Spec.Inventories {
+			if err := check("inventory", "inventories", inventorySpec.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !retained[awxv1alpha1.RetainProjects] {
+		for _, projectSpec := range instance.Spec.Projects {
+			if err := check("project", "projects", projectSpec.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return blocked, nil
+}
+
+// clearDeletionBlockers is the first phase of the finalization. Without
+// cascade it refuses to start deleting while job templates outside the spec
+// still use a managed project or inventory, so no half-deleted state is left
+// behind. With cascade those job templates are deleted first.
+func (r *AWXInstanceReconciler) clearDeletionBlockers(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	logger := log.FromContext(ctx)
+
+	blocked, err := findDeletionBlockers(instance, awxClient)
+	if err != nil {
+		return fmt.Errorf("failed to check dependents of managed objects: %w", err)
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	if !instance.Spec.Cascade {
+		errs := make([]error, 0, len(blocked))
+		for _, object := range blocked {
+			errs = append(errs, object.dependencyError())
+		}
+		err := errors.Join(errs...)
+		r.reportDeletionBlocked(ctx, instance, err)
+		return err
+	}
+
+	jobTemplateManager := awx.NewJobTemplateManager(awxClient)
+	for _, object := range blocked {
+		for _, name := range object.jobTemplates {
+			logger.Info("Deleting dependent job template", "name", name, object.kind, object.name)
+			if err := jobTemplateManager.DeleteJobTemplate(name); err != nil {
+				return fmt.Errorf("failed to delete job template %s using %s %s: %w", name, object.kind, object.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reportDeletionBlocked records in the DeletionBlocked condition which AWX
+// objects keep the instance from being deleted. Other errors are not reported.
+func (r *AWXInstanceReconciler) reportDeletionBlocked(ctx context.Context, instance *awxv1alpha1.AWXInstance, err error) {
+	if _, ok := awx.AsDependencyError(err); !ok {
+		return
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionDeletionBlocked,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "DependentObjects",
+		Message:            strings.ReplaceAll(err.Error(), "\n", "; "),
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update AWXInstance status")
+	}
+}
+
+// deleteManagedObjects deletes the declared objects of the instance from one
+// AWX, each kind before the kinds its objects use, leaving the retained kinds
+// in place. It first makes sure no AWX objects outside the spec still use the
+// managed projects and inventories, so nothing is deleted while blocked.
+func (r *AWXInstanceReconciler) deleteManagedObjects(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	logger := log.FromContext(ctx)
+
+	if err := r.clearDeletionBlockers(ctx, instance, awxClient); err != nil {
+		logger.Error(err, "Deletion of AWX resources is blocked", "name", instance.Name)
+		return err
+	}
+
+	retained := retainedKinds(&instance.Spec)
+	for _, kind := range deletionOrder {
+		if retained[kind] {
+			logger.Info("Retaining AWX objects on deletion", "kind", kind)
+			continue
+		}
+		if err := r.deleteDeclaredObjects(ctx, instance, awxClient, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDeclaredObjects deletes the declared objects of one kind from AWX
+// during the finalization
+func (r *AWXInstanceReconciler) deleteDeclaredObjects(ctx context.Context, instance *awxv1alpha1.AWXInstance,
+	awxClient *awx.Client, kind awxv1alpha1.RetainedKind) error {
+
+	logger := log.FromContext(ctx)
+	switch kind {
+	case awxv1alpha1.RetainSchedules:
+		jobTemplateManager := awx.NewJobTemplateManager(awxClient)
+		for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+			if err := jobTemplateManager.DeleteSchedules(jobTemplateSpec); err != nil {
+				logger.Error(err, "Failed to delete schedules of job template", "name", jobTemplateSpec.Name)
+				return err
+			}
+		}
+		projectManager := awx.NewProjectManager(awxClient)
+		for _, projectSpec := range instance.Spec.Projects {
+			if err := projectManager.DeleteUpdateSchedule(projectSpec); err != nil {
+				logger.Error(err, "Failed to delete update schedule of project", "name", projectSpec.Name)
+				return err
+			}
+		}
+		inventoryManager := awx.NewInventoryManager(awxClient)
+		for _, inventorySpec := range instance.Spec.Inventories {
+			if err := inventoryManager.DeleteUpdateSchedules(inventorySpec); err != nil {
+				logger.Error(err, "Failed to delete update schedules of inventory", "name", inventorySpec.Name)
+				return err
+			}
+		}
+	case awxv1alpha1.RetainWorkflowJobTemplates:
+		workflowManager := awx.NewWorkflowJobTemplateManager(awxClient)
+		for _, workflowSpec := range instance.Spec.WorkflowJobTemplates {
+			logger.Info("Deleting workflow job template", "name", workflowSpec.Name)
+			if err := workflowManager.DeleteWorkflowJobTemplate(workflowSpec.Name); err != nil {
+				logger.Error(err, "Failed to delete workflow job template", "name", workflowSpec.Name)
+				return err
+			}
+		}
+	case awxv1alpha1.RetainJobTemplates:
+		jobTemplateManager := awx.NewJobTemplateManager(awxClient)
+		for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+			logger.Info("Deleting job template", "name", jobTemplateSpec.Name)
+			if err := jobTemplateManager.DeleteJobTemplate(jobTemplateSpec.Name); err != nil {
+				logger.Error(err, "Failed to delete job template", "name", jobTemplateSpec.Name)
+				return err
+			}
+		}
+	case awxv1alpha1.RetainInventories:
+		inventoryManager := awx.NewInventoryManager(awxClient)
+		for _, inventorySpec := range instance.Spec.Inventories {
+			logger.Info("Deleting inventory", "name", inventorySpec.Name)
+			if err := inventoryManager.DeleteInventory(inventorySpec.Name); err != nil {
+				logger.Error(err, "Failed to delete inventory", "name", inventorySpec.Name)
+				r.reportDeletionBlocked(ctx, instance, err)
+				return err
+			}
+		}
+	case awxv1alpha1.RetainProjects:
+		projectManager := awx.NewProjectManager(awxClient)
+		for _, projectSpec := range instance.Spec.Projects {
+			logger.Info("Deleting project", "name", projectSpec.Name)
+			if err := projectManager.DeleteProject(projectSpec.Name); err != nil {
+				logger.Error(err, "Failed to delete project", "name", projectSpec.Name)
+				r.reportDeletionBlocked(ctx, instance, err)
+				return err
+			}
+		}
+	case awxv1alpha1.RetainOrganizations:
+		organizationManager := awx.NewOrganizationManager(awxClient)
+		for _, organizationSpec := range instance.Spec.Organizations {
+			logger.Info("Detaching Galaxy credentials from organization", "name", organizationSpec.Name)
+			if err := organizationManager.ResetOrganization(organizationSpec); err != nil {
+				logger.Error(err, "Failed to detach Galaxy credentials from organization", "name", organizationSpec.Name)
+				return err
+			}
+		}
+	case awxv1alpha1.RetainCredentials:
+		credentialManager := awx.NewCredentialManager(awxClient)
+		for _, credentialSpec := range instance.Spec.Credentials {
+			logger.Info("Deleting credential", "name", credentialSpec.Name)
+			if err := credentialManager.DeleteCredential(credentialSpec.Name); err != nil {
+				logger.Error(err, "Failed to delete credential", "name", credentialSpec.Name)
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// discoveredAdminUser is the admin user created by the upstream awx-operator
+	discoveredAdminUser = "admin"
+	// adminPasswordSecretSuffix and adminPasswordKey locate the admin password
+	// Secret created by the upstream awx-operator
+	adminPasswordSecretSuffix = "-admin-password"
+	adminPasswordKey          = "password"
+	// serviceSuffix locates the Service created by the upstream awx-operator
+	serviceSuffix = "-service"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+
+// applyDiscoveredConnection fills in the connection settings that are missing
+// from the spec using the Secret and Service of an AWX deployed by the upstream
+// awx-operator. The discovered values are only kept in memory.
+func (r *AWXInstanceReconciler) applyDiscoveredConnection(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	logger := log.FromContext(ctx)
+	name := instance.Spec.DiscoverFrom.Name
+
+	if instance.Spec.AdminUser == "" {
+		instance.Spec.AdminUser = discoveredAdminUser
+	}
+
+	if instance.Spec.AdminPassword == "" {
+		secret := &corev1.Secret{}
+		secretName := types.NamespacedName{Namespace: instance.Namespace, Name: name + adminPasswordSecretSuffix}
+		if err := r.Get(ctx, secretName, secret); err != nil {
+			return fmt.Errorf("failed to read admin password Secret %s: %w", secretName.Name, err)
+		}
+		password, ok := secret.Data[adminPasswordKey]
+		if !ok || len(password) == 0 {
+			return fmt.Errorf("admin password Secret %s has no %q key", secretName.Name, adminPasswordKey)
+		}
+		instance.Spec.AdminPassword = string(password)
+		logger.Info("Discovered AWX admin password", "secret", secretName.Name)
+	}
+
+	if instance.Spec.Hostname == "" {
+		service := &corev1.Service{}
+		serviceName := types.NamespacedName{Namespace: instance.Namespace, Name: name + serviceSuffix}
+		if err := r.Get(ctx, serviceName, service); err != nil {
+			return fmt.Errorf("failed to read AWX Service %s: %w", serviceName.Name, err)
+		}
+		protocol, hostname, err := serviceAddress(service, 0, false)
+		if err != nil {
+			return err
+		}
+		instance.Spec.Protocol = protocol
+		instance.Spec.Hostname = hostname
+		logger.Info("Discovered AWX Service", "service", serviceName.Name, "hostname", instance.Spec.Hostname)
+	}
+
+	return nil
+}
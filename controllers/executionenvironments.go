@@ -0,0 +1,48 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// recordExecutionEnvironment records the execution environment the job
+// template effectively runs in, including one inherited from its project or
+// organization
+func (r *AWXInstanceReconciler) recordExecutionEnvironment(ctx context.Context, instance *awxv1alpha1.AWXInstance,
+	jobTemplateManager *awx.JobTemplateManager, name string, jobTemplate map[string]interface{}) {
+
+	effective, err := jobTemplateManager.EffectiveExecutionEnvironment(jobTemplate)
+	if err != nil {
+		// The execution environment is informational, so it never fails the reconcile
+		log.FromContext(ctx).Info("Could not resolve the execution environment of the job template",
+			"name", name,
+			"instance", instance.Name,
+			"error", err.Error())
+		return
+	}
+
+	if instance.Status.JobTemplateExecutionEnvironments == nil {
+		instance.Status.JobTemplateExecutionEnvironments = make(map[string]string)
+	}
+	instance.Status.JobTemplateExecutionEnvironments[name] = effective.String()
+}
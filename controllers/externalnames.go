@@ -0,0 +1,163 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// annotationObjectIDPrefix prefixes the annotations recording the AWX IDs of
+// created objects, e.g. awx.ansible.com/project-id.<name>
+const annotationObjectIDPrefix = "awx.ansible.com/"
+
+// maxAnnotationNameLength is the maximum length of the name part of an
+// annotation key, after its prefix
+const maxAnnotationNameLength = 63
+
+// objectIDKinds names the kind of an AWX endpoint in object ID annotations
+var objectIDKinds = map[string]string{
+	"credentials":            "credential",
+	"projects":               "project",
+	"inventories":            "inventory",
+	"job_templates":          "job-template",
+	"workflow_job_templates": "workflow-job-template",
+}
+
+// objectIDAnnotation returns the annotation recording the ID of an AWX
+// object. Names that are no valid annotation key, e.g. ones with spaces or
+// longer than the key allows, are shortened and suffixed with their hash.
+func objectIDAnnotation(endpoint, name string) string {
+	key := objectIDKinds[endpoint] + "-id." + name
+	if len(validation.IsQualifiedName(annotationObjectIDPrefix+key)) == 0 {
+		return annotationObjectIDPrefix + key
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	key = strings.Map(func(r rune) rune {
+		if r < 0x80 && (r == '-' || r == '_' || r == '.' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return r
+		}
+		return '-'
+	}, key)
+	if maxLength := maxAnnotationNameLength - len(suffix); len(key) > maxLength {
+		key = key[:maxLength]
+	}
+	return annotationObjectIDPrefix + key + suffix
+}
+
+// isObjectIDAnnotation reports whether an annotation records an AWX object ID
+func isObjectIDAnnotation(key string) bool {
+	for _, kind := range objectIDKinds {
+		if strings.HasPrefix(key, annotationObjectIDPrefix+kind+"-id.") {
+			return true
+		}
+	}
+	return false
+}
+
+// patchAnnotations sets the annotations on the AWXInstance, removing the ones
+// with a nil value. Only the metadata is patched, so the status reconciled so
+// far is kept and just the resource version is taken over.
+func (r *AWXInstanceReconciler) patchAnnotations(ctx context.Context, instance *awxv1alpha1.AWXInstance, annotations map[string]interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	patched := instance.DeepCopy()
+	if err := r.Patch(ctx, patched, client.RawPatch(types.MergePatchType, data)); err != nil {
+		return err
+	}
+	instance.Annotations = patched.Annotations
+	instance.ResourceVersion = patched.ResourceVersion
+	return nil
+}
+
+// annotateObjectID records the ID of a reconciled AWX object on the
+// AWXInstance as soon as it is known. Unlike status.objectIDs, which is
+// written at the end of a reconcile, the annotation survives an operator
+// crash right after the object was created. Annotations of the same object
+// under an earlier name are removed. Failures are only logged, as the ID is
+// still written to the status.
+func (r *AWXInstanceReconciler) annotateObjectID(ctx context.Context, instance *awxv1alpha1.AWXInstance, endpoint, name string, id int) {
+	key := objectIDAnnotation(endpoint, name)
+	value := strconv.Itoa(id)
+	if instance.Annotations[key] == value {
+		return
+	}
+
+	annotations := map[string]interface{}{key: value}
+	for existing, existingValue := range instance.Annotations {
+		if existing != key && existingValue == value &&
+			strings.HasPrefix(existing, annotationObjectIDPrefix+objectIDKinds[endpoint]+"-id.") {
+			annotations[existing] = nil
+		}
+	}
+	if err := r.patchAnnotations(ctx, instance, annotations); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to record AWX object ID", "endpoint", endpoint, "name", name, "id", id)
+	}
+}
+
+// restoreObjectIDs takes over the object IDs recorded in annotations, so
+// objects created by a reconcile that didn't finish are managed by ID instead
+// of being looked up by name again. Annotations of names that are no longer
+// declared are removed.
+func (r *AWXInstanceReconciler) restoreObjectIDs(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	declared := make(map[string]bool)
+	for _, objects := range declaredObjectNames(&instance.Spec) {
+		for _, name := range objects.names {
+			key := objectIDAnnotation(objects.endpoint, name)
+			declared[key] = true
+			id, err := strconv.Atoi(instance.Annotations[key])
+			if err != nil {
+				continue
+			}
+			awxClient.SetObjectID(objects.endpoint, name, id)
+			if _, ok := instance.Status.ObjectIDs[objectIDKey(objects.endpoint, name)]; !ok {
+				instance.Status.ObjectIDs[objectIDKey(objects.endpoint, name)] = id
+			}
+		}
+	}
+
+	stale := make(map[string]interface{})
+	for key := range instance.Annotations {
+		if isObjectIDAnnotation(key) && !declared[key] {
+			stale[key] = nil
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return r.patchAnnotations(ctx, instance, stale)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// defaultFactsInterval is the interval between two facts collections of an
+// inventory that doesn't set one
+const defaultFactsInterval = time.Hour
+
+// factsInterval returns the interval between two facts collections
+func factsInterval(facts *awxv1alpha1.HostFactsSpec) time.Duration {
+	if facts.IntervalSeconds <= 0 {
+		return defaultFactsInterval
+	}
+	return time.Duration(facts.IntervalSeconds) * time.Second
+}
+
+// hostFactsStatus maps the facts read from AWX to the status
+func hostFactsStatus(facts awx.HostFacts) awxv1alpha1.HostFactsStatus {
+	status := awxv1alpha1.HostFactsStatus{
+		OS:           facts.OS,
+		Kernel:       facts.Kernel,
+		Architecture: facts.Architecture,
+		Addresses:    facts.Addresses,
+	}
+	if !facts.Modified.IsZero() {
+		status.GatheredAt = &metav1.Time{Time: facts.Modified}
+	}
+	return status
+}
+
+// collectHostFacts publishes the facts of the declared hosts of inventories
+// with facts enabled once their interval has passed. Like the license, facts
+// are informational, so failures are only recorded in the status and never
+// fail the reconcile. Inventories without facts are dropped from the status.
+func (r *AWXInstanceReconciler) collectHostFacts(ctx context.Context, instance *awxv1alpha1.AWXInstance, inventoryManager *awx.InventoryManager) {
+	logger := log.FromContext(ctx)
+	collected := make(map[string]awxv1alpha1.InventoryFactsStatus)
+
+	for _, inventorySpec := range instance.Spec.Inventories {
+		if inventorySpec.Facts == nil {
+			continue
+		}
+		previous, ok := instance.Status.InventoryFacts[inventorySpec.Name]
+		if ok && time.Since(previous.CollectedAt.Time) < factsInterval(inventorySpec.Facts) {
+			collected[inventorySpec.Name] = previous
+			continue
+		}
+
+		hostNames := make([]string, 0, len(inventorySpec.Hosts))
+		for _, host := range inventorySpec.Hosts {
+			hostNames = append(hostNames, host.Name)
+		}
+		facts, err := inventoryManager.HostFacts(inventorySpec.Name, hostNames)
+		if err != nil {
+			logger.Info("Could not read host facts", "inventory", inventorySpec.Name, "error", err.Error())
+			previous.CollectedAt = metav1.Now()
+			previous.Message = err.Error()
+			collected[inventorySpec.Name] = previous
+			continue
+		}
+
+		status := awxv1alpha1.InventoryFactsStatus{
+			CollectedAt: metav1.Now(),
+			Hosts:       make(map[string]awxv1alpha1.HostFactsStatus, len(facts)),
+		}
+		for name, hostFacts := range facts {
+			status.Hosts[name] = hostFactsStatus(hostFacts)
+		}
+		logger.Info("Collected host facts", "inventory", inventorySpec.Name, "hosts", len(status.Hosts))
+		collected[inventorySpec.Name] = status
+	}
+
+	if len(collected) == 0 {
+		collected = nil
+	}
+	instance.Status.InventoryFacts = collected
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// fleetDriftWindow is the window drift corrections are counted in
+const fleetDriftWindow = time.Hour
+
+var (
+	fleetInstancesDesc = prometheus.NewDesc("awx_fleet_instances",
+		"Number of AWXInstances in the cluster (state = connected or degraded)",
+		[]string{"state"}, nil)
+	fleetManagedObjectsDesc = prometheus.NewDesc("awx_fleet_managed_objects",
+		"Number of AWX objects declared by all AWXInstances in the cluster",
+		nil, nil)
+	fleetDriftEventsDesc = prometheus.NewDesc("awx_fleet_drift_events_last_hour",
+		"Number of reconciles that reverted changes made in AWX during the last hour",
+		nil, nil)
+)
+
+// awxFleetCollector summarizes the health of all AWXInstances of the cluster
+// for fleet dashboards. The instances are read from the cache at scrape time.
+type awxFleetCollector struct {
+	reconciler *AWXInstanceReconciler
+}
+
+// Describe implements prometheus.Collector
+func (c *awxFleetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fleetInstancesDesc
+	ch <- fleetManagedObjectsDesc
+	ch <- fleetDriftEventsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *awxFleetCollector) Collect(ch chan<- prometheus.Metric) {
+	instances := &awxv1alpha1.AWXInstanceList{}
+	if err := c.reconciler.List(context.Background(), instances); err != nil {
+		ctrl.Log.WithName("awx-fleet").Info("Could not list AWXInstances", "error", err.Error())
+		return
+	}
+
+	var connected, degraded, objects int
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionReady) {
+			connected++
+		} else {
+			degraded++
+		}
+		objects += countDeclaredObjects(&instance.Spec)
+	}
+
+	ch <- prometheus.MustNewConstMetric(fleetInstancesDesc, prometheus.GaugeValue, float64(connected), "connected")
+	ch <- prometheus.MustNewConstMetric(fleetInstancesDesc, prometheus.GaugeValue, float64(degraded), "degraded")
+	ch <- prometheus.MustNewConstMetric(fleetManagedObjectsDesc, prometheus.GaugeValue, float64(objects))
+	ch <- prometheus.MustNewConstMetric(fleetDriftEventsDesc, prometheus.GaugeValue,
+		float64(c.reconciler.driftEventsSince(time.Now().Add(-fleetDriftWindow))))
+}
+
+// countDeclaredObjects counts the AWX objects declared in the spec
+func countDeclaredObjects(spec *awxv1alpha1.AWXInstanceSpec) int {
+	return len(spec.Credentials) + len(spec.Projects) + len(spec.Inventories) +
+		len(spec.JobTemplates) + len(spec.WorkflowJobTemplates)
+}
+
+// noteDriftEvent records that a reconcile of the instance reverted changes
+// made in AWX. Events older than the drift window are dropped.
+func (r *AWXInstanceReconciler) noteDriftEvent(instance *awxv1alpha1.AWXInstance) {
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	now := time.Now()
+
+	r.driftEventsMu.Lock()
+	defer r.driftEventsMu.Unlock()
+	if r.driftEvents == nil {
+		r.driftEvents = make(map[types.NamespacedName][]time.Time)
+	}
+	r.driftEvents[key] = append(pruneDriftEvents(r.driftEvents[key], now.Add(-fleetDriftWindow)), now)
+}
+
+// driftEventsSince counts the drift events of all instances after cutoff
+func (r *AWXInstanceReconciler) driftEventsSince(cutoff time.Time) int {
+	r.driftEventsMu.Lock()
+	defer r.driftEventsMu.Unlock()
+
+	count := 0
+	for key, events := range r.driftEvents {
+		events = pruneDriftEvents(events, cutoff)
+		if len(events) == 0 {
+			delete(r.driftEvents, key)
+			continue
+		}
+		r.driftEvents[key] = events
+		count += len(events)
+	}
+	return count
+}
+
+// forgetDriftEvents drops the drift events of a deleted instance
+func (r *AWXInstanceReconciler) forgetDriftEvents(instance *awxv1alpha1.AWXInstance) {
+	r.driftEventsMu.Lock()
+	delete(r.driftEvents, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+	r.driftEventsMu.Unlock()
+}
+
+// pruneDriftEvents drops the events before cutoff, events are in order
+func pruneDriftEvents(events []time.Time, cutoff time.Time) []time.Time {
+	for len(events) > 0 && events[0].Before(cutoff) {
+		events = events[1:]
+	}
+	return events
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// Field indexes of AWXInstances by the objects they reference, so that a
+// change of a referenced object requeues exactly the instances that use it
+const (
+	secretRefIndex    = ".spec.secretRefs"
+	configMapRefIndex = ".spec.configMapRefs"
+	targetRefIndex    = ".spec.targets"
+)
+
+// referencedSecrets returns the names of the Secrets the instance reads
+func referencedSecrets(instance *awxv1alpha1.AWXInstance) []string {
+	var names []string
+	if instance.Spec.AdminPasswordSecretRef != nil {
+		names = append(names, instance.Spec.AdminPasswordSecretRef.Name)
+	}
+	if instance.Spec.DiscoverFrom != nil {
+		names = append(names, instance.Spec.DiscoverFrom.Name+adminPasswordSecretSuffix)
+	}
+	for _, credential := range instance.Spec.Credentials {
+		if credential.InputsSecretRef != nil {
+			names = append(names, credential.InputsSecretRef.Name)
+		}
+	}
+	for _, source := range instance.Spec.TemplateValuesFrom {
+		if source.SecretRef != nil {
+			names = append(names, source.SecretRef.Name)
+		}
+	}
+	if instance.Spec.Analytics != nil && instance.Spec.Analytics.CredentialsSecretRef != nil {
+		names = append(names, instance.Spec.Analytics.CredentialsSecretRef.Name)
+	}
+	var surveys []*awxv1alpha1.SurveySpec
+	for _, jobTemplate := range instance.Spec.JobTemplates {
+		surveys = append(surveys, jobTemplate.Survey)
+	}
+	for _, workflow := range instance.Spec.WorkflowJobTemplates {
+		surveys = append(surveys, workflow.Survey)
+	}
+	for _, survey := range surveys {
+		if survey == nil {
+			continue
+		}
+		for _, question := range survey.Questions {
+			if question.DefaultSecretRef != nil {
+				names = append(names, question.DefaultSecretRef.Name)
+			}
+		}
+	}
+	return names
+}
+
+// referencedConfigMaps returns the names of the ConfigMaps the instance reads
+func referencedConfigMaps(instance *awxv1alpha1.AWXInstance) []string {
+	var names []string
+	for _, source := range instance.Spec.TemplateValuesFrom {
+		if source.ConfigMapRef != nil {
+			names = append(names, source.ConfigMapRef.Name)
+		}
+	}
+	return names
+}
+
+// referencedTargets returns the names of the AWXInstances the instance pushes its resources to
+func referencedTargets(instance *awxv1alpha1.AWXInstance) []string {
+	names := make([]string, 0, len(instance.Spec.Targets))
+	for _, target := range instance.Spec.Targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+// setupIndexes registers the reference indexes of AWXInstances with the manager cache
+func setupIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexes := map[string]func(*awxv1alpha1.AWXInstance) []string{
+		secretRefIndex:    referencedSecrets,
+		configMapRefIndex: referencedConfigMaps,
+		targetRefIndex:    referencedTargets,
+	}
+	for field, extract := range indexes {
+		extract := extract
+		err := mgr.GetFieldIndexer().IndexField(ctx, &awxv1alpha1.AWXInstance{}, field, func(obj client.Object) []string {
+			return extract(obj.(*awxv1alpha1.AWXInstance))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instancesReferencing returns reconcile requests for the AWXInstances in the
+// namespace of obj whose index field contains its name
+func (r *AWXInstanceReconciler) instancesReferencing(ctx context.Context, field string, obj client.Object) []reconcile.Request {
+	instances := &awxv1alpha1.AWXInstanceList{}
+	if err := r.List(ctx, instances,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{field: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list AWXInstances referencing object",
+			"index", field, "name", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(instances.Items))
+	for _, instance := range instances.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name},
+		})
+	}
+	return requests
+}
+
+// instancesForSecret maps a Secret to the AWXInstances that read it
+func (r *AWXInstanceReconciler) instancesForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	if r.TenantCredentialsSecret != "" && obj.GetName() == r.TenantCredentialsSecret {
+		return r.instancesForTenantSecret(ctx, obj)
+	}
+	return r.instancesReferencing(ctx, secretRefIndex, obj)
+}
+
+// instancesForConfigMap maps a ConfigMap to the AWXInstances that read it
+func (r *AWXInstanceReconciler) instancesForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.instancesReferencing(ctx, configMapRefIndex, obj)
+}
+
+// instancesForTarget maps an AWXInstance to the instances that push their
+// resources to it, so that new connection settings of a target are used at once
+func (r *AWXInstanceReconciler) instancesForTarget(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.instancesReferencing(ctx, targetRefIndex, obj)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// licenseExpiryWarning is how long before the expiry date the LicenseValid
+// condition starts warning about it
+const licenseExpiryWarning = 30 * 24 * time.Hour
+
+// updateLicenseStatus reads the subscription of the AWX instance and records it
+// in the status, the license metrics and the LicenseValid condition
+func (r *AWXInstanceReconciler) updateLicenseStatus(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) {
+
+	logger := log.FromContext(ctx)
+	info, err := awxClient.GetLicenseInfo()
+	if err != nil {
+		// License reporting is informational, so it never fails the reconcile
+		logger.Info("Could not read AWX license information",
+			"instance", instance.Name,
+			"error", err.Error())
+		return
+	}
+
+	license := &awxv1alpha1.LicenseStatus{
+		Type:      info.LicenseType,
+		Compliant: info.IsCompliant(),
+		HostsUsed: int32(info.CurrentInstances),
+		HostLimit: int32(info.InstanceCount),
+	}
+	instance.Status.License = license
+
+	licenseHostsGauge.WithLabelValues(instance.Namespace, instance.Name, "used").Set(float64(info.CurrentInstances))
+	licenseHostsGauge.WithLabelValues(instance.Namespace, instance.Name, "limit").Set(float64(info.InstanceCount))
+
+	condition := metav1.Condition{
+		Type:               conditionLicenseValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "LicenseValid",
+		Message:            fmt.Sprintf("License %s is valid", info.LicenseType),
+	}
+
+	expiresAt, expires := info.ExpiresAt()
+	if expires {
+		license.ExpiresAt = &metav1.Time{Time: expiresAt}
+		remaining := time.Until(expiresAt)
+		licenseDaysRemainingGauge.WithLabelValues(instance.Namespace, instance.Name).Set(remaining.Hours() / 24)
+
+		if remaining > 0 && remaining < licenseExpiryWarning {
+			condition.Reason = "LicenseExpiringSoon"
+			condition.Message = fmt.Sprintf("License expires on %s", expiresAt.Format(time.RFC3339))
+		}
+	} else {
+		licenseDaysRemainingGauge.DeleteLabelValues(instance.Namespace, instance.Name)
+	}
+
+	switch {
+	case info.DateExpired || (expires && time.Now().After(expiresAt)):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "LicenseExpired"
+		condition.Message = "License has expired"
+		if expires {
+			condition.Message = fmt.Sprintf("License expired on %s", expiresAt.Format(time.RFC3339))
+		}
+	case !license.Compliant:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "HostLimitExceeded"
+		condition.Message = fmt.Sprintf("%d hosts are in use but the license allows %d", license.HostsUsed, license.HostLimit)
+	}
+
+	// Warn once per change rather than on every reconcile
+	previous := meta.FindStatusCondition(instance.Status.Conditions, conditionLicenseValid)
+	changed := previous == nil || previous.Reason != condition.Reason
+	if changed && condition.Reason != "LicenseValid" {
+		r.recordEvent(ctx, instance, corev1.EventTypeWarning, condition.Reason, condition.Message)
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+}
+
+// forgetLicense drops the license metrics of a deleted instance
+func forgetLicense(instance *awxv1alpha1.AWXInstance) {
+	licenseHostsGauge.DeleteLabelValues(instance.Namespace, instance.Name, "used")
+	licenseHostsGauge.DeleteLabelValues(instance.Namespace, instance.Name, "limit")
+	licenseDaysRemainingGauge.DeleteLabelValues(instance.Namespace, instance.Name)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// annotationAllowMassDeletion acknowledges a mass deletion of inventory hosts
+// that the deletion threshold refused. Its value is the generation of the
+// instance it applies to, so the acknowledgment doesn't carry over to later
+// changes of the spec.
+const annotationAllowMassDeletion = "awx.ansible.com/allow-mass-deletion"
+
+// massDeletionAcknowledged reports whether the allow-mass-deletion annotation
+// names the current generation of the instance
+func massDeletionAcknowledged(instance *awxv1alpha1.AWXInstance) bool {
+	value, ok := instance.Annotations[annotationAllowMassDeletion]
+	if !ok {
+		return false
+	}
+	generation, err := strconv.ParseInt(value, 10, 64)
+	return err == nil && generation == instance.Generation
+}
+
+// applyMassDeletionAcknowledgment allows the inventories to delete any share
+// of their hosts when the mass deletion was acknowledged for the current
+// generation. Only the copy of the spec read for this reconcile is changed.
+func applyMassDeletionAcknowledgment(instance *awxv1alpha1.AWXInstance) {
+	if !massDeletionAcknowledged(instance) {
+		return
+	}
+	for i := range instance.Spec.Inventories {
+		instance.Spec.Inventories[i].AllowMassDeletion = true
+	}
+}
+
+// massDeletionMessage describes a refused mass deletion along with the
+// annotation that acknowledges it for the current generation
+func massDeletionMessage(instance *awxv1alpha1.AWXInstance, massErr *awx.MassDeletionError) string {
+	return fmt.Sprintf("%v, or annotate the AWXInstance with %s=%d to allow it for this generation of the spec",
+		massErr, annotationAllowMassDeletion, instance.Generation)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// reconcileMeshInstances registers the declared execution and hop nodes and
+// records their health as reported by AWX. All nodes are tried, so one
+// unreachable node doesn't keep the others from being registered.
+func (r *AWXInstanceReconciler) reconcileMeshInstances(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	logger := log.FromContext(ctx)
+	meshManager := awx.NewMeshInstanceManager(awxClient)
+
+	statuses := make(map[string]string, len(instance.Spec.MeshInstances))
+	var firstErr error
+	for _, instanceSpec := range instance.Spec.MeshInstances {
+		logger.Info("Reconciling mesh instance", "hostname", instanceSpec.Hostname, "instance", instance.Name)
+		meshInstance, err := meshManager.EnsureInstance(instanceSpec)
+		if err != nil {
+			logger.Error(err, "Failed to reconcile mesh instance", "hostname", instanceSpec.Hostname)
+			statuses[instanceSpec.Hostname] = fmt.Sprintf("Failed: %v", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("mesh instance %s: %w", instanceSpec.Hostname, err)
+			}
+			continue
+		}
+		statuses[instanceSpec.Hostname] = awx.InstanceHealth(meshInstance)
+	}
+
+	// Replacing the map drops the statuses of nodes removed from the spec
+	instance.Status.MeshInstanceStatuses = statuses
+	return firstErr
+}
+
+// deprovisionMeshInstances marks the declared nodes for removal from the mesh
+func (r *AWXInstanceReconciler) deprovisionMeshInstances(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	logger := log.FromContext(ctx)
+	meshManager := awx.NewMeshInstanceManager(awxClient)
+	for _, instanceSpec := range instance.Spec.MeshInstances {
+		logger.Info("Deprovisioning mesh instance", "hostname", instanceSpec.Hostname)
+		if err := meshManager.DeprovisionInstance(instanceSpec.Hostname); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// driftedResourcesGauge counts the declared AWX resources that are missing
+	// or differ from the spec, per instance and resource kind
+	driftedResourcesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "awx_instance_drifted_resources",
+		Help: "Number of declared AWX resources that are missing or differ from the spec",
+	}, []string{"namespace", "instance", "kind"})
+
+	// licenseDaysRemainingGauge exposes the days until the AWX subscription
+	// expires, per instance. Licenses without expiry are not reported.
+	licenseDaysRemainingGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "awx_instance_license_days_remaining",
+		Help: "Days until the AWX subscription expires",
+	}, []string{"namespace", "instance"})
+
+	// licenseHostsGauge exposes the hosts used and allowed by the AWX
+	// subscription, per instance
+	licenseHostsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "awx_instance_license_hosts",
+		Help: "Hosts used and allowed by the AWX subscription (type = used or limit)",
+	}, []string{"namespace", "instance", "type"})
+
+	// apiCallsPerReconcileHistogram observes the AWX API requests of each
+	// reconcile, per instance
+	apiCallsPerReconcileHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "awx_instance_api_calls_per_reconcile",
+		Help:    "Number of AWX API requests sent during one reconcile",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"namespace", "instance"})
+
+	// apiCallsTotal counts all AWX API requests sent for an instance
+	apiCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "awx_instance_api_calls_total",
+		Help: "Number of AWX API requests sent for the instance",
+	}, []string{"namespace", "instance"})
+
+	// apiCallsCurrentHourGauge exposes the AWX API requests sent for an
+	// instance since the start of its current one-hour budget window
+	apiCallsCurrentHourGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "awx_instance_api_calls_current_hour",
+		Help: "Number of AWX API requests sent in the current one-hour budget window",
+	}, []string{"namespace", "instance"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(driftedResourcesGauge, licenseDaysRemainingGauge, licenseHostsGauge,
+		apiCallsPerReconcileHistogram, apiCallsTotal, apiCallsCurrentHourGauge)
+}
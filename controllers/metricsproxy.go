@@ -0,0 +1,220 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// awxMetricsScrapeInterval is how often the metrics of the AWX instances are
+// scraped in the background
+const awxMetricsScrapeInterval = 30 * time.Second
+
+// proxiedInstanceLabels are added to every metric scraped from AWX to tell the
+// instances apart. Labels of the same name reported by AWX are replaced.
+var proxiedInstanceLabels = []string{"namespace", "instance"}
+
+// awxMetricsUpDesc reports whether the metrics of an instance could be scraped
+var awxMetricsUpDesc = prometheus.NewDesc("awx_instance_metrics_up",
+	"Whether the metrics of the AWX instance could be scraped (1) or not (0)",
+	proxiedInstanceLabels, nil)
+
+// awxMetricsCollector re-exposes the metrics of the AWX instances on the
+// operator metrics endpoint, so Prometheus can scrape them without AWX
+// credentials. Only instances that have been reconciled are scraped. The
+// instances are scraped concurrently in the background and Collect serves
+// the last results, so a slow AWX delays neither the operator metrics nor
+// the other instances.
+type awxMetricsCollector struct {
+	reconciler *AWXInstanceReconciler
+
+	mu sync.Mutex
+	// scrapes holds the last scrape of each instance
+	scrapes map[types.NamespacedName]awxMetricsScrape
+	// scraping holds the instances whose scrape is still running
+	scraping map[types.NamespacedName]bool
+}
+
+// awxMetricsScrape is the result of scraping the metrics of an instance
+type awxMetricsScrape struct {
+	families map[string]*dto.MetricFamily
+	err      error
+}
+
+// newAWXMetricsCollector returns a collector for the instances of reconciler
+func newAWXMetricsCollector(reconciler *AWXInstanceReconciler) *awxMetricsCollector {
+	return &awxMetricsCollector{
+		reconciler: reconciler,
+		scrapes:    make(map[types.NamespacedName]awxMetricsScrape),
+		scraping:   make(map[types.NamespacedName]bool),
+	}
+}
+
+// Start implements manager.Runnable by scraping the instances every
+// awxMetricsScrapeInterval until ctx is done
+func (c *awxMetricsCollector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(awxMetricsScrapeInterval)
+	defer ticker.Stop()
+	for {
+		c.scrape(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape starts scraping every known instance whose last scrape finished and
+// forgets the instances that are no longer known. Each scrape is bounded by
+// the scrape interval.
+func (c *awxMetricsCollector) scrape(ctx context.Context) {
+	clients := c.reconciler.cachedAWXClients()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.scrapes {
+		if _, ok := clients[key]; !ok {
+			delete(c.scrapes, key)
+		}
+	}
+	for key, awxClient := range clients {
+		if c.scraping[key] {
+			continue
+		}
+		c.scraping[key] = true
+		go c.scrapeInstance(ctx, key, awxClient)
+	}
+}
+
+// scrapeInstance scrapes the metrics of an instance and keeps the result
+func (c *awxMetricsCollector) scrapeInstance(ctx context.Context, key types.NamespacedName, awxClient *awx.Client) {
+	ctx, cancel := context.WithTimeout(ctx, awxMetricsScrapeInterval)
+	defer cancel()
+	families, err := awxClient.WithContext(ctx).GetMetrics()
+	if err != nil {
+		ctrl.Log.WithName("awx-metrics-proxy").Info("Could not scrape AWX metrics",
+			"namespace", key.Namespace,
+			"instance", key.Name,
+			"error", err.Error())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.scraping, key)
+	c.scrapes[key] = awxMetricsScrape{families: families, err: err}
+}
+
+// Describe implements prometheus.Collector. The metrics depend on the AWX
+// version, so the collector is unchecked and describes nothing.
+func (c *awxMetricsCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector with the last scrape of every
+// known AWX instance
+func (c *awxMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	logger := ctrl.Log.WithName("awx-metrics-proxy")
+
+	c.mu.Lock()
+	scrapes := make(map[types.NamespacedName]awxMetricsScrape, len(c.scrapes))
+	for key, scrape := range c.scrapes {
+		scrapes[key] = scrape
+	}
+	c.mu.Unlock()
+
+	for key, scrape := range scrapes {
+		if scrape.err != nil {
+			ch <- prometheus.MustNewConstMetric(awxMetricsUpDesc, prometheus.GaugeValue, 0, key.Namespace, key.Name)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(awxMetricsUpDesc, prometheus.GaugeValue, 1, key.Namespace, key.Name)
+
+		for _, family := range scrape.families {
+			for _, m := range family.GetMetric() {
+				metric, err := proxiedMetric(family, m, key)
+				if err != nil {
+					logger.Info("Skipping AWX metric",
+						"namespace", key.Namespace,
+						"instance", key.Name,
+						"metric", family.GetName(),
+						"error", err.Error())
+					continue
+				}
+				ch <- metric
+			}
+		}
+	}
+}
+
+// proxiedMetric converts a metric scraped from AWX into a constant metric
+// labelled with the instance it was scraped from
+func proxiedMetric(family *dto.MetricFamily, m *dto.Metric, key types.NamespacedName) (prometheus.Metric, error) {
+	var labelNames, labelValues []string
+	for _, label := range m.GetLabel() {
+		if label.GetName() == "namespace" || label.GetName() == "instance" {
+			continue
+		}
+		labelNames = append(labelNames, label.GetName())
+		labelValues = append(labelValues, label.GetValue())
+	}
+	labelNames = append(labelNames, proxiedInstanceLabels...)
+	labelValues = append(labelValues, key.Namespace, key.Name)
+
+	desc := prometheus.NewDesc(family.GetName(), family.GetHelp(), labelNames, nil)
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), labelValues...)
+	case dto.MetricType_GAUGE:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+	case dto.MetricType_SUMMARY:
+		quantiles := make(map[float64]float64)
+		for _, q := range m.GetSummary().GetQuantile() {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		return prometheus.NewConstSummary(desc, m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum(),
+			quantiles, labelValues...)
+	case dto.MetricType_HISTOGRAM:
+		buckets := make(map[float64]uint64)
+		for _, b := range m.GetHistogram().GetBucket() {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		return prometheus.NewConstHistogram(desc, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum(),
+			buckets, labelValues...)
+	default:
+		return prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), labelValues...)
+	}
+}
+
+// cachedAWXClients returns a snapshot of the cached AWX clients
+func (r *AWXInstanceReconciler) cachedAWXClients() map[types.NamespacedName]*awx.Client {
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+
+	clients := make(map[types.NamespacedName]*awx.Client, len(r.clients))
+	for key, cached := range r.clients {
+		clients[key] = cached.client
+	}
+	return clients
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// applyNamingPolicy renames the declared objects in the spec to their AWX
+// names according to the naming policy of the instance. It runs on the copy
+// of the spec a reconcile works with, so the AWXInstance keeps the names
+// without prefix and suffix.
+func (r *AWXInstanceReconciler) applyNamingPolicy(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	policy := instance.Spec.NamingPolicy
+	if policy == nil {
+		return nil
+	}
+
+	data := templateData{ClusterName: r.ClusterName, Namespace: instance.Namespace}
+	if len(instance.Spec.TemplateValuesFrom) > 0 {
+		values, _, err := r.loadTemplateValues(ctx, instance)
+		if err != nil {
+			return err
+		}
+		data.Values = values
+	}
+	prefix, err := renderTemplate("naming policy prefix", policy.Prefix, data)
+	if err != nil {
+		return err
+	}
+	suffix, err := renderTemplate("naming policy suffix", policy.Suffix, data)
+	if err != nil {
+		return err
+	}
+
+	renameDeclaredObjects(&instance.Spec, prefix, suffix)
+	return nil
+}
+
+// renameDeclaredObjects adds prefix and suffix to the names of the declared
+// objects and to the references between them. References to objects that are
+// not declared, e.g. a credential shared by all clusters, are kept.
+func renameDeclaredObjects(spec *awxv1alpha1.AWXInstanceSpec, prefix, suffix string) {
+	if prefix == "" && suffix == "" {
+		return
+	}
+
+	declared := make(map[string]map[string]bool)
+	for _, objects := range declaredObjectNames(spec) {
+		declared[objects.endpoint] = make(map[string]bool, len(objects.names))
+		for _, name := range objects.names {
+			declared[objects.endpoint][name] = true
+		}
+	}
+	rename := func(endpoint string, name *string) {
+		if declared[endpoint][*name] {
+			*name = prefix + *name + suffix
+		}
+	}
+
+	for i := range spec.Credentials {
+		rename("credentials", &spec.Credentials[i].Name)
+	}
+	for i := range spec.Projects {
+		project := &spec.Projects[i]
+		rename("projects", &project.Name)
+		rename("credentials", &project.SCMCredential)
+		rename("credentials", &project.SignatureValidationCredential)
+	}
+	for i := range spec.Inventories {
+		inventory := &spec.Inventories[i]
+		rename("inventories", &inventory.Name)
+		rename("inventories", &inventory.CopyFrom)
+	}
+	for i := range spec.JobTemplates {
+		jobTemplate := &spec.JobTemplates[i]
+		rename("job_templates", &jobTemplate.Name)
+		rename("job_templates", &jobTemplate.CopyFrom)
+		rename("projects", &jobTemplate.ProjectName)
+		rename("inventories", &jobTemplate.InventoryName)
+		for j := range jobTemplate.Credentials {
+			rename("credentials", &jobTemplate.Credentials[j])
+		}
+	}
+	for i := range spec.WorkflowJobTemplates {
+		workflow := &spec.WorkflowJobTemplates[i]
+		rename("workflow_job_templates", &workflow.Name)
+		if workflow.Webhook != nil {
+			rename("credentials", &workflow.Webhook.CredentialName)
+		}
+		for j := range workflow.Nodes {
+			rename("job_templates", &workflow.Nodes[j].JobTemplateName)
+		}
+	}
+}
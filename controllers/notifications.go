@@ -0,0 +1,246 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// notificationPath is followed by the namespace and name of the instance,
+	// e.g. /notifications/awx/production
+	notificationPath = "/notifications/"
+	// maxNotificationSize limits the accepted notification bodies
+	maxNotificationSize = 1 << 20
+	// notificationQueueSize buffers requeues while the controller is busy or
+	// not started, e.g. on a replica that is not the leader
+	notificationQueueSize = 100
+)
+
+// jobNotification holds the fields of the body AWX posts to webhook
+// notification targets for jobs and workflow jobs
+type jobNotification struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	Finished string `json:"finished"`
+}
+
+// notificationReceiver accepts AWX webhook notifications, records the jobs of
+// the declared templates in the instance status and requeues the instance, so
+// job results show up without polling AWX
+type notificationReceiver struct {
+	reconciler *AWXInstanceReconciler
+	address    string
+	token      string
+	events     chan event.GenericEvent
+}
+
+// errNotificationsRefused is returned for notifications addressed to an
+// instance that doesn't accept them
+var errNotificationsRefused = errors.New("AWXInstance doesn't accept notifications")
+
+// newNotificationReceiver creates a receiver listening on address. Requests
+// must carry token as a bearer token, which must not be empty.
+func newNotificationReceiver(r *AWXInstanceReconciler, address, token string) (*notificationReceiver, error) {
+	if token == "" {
+		return nil, errors.New("a notification token is required to receive AWX notifications")
+	}
+	return &notificationReceiver{
+		reconciler: r,
+		address:    address,
+		token:      token,
+		events:     make(chan event.GenericEvent, notificationQueueSize),
+	}, nil
+}
+
+// NeedLeaderElection lets every replica receive notifications, as the Service
+// in front of the operator doesn't know which one is the leader
+func (n *notificationReceiver) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves notifications until ctx is done
+func (n *notificationReceiver) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              n.address,
+		Handler:           n,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	ctrl.Log.WithName("notifications").Info("Receiving AWX notifications", "address", n.address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler for POST /notifications/<namespace>/<name>
+func (n *notificationReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logger := ctrl.Log.WithName("notifications")
+
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !n.authorized(req) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, notificationPath), "/"), "/")
+	if !strings.HasPrefix(req.URL.Path, notificationPath) || len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		http.Error(w, "expected "+notificationPath+"<namespace>/<name>", http.StatusNotFound)
+		return
+	}
+	key := types.NamespacedName{Namespace: segments[0], Name: segments[1]}
+
+	var notification jobNotification
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxNotificationSize)).Decode(&notification); err != nil {
+		http.Error(w, "invalid notification body", http.StatusBadRequest)
+		return
+	}
+	// Test notifications and custom messages don't describe a job
+	if notification.ID == 0 || notification.Name == "" {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	recorded, err := n.recordJob(req.Context(), key, notification)
+	if apierrors.IsNotFound(err) {
+		http.Error(w, "AWXInstance not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errNotificationsRefused) {
+		http.Error(w, "AWXInstance doesn't accept notifications", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		logger.Error(err, "Failed to record job notification", "namespace", key.Namespace, "instance", key.Name)
+		http.Error(w, "failed to record notification", http.StatusInternalServerError)
+		return
+	}
+	if recorded {
+		logger.Info("Recorded job notification",
+			"namespace", key.Namespace,
+			"instance", key.Name,
+			"template", notification.Name,
+			"job", notification.ID,
+			"status", notification.Status)
+		n.requeue(key)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// authorized checks the bearer token of a request in constant time
+func (n *notificationReceiver) authorized(req *http.Request) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(n.token)) == 1
+}
+
+// recordJob stores the job in the status of the instance when it belongs to
+// a declared template and is not older than the job already recorded. Only
+// instances that accept notifications are written to.
+func (n *notificationReceiver) recordJob(ctx context.Context, key types.NamespacedName, notification jobNotification) (bool, error) {
+	recorded := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		recorded = false
+		instance := &awxv1alpha1.AWXInstance{}
+		if err := n.reconciler.Get(ctx, key, instance); err != nil {
+			return err
+		}
+		if !instance.Spec.AcceptNotifications {
+			return errNotificationsRefused
+		}
+		if !declaresTemplate(instance, notification.Name) {
+			return nil
+		}
+		if last, ok := instance.Status.LastJobs[notification.Name]; ok && last.JobID > notification.ID {
+			return nil
+		}
+
+		if instance.Status.LastJobs == nil {
+			instance.Status.LastJobs = make(map[string]awxv1alpha1.JobRunStatus)
+		}
+		instance.Status.LastJobs[notification.Name] = jobRunStatus(notification)
+		recorded = true
+		return n.reconciler.updateStatus(ctx, instance)
+	})
+	return recorded, err
+}
+
+// requeue enqueues the instance without blocking. The controller only reads
+// the queue on the leader, so a full queue drops the requeue.
+func (n *notificationReceiver) requeue(key types.NamespacedName) {
+	instance := &awxv1alpha1.AWXInstance{}
+	instance.Namespace = key.Namespace
+	instance.Name = key.Name
+	select {
+	case n.events <- event.GenericEvent{Object: instance}:
+	default:
+	}
+}
+
+// declaresTemplate reports whether the instance declares a job template or
+// workflow job template of the given name
+func declaresTemplate(instance *awxv1alpha1.AWXInstance, name string) bool {
+	for _, jobTemplate := range instance.Spec.JobTemplates {
+		if jobTemplate.Name == name {
+			return true
+		}
+	}
+	for _, workflow := range instance.Spec.WorkflowJobTemplates {
+		if workflow.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jobRunStatus converts a notification into the status of the job
+func jobRunStatus(notification jobNotification) awxv1alpha1.JobRunStatus {
+	status := awxv1alpha1.JobRunStatus{
+		JobID:  notification.ID,
+		Status: notification.Status,
+		URL:    notification.URL,
+	}
+	if finished, err := time.Parse(time.RFC3339Nano, notification.Finished); err == nil {
+		status.Finished = &metav1.Time{Time: finished}
+	}
+	return status
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+const (
+	// Observed resource states reported in Observe mode
+	observedInSync  = "InSync"
+	observedDrifted = "Drifted"
+	observedMissing = "Missing"
+)
+
+// observeResources compares the declared resources with their actual state in
+// AWX without writing anything, recording the result in the status maps, the
+// InSync condition and the drift metric
+func (r *AWXInstanceReconciler) observeResources(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+
+	logger := log.FromContext(ctx)
+	var drifted []string
+
+	// Check Credentials
+	credentialManager := awx.NewCredentialManager(awxClient)
+	credentialDrift := 0
+	for _, credentialSpec := range instance.Spec.Credentials {
+		credential, err := credentialManager.GetCredential(credentialSpec.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get credential %s: %w", credentialSpec.Name, err)
+		}
+		state := observedState(credential != nil, credential != nil && credentialManager.IsCredentialInDesiredState(credential, credentialSpec))
+		instance.Status.CredentialStatuses[credentialSpec.Name] = state
+		if state != observedInSync {
+			credentialDrift++
+			drifted = append(drifted, fmt.Sprintf("credential %s (%s)", credentialSpec.Name, state))
+		}
+	}
+
+	// Check Projects
+	projectManager := awx.NewProjectManager(awxClient)
+	projectDrift := 0
+	for _, projectSpec := range instance.Spec.Projects {
+		project, err := projectManager.GetProject(projectSpec.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get project %s: %w", projectSpec.Name, err)
+		}
+		state := observedState(project != nil, project != nil && projectManager.IsProjectInDesiredState(project, projectSpec))
+		instance.Status.ProjectStatuses[projectSpec.Name] = state
+		if state != observedInSync {
+			projectDrift++
+			drifted = append(drifted, fmt.Sprintf("project %s (%s)", projectSpec.Name, state))
+		}
+	}
+
+	// Check Inventories
+	inventoryManager := awx.NewInventoryManager(awxClient)
+	inventoryDrift := 0
+	for _, inventorySpec := range instance.Spec.Inventories {
+		inventory, err := inventoryManager.GetInventory(inventorySpec.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get inventory %s: %w", inventorySpec.Name, err)
+		}
+		state := observedState(inventory != nil, inventory != nil && inventoryManager.IsInventoryInDesiredState(inventory, inventorySpec))
+		instance.Status.InventoryStatuses[inventorySpec.Name] = state
+		if state != observedInSync {
+			inventoryDrift++
+			detail := state
+			// Name the drifted hosts, so changes of the host defaults can be
+			// told apart from changes of single hosts
+			if state == observedDrifted {
+				hostDrift, err := inventoryManager.HostDrift(inventory, inventorySpec)
+				if err != nil {
+					logger.Error(err, "Failed to describe host drift", "inventory", inventorySpec.Name)
+				} else if len(hostDrift) > 0 {
+					detail = fmt.Sprintf("%s: %s", state, strings.Join(hostDrift, "; "))
+				}
+			}
+			drifted = append(drifted, fmt.Sprintf("inventory %s (%s)", inventorySpec.Name, detail))
+		}
+	}
+
+	// Check Job Templates
+	jobTemplateManager := awx.NewJobTemplateManager(awxClient)
+	jobTemplateDrift := 0
+	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+		jobTemplate, err := jobTemplateManager.GetJobTemplate(jobTemplateSpec.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get job template %s: %w", jobTemplateSpec.Name, err)
+		}
+		state := observedState(jobTemplate != nil, jobTemplate != nil && jobTemplateManager.IsJobTemplateInDesiredState(jobTemplate, jobTemplateSpec))
+		instance.Status.JobTemplateStatuses[jobTemplateSpec.Name] = state
+		if state != observedInSync {
+			jobTemplateDrift++
+			drifted = append(drifted, fmt.Sprintf("job template %s (%s)", jobTemplateSpec.Name, state))
+		}
+	}
+
+	// Check Workflow Job Templates
+	workflowManager := awx.NewWorkflowJobTemplateManager(awxClient)
+	workflowDrift := 0
+	for _, workflowSpec := range instance.Spec.WorkflowJobTemplates {
+		workflow, err := workflowManager.GetWorkflowJobTemplate(workflowSpec.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get workflow job template %s: %w", workflowSpec.Name, err)
+		}
+		state := observedState(workflow != nil, workflow != nil && workflowManager.IsWorkflowJobTemplateInDesiredState(workflow, workflowSpec))
+		instance.Status.WorkflowJobTemplateStatuses[workflowSpec.Name] = state
+		if state != observedInSync {
+			workflowDrift++
+			drifted = append(drifted, fmt.Sprintf("workflow job template %s (%s)", workflowSpec.Name, state))
+		}
+	}
+
+	driftedResourcesGauge.WithLabelValues(instance.Namespace, instance.Name, "credential").Set(float64(credentialDrift))
+	driftedResourcesGauge.WithLabelValues(instance.Namespace, instance.Name, "project").Set(float64(projectDrift))
+	driftedResourcesGauge.WithLabelValues(instance.Namespace, instance.Name, "inventory").Set(float64(inventoryDrift))
+	driftedResourcesGauge.WithLabelValues(instance.Namespace, instance.Name, "job_template").Set(float64(jobTemplateDrift))
+	driftedResourcesGauge.WithLabelValues(instance.Namespace, instance.Name, "workflow_job_template").Set(float64(workflowDrift))
+
+	if len(drifted) > 0 {
+		logger.Info("Observed drift in AWX resources", "instance", instance.Name, "drifted", drifted)
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               "InSync",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "DriftDetected",
+			Message:            fmt.Sprintf("Resources differ from the spec: %s", strings.Join(drifted, ", ")),
+		})
+	} else {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               "InSync",
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "NoDrift",
+			Message:            "All declared resources match the spec",
+		})
+	}
+
+	return nil
+}
+
+// observedState describes an observed resource
+func observedState(exists, inDesiredState bool) string {
+	switch {
+	case !exists:
+		return observedMissing
+	case !inDesiredState:
+		return observedDrifted
+	default:
+		return observedInSync
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// Labels set on every Kubernetes object the operator creates for an
+// AWXInstance, so they can be listed with kubectl get -l
+const (
+	labelName      = "app.kubernetes.io/name"
+	labelInstance  = "app.kubernetes.io/instance"
+	labelComponent = "app.kubernetes.io/component"
+	labelPartOf    = "app.kubernetes.io/part-of"
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	// labelOwner names the AWXInstance owning the object
+	labelOwner = "awx.ansible.com/awxinstance"
+
+	// operatorName identifies the operator in the managed-by label
+	operatorName = "awx-k8s-operator"
+)
+
+// ownedObjectLabels returns the labels of a child object of the instance.
+// component describes the object's purpose, such as "webhook-key".
+func ownedObjectLabels(instance *awxv1alpha1.AWXInstance, component string) map[string]string {
+	return map[string]string{
+		labelName:      "awx",
+		labelInstance:  instance.Name,
+		labelComponent: component,
+		labelPartOf:    "awx",
+		labelManagedBy: operatorName,
+		labelOwner:     instance.Name,
+	}
+}
+
+// ownedObjectsSelector selects all child objects of the instance
+func ownedObjectsSelector(instance *awxv1alpha1.AWXInstance) client.MatchingLabels {
+	return client.MatchingLabels{
+		labelManagedBy: operatorName,
+		labelOwner:     instance.Name,
+	}
+}
+
+// setOwnership labels obj as a child of the instance and makes the instance
+// its controller, so it is garbage collected together with the instance.
+// Labels already present on obj are kept unless they conflict.
+func (r *AWXInstanceReconciler) setOwnership(instance *awxv1alpha1.AWXInstance, obj client.Object, component string) error {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range ownedObjectLabels(instance, component) {
+		labels[key] = value
+	}
+	obj.SetLabels(labels)
+
+	if err := controllerutil.SetControllerReference(instance, obj, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set owner of %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
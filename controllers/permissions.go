@@ -0,0 +1,108 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// conditionInsufficientPermissions reports that the AWX user or token of the
+// instance may not create objects of a declared kind
+const conditionInsufficientPermissions = "InsufficientPermissions"
+
+// managedEndpoints returns the AWX endpoints of the kinds the spec declares
+// objects of
+func managedEndpoints(spec *awxv1alpha1.AWXInstanceSpec) []string {
+	var endpoints []string
+	if len(spec.Credentials) > 0 {
+		endpoints = append(endpoints, "credentials")
+	}
+	if len(spec.Projects) > 0 {
+		endpoints = append(endpoints, "projects")
+	}
+	if len(spec.Inventories) > 0 {
+		endpoints = append(endpoints, "inventories")
+	}
+	if len(spec.JobTemplates) > 0 {
+		endpoints = append(endpoints, "job_templates")
+	}
+	if len(spec.WorkflowJobTemplates) > 0 {
+		endpoints = append(endpoints, "workflow_job_templates")
+	}
+	return endpoints
+}
+
+// checkPermissions verifies that the AWX user may write the declared kinds
+// before anything is written, and sets the InsufficientPermissions
+// condition. When permissions are missing the reconcile stops with a single
+// condition listing them, instead of failing every object with a 403. The
+// returned result is nil when the reconcile can go on.
+func (r *AWXInstanceReconciler) checkPermissions(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) *ctrl.Result {
+	logger := log.FromContext(ctx)
+
+	missing, err := awxClient.MissingPermissions(managedEndpoints(&instance.Spec)...)
+	if err != nil {
+		// Unknown permissions don't block the reconcile, the writes report them
+		logger.Info("Failed to check AWX permissions", "instance", instance.Name, "error", err.Error())
+		return nil
+	}
+
+	if len(missing) == 0 {
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionInsufficientPermissions) {
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionInsufficientPermissions,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             "PermissionsGranted",
+				Message:            "The AWX user may write all declared kinds",
+			})
+		}
+		return nil
+	}
+
+	message := fmt.Sprintf("The AWX user lacks the permissions to %s", strings.Join(missing, ", "))
+	logger.Info("Insufficient AWX permissions", "instance", instance.Name, "missing", missing)
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionInsufficientPermissions,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "PermissionsMissing",
+		Message:            message,
+	})
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "InsufficientPermissions",
+		Message:            message,
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+	}
+	return &ctrl.Result{RequeueAfter: 5 * time.Minute}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// annotationReconcileNow requests a reconcile of the instance whenever its
+// value changes, e.g. to a timestamp, without changing the spec
+const annotationReconcileNow = "awx.ansible.com/reconcile-now"
+
+// reconcileAnnotations are the annotations whose changes start a reconcile
+var reconcileAnnotations = []string{annotationReconcileNow, annotationAllowMassDeletion}
+
+// reconcileNowChanged passes updates that change the reconcile-now or the
+// allow-mass-deletion annotation
+var reconcileNowChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		for _, annotation := range reconcileAnnotations {
+			if e.ObjectOld.GetAnnotations()[annotation] != e.ObjectNew.GetAnnotations()[annotation] {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// specOrReconcileNowChanged drops updates that need no reconcile, above all
+// the status writes of the reconcile itself and the object ID annotations it
+// records, which would otherwise enter Reconcile again right away. Spec
+// changes and the start of a deletion raise the generation and pass, as does
+// a changed reconcile-now or allow-mass-deletion annotation.
+var specOrReconcileNowChanged = predicate.Or(predicate.GenerationChangedPredicate{}, reconcileNowChanged)
+
+// secretDataChanged passes updates of Secrets that change their data. Secrets
+// produced by controllers such as External Secrets are rewritten on every
+// refresh, usually only with new annotations, which needs no reconcile; a
+// rotated value changes the data.
+var secretDataChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+		if !ok {
+			return true
+		}
+		newSecret, ok := e.ObjectNew.(*corev1.Secret)
+		if !ok {
+			return true
+		}
+		return !reflect.DeepEqual(oldSecret.Data, newSecret.Data) || !reflect.DeepEqual(oldSecret.StringData, newSecret.StringData)
+	},
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// startupWindow is how long after the operator became the leader the initial
+// resync reconciles the critical kinds of all instances first
+const startupWindow = 2 * time.Minute
+
+// deferredKindsDelay is how long the deferred kinds of an instance wait for
+// the critical kinds of the other instances during the initial resync
+const deferredKindsDelay = 5 * time.Second
+
+// initialResync orders the mass reconcile after an operator restart by kind:
+// the credentials, projects and inventories of all instances are reconciled
+// before the job templates, with their schedules and surveys, and workflow
+// job templates of any instance, which become usable only once the objects
+// they use exist
+type initialResync struct {
+	mu sync.Mutex
+	// started is when the operator became the leader, zero until then
+	started time.Time
+	// pending holds the instances whose critical kinds are not reconciled
+	// yet, done those whose critical kinds are, which may be reconciled
+	// before their create event is seen here
+	pending map[types.NamespacedName]bool
+	done    map[types.NamespacedName]bool
+}
+
+// start begins the startup window
+func (s *initialResync) start(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = now
+}
+
+// activeLocked reports whether the startup window is open
+func (s *initialResync) activeLocked(now time.Time) bool {
+	return !s.started.IsZero() && now.Sub(s.started) < startupWindow
+}
+
+// active reports whether the startup window is open
+func (s *initialResync) active(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activeLocked(now)
+}
+
+// track records an instance seen by the initial resync as having its critical
+// kinds pending
+func (s *initialResync) track(key types.NamespacedName, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started.IsZero() && !s.activeLocked(now) || s.done[key] {
+		return
+	}
+	if s.pending == nil {
+		s.pending = make(map[types.NamespacedName]bool)
+	}
+	s.pending[key] = true
+}
+
+// criticalDone records that the critical kinds of the instance are reconciled
+func (s *initialResync) criticalDone(key types.NamespacedName, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started.IsZero() && !s.activeLocked(now) {
+		s.pending = nil
+		s.done = nil
+		return
+	}
+	delete(s.pending, key)
+	if s.done == nil {
+		s.done = make(map[types.NamespacedName]bool)
+	}
+	s.done[key] = true
+}
+
+// waiting reports whether the deferred kinds of the instance have to wait for
+// the critical kinds of other instances. Once the startup window closed
+// nothing waits anymore, e.g. for an instance whose reconcile keeps failing.
+func (s *initialResync) waiting(key types.NamespacedName, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.activeLocked(now) {
+		s.pending = nil
+		s.done = nil
+		return false
+	}
+	for pending := range s.pending {
+		if pending != key {
+			return true
+		}
+	}
+	return false
+}
+
+// createHandler tracks the instances created while the initial resync runs.
+// They are enqueued by the primary watch.
+func (s *initialResync) createHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(_ context.Context, e event.CreateEvent, _ workqueue.RateLimitingInterface) {
+			if _, ok := e.Object.(*awxv1alpha1.AWXInstance); ok {
+				s.track(types.NamespacedName{Namespace: e.Object.GetNamespace(), Name: e.Object.GetName()}, time.Now())
+			}
+		},
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// defaultHostQuotaWarningPercent is the share of the max hosts of an
+// organization at which the operator warns when the spec doesn't set one
+const defaultHostQuotaWarningPercent = 90
+
+// checkHostQuotas compares the hosts of the AWX organizations with their max
+// hosts and warns through the QuotaNearLimit condition and an Event when one
+// reaches the warning threshold. The condition is removed when no organization
+// limits its hosts.
+func (r *AWXInstanceReconciler) checkHostQuotas(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) {
+
+	logger := log.FromContext(ctx)
+	quotas, err := awxClient.OrganizationHostQuotas()
+	if err != nil {
+		// The quota check is advisory, so it never fails the reconcile
+		logger.Info("Could not read the host quotas of the organizations",
+			"instance", instance.Name,
+			"error", err.Error())
+		return
+	}
+	if len(quotas) == 0 {
+		meta.RemoveStatusCondition(&instance.Status.Conditions, conditionQuotaNearLimit)
+		return
+	}
+
+	threshold := int(instance.Spec.HostQuotaWarningPercent)
+	if threshold == 0 {
+		threshold = defaultHostQuotaWarningPercent
+	}
+
+	var nearLimit []string
+	for _, quota := range quotas {
+		if quota.UsedPercent() < threshold {
+			continue
+		}
+		message := fmt.Sprintf("organization %s uses %d of its %d max hosts", quota.Name, quota.Hosts, quota.MaxHosts)
+		nearLimit = append(nearLimit, message)
+		r.recordEvent(ctx, instance, corev1.EventTypeWarning, "HostQuotaNearLimit", message)
+	}
+
+	if len(nearLimit) > 0 {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionQuotaNearLimit,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "HostQuotaNearLimit",
+			Message:            strings.Join(nearLimit, "; "),
+		})
+		return
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionQuotaNearLimit,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "HostQuotaAvailable",
+		Message:            fmt.Sprintf("Organizations use less than %d%% of their max hosts", threshold),
+	})
+}
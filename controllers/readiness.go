@@ -0,0 +1,133 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// Readiness phases of an AWX deployed in the cluster, in the order they are passed
+const (
+	// phaseProvisioning is reported until the web pods are ready and the API answers
+	phaseProvisioning = "Provisioning"
+	// phaseMigrating is reported while AWX runs its database migrations
+	phaseMigrating = "Migrating"
+	// phaseReady is reported once /ping returns the AWX version
+	phaseReady = "Ready"
+)
+
+const (
+	// webPodSuffix is appended to the upstream AWX name in the
+	// app.kubernetes.io/name label of its web pods
+	webPodSuffix = "-web"
+	// readinessRequeueDelay is the delay between two readiness checks
+	readinessRequeueDelay = 15 * time.Second
+)
+
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+
+// readinessPhase determines how far an AWX deployed in the cluster got: its
+// web pods are ready, the database migrations are complete and /ping reports
+// a version. The web pods are only checked for AWX discovered from the
+// upstream awx-operator, whose pod labels are known.
+func (r *AWXInstanceReconciler) readinessPhase(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) (string, string) {
+	if instance.Spec.DiscoverFrom != nil {
+		ready, total, err := r.webPodsReady(ctx, instance.Namespace, instance.Spec.DiscoverFrom.Name)
+		if err != nil {
+			return phaseProvisioning, fmt.Sprintf("Failed to list AWX web pods: %v", err)
+		}
+		if total == 0 || ready < total {
+			return phaseProvisioning, fmt.Sprintf("%d of %d AWX web pods are ready", ready, total)
+		}
+	}
+
+	info, err := awxClient.Ping()
+	if awx.IsMigrating(err) {
+		return phaseMigrating, "AWX is running its database migrations"
+	}
+	if err != nil {
+		return phaseProvisioning, fmt.Sprintf("AWX API is not available yet: %v", err)
+	}
+	if info.Version == "" {
+		return phaseProvisioning, "AWX API reports no version yet"
+	}
+	return phaseReady, fmt.Sprintf("AWX %s is ready", info.Version)
+}
+
+// webPodsReady returns how many of the web pods of an upstream AWX are ready.
+// The pods are listed from the API server rather than the cache, which would
+// watch every pod of the cluster.
+func (r *AWXInstanceReconciler) webPodsReady(ctx context.Context, namespace, name string) (int, int, error) {
+	pods := &corev1.PodList{}
+	if err := r.uncachedReader().List(ctx, pods, client.InNamespace(namespace),
+		client.MatchingLabels{labelName: name + webPodSuffix}); err != nil {
+		return 0, 0, err
+	}
+
+	ready := 0
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready++
+				break
+			}
+		}
+	}
+	return ready, len(pods.Items), nil
+}
+
+// uncachedReader returns the reader for objects the operator doesn't watch
+func (r *AWXInstanceReconciler) uncachedReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// waitForReadiness records a phase before Ready and requeues the instance
+// instead of reconciling resources against an AWX that can't accept them yet
+func (r *AWXInstanceReconciler) waitForReadiness(ctx context.Context, instance *awxv1alpha1.AWXInstance, phase, message string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Waiting for AWX to become ready", "instance", instance.Name, "phase", phase, "message", message)
+
+	instance.Status.Phase = phase
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             phase,
+		Message:            message,
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: readinessRequeueDelay}, nil
+}
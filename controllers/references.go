@@ -0,0 +1,68 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// conditionReferencesResolved reports whether the Secrets, ConfigMaps and AWX
+// objects referenced by name in the spec exist
+const conditionReferencesResolved = "ReferencesResolved"
+
+// missingReference turns the NotFound error of a referenced Kubernetes object
+// into a ReferenceNotFoundError, leaving other errors unchanged
+func missingReference(err error, kind, name string) error {
+	if apierrors.IsNotFound(err) {
+		return &awx.ReferenceNotFoundError{Kind: kind, Name: name}
+	}
+	return err
+}
+
+// setReferencesResolved sets the ReferencesResolved condition to False with
+// the exact missing reference when err was caused by one, or to True when err
+// is nil. Other errors leave the condition unchanged.
+func setReferencesResolved(instance *awxv1alpha1.AWXInstance, err error) {
+	if err == nil {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionReferencesResolved,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "AllReferencesResolved",
+			Message:            "All referenced objects exist",
+		})
+		return
+	}
+
+	if _, ok := awx.AsReferenceNotFoundError(err); !ok {
+		return
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReferencesResolved,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "ReferenceNotFound",
+		Message:            err.Error(),
+	})
+}
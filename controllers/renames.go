@@ -0,0 +1,142 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// declaredObjects pairs an AWX endpoint with the names declared for it
+type declaredObjects struct {
+	endpoint string
+	names    []string
+}
+
+// declaredObjectNames returns the declared names per AWX endpoint, in
+// dependency order
+func declaredObjectNames(spec *awxv1alpha1.AWXInstanceSpec) []declaredObjects {
+	objects := []declaredObjects{
+		{endpoint: "credentials"},
+		{endpoint: "projects"},
+		{endpoint: "inventories"},
+		{endpoint: "job_templates"},
+		{endpoint: "workflow_job_templates"},
+	}
+	for _, credential := range spec.Credentials {
+		objects[0].names = append(objects[0].names, credential.Name)
+	}
+	for _, project := range spec.Projects {
+		objects[1].names = append(objects[1].names, project.Name)
+	}
+	for _, inventory := range spec.Inventories {
+		objects[2].names = append(objects[2].names, inventory.Name)
+	}
+	for _, jobTemplate := range spec.JobTemplates {
+		objects[3].names = append(objects[3].names, jobTemplate.Name)
+	}
+	for _, workflow := range spec.WorkflowJobTemplates {
+		objects[4].names = append(objects[4].names, workflow.Name)
+	}
+	return objects
+}
+
+// objectIDKey returns the key of an AWX object in status.objectIDs
+func objectIDKey(endpoint, name string) string {
+	return endpoint + "/" + name
+}
+
+// recordObjectID stores the AWX ID of a reconciled object, so a later rename
+// in the spec can be applied to the same object
+func (r *AWXInstanceReconciler) recordObjectID(ctx context.Context, instance *awxv1alpha1.AWXInstance, endpoint, name string, obj map[string]interface{}) {
+	id, err := awx.ObjectID(obj)
+	if err != nil {
+		return
+	}
+	r.annotateObjectID(ctx, instance, endpoint, name, id)
+	if instance.Status.ObjectIDs == nil {
+		instance.Status.ObjectIDs = make(map[string]int)
+	}
+	instance.Status.ObjectIDs[objectIDKey(endpoint, name)] = id
+}
+
+// renameCandidates returns the names with a stored ID that are no longer
+// declared and the declared names without a stored ID
+func renameCandidates(ids map[string]int, endpoint string, names []string) (stale, fresh []string) {
+	declared := make(map[string]bool, len(names))
+	for _, name := range names {
+		declared[name] = true
+		if _, ok := ids[objectIDKey(endpoint, name)]; !ok {
+			fresh = append(fresh, name)
+		}
+	}
+
+	prefix := objectIDKey(endpoint, "")
+	for key := range ids {
+		name, ok := strings.CutPrefix(key, prefix)
+		if ok && !declared[name] {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale, fresh
+}
+
+// renameObjects applies renames in the spec to the existing AWX objects
+// instead of creating new ones next to them. A rename is recognised when
+// exactly one name of a kind disappeared from the spec and exactly one new
+// name appeared. IDs of names that are no longer declared are forgotten.
+func (r *AWXInstanceReconciler) renameObjects(ctx context.Context, instance *awxv1alpha1.AWXInstance, awxClient *awx.Client) error {
+	logger := log.FromContext(ctx)
+	ids := instance.Status.ObjectIDs
+
+	for _, objects := range declaredObjectNames(&instance.Spec) {
+		stale, fresh := renameCandidates(ids, objects.endpoint, objects.names)
+		if len(stale) == 0 {
+			continue
+		}
+
+		if len(stale) == 1 && len(fresh) == 1 {
+			oldName, newName := stale[0], fresh[0]
+			id := ids[objectIDKey(objects.endpoint, oldName)]
+			renamed, err := awxClient.RenameObject(objects.endpoint, id, newName)
+			if err != nil {
+				return fmt.Errorf("failed to rename %s %s to %s: %w", objects.endpoint, oldName, newName, err)
+			}
+			if renamed {
+				logger.Info("Renamed AWX object", "endpoint", objects.endpoint,
+					"id", id, "from", oldName, "to", newName)
+				ids[objectIDKey(objects.endpoint, newName)] = id
+			}
+		} else if len(fresh) > 0 {
+			logger.Info("Several names changed at once, not treating them as renames",
+				"endpoint", objects.endpoint, "removed", stale, "added", fresh)
+		}
+
+		for _, name := range stale {
+			delete(ids, objectIDKey(objects.endpoint, name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// minThrottleRequeue keeps requeues for throttled requests from hammering AWX
+// when the requested delay has already passed
+const minThrottleRequeue = time.Second
+
+// requeueAfterError returns the result for a failed reconcile. When AWX
+// throttled the request or the circuit breaker is open, the reconcile is
+// requeued after the delay AWX asked for instead of returning the error,
+// which would make controller-runtime retry on its own backoff schedule.
+func requeueAfterError(err error, fallback time.Duration) (ctrl.Result, error) {
+	if delay, ok := awx.RetryAfter(err); ok {
+		if delay < minThrottleRequeue {
+			delay = minThrottleRequeue
+		}
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	return ctrl.Result{RequeueAfter: fallback}, err
+}
+
+// maxRequeueHistory is the number of requeue reasons kept in the status
+const maxRequeueHistory = 5
+
+// Requeue reasons that are not taken from a condition of the instance
+const (
+	requeueReasonDrift          = "DriftCorrected"
+	requeueReasonStatusConflict = "StatusConflict"
+	requeueReasonError          = "ReconcileError"
+	requeueReasonResync         = "Resync"
+)
+
+// requeueTrace collects why a reconcile is requeued while it runs
+type requeueTrace struct {
+	reason  string
+	message string
+}
+
+// requeueTraceKey is the context key of the requeue trace of a reconcile
+type requeueTraceKey struct{}
+
+// withRequeueTrace returns a context carrying a new requeue trace
+func withRequeueTrace(ctx context.Context) (context.Context, *requeueTrace) {
+	trace := &requeueTrace{}
+	return context.WithValue(ctx, requeueTraceKey{}, trace), trace
+}
+
+// noteRequeueReason records why the reconcile in ctx will be requeued, for
+// causes that don't show in the conditions of the instance. The first reason
+// noted wins.
+func noteRequeueReason(ctx context.Context, reason, message string) {
+	if trace, ok := ctx.Value(requeueTraceKey{}).(*requeueTrace); ok && trace.reason == "" {
+		trace.reason = reason
+		trace.message = message
+	}
+}
+
+// requeueReason determines why a reconcile was requeued: a conflicting
+// status update, a noted reason, the returned error or the first condition
+// that isn't in the state of an instance in sync, in that order
+func requeueReason(instance *awxv1alpha1.AWXInstance, trace *requeueTrace, err error) (string, string) {
+	switch {
+	case apierrors.IsConflict(err):
+		return requeueReasonStatusConflict, "The instance was modified while it was reconciled"
+	case trace.reason != "":
+		return trace.reason, trace.message
+	case err != nil:
+		return requeueReasonError, err.Error()
+	}
+
+	if condition := meta.FindStatusCondition(instance.Status.Conditions, conditionReady); condition != nil && condition.Status != metav1.ConditionTrue {
+		return condition.Reason, condition.Message
+	}
+	if condition := meta.FindStatusCondition(instance.Status.Conditions, conditionReconciling); condition != nil && condition.Status == metav1.ConditionTrue {
+		return condition.Reason, condition.Message
+	}
+	if condition := meta.FindStatusCondition(instance.Status.Conditions, "InSync"); condition != nil && condition.Status != metav1.ConditionTrue {
+		return condition.Reason, condition.Message
+	}
+	return requeueReasonResync, "The instance is in sync and checked periodically"
+}
+
+// recordRequeueReason stores why the reconcile was requeued in the status.
+// The history only grows when the reason changes, so that the periodic
+// requeues of an instance in sync don't update it over and over. The status
+// is patched, as the reconcile may have failed on a conflicting update.
+func (r *AWXInstanceReconciler) recordRequeueReason(ctx context.Context, key types.NamespacedName, trace *requeueTrace, reconcileErr error) {
+	instance := &awxv1alpha1.AWXInstance{}
+	if err := r.Get(ctx, key, instance); err != nil {
+		return
+	}
+	reason, message := requeueReason(instance, trace, reconcileErr)
+	if instance.Status.LastRequeueReason == reason {
+		return
+	}
+
+	original := instance.DeepCopy()
+	instance.Status.LastRequeueReason = reason
+	instance.Status.RequeueHistory = append(instance.Status.RequeueHistory, awxv1alpha1.RequeueRecord{
+		Reason:  reason,
+		Message: message,
+		Time:    metav1.Now(),
+	})
+	if excess := len(instance.Status.RequeueHistory) - maxRequeueHistory; excess > 0 {
+		instance.Status.RequeueHistory = instance.Status.RequeueHistory[excess:]
+	}
+	if err := r.Status().Patch(ctx, instance, client.MergeFrom(original)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to record requeue reason", "instance", key.Name)
+	}
+}
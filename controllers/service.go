@@ -0,0 +1,93 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// applyServiceRef points the connection settings at the ClusterIP of the
+// referenced Service. The resolved address is only kept in memory.
+func (r *AWXInstanceReconciler) applyServiceRef(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	logger := log.FromContext(ctx)
+	ref := instance.Spec.ServiceRef
+
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = instance.Namespace
+	}
+
+	service := &corev1.Service{}
+	serviceName := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	if err := r.Get(ctx, serviceName, service); err != nil {
+		return fmt.Errorf("failed to read AWX Service %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	protocol, hostname, err := serviceAddress(service, ref.Port, true)
+	if err != nil {
+		return err
+	}
+	instance.Spec.Protocol = protocol
+	instance.Spec.Hostname = hostname
+	logger.Info("Resolved AWX Service", "service", serviceName.String(), "hostname", hostname)
+	return nil
+}
+
+// serviceAddress returns the protocol and host:port to reach the Service on
+// the given port, or its first port when port is 0. With useClusterIP the
+// ClusterIP is used instead of the cluster DNS name, unless the Service is
+// headless.
+func serviceAddress(service *corev1.Service, port int32, useClusterIP bool) (string, string, error) {
+	if len(service.Spec.Ports) == 0 {
+		return "", "", fmt.Errorf("AWX Service %s exposes no ports", service.Name)
+	}
+
+	servicePort := service.Spec.Ports[0]
+	if port != 0 {
+		found := false
+		for _, p := range service.Spec.Ports {
+			if p.Port == port {
+				servicePort = p
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", "", fmt.Errorf("AWX Service %s does not expose port %d", service.Name, port)
+		}
+	}
+
+	protocol := "http"
+	if servicePort.Name == "https" || servicePort.Port == 443 {
+		protocol = "https"
+	}
+
+	host := fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace)
+	if useClusterIP && service.Spec.ClusterIP != "" && service.Spec.ClusterIP != corev1.ClusterIPNone {
+		host = service.Spec.ClusterIP
+	}
+	return protocol, net.JoinHostPort(host, strconv.Itoa(int(servicePort.Port))), nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+const (
+	// componentSpecHashKey labels the Secrets holding the spec hash keys
+	componentSpecHashKey = "spec-hash-key"
+	// specHashKeyField is the field of the Secret holding the key
+	specHashKeyField = "key"
+	// specHashKeySize is the number of random bytes of a generated key
+	specHashKeySize = 32
+)
+
+// specHashKeySecretName returns the name of the Secret holding the spec hash
+// key of an instance
+func specHashKeySecretName(instance *awxv1alpha1.AWXInstance) string {
+	return instance.Name + "-spec-hash-key"
+}
+
+// loadSpecHashKey reads the key the spec hashes of the instance are computed
+// with from a Secret owned by the instance, creating it with a random key on
+// first use. The hashes cover credential inputs and survey defaults read from
+// Secrets, so they are keyed to keep readers of the status from testing
+// guesses of Secret values against them.
+func (r *AWXInstanceReconciler) loadSpecHashKey(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	name := specHashKeySecretName(instance)
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		hashKey := make([]byte, specHashKeySize)
+		if _, err := rand.Read(hashKey); err != nil {
+			return fmt.Errorf("failed to generate spec hash key: %w", err)
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: instance.Namespace, Name: name},
+			Data:       map[string][]byte{specHashKeyField: hashKey},
+		}
+		if err := r.setOwnership(instance, secret, componentSpecHashKey); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to create spec hash key Secret %s: %w", name, err)
+		}
+		log.FromContext(ctx).Info("Created spec hash key", "secret", name)
+	case err != nil:
+		return fmt.Errorf("failed to read spec hash key Secret %s: %w", name, err)
+	case len(secret.Data[specHashKeyField]) == 0:
+		return fmt.Errorf("spec hash key Secret %s has no %q field", name, specHashKeyField)
+	}
+
+	r.specHashKeysMu.Lock()
+	defer r.specHashKeysMu.Unlock()
+	if r.specHashKeys == nil {
+		r.specHashKeys = make(map[types.NamespacedName][]byte)
+	}
+	r.specHashKeys[key] = secret.Data[specHashKeyField]
+	return nil
+}
+
+// forgetSpecHashKey drops the key of a deleted instance
+func (r *AWXInstanceReconciler) forgetSpecHashKey(instance *awxv1alpha1.AWXInstance) {
+	r.specHashKeysMu.Lock()
+	defer r.specHashKeysMu.Unlock()
+	delete(r.specHashKeys, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+}
+
+// specHash returns an HMAC of the declared state of an object, keyed with the
+// spec hash key of the instance. Credential inputs and survey defaults are
+// resolved from their Secrets beforehand, so a changed Secret changes the hash
+// as well. Without a loaded key it returns an empty hash, which disables
+// skipping unchanged objects.
+func (r *AWXInstanceReconciler) specHash(instance *awxv1alpha1.AWXInstance, spec interface{}) string {
+	r.specHashKeysMu.Lock()
+	hashKey := r.specHashKeys[types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}]
+	r.specHashKeysMu.Unlock()
+	if len(hashKey) == 0 {
+		return ""
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// specUnchanged returns whether reconciling an object can be skipped, which
+// is the case when its spec hash matches the one in the status and the client
+// verified the object against AWX in the current inventory generation
+func (r *AWXInstanceReconciler) specUnchanged(instance *awxv1alpha1.AWXInstance, awxClient *awx.Client, endpoint, name string, spec interface{}) bool {
+	hash := r.specHash(instance, spec)
+	if hash == "" || instance.Status.SpecHashes[objectIDKey(endpoint, name)] != hash {
+		return false
+	}
+	return awxClient.SpecVerified(endpoint, name, hash)
+}
+
+// recordSpecHash stores the spec hash of a reconciled object in the status
+// and marks the object as verified by the client
+func (r *AWXInstanceReconciler) recordSpecHash(instance *awxv1alpha1.AWXInstance, awxClient *awx.Client, endpoint, name string, spec interface{}) {
+	hash := r.specHash(instance, spec)
+	if hash == "" {
+		return
+	}
+	if instance.Status.SpecHashes == nil {
+		instance.Status.SpecHashes = make(map[string]string)
+	}
+	instance.Status.SpecHashes[objectIDKey(endpoint, name)] = hash
+	awxClient.MarkSpecVerified(endpoint, name, hash)
+}
+
+// pruneSpecHashes removes the spec hashes of objects that are no longer declared
+func pruneSpecHashes(instance *awxv1alpha1.AWXInstance) {
+	declared := make(map[string]bool)
+	for _, objects := range declaredObjectNames(&instance.Spec) {
+		for _, name := range objects.names {
+			declared[objectIDKey(objects.endpoint, name)] = true
+		}
+	}
+	for key := range instance.Status.SpecHashes {
+		if !declared[key] {
+			delete(instance.Status.SpecHashes, key)
+		}
+	}
+}
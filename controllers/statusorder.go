@@ -0,0 +1,50 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// sortStatusLists brings the lists of the status into a deterministic order.
+// Conditions are appended in the order they are first set and addresses come
+// in the order Ansible gathered them, so without sorting an unchanged state
+// would show up as a diff of the status. Maps need no sorting, they are
+// serialized by key. The requeue history stays in its chronological order.
+func sortStatusLists(status *awxv1alpha1.AWXInstanceStatus) {
+	slices.SortStableFunc(status.Conditions, func(a, b metav1.Condition) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	slices.Sort(status.SessionTokenIDs)
+	for _, inventoryFacts := range status.InventoryFacts {
+		for _, hostFacts := range inventoryFacts.Hosts {
+			slices.Sort(hostFacts.Addresses)
+		}
+	}
+}
+
+// updateStatus writes the status of the instance with its lists sorted
+func (r *AWXInstanceReconciler) updateStatus(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	sortStatusLists(&instance.Status)
+	return r.Status().Update(ctx, instance)
+}
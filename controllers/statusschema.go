@@ -0,0 +1,62 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// currentStatusSchemaVersion is the version of the status layout written by
+// this operator. Bump it together with a new entry in statusMigrations
+// whenever a status field changes its meaning or shape.
+const currentStatusSchemaVersion = 1
+
+// statusMigrations upgrade a status in place, statusMigrations[i] from
+// schema version i to i+1. Migrations must keep spec hashes and object IDs,
+// otherwise every object is reconciled again after an operator upgrade.
+var statusMigrations = []func(status *awxv1alpha1.AWXInstanceStatus){
+	// Statuses written before the schema version was recorded already have
+	// the layout of version 1
+	func(status *awxv1alpha1.AWXInstanceStatus) {},
+}
+
+// migrateStatus upgrades the status of the instance to the current schema
+// version and reports whether it was changed. A status written by a newer
+// operator is left alone.
+func migrateStatus(ctx context.Context, instance *awxv1alpha1.AWXInstance) bool {
+	version := instance.Status.SchemaVersion
+	if version == currentStatusSchemaVersion {
+		return false
+	}
+	if version > currentStatusSchemaVersion {
+		log.FromContext(ctx).Info("Status was written by a newer operator, leaving it unchanged",
+			"instance", instance.Name, "schemaVersion", version, "supported", currentStatusSchemaVersion)
+		return false
+	}
+
+	for ; version < currentStatusSchemaVersion; version++ {
+		statusMigrations[version](&instance.Status)
+	}
+	log.FromContext(ctx).Info("Migrated status schema", "instance", instance.Name,
+		"from", instance.Status.SchemaVersion, "to", currentStatusSchemaVersion)
+	instance.Status.SchemaVersion = currentStatusSchemaVersion
+	return true
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// resolveSurveyDefaults sets the defaults of the survey questions that are
+// read from Secrets. The values only live in the in-memory spec and are
+// never logged or written to the status.
+func (r *AWXInstanceReconciler) resolveSurveyDefaults(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	for i := range instance.Spec.JobTemplates {
+		jobTemplate := &instance.Spec.JobTemplates[i]
+		if err := r.resolveQuestionDefaults(ctx, instance.Namespace, "job template", jobTemplate.Name, jobTemplate.Survey); err != nil {
+			return err
+		}
+	}
+	for i := range instance.Spec.WorkflowJobTemplates {
+		workflow := &instance.Spec.WorkflowJobTemplates[i]
+		if err := r.resolveQuestionDefaults(ctx, instance.Namespace, "workflow job template", workflow.Name, workflow.Survey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveQuestionDefaults sets the defaults read from Secrets of the
+// questions of a survey, which may be nil
+func (r *AWXInstanceReconciler) resolveQuestionDefaults(ctx context.Context, namespace, kind, name string, survey *awxv1alpha1.SurveySpec) error {
+	if survey == nil {
+		return nil
+	}
+	for j := range survey.Questions {
+		question := &survey.Questions[j]
+		ref := question.DefaultSecretRef
+		if ref == nil {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+		if err := r.Get(ctx, key, secret); err != nil {
+			if ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			return fmt.Errorf("failed to read Secret %s for survey question %s of %s %s: %w",
+				key.Name, question.Variable, kind, name, missingReference(err, "Secret", key.Name))
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			if ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			return fmt.Errorf("survey question %s of %s %s: %w", question.Variable, kind, name,
+				&awx.ReferenceNotFoundError{Kind: "Secret key", Name: key.Name + "/" + ref.Key})
+		}
+		question.Default = string(value)
+	}
+	return nil
+}
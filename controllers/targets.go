@@ -0,0 +1,210 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// errObservedTarget is returned for a target AWXInstance in Observe mode,
+// whose AWX must not be written to
+var errObservedTarget = errors.New("target AWXInstance is in Observe mode")
+
+// targetClient returns an AWX client built from the connection settings of
+// the referenced target AWXInstance. Targets in Observe mode are refused
+// with errObservedTarget.
+func (r *AWXInstanceReconciler) targetClient(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, target awxv1alpha1.InstanceRef) (*awx.Client, error) {
+
+	targetInstance, err := r.referencedInstance(ctx, instance.Namespace, target)
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+	if targetInstance.Spec.Mode == awxv1alpha1.ModeObserve {
+		return nil, errObservedTarget
+	}
+	return r.awxClientFor(ctx, targetInstance), nil
+}
+
+// referencedClient returns an AWX client built from the connection settings
+// of the referenced AWXInstance in the namespace
+func (r *AWXInstanceReconciler) referencedClient(ctx context.Context,
+	namespace string, ref awxv1alpha1.InstanceRef) (*awx.Client, error) {
+
+	targetInstance, err := r.referencedInstance(ctx, namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+	return r.awxClientFor(ctx, targetInstance), nil
+}
+
+// referencedInstance returns the referenced AWXInstance in the namespace with
+// its connection settings resolved
+func (r *AWXInstanceReconciler) referencedInstance(ctx context.Context,
+	namespace string, ref awxv1alpha1.InstanceRef) (*awxv1alpha1.AWXInstance, error) {
+
+	targetInstance := &awxv1alpha1.AWXInstance{}
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	if err := r.Get(ctx, key, targetInstance); err != nil {
+		return nil, fmt.Errorf("failed to get AWXInstance %s: %w", ref.Name,
+			missingReference(err, "AWXInstance", ref.Name))
+	}
+	if err := r.resolveConnection(ctx, targetInstance); err != nil {
+		return nil, err
+	}
+	return targetInstance, nil
+}
+
+// pushToTargets reconciles the declared resources on every target AWX in
+// addition to the instance itself, recording the result per target in the
+// status and the TargetsSynced condition. Failing targets don't stop the
+// remaining targets from being reconciled. Targets in Observe mode are
+// skipped.
+func (r *AWXInstanceReconciler) pushToTargets(ctx context.Context, instance *awxv1alpha1.AWXInstance) {
+	logger := log.FromContext(ctx)
+
+	if len(instance.Spec.Targets) == 0 {
+		instance.Status.TargetStatuses = nil
+		meta.RemoveStatusCondition(&instance.Status.Conditions, conditionTargetsSynced)
+		return
+	}
+
+	statuses := make(map[string]string, len(instance.Spec.Targets))
+	var failed []string
+	for _, target := range instance.Spec.Targets {
+		logger.Info("Reconciling resources on target", "instance", instance.Name, "target", target.Name)
+
+		awxClient, err := r.targetClient(ctx, instance, target)
+		if errors.Is(err, errObservedTarget) {
+			logger.Info("Target is in Observe mode, skipping it", "instance", instance.Name, "target", target.Name)
+			statuses[target.Name] = "Skipped: Observe mode"
+			continue
+		}
+		if err == nil {
+			err = ensureResources(awxClient, &instance.Spec)
+		}
+		if err != nil {
+			logger.Error(err, "Failed to reconcile resources on target", "instance", instance.Name, "target", target.Name)
+			statuses[target.Name] = fmt.Sprintf("Failed: %v", err)
+			failed = append(failed, target.Name)
+			continue
+		}
+		statuses[target.Name] = "Reconciled"
+	}
+	instance.Status.TargetStatuses = statuses
+
+	condition := metav1.Condition{
+		Type:               conditionTargetsSynced,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "Synced",
+		Message:            fmt.Sprintf("All %d targets are synced", len(instance.Spec.Targets)),
+	}
+	if len(failed) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SyncFailed"
+		condition.Message = fmt.Sprintf("Failed to sync targets: %s", strings.Join(failed, ", "))
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+}
+
+// ensureResources reconciles all declared resources on one AWX, in dependency
+// order, stopping at the first failure
+func ensureResources(awxClient *awx.Client, spec *awxv1alpha1.AWXInstanceSpec) error {
+	credentialManager := awx.NewCredentialManager(awxClient)
+	for _, credentialSpec := range spec.Credentials {
+		if _, err := credentialManager.EnsureCredential(credentialSpec); err != nil {
+			return fmt.Errorf("credential %s: %w", credentialSpec.Name, err)
+		}
+	}
+
+	if err := awx.NewOrganizationManager(awxClient).EnsureOrganizations(spec.Organizations); err != nil {
+		return err
+	}
+
+	projectManager := awx.NewProjectManager(awxClient)
+	for _, projectSpec := range spec.Projects {
+		if _, err := projectManager.EnsureProject(projectSpec); err != nil {
+			return fmt.Errorf("project %s: %w", projectSpec.Name, err)
+		}
+	}
+
+	inventoryManager := awx.NewInventoryManager(awxClient)
+	for _, inventorySpec := range spec.Inventories {
+		if _, err := inventoryManager.EnsureInventory(inventorySpec); err != nil {
+			return fmt.Errorf("inventory %s: %w", inventorySpec.Name, err)
+		}
+	}
+
+	jobTemplateManager := awx.NewJobTemplateManager(awxClient)
+	for _, jobTemplateSpec := range spec.JobTemplates {
+		if _, err := jobTemplateManager.EnsureJobTemplate(jobTemplateSpec); err != nil {
+			return fmt.Errorf("job template %s: %w", jobTemplateSpec.Name, err)
+		}
+	}
+
+	workflowManager := awx.NewWorkflowJobTemplateManager(awxClient)
+	for _, workflowSpec := range spec.WorkflowJobTemplates {
+		if _, err := workflowManager.EnsureWorkflowJobTemplate(workflowSpec); err != nil {
+			return fmt.Errorf("workflow job template %s: %w", workflowSpec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteFromTargets removes the declared resources from every target AWX in
+// the same way as from the instance itself. Targets whose AWXInstance no
+// longer exists are skipped, as there is no connection left to delete them
+// with, and so are targets in Observe mode.
+func (r *AWXInstanceReconciler) deleteFromTargets(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	logger := log.FromContext(ctx)
+
+	for _, target := range instance.Spec.Targets {
+		logger.Info("Deleting resources on target", "instance", instance.Name, "target", target.Name)
+		awxClient, err := r.targetClient(ctx, instance, target)
+		if refErr, ok := awx.AsReferenceNotFoundError(err); ok && refErr.Kind == "AWXInstance" {
+			logger.Info("Target AWXInstance not found, leaving its resources in place",
+				"instance", instance.Name, "target", target.Name)
+			continue
+		}
+		if errors.Is(err, errObservedTarget) {
+			logger.Info("Target is in Observe mode, leaving its resources in place",
+				"instance", instance.Name, "target", target.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.deleteManagedObjects(ctx, instance, awxClient); err != nil {
+			return fmt.Errorf("failed to delete resources on target %s: %w", target.Name, err)
+		}
+	}
+	return nil
+}
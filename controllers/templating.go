@@ -0,0 +1,144 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// Template delimiters are chosen so that Jinja expressions ({{ ... }}) in
+// Ansible variables are passed through to AWX untouched
+const (
+	templateLeftDelim  = "${{"
+	templateRightDelim = "}}"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// templateData is the data available to variable templates. The cluster
+// name and namespace are only set for the naming policy.
+type templateData struct {
+	Values      map[string]string
+	ClusterName string
+	Namespace   string
+}
+
+// loadTemplateValues collects the template values from the ConfigMaps and
+// Secrets referenced by the instance. Later sources override earlier ones.
+// The values read from Secrets are returned as well, so they can be masked
+// in the logs of the requests that carry them once rendered.
+func (r *AWXInstanceReconciler) loadTemplateValues(ctx context.Context, instance *awxv1alpha1.AWXInstance) (map[string]string, []string, error) {
+	values := make(map[string]string)
+	var secretValues []string
+
+	for _, source := range instance.Spec.TemplateValuesFrom {
+		if source.ConfigMapRef != nil {
+			configMap := &corev1.ConfigMap{}
+			key := types.NamespacedName{Namespace: instance.Namespace, Name: source.ConfigMapRef.Name}
+			if err := r.Get(ctx, key, configMap); err != nil {
+				return nil, nil, fmt.Errorf("failed to read ConfigMap %s: %w", key.Name, missingReference(err, "ConfigMap", key.Name))
+			}
+			for k, v := range configMap.Data {
+				values[k] = v
+			}
+		}
+		if source.SecretRef != nil {
+			secret := &corev1.Secret{}
+			key := types.NamespacedName{Namespace: instance.Namespace, Name: source.SecretRef.Name}
+			if err := r.Get(ctx, key, secret); err != nil {
+				return nil, nil, fmt.Errorf("failed to read Secret %s: %w", key.Name, missingReference(err, "Secret", key.Name))
+			}
+			for k, v := range secret.Data {
+				values[k] = string(v)
+				secretValues = append(secretValues, string(v))
+			}
+		}
+	}
+
+	return values, secretValues, nil
+}
+
+// renderSpecTemplates renders the variables and extra vars in the spec with the given values
+func renderSpecTemplates(spec *awxv1alpha1.AWXInstanceSpec, values map[string]string) error {
+	data := templateData{Values: values}
+
+	for i := range spec.Inventories {
+		inventory := &spec.Inventories[i]
+		rendered, err := renderTemplate("inventory "+inventory.Name, inventory.Variables, data)
+		if err != nil {
+			return err
+		}
+		inventory.Variables = rendered
+
+		if inventory.HostDefaults != nil {
+			rendered, err := renderTemplate("host defaults of inventory "+inventory.Name, inventory.HostDefaults.Variables, data)
+			if err != nil {
+				return err
+			}
+			inventory.HostDefaults.Variables = rendered
+		}
+
+		for j := range inventory.Hosts {
+			host := &inventory.Hosts[j]
+			rendered, err := renderTemplate("host "+host.Name, host.Variables, data)
+			if err != nil {
+				return err
+			}
+			host.Variables = rendered
+		}
+	}
+
+	for i := range spec.JobTemplates {
+		jobTemplate := &spec.JobTemplates[i]
+		rendered, err := renderTemplate("job template "+jobTemplate.Name, jobTemplate.ExtraVars, data)
+		if err != nil {
+			return err
+		}
+		jobTemplate.ExtraVars = rendered
+	}
+
+	return nil
+}
+
+// renderTemplate renders a single template, failing on references to missing values
+func renderTemplate(name, text string, data templateData) (string, error) {
+	if text == "" {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).
+		Delims(templateLeftDelim, templateRightDelim).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template in %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template in %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
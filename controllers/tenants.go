@@ -0,0 +1,113 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// tenantClientFor returns the AWX client the declared resources of the
+// instance are reconciled with. When the operator is configured with a tenant
+// credentials Secret, the client logs in as the organization scoped AWX user
+// in that Secret in the namespace of the instance, so that AWX enforces the
+// permissions of the tenant. A namespace without the Secret fails closed
+// unless it is listed in AdminNamespaces, which are reconciled as the admin.
+// Without tenant credentials the admin client is always used.
+func (r *AWXInstanceReconciler) tenantClientFor(ctx context.Context, instance *awxv1alpha1.AWXInstance, adminClient *awx.Client) (*awx.Client, error) {
+	if r.TenantCredentialsSecret == "" {
+		return adminClient, nil
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: r.TenantCredentialsSecret}
+	if err := r.Get(ctx, key, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to read tenant credentials Secret %s: %w", key.Name, err)
+		}
+		r.forgetTenantClient(instance)
+		if slices.Contains(r.AdminNamespaces, instance.Namespace) {
+			return adminClient, nil
+		}
+		return nil, fmt.Errorf("tenant credentials Secret %s not found and namespace %s is not reconciled as the admin",
+			key.Name, instance.Namespace)
+	}
+	username, password := string(secret.Data["username"]), string(secret.Data["password"])
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("tenant credentials Secret %s must have a username and a password", key.Name)
+	}
+
+	config := clientConfigFor(instance)
+	config.username = username
+	config.password = password
+	config.apiPath = adminClient.APIPath()
+	instanceKey := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	if cached, ok := r.tenantClients[instanceKey]; ok && cached.config == config {
+		cached.client.SetCorrelationID(correlationIDFrom(ctx))
+		cached.client.SetRequestLimiter(r.requestLimiterLocked(instance))
+		cached.client.SetTokenCreation(instance.Spec.Mode != awxv1alpha1.ModeObserve)
+		return cached.client.WithContext(ctx), nil
+	}
+
+	log.FromContext(ctx).Info("Reconciling resources as tenant AWX user", "instance", instance.Name, "username", username)
+	tenantClient := newAWXClient(ctx, instance, config)
+	tenantClient.SetRequestLimiter(r.requestLimiterLocked(instance))
+	if r.tenantClients == nil {
+		r.tenantClients = make(map[types.NamespacedName]*cachedAWXClient)
+	}
+	r.tenantClients[instanceKey] = &cachedAWXClient{config: config, client: tenantClient}
+	return tenantClient.WithContext(ctx), nil
+}
+
+// forgetTenantClient drops the cached tenant client for the instance
+func (r *AWXInstanceReconciler) forgetTenantClient(instance *awxv1alpha1.AWXInstance) {
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	delete(r.tenantClients, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+}
+
+// instancesForTenantSecret maps the tenant credentials Secret of a namespace
+// to all AWXInstances in it
+func (r *AWXInstanceReconciler) instancesForTenantSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	instances := &awxv1alpha1.AWXInstanceList{}
+	if err := r.List(ctx, instances, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list AWXInstances for tenant credentials", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(instances.Items))
+	for _, instance := range instances.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// sessionTokenIDs returns the IDs of the session tokens the clients hold
+func sessionTokenIDs(clients ...*awx.Client) []int {
+	var ids []int
+	for _, awxClient := range clients {
+		if id := awxClient.SessionTokenID(); id != 0 && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// trackSessionTokens records the session tokens of the clients of the
+// instance in the status and revokes the tracked tokens no longer in use,
+// e.g. those of the clients before an operator restart or a change of the
+// connection settings. A failed revocation is retried on the next reconcile.
+func (r *AWXInstanceReconciler) trackSessionTokens(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, adminClient *awx.Client, clients ...*awx.Client) {
+
+	logger := log.FromContext(ctx)
+	current := sessionTokenIDs(append([]*awx.Client{adminClient}, clients...)...)
+
+	tracked := slices.Clone(current)
+	for _, id := range instance.Status.SessionTokenIDs {
+		if slices.Contains(current, id) {
+			continue
+		}
+		if err := adminClient.RevokeToken(id); err != nil {
+			logger.Info("Could not revoke unused AWX session token",
+				"instance", instance.Name,
+				"tokenID", id,
+				"error", err.Error())
+			tracked = append(tracked, id)
+		}
+	}
+	slices.Sort(tracked)
+	instance.Status.SessionTokenIDs = tracked
+}
+
+// revokeSessionTokens revokes the tracked session tokens and those of the
+// clients when the instance is deleted, so that no tokens of the operator
+// are left in AWX. The tracked tokens go first and the token of the admin
+// client last. A token AWX refuses to revoke because the credentials are no
+// longer accepted (401) or lack the permission (403) doesn't block the
+// deletion; it is reported in an Event and expires on its own.
+func (r *AWXInstanceReconciler) revokeSessionTokens(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, adminClient *awx.Client, clients ...*awx.Client) error {
+
+	logger := log.FromContext(ctx)
+	clients = append(clients, adminClient)
+	held := sessionTokenIDs(clients...)
+	for _, id := range instance.Status.SessionTokenIDs {
+		if slices.Contains(held, id) {
+			continue
+		}
+		logger.Info("Revoking AWX session token", "instance", instance.Name, "tokenID", id)
+		if err := r.tokenNotRevoked(ctx, instance, id, adminClient.RevokeToken(id)); err != nil {
+			return fmt.Errorf("failed to revoke session token %d: %w", id, err)
+		}
+	}
+	for _, awxClient := range clients {
+		id := awxClient.SessionTokenID()
+		if err := r.tokenNotRevoked(ctx, instance, id, awxClient.RevokeSessionToken()); err != nil {
+			return fmt.Errorf("failed to revoke session token: %w", err)
+		}
+	}
+	instance.Status.SessionTokenIDs = nil
+	return nil
+}
+
+// revokeObservedSessionTokens revokes the session tokens of a deleted
+// instance in Observe mode. Its clients never create tokens, but may still
+// hold or have tracked one from before the instance was observed.
+func (r *AWXInstanceReconciler) revokeObservedSessionTokens(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	if len(instance.Status.SessionTokenIDs) == 0 && !r.holdsSessionToken(instance) {
+		return nil
+	}
+	if err := r.resolveConnection(ctx, instance); err != nil {
+		return err
+	}
+	adminClient := r.awxClientFor(ctx, instance)
+	clients := []*awx.Client{}
+	if tenantClient, err := r.tenantClientFor(ctx, instance, adminClient); err == nil && !tenantClient.Shares(adminClient) {
+		clients = append(clients, tenantClient)
+	}
+	return r.revokeSessionTokens(ctx, instance, adminClient, clients...)
+}
+
+// holdsSessionToken reports whether a cached client of the instance holds a
+// session token
+func (r *AWXInstanceReconciler) holdsSessionToken(instance *awxv1alpha1.AWXInstance) bool {
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	for _, cached := range []*cachedAWXClient{r.clients[key], r.tenantClients[key]} {
+		if cached != nil && cached.client.SessionTokenID() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenNotRevoked reports a session token AWX refused to revoke with 401 or
+// 403 in an Event and drops the error, other errors are returned
+func (r *AWXInstanceReconciler) tokenNotRevoked(ctx context.Context, instance *awxv1alpha1.AWXInstance, id int, err error) error {
+	if !awx.IsStatus(err, http.StatusUnauthorized) && !awx.IsStatus(err, http.StatusForbidden) {
+		return err
+	}
+	log.FromContext(ctx).Info("AWX refused to revoke session token, leaving it to expire",
+		"instance", instance.Name, "tokenID", id, "error", err.Error())
+	r.recordEvent(ctx, instance, corev1.EventTypeWarning, "SessionTokenNotRevoked",
+		fmt.Sprintf("AWX session token %d was not revoked and expires on its own: %v", id, err))
+	return nil
+}
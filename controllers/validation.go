@@ -0,0 +1,268 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// validateSpec checks the AWXInstance spec for problems that the CRD schema
+// cannot catch on older API servers, such as duplicate resource names or
+// missing connection settings.
+func validateSpec(spec *awxv1alpha1.AWXInstanceSpec) error {
+	var problems []string
+
+	if spec.ServiceRef != nil && spec.Hostname != "" {
+		problems = append(problems, "hostname and serviceRef are mutually exclusive")
+	}
+	if spec.Hostname != "" {
+		if _, port, err := splitHostname(spec.Hostname); err != nil {
+			problems = append(problems, err.Error())
+		} else if port != 0 && spec.Port != 0 {
+			problems = append(problems, "port requires a hostname without a port")
+		}
+	} else if spec.Port != 0 {
+		problems = append(problems, "port requires a hostname")
+	}
+	if spec.AdminPasswordSecretRef != nil && spec.AdminPassword != "" {
+		problems = append(problems, "adminPassword and adminPasswordSecretRef are mutually exclusive")
+	}
+
+	// Connection settings may only be omitted when they are discovered
+	if spec.DiscoverFrom == nil {
+		if spec.Hostname == "" && spec.ServiceRef == nil {
+			problems = append(problems, "hostname is required unless serviceRef or discoverFrom is set")
+		}
+		if spec.AdminUser == "" || (spec.AdminPassword == "" && spec.AdminPasswordSecretRef == nil) {
+			problems = append(problems, "adminUser and adminPassword or adminPasswordSecretRef are required unless discoverFrom is set")
+		}
+	}
+
+	credentialNames := make([]string, 0, len(spec.Credentials))
+	for _, credential := range spec.Credentials {
+		credentialNames = append(credentialNames, credential.Name)
+	}
+	if dups := findDuplicates(credentialNames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate credential names: %s", strings.Join(dups, ", ")))
+	}
+
+	organizationNames := make([]string, 0, len(spec.Organizations))
+	for _, organization := range spec.Organizations {
+		organizationNames = append(organizationNames, organization.Name)
+		if dups := findDuplicates(organization.GalaxyCredentials); len(dups) > 0 {
+			problems = append(problems, fmt.Sprintf("duplicate Galaxy credentials in organization %s: %s",
+				organization.Name, strings.Join(dups, ", ")))
+		}
+	}
+	if dups := findDuplicates(organizationNames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate organization names: %s", strings.Join(dups, ", ")))
+	}
+
+	projectNames := make([]string, 0, len(spec.Projects))
+	for _, project := range spec.Projects {
+		projectNames = append(projectNames, project.Name)
+		if project.SCMType == "insights" && project.SCMCredential == "" {
+			problems = append(problems, fmt.Sprintf("project %s of type insights requires an Insights scmCredential", project.Name))
+		}
+		if project.UpdateSchedule != nil {
+			if project.SCMType == "manual" {
+				problems = append(problems, fmt.Sprintf("project %s: manual projects can't be updated on a schedule", project.Name))
+			} else if err := awx.ValidateUpdateSchedule(*project.UpdateSchedule); err != nil {
+				problems = append(problems, fmt.Sprintf("project %s: %v", project.Name, err))
+			}
+		}
+	}
+	if dups := findDuplicates(projectNames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate project names: %s", strings.Join(dups, ", ")))
+	}
+
+	inventoryNames := make([]string, 0, len(spec.Inventories))
+	for _, inventory := range spec.Inventories {
+		inventoryNames = append(inventoryNames, inventory.Name)
+
+		hostNames := make([]string, 0, len(inventory.Hosts))
+		for _, host := range inventory.Hosts {
+			hostNames = append(hostNames, host.Name)
+		}
+		if dups := findDuplicates(hostNames); len(dups) > 0 {
+			problems = append(problems, fmt.Sprintf("duplicate host names in inventory %s: %s",
+				inventory.Name, strings.Join(dups, ", ")))
+		}
+		if inventory.HostDefaults != nil {
+			if err := awx.ValidateHostDefaults(*inventory.HostDefaults); err != nil {
+				problems = append(problems, fmt.Sprintf("inventory %s: %v", inventory.Name, err))
+			}
+		}
+		sourceNames := make([]string, 0, len(inventory.Sources))
+		for _, source := range inventory.Sources {
+			sourceNames = append(sourceNames, source.Name)
+			if err := awx.ValidateInventorySource(source); err != nil {
+				problems = append(problems, fmt.Sprintf("source %s of inventory %s: %v", source.Name, inventory.Name, err))
+			}
+		}
+		if dups := findDuplicates(sourceNames); len(dups) > 0 {
+			problems = append(problems, fmt.Sprintf("duplicate source names in inventory %s: %s",
+				inventory.Name, strings.Join(dups, ", ")))
+		}
+	}
+	if dups := findDuplicates(inventoryNames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate inventory names: %s", strings.Join(dups, ", ")))
+	}
+
+	jobTemplateNames := make([]string, 0, len(spec.JobTemplates))
+	for _, jobTemplate := range spec.JobTemplates {
+		jobTemplateNames = append(jobTemplateNames, jobTemplate.Name)
+
+		scheduleNames := make([]string, 0, len(jobTemplate.Schedules))
+		for _, schedule := range jobTemplate.Schedules {
+			scheduleNames = append(scheduleNames, schedule.Name)
+			if _, err := awx.BuildRRule(schedule); err != nil {
+				problems = append(problems, fmt.Sprintf("schedule %s of job template %s: %v",
+					schedule.Name, jobTemplate.Name, err))
+			}
+			if err := awx.ValidateScheduleOverrides(jobTemplate, schedule); err != nil {
+				problems = append(problems, fmt.Sprintf("schedule %s of job template %s: %v",
+					schedule.Name, jobTemplate.Name, err))
+			}
+		}
+		if dups := findDuplicates(scheduleNames); len(dups) > 0 {
+			problems = append(problems, fmt.Sprintf("duplicate schedule names in job template %s: %s",
+				jobTemplate.Name, strings.Join(dups, ", ")))
+		}
+		if jobTemplate.Survey != nil {
+			problems = append(problems, validateSurvey("job template", jobTemplate.Name, jobTemplate.Survey)...)
+		}
+	}
+	if dups := findDuplicates(jobTemplateNames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate job template names: %s", strings.Join(dups, ", ")))
+	}
+
+	workflowNames := make([]string, 0, len(spec.WorkflowJobTemplates))
+	for _, workflow := range spec.WorkflowJobTemplates {
+		workflowNames = append(workflowNames, workflow.Name)
+		problems = append(problems, validateWorkflowNodes(workflow)...)
+		if workflow.Survey != nil {
+			problems = append(problems, validateSurvey("workflow job template", workflow.Name, workflow.Survey)...)
+		}
+	}
+	if dups := findDuplicates(workflowNames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate workflow job template names: %s", strings.Join(dups, ", ")))
+	}
+
+	hostnames := make([]string, 0, len(spec.MeshInstances))
+	for _, meshInstance := range spec.MeshInstances {
+		hostnames = append(hostnames, meshInstance.Hostname)
+		if meshInstance.NodeType == "hop" && meshInstance.ListenerPort == 0 {
+			problems = append(problems, fmt.Sprintf("hop node %s requires a listenerPort", meshInstance.Hostname))
+		}
+	}
+	if dups := findDuplicates(hostnames); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate mesh instance hostnames: %s", strings.Join(dups, ", ")))
+	}
+
+	if spec.JobCleanup != nil {
+		if spec.JobCleanup.Jobs != nil {
+			if err := awx.ValidateCleanupSchedule(*spec.JobCleanup.Jobs); err != nil {
+				problems = append(problems, fmt.Sprintf("job cleanup schedule: %v", err))
+			}
+		}
+		if spec.JobCleanup.ActivityStream != nil {
+			if err := awx.ValidateCleanupSchedule(*spec.JobCleanup.ActivityStream); err != nil {
+				problems = append(problems, fmt.Sprintf("activity stream cleanup schedule: %v", err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid spec: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateWorkflowNodes checks that the node identifiers of a workflow are
+// unique and that every edge points to a declared node
+func validateWorkflowNodes(workflow awxv1alpha1.WorkflowJobTemplateSpec) []string {
+	var problems []string
+
+	identifiers := make([]string, 0, len(workflow.Nodes))
+	declared := make(map[string]bool, len(workflow.Nodes))
+	for _, node := range workflow.Nodes {
+		identifiers = append(identifiers, node.Identifier)
+		declared[node.Identifier] = true
+	}
+	if dups := findDuplicates(identifiers); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate node identifiers in workflow job template %s: %s",
+			workflow.Name, strings.Join(dups, ", ")))
+	}
+
+	for _, node := range workflow.Nodes {
+		var children []string
+		children = append(children, node.SuccessNodes...)
+		children = append(children, node.FailureNodes...)
+		children = append(children, node.AlwaysNodes...)
+		for _, child := range children {
+			if !declared[child] {
+				problems = append(problems, fmt.Sprintf("node %s of workflow job template %s links to unknown node %s",
+					node.Identifier, workflow.Name, child))
+			}
+		}
+	}
+	return problems
+}
+
+// validateSurvey checks that survey variables are unique and that password
+// defaults are only read from Secrets, so they never appear in the spec. kind
+// is the kind of template the survey belongs to, e.g. "job template".
+func validateSurvey(kind, name string, survey *awxv1alpha1.SurveySpec) []string {
+	var problems []string
+
+	variables := make([]string, 0, len(survey.Questions))
+	for _, question := range survey.Questions {
+		variables = append(variables, question.Variable)
+		if question.Type == "password" && question.Default != "" {
+			problems = append(problems, fmt.Sprintf("password question %s of %s %s must take its default from defaultSecretRef",
+				question.Variable, kind, name))
+		}
+		if question.Type != "password" && question.DefaultSecretRef != nil {
+			problems = append(problems, fmt.Sprintf("question %s of %s %s can only use defaultSecretRef as a password question",
+				question.Variable, kind, name))
+		}
+	}
+	if dups := findDuplicates(variables); len(dups) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate survey variables in %s %s: %s",
+			kind, name, strings.Join(dups, ", ")))
+	}
+	return problems
+}
+
+// findDuplicates returns each name that appears more than once, in order of
+// its first repeated occurrence
+func findDuplicates(names []string) []string {
+	seen := make(map[string]int, len(names))
+	var dups []string
+	for _, name := range names {
+		seen[name]++
+		if seen[name] == 2 {
+			dups = append(dups, name)
+		}
+	}
+	return dups
+}
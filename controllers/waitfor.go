@@ -0,0 +1,88 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+// statusWaiting prefixes the status of a job template that was reconciled
+// but waits for the syncs of its project or inventory sources
+const statusWaiting = "Waiting: "
+
+// waitingStatus checks the syncs declared in waitFor of a job template and
+// returns the status to report while it waits, or "" once they succeeded
+func waitingStatus(jobTemplateManager *awx.JobTemplateManager, jobTemplateSpec awxv1alpha1.JobTemplateSpec) string {
+	err := jobTemplateManager.DependenciesReady(jobTemplateSpec)
+	if err == nil {
+		return ""
+	}
+	if notReady, ok := awx.AsDependencyNotReadyError(err); ok {
+		return statusWaiting + notReady.Error()
+	}
+	return statusWaiting + fmt.Sprintf("failed to check dependencies: %v", err)
+}
+
+// waitingJobTemplates returns the declared job templates that wait for their
+// dependencies, with the reason they wait
+func waitingJobTemplates(instance *awxv1alpha1.AWXInstance) []string {
+	var waiting []string
+	for _, jobTemplateSpec := range instance.Spec.JobTemplates {
+		status := instance.Status.JobTemplateStatuses[jobTemplateSpec.Name]
+		if reason, ok := strings.CutPrefix(status, statusWaiting); ok {
+			waiting = append(waiting, fmt.Sprintf("%s (%s)", jobTemplateSpec.Name, reason))
+		}
+	}
+	return waiting
+}
+
+// waitForDependencies keeps the instance not Ready while job templates wait
+// for the syncs they declared, and checks again shortly
+func (r *AWXInstanceReconciler) waitForDependencies(ctx context.Context,
+	instance *awxv1alpha1.AWXInstance, waiting []string) (ctrl.Result, error) {
+
+	logger := log.FromContext(ctx)
+	logger.Info("Job templates wait for their dependencies", "instance", instance.Name, "waiting", waiting)
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "WaitingForDependencies",
+		Message:            "Job templates wait for their dependencies: " + strings.Join(waiting, ", "),
+	})
+
+	if err := r.updateStatus(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update AWXInstance status")
+		return ctrl.Result{}, err
+	}
+
+	// Project syncs and inventory updates usually finish within minutes
+	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;delete
+
+// componentWebhookKey labels the Secrets publishing webhook keys
+const componentWebhookKey = "webhook-key"
+
+// webhookSecretName returns the name of the Secret publishing the webhook of
+// a workflow job template. Workflow names that are no valid object name, e.g.
+// ones with spaces, are shortened and suffixed with their hash.
+func webhookSecretName(instance *awxv1alpha1.AWXInstance, workflowName string) string {
+	name := instance.Name + "-" + workflowName + "-webhook"
+	if len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(workflowName))
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(workflowName))
+	suffix := fmt.Sprintf("-%08x-webhook", hash.Sum32())
+	name = instance.Name + "-" + sanitized
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > maxLength {
+		name = name[:maxLength]
+	}
+	return name + suffix
+}
+
+// publishWebhookSecret writes the receiver URL and key of the webhook of a
+// workflow job template to a Secret owned by the instance, so the key can be
+// configured in GitHub or GitLab without reading it from AWX
+func (r *AWXInstanceReconciler) publishWebhookSecret(ctx context.Context, instance *awxv1alpha1.AWXInstance,
+	workflowManager *awx.WorkflowJobTemplateManager, workflowSpec awxv1alpha1.WorkflowJobTemplateSpec, workflow map[string]interface{}) error {
+
+	receiver, err := workflowManager.WebhookReceiver(workflow)
+	if err != nil {
+		return err
+	}
+	data := map[string][]byte{
+		"service": []byte(workflowSpec.Webhook.Service),
+		"url":     []byte(receiver.URL),
+		"key":     []byte(receiver.Key),
+	}
+
+	name := webhookSecretName(instance, workflowSpec.Name)
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: instance.Namespace, Name: name},
+			Data:       data,
+		}
+		if err := r.setOwnership(instance, secret, componentWebhookKey); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to create webhook Secret %s: %w", name, err)
+		}
+		log.FromContext(ctx).Info("Published webhook key", "workflowJobTemplate", workflowSpec.Name, "secret", name)
+	case err != nil:
+		return fmt.Errorf("failed to read webhook Secret %s: %w", name, err)
+	case !secretDataEqual(secret.Data, data):
+		secret.Data = data
+		if err := r.Update(ctx, secret); err != nil {
+			return fmt.Errorf("failed to update webhook Secret %s: %w", name, err)
+		}
+		log.FromContext(ctx).Info("Updated webhook key", "workflowJobTemplate", workflowSpec.Name, "secret", name)
+	}
+
+	if instance.Status.WebhookSecrets == nil {
+		instance.Status.WebhookSecrets = make(map[string]string)
+	}
+	instance.Status.WebhookSecrets[workflowSpec.Name] = name
+	return nil
+}
+
+// secretDataEqual reports whether two Secrets hold the same data
+func secretDataEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || string(other) != string(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneWebhookSecrets deletes the webhook Secrets of workflow job templates
+// that no longer declare a webhook
+func (r *AWXInstanceReconciler) pruneWebhookSecrets(ctx context.Context, instance *awxv1alpha1.AWXInstance) error {
+	wanted := make(map[string]bool)
+	for _, workflow := range instance.Spec.WorkflowJobTemplates {
+		if workflow.Webhook != nil {
+			wanted[webhookSecretName(instance, workflow.Name)] = true
+		}
+	}
+	for name := range instance.Status.WebhookSecrets {
+		if !wanted[webhookSecretName(instance, name)] {
+			delete(instance.Status.WebhookSecrets, name)
+		}
+	}
+	if len(instance.Status.WebhookSecrets) == 0 {
+		instance.Status.WebhookSecrets = nil
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(instance.Namespace), ownedObjectsSelector(instance),
+		client.MatchingLabels{labelComponent: componentWebhookKey}); err != nil {
+		return fmt.Errorf("failed to list webhook Secrets: %w", err)
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if wanted[secret.Name] {
+			continue
+		}
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete webhook Secret %s: %w", secret.Name, err)
+		}
+		log.FromContext(ctx).Info("Deleted webhook Secret", "secret", secret.Name)
+	}
+	return nil
+}
@@ -6,6 +6,11 @@ IMAGE_NAME=${IMAGE_NAME:-awx-operator}
 TAG=${TAG:-aed406c}
 NAMESPACE=${NAMESPACE:-awx-operator-system}
 
+# End-to-end test settings
+E2E_CLUSTER=${E2E_CLUSTER:-awx-operator-e2e}
+E2E_AWX_OPERATOR_VERSION=${E2E_AWX_OPERATOR_VERSION:-2.19.1}
+E2E_AWX_PORT=${E2E_AWX_PORT:-8052}
+
 # Colors for better output
 RED='\033[0;31m'
 GREEN='\033[0;32m'
@@ -55,7 +60,7 @@ check_prerequisites() {
 # Build the operator image
 build() {
   print_header "Building operator image"
-  docker build -t "${REGISTRY}/${IMAGE_NAME}:${TAG}" .
+  docker build --build-arg VERSION="${TAG}" -t "${REGISTRY}/${IMAGE_NAME}:${TAG}" .
   echo "Image built successfully: ${REGISTRY}/${IMAGE_NAME}:${TAG}"
 }
 
@@ -150,6 +155,115 @@ undeploy() {
   echo "Operator undeployed"
 }
 
+# Check the tools needed by the end-to-end tests
+check_e2e_prerequisites() {
+  print_header "Checking e2e prerequisites"
+
+  for tool in kind go; do
+    if ! command -v "${tool}" &> /dev/null; then
+      print_error "${tool} not found. Please install ${tool} first."
+      exit 1
+    fi
+  done
+
+  print_info "All e2e prerequisites satisfied"
+}
+
+# Create a kind cluster running AWX deployed by the upstream awx-operator
+e2e_cluster() {
+  print_header "Creating e2e cluster"
+
+  if ! kind get clusters | grep -qx "${E2E_CLUSTER}"; then
+    kind create cluster --name "${E2E_CLUSTER}"
+  fi
+  kubectl config use-context "kind-${E2E_CLUSTER}"
+
+  # Install the pinned upstream awx-operator the way its documentation does
+  local kustomize_dir
+  kustomize_dir=$(mktemp -d)
+  cat > "${kustomize_dir}/kustomization.yaml" << EOF
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - github.com/ansible/awx-operator/config/default?ref=${E2E_AWX_OPERATOR_VERSION}
+images:
+  - name: quay.io/ansible/awx-operator
+    newTag: ${E2E_AWX_OPERATOR_VERSION}
+namespace: awx
+EOF
+  kubectl apply -k "${kustomize_dir}"
+  rm -rf "${kustomize_dir}"
+  kubectl wait --for=condition=available deployment/awx-operator-controller-manager -n awx --timeout=300s
+
+  kubectl apply -f test/e2e/awx.yaml
+  echo "Waiting for AWX to be deployed, this takes several minutes..."
+  kubectl wait --for=condition=Successful awx/awx -n awx --timeout=1800s
+  kubectl wait --for=condition=available deployment/awx-web -n awx --timeout=600s
+
+  echo "AWX is ready in cluster: ${E2E_CLUSTER}"
+}
+
+# Deploy the operator built from the working tree into the e2e cluster
+e2e_deploy() {
+  print_header "Deploying operator to e2e cluster"
+
+  TAG=e2e
+  build
+  kind load docker-image "${REGISTRY}/${IMAGE_NAME}:${TAG}" --name "${E2E_CLUSTER}"
+
+  helm upgrade --install awx-operator ./argocd \
+    --namespace "${NAMESPACE}" \
+    --create-namespace \
+    --values argocd/values.yaml \
+    --set operator.image.registry="${REGISTRY}" \
+    --set operator.image.repository="${IMAGE_NAME}" \
+    --set operator.image.tag="${TAG}" \
+    --set operator.image.pullPolicy=Never \
+    --set leaderElection=false
+
+  kubectl wait --for=condition=ready pod -l app=awx-operator -n "${NAMESPACE}" --timeout=120s
+  echo "Operator is ready!"
+}
+
+# Run the end-to-end suites against AWX through a port-forward
+e2e_test() {
+  print_header "Running e2e tests"
+
+  kubectl port-forward -n awx service/awx-service "${E2E_AWX_PORT}:80" > /dev/null &
+  local port_forward=$!
+  trap "kill ${port_forward} 2> /dev/null" EXIT
+  sleep 5
+
+  AWX_E2E_URL="http://localhost:${E2E_AWX_PORT}" \
+  AWX_E2E_PASSWORD=$(kubectl get secret awx-admin-password -n awx -o jsonpath='{.data.password}' | base64 -d) \
+    go test -tags e2e -v -count=1 -timeout 60m ./test/e2e/...
+}
+
+# Record a contract fixture of the AWX version in the e2e cluster. The
+# scenario creates and deletes objects, so the AWX must be a disposable one.
+e2e_record() {
+  print_header "Recording contract fixture"
+
+  kubectl port-forward -n awx service/awx-service "${E2E_AWX_PORT}:80" > /dev/null &
+  local port_forward=$!
+  trap "kill ${port_forward} 2> /dev/null" EXIT
+  sleep 5
+
+  (cd pkg/awx && \
+    AWX_CONTRACT_URL="http://localhost:${E2E_AWX_PORT}" \
+    AWX_CONTRACT_USER=admin \
+    AWX_CONTRACT_PASSWORD=$(kubectl get secret awx-admin-password -n awx -o jsonpath='{.data.password}' | base64 -d) \
+      go test -count=1 -v -run TestContracts . -record-contract)
+  echo "Review the fixture in pkg/awx/testdata/contracts and delete the synthetic one of the same version"
+}
+
+# Delete the e2e cluster
+e2e_teardown() {
+  print_header "Deleting e2e cluster"
+  kind delete cluster --name "${E2E_CLUSTER}"
+  echo "Cluster deleted: ${E2E_CLUSTER}"
+}
+
 show_help() {
   echo "Usage: $0 [command]"
   echo ""
@@ -161,12 +275,18 @@ show_help() {
   echo "  deploy               Deploy the operator to the Kubernetes cluster"
   echo "  undeploy             Remove the operator from the Kubernetes cluster"
   echo "  all                  Run all commands in sequence (build, push, update-values, install-crd, deploy)"
+  echo "  e2e                  Run the end-to-end tests against a real AWX in a kind cluster"
+  echo "  e2e-record           Record a contract fixture against the AWX of the e2e cluster"
+  echo "  e2e-teardown         Delete the kind cluster of the end-to-end tests"
   echo ""
   echo "Environment variables:"
   echo "  REGISTRY             Container registry (default: quay.io/wolkenzentrale)"
   echo "  IMAGE_NAME           Image name (default: awx-operator)"
   echo "  TAG                  Image tag (default: aed406c)"
   echo "  NAMESPACE            Namespace for deployment (default: awx-operator-system)"
+  echo "  E2E_CLUSTER          Kind cluster of the end-to-end tests (default: awx-operator-e2e)"
+  echo "  E2E_AWX_OPERATOR_VERSION  Upstream awx-operator deploying AWX (default: 2.19.1)"
+  echo "  E2E_AWX_PORT         Local port forwarded to AWX (default: 8052)"
 }
 
 # Main execution
@@ -203,6 +323,22 @@ case "$1" in
     install_crd
     deploy
     ;;
+  e2e)
+    check_prerequisites
+    check_e2e_prerequisites
+    e2e_cluster
+    e2e_deploy
+    e2e_test
+    ;;
+  e2e-record)
+    check_e2e_prerequisites
+    e2e_cluster
+    e2e_record
+    ;;
+  e2e-teardown)
+    check_e2e_prerequisites
+    e2e_teardown
+    ;;
   *)
     show_help
     ;;
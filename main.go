@@ -19,6 +19,8 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -30,11 +32,13 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
 	"github.com/derzufall/awx-k8s-operator/controllers"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -48,17 +52,101 @@ func init() {
 
 	utilruntime.Must(awxv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
+
+	metrics.Registry.MustRegister(awx.Collectors()...)
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var awxTransport awx.TransportOptions
+	var proxyAWXMetrics bool
+	var fleetMetrics bool
+	var maxBodyLogSize int
+	var hostConcurrency int
+	var apiBudget controllers.APIBudget
+	var maxObjectsPerReconcile int
+	var reconcileTimeout time.Duration
+	var notificationAddr string
+	var notificationToken string
+	var tlsMinVersion string
+	var tlsCipherSuites string
+	var inventoryResync time.Duration
+	var protectedNames string
+	var disableFieldValidation bool
+	var clusterName string
+	var tenantCredentialsSecret string
+	var adminNamespaces string
+	var artifactStore string
+	var artifactStorePath string
+	var enableAdmissionWebhook bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&awxTransport.DisableHTTP2, "awx-disable-http2", false,
+		"Restrict connections to AWX to HTTP/1.1.")
+	flag.BoolVar(&awxTransport.DisableCompression, "awx-disable-compression", false,
+		"Do not request gzip compressed responses from AWX.")
+	flag.BoolVar(&awxTransport.CompressRequests, "awx-compress-requests", false,
+		"Send gzip compressed request bodies to AWX. Requires a proxy in front of AWX that decompresses them.")
+	flag.StringVar(&awxTransport.DialAddress, "awx-dial-address", os.Getenv("AWX_DIAL_ADDRESS"),
+		"Route all AWX connections to this address, e.g. localhost:8043 for a port-forward "+
+			"or unix:///tmp/awx.sock. Defaults to the AWX_DIAL_ADDRESS environment variable.")
+	flag.StringVar(&tlsMinVersion, "awx-tls-min-version", "",
+		"Minimum TLS version for connections to AWX, 1.2 or 1.3. Empty keeps the Go default.")
+	flag.StringVar(&tlsCipherSuites, "awx-tls-cipher-suites", "",
+		"Comma separated TLS 1.2 cipher suites allowed for connections to AWX, or FIPS for the suites "+
+			"approved for FIPS 140. Empty keeps the Go defaults.")
+	flag.DurationVar(&inventoryResync, "awx-inventory-resync", 0,
+		"How long objects looked up by name are served from the in-memory inventory of each AWX "+
+			"instance before they are read again, e.g. 5m. 0 reads them from AWX on every drift check.")
+	flag.StringVar(&protectedNames, "awx-protected-names", "",
+		"Comma separated names of AWX objects that are never updated or deleted, e.g. \"Demo Project,Default\".")
+	flag.BoolVar(&disableFieldValidation, "awx-disable-field-validation", false,
+		"Send payloads to AWX without validating them against the fields AWX advertises through OPTIONS.")
+	flag.BoolVar(&proxyAWXMetrics, "awx-metrics-proxy", false,
+		"Scrape the metrics of the managed AWX instances and re-expose them on the metrics endpoint.")
+	flag.BoolVar(&fleetMetrics, "awx-fleet-metrics", false,
+		"Expose metrics summarizing the health of all AWXInstances of the cluster on the metrics endpoint.")
+	flag.IntVar(&maxBodyLogSize, "awx-max-body-log-size", awx.DefaultMaxBodyLogSize,
+		"Number of bytes of AWX request and response bodies that are logged. 0 disables body logging.")
+	flag.IntVar(&hostConcurrency, "awx-host-concurrency", awx.DefaultHostConcurrency,
+		"Number of inventory host requests sent to AWX in parallel.")
+	flag.Int64Var(&apiBudget.PerReconcile, "awx-api-budget-per-reconcile", 1000,
+		"AWX API requests of a single reconcile above which an instance is warned. 0 disables the limit.")
+	flag.Int64Var(&apiBudget.PerHour, "awx-api-budget-per-hour", 20000,
+		"AWX API requests per instance and hour above which an instance is warned. 0 disables the limit.")
+	flag.IntVar(&maxObjectsPerReconcile, "max-objects-per-reconcile", controllers.DefaultMaxObjectsPerReconcile,
+		"Declared objects reconciled in one pass. Larger specs are reconciled in batches. 0 disables batching.")
+	flag.DurationVar(&reconcileTimeout, "reconcile-timeout", controllers.DefaultReconcileTimeout,
+		"Deadline of a reconcile of AWXInstances that don't set spec.reconcileTimeouts. Requests to AWX still "+
+			"running at the deadline are canceled and the remaining objects are reconciled by the next pass. "+
+			"0 disables the deadline.")
+	flag.StringVar(&notificationAddr, "notification-bind-address", "",
+		"The address AWX webhook notifications are received on, e.g. :9444. Empty disables the receiver.")
+	flag.StringVar(&notificationToken, "notification-token", os.Getenv("AWX_NOTIFICATION_TOKEN"),
+		"Bearer token AWX notifications must carry, required with --notification-bind-address. Defaults to the AWX_NOTIFICATION_TOKEN environment variable.")
+	flag.StringVar(&tenantCredentialsSecret, "tenant-credentials-secret", "",
+		"Name of the Secret with the username and password of an organization scoped AWX user. Resources of "+
+			"AWXInstances in a namespace holding this Secret are reconciled as that user instead of the admin. "+
+			"Namespaces without it are not reconciled unless listed in --admin-namespaces.")
+	flag.StringVar(&adminNamespaces, "admin-namespaces", "",
+		"Comma-separated namespaces reconciled as the AWX admin when they don't hold the tenant credentials Secret.")
+	flag.StringVar(&clusterName, "cluster-name", "",
+		"Name of the cluster, available as ${{ .ClusterName }} in the naming policy of AWXInstances.")
+	flag.StringVar(&artifactStore, "artifact-store", "",
+		"Where the complete output of project updates and jobs is kept, configmap, file or s3. Empty only "+
+			"keeps its last lines in the status.")
+	flag.StringVar(&artifactStorePath, "artifact-store-path", "",
+		"Directory of the file artifact store, e.g. the mount path of a PersistentVolume, or URL of the "+
+			"bucket of the s3 artifact store, e.g. https://minio.example.com/artifacts. The s3 store reads "+
+			"its credentials from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY and its region from AWS_REGION.")
+	flag.BoolVar(&enableAdmissionWebhook, "enable-admission-webhook", false,
+		"Serve the validating webhook checking the credential inputs of AWXInstances against the cached "+
+			"AWX credential type catalog. Requires a serving certificate in /tmp/k8s-webhook-server/serving-certs.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -66,6 +154,27 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	awx.SetLogger(ctrl.Log.WithName("awx-client"))
+
+	var err error
+	if awxTransport.TLSMinVersion, err = awx.ParseTLSVersion(tlsMinVersion); err != nil {
+		setupLog.Error(err, "invalid --awx-tls-min-version")
+		os.Exit(1)
+	}
+	if awxTransport.TLSCipherSuites, err = awx.ParseCipherSuites(tlsCipherSuites); err != nil {
+		setupLog.Error(err, "invalid --awx-tls-cipher-suites")
+		os.Exit(1)
+	}
+	if err := awx.ValidateTLSOptions(awxTransport); err != nil {
+		setupLog.Error(err, "invalid AWX TLS settings")
+		os.Exit(1)
+	}
+	awx.SetTransportOptions(awxTransport)
+	awx.SetMaxBodyLogSize(maxBodyLogSize)
+	awx.SetHostConcurrency(hostConcurrency)
+	awx.SetInventoryResync(inventoryResync)
+	awx.SetProtectedNames(strings.Split(protectedNames, ","))
+	awx.SetFieldValidation(!disableFieldValidation)
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -80,13 +189,61 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.AWXInstanceReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	instanceReconciler := &controllers.AWXInstanceReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorderFor("awxinstance-controller"),
+		APIReader:               mgr.GetAPIReader(),
+		ProxyAWXMetrics:         proxyAWXMetrics,
+		FleetMetrics:            fleetMetrics,
+		APIBudget:               apiBudget,
+		MaxObjectsPerReconcile:  maxObjectsPerReconcile,
+		ReconcileTimeout:        reconcileTimeout,
+		NotificationAddress:     notificationAddr,
+		NotificationToken:       notificationToken,
+		ClusterName:             clusterName,
+		TenantCredentialsSecret: tenantCredentialsSecret,
+		AdminNamespaces:         strings.Split(adminNamespaces, ","),
+	}
+	if err = instanceReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AWXInstance")
 		os.Exit(1)
 	}
+	if enableAdmissionWebhook {
+		if err = (&controllers.CredentialInputsValidator{
+			Client:    mgr.GetClient(),
+			Instances: instanceReconciler,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AWXInstance")
+			os.Exit(1)
+		}
+	}
+	artifacts, err := controllers.NewArtifactStore(artifactStore, artifactStorePath,
+		mgr.GetClient(), mgr.GetAPIReader(), mgr.GetScheme())
+	if err != nil {
+		setupLog.Error(err, "invalid artifact store")
+		os.Exit(1)
+	}
+	if err = (&controllers.AWXProjectSyncReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("awxprojectsync-controller"),
+		Instances: instanceReconciler,
+		Artifacts: artifacts,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWXProjectSync")
+		os.Exit(1)
+	}
+	if err = (&controllers.AWXJobReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("awxjob-controller"),
+		Instances: instanceReconciler,
+		Artifacts: artifacts,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWXJob")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,241 @@
+package awx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// AuthMethodBasic sends the username and password with every request
+	AuthMethodBasic = "Basic"
+	// AuthMethodToken exchanges the username and password for an OAuth2 token once
+	// and sends the token with every request, refreshing it before it expires
+	AuthMethodToken = "Token"
+
+	// tokenRefreshMargin is how long before expiry a token is refreshed
+	tokenRefreshMargin = time.Minute
+	// defaultTokenLifetime is assumed when AWX doesn't report a token expiry
+	defaultTokenLifetime = time.Hour
+	// tokenDescription identifies the tokens created by the operator in AWX
+	tokenDescription = "awx-k8s-operator session"
+)
+
+// SetAuthMethod selects how the client authenticates (AuthMethodBasic or AuthMethodToken)
+func (c *Client) SetAuthMethod(method string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.authMethod = method
+	c.token = ""
+}
+
+// SetTokenCreation sets whether the client may create session tokens in AWX,
+// which is the default. Without, a client using AuthMethodToken keeps using
+// the session token it already holds until it expires but never requests a
+// new one, falling back to basic authentication, e.g. for an instance that
+// is only observed and must not be written to.
+func (c *Client) SetTokenCreation(enabled bool) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.noTokenCreation = !enabled
+}
+
+// setAuthHeader sets the Authorization header for the configured auth method
+func (c *Client) setAuthHeader(req *http.Request) error {
+	token, err := c.getToken()
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		req.SetBasicAuth(c.username, c.password)
+		return nil
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// usesToken reports whether the client authenticates with a session token
+func (c *Client) usesToken() bool {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.authMethod == AuthMethodToken
+}
+
+// getToken returns a valid session token, logging in again if it is missing
+// or about to expire. With basic authentication or without token creation, it
+// returns an empty token instead of logging in. The auth method is read under
+// the same lock, so SetAuthMethod never races an authenticating request.
+func (c *Client) getToken() (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.authMethod != AuthMethodToken {
+		return "", nil
+	}
+	if c.token != "" && time.Now().Add(tokenRefreshMargin).Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+	if c.noTokenCreation {
+		return "", nil
+	}
+	return c.login()
+}
+
+// invalidateToken drops the cached token so the next request logs in again
+func (c *Client) invalidateToken() {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.token = ""
+}
+
+// login exchanges the basic credentials for a new token. Callers must hold tokenMu.
+func (c *Client) login() (string, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	u.Path = c.apiURLPath(u.Path, "tokens")
+
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"description": tokenDescription,
+		"scope":       "write",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(c.Context(), http.MethodPost, u.String(), bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	c.log.Info("Requesting AWX session token", "baseURL", c.baseURL, "username", c.username)
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to obtain token: %w",
+			&APIError{StatusCode: resp.StatusCode, Body: string(respBody)})
+	}
+
+	var result struct {
+		ID      int    `json:"id"`
+		Token   string `json:"token"`
+		Expires string `json:"expires"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if result.Token == "" {
+		return "", fmt.Errorf("token response did not contain a token")
+	}
+
+	expiry := time.Now().Add(defaultTokenLifetime)
+	if result.Expires != "" {
+		if parsed, err := time.Parse(time.RFC3339, result.Expires); err == nil {
+			expiry = parsed
+		}
+	}
+
+	previousID := c.tokenID
+	c.token = result.Token
+	c.tokenID = result.ID
+	c.tokenExpiry = expiry
+	c.log.Info("Obtained AWX session token", "baseURL", c.baseURL, "tokenID", result.ID, "expires", expiry)
+
+	// Revoke the token that was replaced instead of leaving it until it expires
+	if previousID != 0 && previousID != result.ID {
+		if err := c.RevokeToken(previousID); err != nil {
+			c.log.Info("Could not revoke replaced AWX session token", "tokenID", previousID, "error", err.Error())
+		}
+	}
+	return c.token, nil
+}
+
+// SessionTokenID returns the ID of the current session token, or 0 when the
+// client holds none
+func (c *Client) SessionTokenID() int {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token == "" {
+		return 0
+	}
+	return c.tokenID
+}
+
+// RevokeToken deletes a token in AWX. The request authenticates with the
+// username and password of the client, so a session token can be revoked
+// without being used. A token that doesn't exist anymore is already revoked.
+func (c *Client) RevokeToken(id int) error {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+	u.Path = c.apiURLPath(u.Path, fmt.Sprintf("tokens/%d", id))
+
+	req, err := http.NewRequestWithContext(c.Context(), http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create token revocation request: %w", err)
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("token revocation failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read token revocation response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to revoke token %d: %w", id,
+			&APIError{StatusCode: resp.StatusCode, Body: string(respBody)})
+	}
+	c.log.Info("Revoked AWX token", "baseURL", c.baseURL, "tokenID", id)
+	return nil
+}
+
+// RevokeSessionToken revokes the current session token of the client. The
+// next request logs in again.
+func (c *Client) RevokeSessionToken() error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token == "" {
+		return nil
+	}
+	if err := c.RevokeToken(c.tokenID); err != nil {
+		return err
+	}
+	c.token = ""
+	c.tokenID = 0
+	return nil
+}
+
+// VerifyCredentials checks that AWX accepts the credentials of the client.
+// Unlike the ping endpoint, the current user endpoint requires authentication.
+func (c *Client) VerifyCredentials() error {
+	if _, err := c.doRequest(http.MethodGet, "me", nil); err != nil {
+		return fmt.Errorf("failed to authenticate as %s: %w", c.username, err)
+	}
+	return nil
+}
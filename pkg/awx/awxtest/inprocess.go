@@ -0,0 +1,52 @@
+package awxtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+)
+
+// ErrUnreachable is returned for the requests served through RoundTrip while
+// the fake AWX is unreachable
+var ErrUnreachable = errors.New("fake AWX is unreachable")
+
+// lastHost numbers the hosts of in-process servers. Each server gets a host
+// of its own, so the circuit breakers opened by failures of one don't affect
+// the others.
+var lastHost atomic.Int64
+
+// NewInProcess returns a fake AWX API accepting admin/password that is served
+// in process, without an HTTP listener, e.g. for integration tests of other
+// tools using the client. Clients use the server as their transport:
+//
+//	server := awxtest.NewInProcess()
+//	client := awx.NewClient(server.URL, server.Username, server.Password)
+//	client.SetTransport(server)
+func NewInProcess() *Server {
+	s := NewHandler()
+	s.URL = fmt.Sprintf("http://awx-%d.fake", lastHost.Add(1))
+	return s
+}
+
+// SetUnreachable makes the requests served through RoundTrip fail as if AWX
+// could not be reached, until it is called with false
+func (s *Server) SetUnreachable(unreachable bool) {
+	s.unreachable.Store(unreachable)
+}
+
+// RoundTrip serves a request of a client in process
+func (s *Server) RoundTrip(req *http.Request) (*http.Response, error) {
+	if s.unreachable.Load() {
+		return nil, ErrUnreachable
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
@@ -0,0 +1,111 @@
+package awxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+)
+
+// Fixture is a recorded conversation with an AWX version, in the format
+// written by the recording mode of the AWX client
+type Fixture struct {
+	// Version is the AWX version the fixture was recorded against
+	Version string `json:"version"`
+	// Recorded is the date of the recording, empty for fixtures written by hand
+	Recorded string `json:"recorded,omitempty"`
+	// APIPath is the API path prefix the AWX version serves, empty for api/v2
+	APIPath      string        `json:"apiPath,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and the response AWX gave to it
+type Interaction struct {
+	Method string          `json:"method"`
+	URI    string          `json:"uri"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// LoadFixture reads a fixture file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// ReplayServer answers requests with the responses of a fixture. Requests must
+// arrive in the recorded order; any other request is answered with 500 and
+// reported by Mismatches.
+type ReplayServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	fixture    *Fixture
+	next       int
+	mismatches []string
+}
+
+// NewReplayServer starts a server replaying the fixture. Callers must Close it when done.
+func NewReplayServer(fixture *Fixture) *ReplayServer {
+	s := &ReplayServer{fixture: fixture}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Mismatches returns the requests that deviated from the fixture
+func (s *ReplayServer) Mismatches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.mismatches...)
+}
+
+// Remaining returns the recorded interactions that were not replayed
+func (s *ReplayServer) Remaining() []Interaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Interaction(nil), s.fixture.Interactions[s.next:]...)
+}
+
+// handle replays the next recorded interaction if the request matches it
+func (s *ReplayServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next >= len(s.fixture.Interactions) {
+		s.mismatch(w, fmt.Sprintf("unexpected request %s %s after the end of the fixture", r.Method, r.URL.RequestURI()))
+		return
+	}
+	expected := s.fixture.Interactions[s.next]
+	if expected.Method != r.Method || expected.URI != r.URL.RequestURI() {
+		s.mismatch(w, fmt.Sprintf("request %d: expected %s %s, got %s %s",
+			s.next, expected.Method, expected.URI, r.Method, r.URL.RequestURI()))
+		return
+	}
+	s.next++
+
+	body := []byte(expected.Body)
+	var text string
+	if json.Unmarshal(expected.Body, &text) == nil {
+		// Bodies that weren't JSON were recorded as a string
+		body = []byte(text)
+	} else if len(body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(expected.Status)
+	_, _ = w.Write(body)
+}
+
+// mismatch records a deviation from the fixture. Callers must hold mu.
+func (s *ReplayServer) mismatch(w http.ResponseWriter, message string) {
+	s.mismatches = append(s.mismatches, message)
+	writeJSON(w, http.StatusInternalServerError, detail(message))
+}
@@ -0,0 +1,889 @@
+// Package awxtest provides a fake AWX API server for integration tests of the
+// AWX client, its managers and the controllers built on top of them.
+package awxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// APIPath is the API prefix served by the fake server
+	APIPath = "/api/v2/"
+	// Version is the AWX version reported by the ping endpoint
+	Version = "23.9.0"
+	// DefaultPageSize is the page size of list endpoints without page_size
+	DefaultPageSize = 25
+)
+
+// Fault makes the server answer matching requests with an error
+type Fault struct {
+	// Method restricts the fault to one HTTP method, empty matches all
+	Method string
+	// Path is matched as a prefix of the request path below APIPath, e.g. "projects/"
+	Path string
+	// Status is the HTTP status returned
+	Status int
+	// Body is returned as the response body, defaulting to a detail message
+	Body string
+	// RetryAfter is sent as the Retry-After header when set
+	RetryAfter string
+	// Times is how many requests fail, 0 fails all matching requests
+	Times int
+}
+
+// Request is a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+}
+
+// Server is a fake AWX API backed by in-memory objects. Objects are plain
+// JSON maps stored per endpoint, so any endpoint name can be used. The
+// embedded httptest.Server is only set for servers started with NewServer.
+type Server struct {
+	*httptest.Server
+
+	// URL is the base URL clients of the fake API are configured with. It is
+	// a fake host for servers created with NewInProcess.
+	URL string
+
+	// Username and Password are accepted for basic authentication and for
+	// requesting session tokens
+	Username string
+	Password string
+
+	// unreachable fails the requests served through RoundTrip
+	unreachable atomic.Bool
+
+	mu          sync.Mutex
+	nextID      int
+	objects     map[string]map[int]map[string]interface{}
+	related     map[string][]int
+	surveys     map[string]map[string]interface{}
+	facts       map[string]map[string]interface{}
+	webhookKeys map[string]string
+	options     map[string]map[string]interface{}
+	tokens      map[string]int
+	faults      []*Fault
+	latency     time.Duration
+	requests    []Request
+}
+
+// NewServer starts a fake AWX server accepting admin/password. Callers must
+// Close it when done.
+func NewServer() *Server {
+	s := NewHandler()
+	s.Server = httptest.NewServer(s)
+	s.URL = s.Server.URL
+	return s
+}
+
+// NewHandler returns a fake AWX API accepting admin/password that doesn't
+// listen on a port. Requests are served by calling ServeHTTP.
+func NewHandler() *Server {
+	return &Server{
+		Username:    "admin",
+		Password:    "password",
+		nextID:      1,
+		objects:     make(map[string]map[int]map[string]interface{}),
+		related:     make(map[string][]int),
+		surveys:     make(map[string]map[string]interface{}),
+		facts:       make(map[string]map[string]interface{}),
+		webhookKeys: make(map[string]string),
+		options:     make(map[string]map[string]interface{}),
+		tokens:      make(map[string]int),
+	}
+}
+
+// ServeHTTP serves a request to the fake API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handle(w, r)
+}
+
+// Close stops a server started with NewServer and does nothing otherwise
+func (s *Server) Close() {
+	if s.Server != nil {
+		s.Server.Close()
+	}
+}
+
+// Add stores an object on an endpoint, assigning it an ID, and returns it
+func (s *Server) Add(endpoint string, object map[string]interface{}) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(endpoint, object)
+}
+
+// Object returns the object with the given name on an endpoint, or nil
+func (s *Server) Object(endpoint, name string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, object := range s.sorted(endpoint) {
+		if object["name"] == name {
+			return copyObject(object)
+		}
+	}
+	return nil
+}
+
+// Set changes fields of the object with the given ID on an endpoint, e.g.
+// the status of a job
+func (s *Server) Set(endpoint string, id int, fields map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if object, ok := s.objects[endpoint][id]; ok {
+		for field, value := range fields {
+			object[field] = value
+		}
+	}
+}
+
+// Tokens returns the IDs of the session tokens that were issued and not
+// revoked, in ascending order
+func (s *Server) Tokens() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]int, 0, len(s.tokens))
+	for _, id := range s.tokens {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Objects returns all objects of an endpoint ordered by ID
+func (s *Server) Objects(endpoint string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var objects []map[string]interface{}
+	for _, object := range s.sorted(endpoint) {
+		objects = append(objects, copyObject(object))
+	}
+	return objects
+}
+
+// Survey returns the survey spec stored for an object, e.g. a job template,
+// including password defaults that the API only returns encrypted
+func (s *Server) Survey(endpoint string, id int) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneJSON(s.surveys[relatedKey(endpoint, id, "survey_spec")])
+}
+
+// SetFacts stores the Ansible facts of a host as if a job gathered them,
+// served on "hosts/<id>/ansible_facts"
+func (s *Server) SetFacts(hostID int, facts map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.facts[relatedKey("hosts", hostID, "ansible_facts")] = cloneJSON(facts)
+	if host, ok := s.objects["hosts"][hostID]; ok {
+		host["ansible_facts_modified"] = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// Associate relates an object with another one, e.g. an instance group with
+// a job template on "job_templates/<id>/instance_groups"
+func (s *Server) Associate(endpoint string, id int, related string, relatedID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := relatedKey(endpoint, id, related)
+	s.related[key] = append(s.related[key], relatedID)
+}
+
+// SetOptions sets the metadata OPTIONS requests of an endpoint are answered
+// with, e.g. {"actions": {"POST": {...}}}. The metadata of the objects of an
+// endpoint is set as "projects/*".
+func (s *Server) SetOptions(endpoint string, metadata map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options[endpoint] = cloneJSON(metadata)
+}
+
+// Inject adds a fault. Faults are matched in the order they were added.
+func (s *Server) Inject(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// handle serves a request to the fake API
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone()})
+	latency := s.latency
+	s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if !strings.HasPrefix(r.URL.Path, APIPath) {
+		writeJSON(w, http.StatusNotFound, detail("The requested resource could not be found."))
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, APIPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fault := s.matchFault(r.Method, path); fault != nil {
+		if fault.RetryAfter != "" {
+			w.Header().Set("Retry-After", fault.RetryAfter)
+		}
+		if fault.Body != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(fault.Status)
+			_, _ = w.Write([]byte(fault.Body))
+			return
+		}
+		writeJSON(w, fault.Status, detail(http.StatusText(fault.Status)))
+		return
+	}
+
+	// Segments are split on the escaped path, so names in named URLs may
+	// contain slashes
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), APIPath), "/"), "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segments[i] = unescaped
+		}
+	}
+	switch {
+	case segments[0] == "ping":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"version": Version, "active_node": "awx"})
+		return
+	case !s.authenticate(r):
+		writeJSON(w, http.StatusUnauthorized, detail("Authentication credentials were not provided."))
+		return
+	case segments[0] == "tokens" && r.Method == http.MethodPost:
+		s.issueToken(w)
+		return
+	case segments[0] == "tokens" && r.Method == http.MethodDelete && len(segments) == 2:
+		s.revokeToken(w, segments[1])
+		return
+	case segments[0] == "config":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"version": Version, "license_info": map[string]interface{}{}})
+		return
+	case len(segments) == 2 && segments[0] == "bulk" && segments[1] == "host_create" && r.Method == http.MethodPost:
+		s.bulkCreateHosts(w, r)
+		return
+	}
+
+	switch len(segments) {
+	case 1:
+		s.handleList(w, r, segments[0])
+	case 2:
+		s.handleObject(w, r, segments[0], segments[1])
+	case 3:
+		s.handleRelated(w, r, segments[0], segments[1], segments[2])
+	default:
+		writeJSON(w, http.StatusNotFound, detail("The requested resource could not be found."))
+	}
+}
+
+// launchPrompts maps the launch parameters a job may override to the
+// ask_*_on_launch field of the job template that allows it. Survey answers
+// are accepted as extra_vars when the survey is enabled.
+var launchPrompts = map[string]string{
+	"verbosity":  "ask_verbosity_on_launch",
+	"diff_mode":  "ask_diff_mode_on_launch",
+	"job_tags":   "ask_tags_on_launch",
+	"skip_tags":  "ask_skip_tags_on_launch",
+	"extra_vars": "survey_enabled",
+}
+
+// matchFault returns the first active fault matching the request. Callers must hold mu.
+func (s *Server) matchFault(method, path string) *Fault {
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != method {
+			continue
+		}
+		if !strings.HasPrefix(path, fault.Path) {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// authenticate accepts the configured basic credentials or an issued token
+func (s *Server) authenticate(r *http.Request) bool {
+	if username, password, ok := r.BasicAuth(); ok {
+		return username == s.Username && password == s.Password
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		_, ok := s.tokens[token]
+		return ok
+	}
+	return false
+}
+
+// revokeToken deletes a session token by ID
+func (s *Server) revokeToken(w http.ResponseWriter, id string) {
+	for token, tokenID := range s.tokens {
+		if strconv.Itoa(tokenID) == id {
+			delete(s.tokens, token)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, detail("Not found."))
+}
+
+// issueToken creates a session token valid for an hour
+func (s *Server) issueToken(w http.ResponseWriter) {
+	id := s.nextID
+	s.nextID++
+	token := fmt.Sprintf("token-%d", id)
+	s.tokens[token] = id
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":      id,
+		"token":   token,
+		"expires": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+}
+
+// handleList lists the objects of an endpoint or creates a new one
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request, endpoint string) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		var matches []map[string]interface{}
+		for _, object := range s.sorted(endpoint) {
+			if s.matchesFilters(object, query) {
+				matches = append(matches, object)
+			}
+		}
+		writePage(w, r, matches)
+	case http.MethodPost:
+		var data map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeJSON(w, http.StatusBadRequest, detail("JSON parse error"))
+			return
+		}
+		if name, ok := data["name"].(string); ok {
+			for _, object := range s.objects[endpoint] {
+				if object["name"] == name {
+					writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+						"__all__": []string{fmt.Sprintf("%s with this Name already exists.", objectType(endpoint))},
+					})
+					return
+				}
+			}
+		}
+		writeJSON(w, http.StatusCreated, s.add(endpoint, data))
+	case http.MethodOptions:
+		writeJSON(w, http.StatusOK, s.endpointOptions(endpoint))
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, detail(fmt.Sprintf("Method \"%s\" not allowed.", r.Method)))
+	}
+}
+
+// endpointOptions returns the metadata set for an endpoint, listing no
+// actions by default. Callers must hold mu.
+func (s *Server) endpointOptions(endpoint string) map[string]interface{} {
+	if metadata, ok := s.options[endpoint]; ok {
+		return metadata
+	}
+	return map[string]interface{}{"name": endpoint, "actions": map[string]interface{}{}}
+}
+
+// objectByNamedURL returns the ID of the object a named URL identifier such
+// as "web++Default" refers to. Only the name is matched, organizations and
+// other identifiers are ignored. Callers must hold mu.
+func (s *Server) objectByNamedURL(endpoint, identifier string) (int, bool) {
+	name, _, _ := strings.Cut(identifier, "++")
+	name = strings.ReplaceAll(name, "[+]", "+")
+	for _, object := range s.sorted(endpoint) {
+		if object["name"] == name {
+			return object["id"].(int), true
+		}
+	}
+	return 0, false
+}
+
+// handleObject reads, updates or deletes a single object, addressed by its
+// ID or its named URL
+func (s *Server) handleObject(w http.ResponseWriter, r *http.Request, endpoint, idSegment string) {
+	id, err := strconv.Atoi(idSegment)
+	if err != nil {
+		var ok bool
+		if id, ok = s.objectByNamedURL(endpoint, idSegment); !ok {
+			writeJSON(w, http.StatusNotFound, detail("Not found."))
+			return
+		}
+	}
+	object, ok := s.objects[endpoint][id]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, detail("Not found."))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, object)
+	case http.MethodPatch, http.MethodPut:
+		var data map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeJSON(w, http.StatusBadRequest, detail("JSON parse error"))
+			return
+		}
+		for key, value := range data {
+			if key != "id" && key != "type" {
+				object[key] = value
+			}
+		}
+		writeJSON(w, http.StatusOK, object)
+	case http.MethodDelete:
+		delete(s.objects[endpoint], id)
+		// Deleted objects are no longer listed by related endpoints
+		for key, ids := range s.related {
+			if strings.HasSuffix(key, "/"+endpoint) {
+				s.related[key] = slices.DeleteFunc(ids, func(relatedID int) bool { return relatedID == id })
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodOptions:
+		writeJSON(w, http.StatusOK, s.endpointOptions(endpoint+"/*"))
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, detail(fmt.Sprintf("Method \"%s\" not allowed.", r.Method)))
+	}
+}
+
+// handleRelated serves the related endpoints of an object, its copy endpoint,
+// the update endpoint of projects, the output of project updates and the
+// launch endpoint of job templates
+func (s *Server) handleRelated(w http.ResponseWriter, r *http.Request, endpoint, idSegment, related string) {
+	id, err := strconv.Atoi(idSegment)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, detail("The requested resource could not be found."))
+		return
+	}
+	object, ok := s.objects[endpoint][id]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, detail("Not found."))
+		return
+	}
+
+	var data map[string]interface{}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeJSON(w, http.StatusBadRequest, detail("JSON parse error"))
+			return
+		}
+	}
+
+	if related == "copy" {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"can_copy": true})
+			return
+		}
+		duplicate := copyObject(object)
+		duplicate["name"] = data["name"]
+		copied := s.add(endpoint, duplicate)
+		if endpoint == "inventories" {
+			// Like AWX, copies of inventories come with their hosts
+			copiedID := copied["id"].(int)
+			key := relatedKey(endpoint, copiedID, "hosts")
+			for _, hostID := range s.related[relatedKey(endpoint, id, "hosts")] {
+				host := copyObject(s.objects["hosts"][hostID])
+				host["inventory"] = copiedID
+				s.related[key] = append(s.related[key], s.add("hosts", host)["id"].(int))
+			}
+		}
+		writeJSON(w, http.StatusCreated, copied)
+		return
+	}
+
+	if endpoint == "projects" && related == "update" && r.Method == http.MethodPost {
+		// Project updates finish at once, Set changes their status
+		now := time.Now().UTC().Format(time.RFC3339)
+		update := s.add("project_updates", map[string]interface{}{
+			"project":  id,
+			"status":   "successful",
+			"started":  now,
+			"finished": now,
+			"stdout":   fmt.Sprintf("Updating project %v\nPLAY RECAP\nlocalhost : ok=3 changed=1 failed=0\n", object["name"]),
+		})
+		started := copyObject(update)
+		started["project_update"] = update["id"]
+		writeJSON(w, http.StatusAccepted, started)
+		return
+	}
+	if endpoint == "job_templates" && related == "launch" && r.Method == http.MethodPost {
+		// Like AWX, a launch is refused until every password listed in
+		// passwords_needed_to_start is supplied. Jobs finish at once, Set
+		// changes their status.
+		passwords, _ := data["credential_passwords"].(map[string]interface{})
+		var missing []interface{}
+		needed, _ := object["passwords_needed_to_start"].([]interface{})
+		for _, password := range needed {
+			if value, _ := passwords[fmt.Sprint(password)].(string); value == "" {
+				missing = append(missing, password)
+			}
+		}
+		if len(missing) > 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"passwords_needed_to_start": missing})
+			return
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		job := map[string]interface{}{
+			"job_template": id,
+			"created":      now,
+			"status":       "successful",
+			"started":      now,
+			"finished":     now,
+			"stdout":       fmt.Sprintf("Running job template %v\nPLAY RECAP\nlocalhost : ok=5 changed=2 failed=0\n", object["name"]),
+		}
+		// Like AWX, overrides the job template doesn't prompt for are ignored
+		for field, prompt := range launchPrompts {
+			job[field] = object[field]
+			if value, ok := data[field]; ok && object[prompt] == true {
+				job[field] = value
+			}
+		}
+		job = s.add("jobs", job)
+		launched := copyObject(job)
+		launched["job"] = job["id"]
+		writeJSON(w, http.StatusCreated, launched)
+		return
+	}
+	if (endpoint == "project_updates" || endpoint == "jobs") && related == "stdout" && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"content": object["stdout"]})
+		return
+	}
+
+	key := relatedKey(endpoint, id, related)
+	if related == "survey_spec" {
+		s.handleSurvey(w, r, key, data)
+		return
+	}
+	if related == "webhook_key" {
+		// AWX generates a key once a webhook service is set and a new one
+		// on every POST
+		if service, _ := object["webhook_service"].(string); service == "" {
+			delete(s.webhookKeys, key)
+		} else if s.webhookKeys[key] == "" || r.Method == http.MethodPost {
+			s.webhookKeys[key] = fmt.Sprintf("webhook-key-%d-%d", id, len(s.requests))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"webhook_key": s.webhookKeys[key]})
+		return
+	}
+	if related == "ansible_facts" && r.Method == http.MethodGet {
+		facts := cloneJSON(s.facts[key])
+		if facts == nil {
+			facts = map[string]interface{}{}
+		}
+		writeJSON(w, http.StatusOK, facts)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var objects []map[string]interface{}
+		for _, relatedID := range s.related[key] {
+			if relatedObject, ok := s.objects[relatedEndpoint(related)][relatedID]; ok {
+				objects = append(objects, relatedObject)
+			} else {
+				objects = append(objects, map[string]interface{}{"id": relatedID})
+			}
+		}
+		writePage(w, r, objects)
+	case http.MethodPost:
+		relatedID, ok := data["id"].(float64)
+		if !ok {
+			// Creating objects through related endpoints, e.g. hosts of an
+			// inventory, also lists them there. Schedules launch the object
+			// they were created below.
+			if related == "schedules" {
+				data["unified_job_template"] = id
+			}
+			created := s.add(related, data)
+			s.related[key] = append(s.related[key], created["id"].(int))
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+		ids := s.related[key][:0]
+		for _, existing := range s.related[key] {
+			if existing != int(relatedID) {
+				ids = append(ids, existing)
+			}
+		}
+		if disassociate, _ := data["disassociate"].(bool); !disassociate {
+			ids = append(ids, int(relatedID))
+		}
+		s.related[key] = ids
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, detail(fmt.Sprintf("Method \"%s\" not allowed.", r.Method)))
+	}
+}
+
+// handleSurvey serves the survey_spec endpoint of a job template. Password
+// defaults are returned as $encrypted$ like AWX does.
+func (s *Server) handleSurvey(w http.ResponseWriter, r *http.Request, key string, data map[string]interface{}) {
+	switch r.Method {
+	case http.MethodGet:
+		survey := cloneJSON(s.surveys[key])
+		if survey == nil {
+			survey = map[string]interface{}{}
+		}
+		questions, _ := survey["spec"].([]interface{})
+		for _, question := range questions {
+			question, _ := question.(map[string]interface{})
+			if question["type"] == "password" && question["default"] != "" && question["default"] != nil {
+				question["default"] = "$encrypted$"
+			}
+		}
+		writeJSON(w, http.StatusOK, survey)
+	case http.MethodPost:
+		s.surveys[key] = data
+		writeJSON(w, http.StatusOK, map[string]interface{}{})
+	case http.MethodDelete:
+		delete(s.surveys, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, detail(fmt.Sprintf("Method \"%s\" not allowed.", r.Method)))
+	}
+}
+
+// cloneJSON deep copies a decoded JSON object
+func cloneJSON(object map[string]interface{}) map[string]interface{} {
+	if object == nil {
+		return nil
+	}
+	data, _ := json.Marshal(object)
+	var clone map[string]interface{}
+	_ = json.Unmarshal(data, &clone)
+	return clone
+}
+
+// bulkCreateHosts creates the hosts of a bulk request in their inventory
+func (s *Server) bulkCreateHosts(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		Inventory int                      `json:"inventory"`
+		Hosts     []map[string]interface{} `json:"hosts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, detail("JSON parse error"))
+		return
+	}
+	if _, ok := s.objects["inventories"][data.Inventory]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"inventory": []string{"Invalid pk - object does not exist."}})
+		return
+	}
+
+	key := relatedKey("inventories", data.Inventory, "hosts")
+	created := make([]map[string]interface{}, 0, len(data.Hosts))
+	for _, host := range data.Hosts {
+		host["inventory"] = data.Inventory
+		stored := s.add("hosts", host)
+		s.related[key] = append(s.related[key], stored["id"].(int))
+		created = append(created, stored)
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"hosts": created})
+}
+
+// add stores a copy of the object with a new ID. Callers must hold mu.
+func (s *Server) add(endpoint string, object map[string]interface{}) map[string]interface{} {
+	stored := copyObject(object)
+	stored["id"] = s.nextID
+	stored["type"] = objectType(endpoint)
+	stored["url"] = fmt.Sprintf("%s%s/%d/", APIPath, endpoint, s.nextID)
+	s.nextID++
+
+	if s.objects[endpoint] == nil {
+		s.objects[endpoint] = make(map[int]map[string]interface{})
+	}
+	s.objects[endpoint][stored["id"].(int)] = stored
+	return copyObject(stored)
+}
+
+// sorted returns the objects of an endpoint ordered by ID. Callers must hold mu.
+func (s *Server) sorted(endpoint string) []map[string]interface{} {
+	ids := make([]int, 0, len(s.objects[endpoint]))
+	for id := range s.objects[endpoint] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	objects := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		objects = append(objects, s.objects[endpoint][id])
+	}
+	return objects
+}
+
+// matchesFilters applies exact match query filters like name=foo, ignoring
+// the pagination and ordering parameters
+func (s *Server) matchesFilters(object map[string]interface{}, query map[string][]string) bool {
+	for key, values := range query {
+		switch key {
+		case "page", "page_size", "order_by", "format":
+			continue
+		}
+		// Timestamps are compared as RFC 3339 strings
+		if field, ok := strings.CutSuffix(key, "__gte"); ok {
+			if value, _ := object[field].(string); value < values[0] {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(s.lookup(object, key)) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns a field of an object. A field of a related object is looked
+// up like in AWX filters, e.g. inventory__organization for a host.
+func (s *Server) lookup(object map[string]interface{}, key string) interface{} {
+	field, related, ok := strings.Cut(key, "__")
+	if !ok {
+		return object[key]
+	}
+	// IDs sent by the client are decoded as numbers, those of Add are ints
+	var id int
+	switch value := object[field].(type) {
+	case int:
+		id = value
+	case float64:
+		id = int(value)
+	default:
+		return nil
+	}
+	relatedObject, ok := s.objects[endpointOf(field)][id]
+	if !ok {
+		return nil
+	}
+	return s.lookup(relatedObject, related)
+}
+
+// writePage writes one page of objects in the AWX list format
+func writePage(w http.ResponseWriter, r *http.Request, objects []map[string]interface{}) {
+	query := r.URL.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(query.Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+
+	start := min((page-1)*pageSize, len(objects))
+	end := min(start+pageSize, len(objects))
+
+	pageLink := func(n int) interface{} {
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(n))
+		return r.URL.Path + "?" + q.Encode()
+	}
+	var next, previous interface{}
+	if end < len(objects) {
+		next = pageLink(page + 1)
+	}
+	if page > 1 {
+		previous = pageLink(page - 1)
+	}
+
+	results := objects[start:end]
+	if results == nil {
+		results = []map[string]interface{}{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count":    len(objects),
+		"next":     next,
+		"previous": previous,
+		"results":  results,
+	})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// detail returns an error body in the format used by AWX
+func detail(message string) map[string]interface{} {
+	return map[string]interface{}{"detail": message}
+}
+
+// objectType returns the type AWX reports for objects of an endpoint,
+// e.g. "inventory" for "inventories"
+func objectType(endpoint string) string {
+	if trimmed, ok := strings.CutSuffix(endpoint, "ies"); ok {
+		return trimmed + "y"
+	}
+	return strings.TrimSuffix(endpoint, "s")
+}
+
+// endpointOf returns the endpoint of an object type, the reverse of objectType
+func endpointOf(objectType string) string {
+	if trimmed, ok := strings.CutSuffix(objectType, "y"); ok {
+		return trimmed + "ies"
+	}
+	return objectType + "s"
+}
+
+// relatedEndpoints maps related endpoints whose objects are stored under
+// another endpoint, e.g. the Galaxy credentials of an organization
+var relatedEndpoints = map[string]string{
+	"galaxy_credentials": "credentials",
+}
+
+// relatedEndpoint returns the endpoint holding the objects of a related endpoint
+func relatedEndpoint(related string) string {
+	if endpoint, ok := relatedEndpoints[related]; ok {
+		return endpoint
+	}
+	return related
+}
+
+// relatedKey identifies the related objects of an object
+func relatedKey(endpoint string, id int, related string) string {
+	return fmt.Sprintf("%s/%d/%s", endpoint, id, related)
+}
+
+// copyObject returns a shallow copy of an object
+func copyObject(object map[string]interface{}) map[string]interface{} {
+	duplicate := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		duplicate[key] = value
+	}
+	return duplicate
+}
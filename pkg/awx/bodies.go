@@ -0,0 +1,118 @@
+package awx
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultMaxBodyLogSize is the number of bytes of a request or response body
+// that is logged by default
+const DefaultMaxBodyLogSize = 1024
+
+// maxBodyLogSize limits the logged part of request and response bodies
+var maxBodyLogSize atomic.Int64
+
+func init() {
+	maxBodyLogSize.Store(DefaultMaxBodyLogSize)
+}
+
+// SetMaxBodyLogSize sets how many bytes of request and response bodies are
+// logged, typically once from operator flags at startup. Zero disables body
+// logging, which also keeps large payloads out of the logs entirely.
+func SetMaxBodyLogSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	maxBodyLogSize.Store(int64(size))
+}
+
+// streamJSON encodes body into a pipe while it is sent, so large payloads
+// such as host variables are not marshalled into memory in full. Closing the
+// returned reader stops the encoder if the request was never sent.
+func streamJSON(body interface{}) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(json.NewEncoder(writer).Encode(body))
+	}()
+	return reader
+}
+
+// sensitiveBody marks a request body holding secrets, such as the password
+// defaults of a survey. It is sent like any other body but never logged.
+type sensitiveBody struct {
+	value interface{}
+}
+
+// MarshalJSON encodes the wrapped body
+func (b sensitiveBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.value)
+}
+
+// logBuffer keeps the first bytes written to it for logging and counts the
+// rest. The transport may still be writing the body when the response arrives.
+// Values of the redactor are masked in the kept bytes, including values cut
+// in half by the limit, for which enough bytes past the limit are kept.
+type logBuffer struct {
+	mu       sync.Mutex
+	data     []byte
+	limit    int
+	size     int
+	redactor *redactor
+}
+
+// newLogBuffer returns a logBuffer limited to the configured body log size
+// that masks the values of redactor, which may be nil
+func newLogBuffer(redactor *redactor) *logBuffer {
+	return &logBuffer{limit: int(maxBodyLogSize.Load()), redactor: redactor}
+}
+
+// Write implements io.Writer and never fails
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.keep() - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(room, len(p))]...)
+	}
+	b.size += len(p)
+	return len(p), nil
+}
+
+// keep returns the number of bytes kept, the limit plus the longest value of
+// the redactor
+func (b *logBuffer) keep() int {
+	if b.limit == 0 {
+		return 0
+	}
+	return b.limit + b.redactor.longest()
+}
+
+// String returns the kept bytes with the values of the redactor masked,
+// marked when the body was truncated. A character cut in half by the limit
+// is dropped, so truncated names in non-Latin scripts don't end in invalid
+// UTF-8.
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := b.redactor.mask(b.data)
+	if b.size > b.limit {
+		data = data[:min(b.limit, len(data))]
+		for i := 1; i < utf8.UTFMax && len(data) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size > 1 {
+				break
+			}
+			data = data[:len(data)-1]
+		}
+		return b.redactor.collapse(data) + "..."
+	}
+	return b.redactor.collapse(data)
+}
+
+// bodyForLog returns the part of a body that is logged
+func (c *Client) bodyForLog(body []byte) string {
+	buffer := newLogBuffer(c.redactor.Load())
+	buffer.Write(body)
+	return buffer.String()
+}
@@ -0,0 +1,158 @@
+package awx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// breakerFailureThreshold is the number of consecutive failures that opens the breaker
+	breakerFailureThreshold = 5
+	// breakerOpenDuration is how long the breaker stays open before a trial request is let through
+	breakerOpenDuration = 30 * time.Second
+)
+
+// breakerState is the state of a circuit breaker
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// String returns a human readable breaker state
+func (s breakerState) String() string {
+	switch s {
+	case breakerHalfOpen:
+		return "half-open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// ErrCircuitOpen is wrapped by CircuitOpenError
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned without contacting AWX while the circuit
+// breaker for the AWX host is open
+type CircuitOpenError struct {
+	Host    string
+	RetryAt time.Time
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for AWX host %s, retrying after %s",
+		ErrCircuitOpen, e.Host, e.RetryAt.Format(time.RFC3339))
+}
+
+// Unwrap allows errors.Is(err, ErrCircuitOpen)
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// IsCircuitOpen reports whether err was caused by an open circuit breaker
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// circuitBreaker stops requests to an AWX host after consecutive failures
+// (5xx responses or transport errors) and lets a single trial request through
+// once the open period has elapsed
+type circuitBreaker struct {
+	mu            sync.Mutex
+	host          string
+	state         breakerState
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*circuitBreaker)
+)
+
+// breakerFor returns the circuit breaker shared by all clients of the AWX host in baseURL
+func breakerFor(baseURL string) *circuitBreaker {
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b, ok := breakers[host]
+	if !ok {
+		b = &circuitBreaker{host: host}
+		breakers[host] = b
+		breakerStateGauge.WithLabelValues(host).Set(float64(breakerClosed))
+	}
+	return b
+}
+
+// allow returns a CircuitOpenError if a request must not be sent right now
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		retryAt := b.openedAt.Add(breakerOpenDuration)
+		if time.Now().Before(retryAt) {
+			return &CircuitOpenError{Host: b.host, RetryAt: retryAt}
+		}
+		b.setState(breakerHalfOpen)
+		b.trialInFlight = true
+		return nil
+	case breakerHalfOpen:
+		if b.trialInFlight {
+			return &CircuitOpenError{Host: b.host, RetryAt: time.Now().Add(breakerOpenDuration)}
+		}
+		b.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+	if !failed {
+		b.failures = 0
+		b.setState(breakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= breakerFailureThreshold {
+		if b.state != breakerOpen {
+			log.Info("Opening circuit breaker for AWX host",
+				"host", b.host,
+				"consecutiveFailures", b.failures)
+		}
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+// setState changes the breaker state and publishes it. Callers must hold mu.
+func (b *circuitBreaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	log.Info("Circuit breaker state changed", "host", b.host, "from", b.state.String(), "to", state.String())
+	b.state = state
+	breakerStateGauge.WithLabelValues(b.host).Set(float64(state))
+}
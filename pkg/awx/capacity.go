@@ -0,0 +1,74 @@
+package awx
+
+import (
+	"fmt"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// defaultForks is the number of forks Ansible uses when forks is 0
+	defaultForks = 5
+	// defaultInstanceGroup is used by job templates without instance groups
+	defaultInstanceGroup = "default"
+)
+
+// jobSliceCount returns the desired job slice count, defaulting to a single slice
+func jobSliceCount(jobTemplateSpec awxv1alpha1.JobTemplateSpec) int32 {
+	if jobTemplateSpec.JobSliceCount < 1 {
+		return 1
+	}
+	return jobTemplateSpec.JobSliceCount
+}
+
+// RequestedParallelism returns the capacity a job template needs to run all of
+// its slices at once
+func RequestedParallelism(jobTemplateSpec awxv1alpha1.JobTemplateSpec) int {
+	forks := int(jobTemplateSpec.Forks)
+	if forks == 0 {
+		forks = defaultForks
+	}
+	return forks * int(jobSliceCount(jobTemplateSpec))
+}
+
+// AvailableCapacity returns the total capacity of the instance groups the job
+// template runs on, falling back to the default instance group when the job
+// template has none assigned
+func (jtm *JobTemplateManager) AvailableCapacity(name string) (int, error) {
+	jobTemplate, err := jtm.GetJobTemplate(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job template %s: %w", name, err)
+	}
+	if jobTemplate == nil {
+		return 0, fmt.Errorf("job template %s not found", name)
+	}
+	id, err := getObjectID(jobTemplate)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job template ID: %w", err)
+	}
+
+	groups, err := jtm.client.ListRelated("job_templates", id, "instance_groups")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list instance groups of job template %s: %w", name, err)
+	}
+	if len(groups) == 0 {
+		group, err := jtm.client.FindObjectByName("instance_groups", defaultInstanceGroup)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get instance group %s: %w", defaultInstanceGroup, err)
+		}
+		if group == nil {
+			return 0, fmt.Errorf("instance group %s not found", defaultInstanceGroup)
+		}
+		groups = append(groups, group)
+	}
+
+	capacity := 0
+	for _, group := range groups {
+		if groupCapacity, ok := group["capacity"].(float64); ok {
+			capacity += int(groupCapacity)
+		}
+	}
+
+	jtm.client.log.Info("Determined instance group capacity", "jobTemplate", name, "groups", len(groups), "capacity", capacity)
+	return capacity, nil
+}
@@ -0,0 +1,166 @@
+package awx
+
+import (
+	"fmt"
+	"strconv"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// systemJobTemplatesEndpoint lists the system job templates AWX installs for
+// maintenance tasks such as the cleanup of old jobs
+const systemJobTemplatesEndpoint = "system_job_templates"
+
+// cleanupJob is a cleanup system job template and the schedule AWX creates
+// for it on install. The schedule is updated in place instead of adding a
+// second one.
+type cleanupJob struct {
+	jobType      string
+	scheduleName string
+}
+
+var (
+	cleanupJobs           = cleanupJob{jobType: "cleanup_jobs", scheduleName: "Cleanup Job Schedule"}
+	cleanupActivityStream = cleanupJob{jobType: "cleanup_activitystream", scheduleName: "Cleanup Activity Schedule"}
+)
+
+// CleanupManager handles the schedules of the cleanup system job templates
+type CleanupManager struct {
+	client *Client
+}
+
+// NewCleanupManager creates a new CleanupManager
+func NewCleanupManager(client *Client) *CleanupManager {
+	return &CleanupManager{
+		client: client,
+	}
+}
+
+// cleanupScheduleSpec returns the cleanup schedule as a schedule spec
+func cleanupScheduleSpec(name string, cleanupSpec awxv1alpha1.CleanupScheduleSpec) awxv1alpha1.ScheduleSpec {
+	return awxv1alpha1.ScheduleSpec{
+		Name:       name,
+		Recurrence: cleanupSpec.Recurrence,
+		Start:      cleanupSpec.Start,
+		Timezone:   cleanupSpec.Timezone,
+		Enabled:    cleanupSpec.Enabled,
+	}
+}
+
+// ValidateCleanupSchedule checks that the recurrence and start of a cleanup
+// schedule can be turned into an rrule
+func ValidateCleanupSchedule(cleanupSpec awxv1alpha1.CleanupScheduleSpec) error {
+	_, err := BuildRRule(cleanupScheduleSpec("cleanup", cleanupSpec))
+	return err
+}
+
+// retentionDays returns the days a cleanup schedule keeps, which AWX stores
+// in the extra data of the schedule as a number or a string
+func retentionDays(schedule map[string]interface{}) (int, bool) {
+	extraData, _ := schedule["extra_data"].(map[string]interface{})
+	switch days := extraData["days"].(type) {
+	case float64:
+		return int(days), true
+	case string:
+		value, err := strconv.Atoi(days)
+		return value, err == nil
+	}
+	return 0, false
+}
+
+// isCleanupScheduleInDesiredState checks if a cleanup schedule runs when
+// declared and keeps the declared number of days
+func isCleanupScheduleInDesiredState(schedule map[string]interface{}, name string, cleanupSpec awxv1alpha1.CleanupScheduleSpec) bool {
+	if days, ok := retentionDays(schedule); !ok || days != int(cleanupSpec.RetentionDays) {
+		return false
+	}
+	// The description AWX gave the schedule is kept
+	return isScheduleTimingInDesiredState(schedule, cleanupScheduleSpec(name, cleanupSpec))
+}
+
+// systemJobTemplateID returns the ID of the system job template of a job type
+func (cm *CleanupManager) systemJobTemplateID(jobType string) (int, error) {
+	templates, err := cm.client.ListObjects(systemJobTemplatesEndpoint, map[string]string{"job_type": jobType})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list system job templates: %w", err)
+	}
+	if len(templates) == 0 {
+		return 0, fmt.Errorf("system job template %s not found", jobType)
+	}
+	return getObjectID(templates[0])
+}
+
+// ensureCleanupSchedule creates or updates the schedule of a cleanup system
+// job template. Other schedules of the template are left alone.
+func (cm *CleanupManager) ensureCleanupSchedule(job cleanupJob, cleanupSpec awxv1alpha1.CleanupScheduleSpec) error {
+	templateID, err := cm.systemJobTemplateID(job.jobType)
+	if err != nil {
+		return err
+	}
+	schedules, err := cm.client.ListRelated(systemJobTemplatesEndpoint, templateID, "schedules")
+	if err != nil {
+		return fmt.Errorf("failed to list schedules of %s: %w", job.jobType, err)
+	}
+
+	var schedule map[string]interface{}
+	for _, existing := range schedules {
+		if name, _ := existing["name"].(string); name == job.scheduleName {
+			schedule = existing
+			break
+		}
+	}
+	if schedule != nil && isCleanupScheduleInDesiredState(schedule, job.scheduleName, cleanupSpec) {
+		return nil
+	}
+
+	scheduleSpec := cleanupScheduleSpec(job.scheduleName, cleanupSpec)
+	rrule, err := BuildRRule(scheduleSpec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of %s: %w", job.jobType, err)
+	}
+	scheduleData := map[string]interface{}{
+		"name":       job.scheduleName,
+		"rrule":      rrule,
+		"enabled":    scheduleEnabled(scheduleSpec),
+		"extra_data": map[string]interface{}{"days": cleanupSpec.RetentionDays},
+	}
+
+	if schedule == nil {
+		cm.client.log.Info("Creating cleanup schedule", "jobType", job.jobType, "retentionDays", cleanupSpec.RetentionDays, "rrule", rrule)
+		endpoint := fmt.Sprintf("%s/%d/schedules", systemJobTemplatesEndpoint, templateID)
+		if _, err := cm.client.CreateObject(endpoint, scheduleData, "schedule"); err != nil {
+			return fmt.Errorf("failed to create schedule of %s: %w", job.jobType, err)
+		}
+		return nil
+	}
+
+	scheduleID, err := getObjectID(schedule)
+	if err != nil {
+		return fmt.Errorf("failed to get schedule ID: %w", err)
+	}
+	cm.client.log.Info("Updating cleanup schedule", "jobType", job.jobType, "retentionDays", cleanupSpec.RetentionDays, "rrule", rrule)
+	err = cm.client.retryOnConflict("update schedule of "+job.jobType, func() error {
+		_, err := cm.client.UpdateObject("schedules", scheduleID, scheduleData)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update schedule of %s: %w", job.jobType, err)
+	}
+	return nil
+}
+
+// EnsureJobCleanup applies the declared cleanup schedules. Cleanups that are
+// not declared keep their schedule.
+func (cm *CleanupManager) EnsureJobCleanup(cleanupSpec *awxv1alpha1.JobCleanupSpec) error {
+	if cleanupSpec.Jobs != nil {
+		if err := cm.ensureCleanupSchedule(cleanupJobs, *cleanupSpec.Jobs); err != nil {
+			return err
+		}
+	}
+	if cleanupSpec.ActivityStream != nil {
+		if err := cm.ensureCleanupSchedule(cleanupActivityStream, *cleanupSpec.ActivityStream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
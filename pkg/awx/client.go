@@ -2,42 +2,236 @@ package awx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	ctrl "sigs.k8s.io/controller-runtime"
+	"github.com/go-logr/logr"
 )
 
-var log = ctrl.Log.WithName("awx-client")
+// DefaultAPIPath is the API path prefix used by standalone AWX installations
+const DefaultAPIPath = "api/v2"
 
-// Client represents an AWX API client
+// KnownAPIPaths lists the API path prefixes probed by DetectAPIPath, in order.
+// AAP 2.4+ gateway deployments expose the controller API under api/controller/v2.
+var KnownAPIPaths = []string{DefaultAPIPath, "api/controller/v2"}
+
+// Client represents an AWX API client. Clients derived with WithContext share
+// their state and differ only in the context their requests are sent within.
 type Client struct {
+	*clientState
+	ctx context.Context
+
+	// API path prefix probed by DetectAPIPath instead of the configured one
+	probePath string
+}
+
+// clientState is the connection, session and cached state of a client
+type clientState struct {
 	baseURL    string
 	username   string
 	password   string
+	authMethod string
 	httpClient *http.Client
+	breaker    *circuitBreaker
+	managedBy  string
+	log        logr.Logger
+
+	// API path prefix, replaced by DetectAPIPath while other users of the
+	// client may be sending requests
+	apiPathMu sync.RWMutex
+	apiPath   string
+
+	// requestCount counts the requests sent to AWX, for API budget metrics
+	requestCount atomic.Int64
+
+	// Rate and concurrency limits shared with the other clients of the instance
+	limiter atomic.Pointer[RequestLimiter]
+
+	// Secret values masked in logged bodies
+	redactor atomic.Pointer[redactor]
+
+	// TLS connection last negotiated with AWX, reported in the instance status
+	tlsState      atomic.Pointer[TLSState]
+	restrictedTLS bool
+
+	// Whether request bodies are sent gzip compressed
+	compressRequests bool
+
+	// Correlation ID of the current reconcile and the sequence number of the
+	// last request, both logged with every request
+	correlationID atomic.Pointer[string]
+	requestSeq    atomic.Int64
+
+	// Session token state, used when authMethod is AuthMethodToken
+	tokenMu         sync.Mutex
+	token           string
+	tokenID         int
+	tokenExpiry     time.Time
+	noTokenCreation bool
+
+	// Credential type catalog, cached for credential input validation
+	credentialTypesMu      sync.Mutex
+	credentialTypes        []CredentialType
+	credentialTypesFetched time.Time
+
+	// Objects looked up by name, served until the next inventory resync
+	inventory objectInventory
+
+	// IDs of objects recorded on the AWXInstance, read by ID instead of by name
+	objectIDsMu sync.Mutex
+	objectIDs   map[string]int
+
+	// Objects with a protected name, refused to be updated or deleted
+	protected protectedObjects
+
+	// OPTIONS metadata per endpoint kind, cached for field validation and
+	// the permission check, and the AWX version it was read from
+	metadataMu sync.Mutex
+	metadata   map[string]cachedMetadata
+	version    string
+
+	// Recorded requests and responses, used to capture contract test fixtures
+	recordingMu  sync.Mutex
+	recording    bool
+	interactions []Interaction
 }
 
 // NewClient creates a new AWX API client
 func NewClient(baseURL, username, password string) *Client {
-	return &Client{
-		baseURL:  baseURL,
-		username: username,
-		password: password,
+	opts := currentTransportOptions()
+	return &Client{clientState: &clientState{
+		baseURL:    baseURL,
+		apiPath:    DefaultAPIPath,
+		username:   username,
+		password:   password,
+		authMethod: AuthMethodBasic,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(opts),
 		},
+		breaker:          breakerFor(baseURL),
+		restrictedTLS:    opts.restrictsTLS(),
+		compressRequests: opts.CompressRequests,
+		log:              log,
+	}}
+}
+
+// SetTransport replaces the transport the client sends its requests with,
+// e.g. to serve them from an in-memory fake of AWX in tests. The transport
+// options set with SetTransportOptions no longer apply to the client.
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
+// apiURLPath joins the base path, the API prefix and the endpoint. AWX only
+// accepts writes on canonical URLs, which always end with a slash.
+func (c *Client) apiURLPath(basePath, endpoint string) string {
+	return path.Join(basePath, c.APIPath(), endpoint) + "/"
+}
+
+// do sends the request through the circuit breaker of the AWX host, failing
+// fast while the breaker is open, and within the request limits of the client
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	release, err := c.limiter.Load().acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	c.requestCount.Add(1)
+	c.setAttributionHeaders(req)
+	if c.compressRequests {
+		compressRequestBody(req)
 	}
+
+	resp, err := c.httpClient.Do(req)
+	c.breaker.record(err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	c.recordTLSState(resp.TLS)
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	if recordErr := c.recordInteraction(req, resp); recordErr != nil {
+		resp.Body.Close()
+		return nil, recordErr
+	}
+	return resp, nil
+}
+
+// RequestCount returns the number of requests the client sent to AWX
+func (c *Client) RequestCount() int64 {
+	return c.requestCount.Load()
+}
+
+// SetAPIPath overrides the API path prefix (e.g. "api/controller/v2")
+func (c *Client) SetAPIPath(apiPath string) {
+	c.apiPathMu.Lock()
+	defer c.apiPathMu.Unlock()
+	c.apiPath = strings.Trim(apiPath, "/")
 }
 
-// doRequest performs an HTTP request to the AWX API
+// APIPath returns the API path prefix used by the client
+func (c *Client) APIPath() string {
+	if c.probePath != "" {
+		return c.probePath
+	}
+	c.apiPathMu.RLock()
+	defer c.apiPathMu.RUnlock()
+	return c.apiPath
+}
+
+// DetectAPIPath probes the known API path prefixes and configures the client
+// with the first one that answers the ping endpoint. The candidates are probed
+// through a handle of their own, so requests sent meanwhile keep the
+// configured prefix until the detected one replaces it.
+func (c *Client) DetectAPIPath() (string, error) {
+	var lastErr error
+	for _, candidate := range KnownAPIPaths {
+		probe := &Client{clientState: c.clientState, ctx: c.ctx, probePath: candidate}
+		if _, err := probe.doRequest(http.MethodGet, "ping", nil); err != nil {
+			c.log.Info("API path prefix not available", "baseURL", c.baseURL, "apiPath", candidate)
+			lastErr = err
+			continue
+		}
+		c.log.Info("Detected API path prefix", "baseURL", c.baseURL, "apiPath", candidate)
+		c.SetAPIPath(candidate)
+		return candidate, nil
+	}
+	return "", fmt.Errorf("failed to detect API path prefix: %w", lastErr)
+}
+
+// doRequest performs an HTTP request to the AWX API, logging in again once
+// if a session token was rejected
 func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, error) {
+	if method != http.MethodGet && method != http.MethodOptions {
+		if err := c.protected.check(endpoint); err != nil {
+			return nil, err
+		}
+		c.inventory.invalidate(endpoint)
+	}
+	respBody, err := c.sendRequest(method, endpoint, body)
+	if err != nil && c.usesToken() && IsStatus(err, http.StatusUnauthorized) {
+		c.log.Info("Session token rejected, logging in again", "method", method, "endpoint", endpoint)
+		c.invalidateToken()
+		return c.sendRequest(method, endpoint, body)
+	}
+	return respBody, err
+}
+
+// sendRequest performs a single HTTP request to the AWX API
+func (c *Client) sendRequest(method, endpoint string, body interface{}) ([]byte, error) {
 	// Prepare URL, preserving query parameters
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
@@ -53,8 +247,11 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 		queryString = endpoint[idx+1:]
 	}
 
-	// Set path properly without losing query parameters
-	u.Path = path.Join(u.Path, "api/v2", endpointPath)
+	// Set path properly without losing query parameters. The endpoint is an
+	// escaped path, so names in named URLs keep their reserved characters.
+	if err := setEscapedPath(u, c.apiURLPath(u.EscapedPath(), endpointPath)); err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
+	}
 
 	// Restore or set query string
 	if queryString != "" {
@@ -62,53 +259,69 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 	}
 
 	fullURL := u.String()
-
-	// Log the request details (before making the request)
-	requestID := fmt.Sprintf("%d", time.Now().UnixNano())
-	log.Info("REST API Request",
-		"requestID", requestID,
+	loggedURL := readableURL(u)
+
+	// Log the request details (before making the request). The correlation
+	// ID ties the request to its reconcile, the sequence number pairs the
+	// request with its response.
+	correlationID := c.CorrelationID()
+	request := c.requestSeq.Add(1)
+	c.log.Info("REST API Request",
+		"correlationID", correlationID,
+		"request", request,
 		"method", method,
-		"url", fullURL)
+		"url", loggedURL)
 
-	// Prepare request body
+	// Prepare request body. It is streamed to AWX while its first bytes are
+	// kept for the log, so large payloads don't have to fit in memory twice.
 	var reqBody io.Reader
-	var bodyStr string
+	var loggedBody *logBuffer
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		stream := streamJSON(body)
+		defer stream.Close()
+		loggedBody = newLogBuffer(c.redactor.Load())
+		if _, ok := body.(sensitiveBody); ok {
+			loggedBody.limit = 0
 		}
-		bodyStr = string(jsonBody)
-		reqBody = bytes.NewReader(jsonBody)
-
-		// Log request body (if any)
-		log.Info("REST API Request Body",
-			"requestID", requestID,
-			"body", bodyStr)
+		reqBody = io.TeeReader(stream, loggedBody)
 
 		// For POST requests, log more details
 		if method == http.MethodPost {
 			if data, ok := body.(map[string]interface{}); ok {
-				log.Info("Creating object with data",
-					"requestID", requestID,
+				c.log.Info("Creating object with data",
+					"correlationID", correlationID,
+					"request", request,
 					"type", endpoint,
 					"name", data["name"])
 			}
 		}
 	}
 
-	// Create request
-	req, err := http.NewRequest(method, fullURL, reqBody)
+	// Create request, canceled at the deadline of the client
+	ctx, cancel, err := c.requestContext()
+	if err != nil {
+		c.log.Info("REST API Request refused after the deadline",
+			"correlationID", correlationID,
+			"request", request,
+			"method", method,
+			"url", loggedURL)
+		return nil, err
+	}
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, reqBody)
 	if err != nil {
-		log.Error(err, "Failed to create HTTP request",
-			"requestID", requestID,
+		c.log.Error(err, "Failed to create HTTP request",
+			"correlationID", correlationID,
+			"request", request,
 			"method", method,
-			"url", fullURL)
+			"url", loggedURL)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.SetBasicAuth(c.username, c.password)
+	if err := c.setAuthHeader(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -121,21 +334,34 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 			headers[name] = strings.Join(values, ",")
 		}
 	}
-	log.Info("REST API Request Headers",
-		"requestID", requestID,
+	c.log.Info("REST API Request Headers",
+		"correlationID", correlationID,
+		"request", request,
 		"headers", headers)
 
 	// Execute request
 	startTime := time.Now()
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	requestDuration := time.Since(startTime)
 
+	// Log the part of the request body that was sent
+	if loggedBody != nil && loggedBody.limit > 0 {
+		c.log.Info("REST API Request Body",
+			"correlationID", correlationID,
+			"request", request,
+			"body", loggedBody.String())
+	}
+
 	if err != nil {
-		log.Error(err, "REST API Request failed",
-			"requestID", requestID,
+		c.log.Error(err, "REST API Request failed",
+			"correlationID", correlationID,
+			"request", request,
 			"method", method,
-			"url", fullURL,
+			"url", loggedURL,
 			"duration_ms", requestDuration.Milliseconds())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("request failed: %w", ErrDeadlineExceeded)
+		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
@@ -143,10 +369,11 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error(err, "Failed to read response body",
-			"requestID", requestID,
+		c.log.Error(err, "Failed to read response body",
+			"correlationID", correlationID,
+			"request", request,
 			"method", method,
-			"url", fullURL)
+			"url", loggedURL)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
@@ -156,44 +383,44 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 		respHeaders[name] = strings.Join(values, ",")
 	}
 
-	log.Info("REST API Response",
-		"requestID", requestID,
+	c.log.Info("REST API Response",
+		"correlationID", correlationID,
+		"request", request,
 		"method", method,
-		"url", fullURL,
+		"url", loggedURL,
 		"status", resp.StatusCode,
 		"statusText", resp.Status,
 		"duration_ms", requestDuration.Milliseconds())
 
-	log.Info("REST API Response Headers",
-		"requestID", requestID,
+	c.log.Info("REST API Response Headers",
+		"correlationID", correlationID,
+		"request", request,
 		"headers", respHeaders)
 
-	// Log response body - limit size if too large
-	respBodyStr := string(respBody)
-	if len(respBodyStr) > 1024 {
-		// Truncate long responses for logging
-		log.Info("REST API Response Body (truncated)",
-			"requestID", requestID,
-			"bodySize", len(respBodyStr),
-			"body", respBodyStr[:1024]+"...")
-	} else {
-		log.Info("REST API Response Body",
-			"requestID", requestID,
+	// Log response body, truncated to the configured size
+	respBodyStr := c.bodyForLog(respBody)
+	if maxBodyLogSize.Load() > 0 {
+		c.log.Info("REST API Response Body",
+			"correlationID", correlationID,
+			"request", request,
+			"bodySize", len(respBody),
 			"body", respBodyStr)
 	}
 
 	// For POST requests, add additional debug info
 	if method == http.MethodPost && resp.StatusCode == http.StatusOK {
-		log.Info("POST request successful, analyzing response",
-			"requestID", requestID,
+		c.log.Info("POST request successful, analyzing response",
+			"correlationID", correlationID,
+			"request", request,
 			"endpoint", endpoint)
 
 		// Try to quickly check if we got back what we expected
 		var resultObj map[string]interface{}
 		if err := json.Unmarshal(respBody, &resultObj); err == nil {
 			if resultsArray, ok := resultObj["results"].([]interface{}); ok {
-				log.Info("Response contains results array",
-					"requestID", requestID,
+				c.log.Info("Response contains results array",
+					"correlationID", correlationID,
+					"request", request,
 					"count", len(resultsArray))
 
 				// See if any of the results match our request
@@ -205,8 +432,9 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 							for i, item := range resultsArray {
 								if obj, ok := item.(map[string]interface{}); ok {
 									if name, ok := obj["name"].(string); ok && name == reqName {
-										log.Info("Found matching result",
-											"requestID", requestID,
+										c.log.Info("Found matching result",
+											"correlationID", correlationID,
+											"request", request,
 											"index", i,
 											"name", name)
 										found = true
@@ -215,8 +443,9 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 								}
 							}
 							if !found {
-								log.Info("Could not find matching result by name",
-									"requestID", requestID,
+								c.log.Info("Could not find matching result by name",
+									"correlationID", correlationID,
+									"request", request,
 									"requestedName", reqName,
 									"results", len(resultsArray))
 							}
@@ -226,8 +455,9 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 			} else {
 				// Not a results array, check if it's what we expect
 				if name, ok := resultObj["name"].(string); ok {
-					log.Info("Response contains direct object",
-						"requestID", requestID,
+					c.log.Info("Response contains direct object",
+						"correlationID", correlationID,
+						"request", request,
 						"name", name)
 				}
 			}
@@ -236,13 +466,19 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Error(nil, "REST API Request failed with error status",
-			"requestID", requestID,
+		c.log.Error(nil, "REST API Request failed with error status",
+			"correlationID", correlationID,
+			"request", request,
 			"method", method,
-			"url", fullURL,
+			"url", loggedURL,
 			"status", resp.StatusCode,
 			"response", respBodyStr)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Fields:     parseFieldErrors(resp.StatusCode, string(respBody)),
+		}
 	}
 
 	return respBody, nil
@@ -264,13 +500,14 @@ func (c *Client) GetObject(endpoint string, id int) (map[string]interface{}, err
 
 	// Verify the response has an ID field
 	if _, ok := result["id"]; !ok {
-		log.Error(nil, "Object returned by API missing ID field",
+		c.log.Error(nil, "Object returned by API missing ID field",
 			"endpoint", endpoint,
 			"id", id,
 			"keys", getMapKeys(result))
 		return nil, fmt.Errorf("API returned object without ID field")
 	}
 
+	c.protected.note(result)
 	return result, nil
 }
 
@@ -318,7 +555,7 @@ func (c *Client) ListObjects(endpoint string, filters map[string]string) ([]map[
 
 	if paginatedResult.Results != nil {
 		// Standard paginated response with results array (AWX's typical format)
-		log.Info("API returned paginated response",
+		c.log.Info("API returned paginated response",
 			"endpoint", endpoint,
 			"count", paginatedResult.Count,
 			"resultsCount", len(paginatedResult.Results))
@@ -326,13 +563,14 @@ func (c *Client) ListObjects(endpoint string, filters map[string]string) ([]map[
 		// Validate the result objects for required fields
 		for i, obj := range paginatedResult.Results {
 			if _, ok := obj["id"]; !ok {
-				log.Info("API object missing ID field",
+				c.log.Info("API object missing ID field",
 					"endpoint", endpoint,
 					"index", i,
 					"keys", getMapKeys(obj))
 			}
 		}
 
+		c.protected.note(paginatedResult.Results...)
 		return paginatedResult.Results, nil
 	}
 
@@ -341,37 +579,54 @@ func (c *Client) ListObjects(endpoint string, filters map[string]string) ([]map[
 	err = json.Unmarshal(respBody, &directResult)
 	if err != nil {
 		// Neither a paginated response nor a direct array - log error and return empty array
-		log.Error(err, "Response is neither paginated nor a direct array",
+		c.log.Error(err, "Response is neither paginated nor a direct array",
 			"endpoint", endpoint)
 		return []map[string]interface{}{}, nil
 	}
 
-	log.Info("API returned direct array",
+	c.log.Info("API returned direct array",
 		"endpoint", endpoint,
 		"count", len(directResult))
 
 	// Validate the direct result objects for required fields
 	for i, obj := range directResult {
 		if _, ok := obj["id"]; !ok {
-			log.Info("API object missing ID field in direct array",
+			c.log.Info("API object missing ID field in direct array",
 				"endpoint", endpoint,
 				"index", i,
 				"keys", getMapKeys(obj))
 		}
 	}
 
+	c.protected.note(directResult...)
 	return directResult, nil
 }
 
-// Post performs a POST request to the AWX API
+// Post performs a POST request to the AWX API, logging in again once if a
+// session token was rejected
 func (c *Client) Post(endpoint string, body interface{}) (*http.Response, error) {
+	c.inventory.invalidate(endpoint)
+	resp, err := c.post(endpoint, body)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.usesToken() {
+		resp.Body.Close()
+		c.log.Info("Session token rejected, logging in again", "method", http.MethodPost, "endpoint", endpoint)
+		c.invalidateToken()
+		return c.post(endpoint, body)
+	}
+	return resp, err
+}
+
+// post performs a single POST request to the AWX API
+func (c *Client) post(endpoint string, body interface{}) (*http.Response, error) {
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
 	}
 
 	// Set path properly
-	u.Path = path.Join(u.Path, "api/v2", endpoint)
+	if err := setEscapedPath(u, c.apiURLPath(u.EscapedPath(), endpoint)); err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
+	}
 	fullURL := u.String()
 
 	// Marshal request body
@@ -381,19 +636,36 @@ func (c *Client) Post(endpoint string, body interface{}) (*http.Response, error)
 	}
 	reqBody := bytes.NewReader(jsonBody)
 
-	// Create request
-	req, err := http.NewRequest(http.MethodPost, fullURL, reqBody)
+	// Create request, canceled when the context of the client ends
+	ctx, cancel, err := c.requestContext()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, reqBody)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.SetBasicAuth(c.username, c.password)
+	if err := c.setAuthHeader(req); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	// Execute request
-	return c.httpClient.Do(req)
+	// Execute request, keeping its context until the caller closed the body
+	resp, err := c.do(req)
+	if err != nil {
+		cancel()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("request failed: %w", ErrDeadlineExceeded)
+		}
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: cancel}
+	return resp, nil
 }
 
 // GetObjectByName retrieves an object from the AWX API by name
@@ -403,47 +675,68 @@ func (c *Client) GetObjectByName(endpoint, name string) (map[string]interface{},
 
 // CreateObject creates an object in the AWX API
 func (c *Client) CreateObject(endpoint string, payload map[string]interface{}, expectedObj string) (map[string]interface{}, error) {
+	return c.createObject(endpoint, payload, payload, expectedObj)
+}
+
+// CreateSensitiveObject creates an object like CreateObject from a payload
+// holding secrets, e.g. the inputs of a credential. The payload is never logged.
+func (c *Client) CreateSensitiveObject(endpoint string, payload map[string]interface{}, expectedObj string) (map[string]interface{}, error) {
+	return c.createObject(endpoint, payload, sensitiveBody{value: payload}, expectedObj)
+}
+
+// createObject POSTs body, which is the payload or the payload marked as
+// sensitive, to create an object
+func (c *Client) createObject(endpoint string, payload map[string]interface{}, body interface{}, expectedObj string) (map[string]interface{}, error) {
+	if err := c.validatePayload(http.MethodPost, endpoint, payload); err != nil {
+		return nil, err
+	}
+
 	// Directly try to create the object with POST without checking if it exists first
-	log.Info("Creating object", "endpoint", endpoint, "keys", getMapKeys(payload))
-	resp, err := c.Post(endpoint, payload)
+	c.log.Info("Creating object", "endpoint", endpoint, "keys", getMapKeys(payload))
+	resp, err := c.Post(endpoint, body)
 	if err != nil {
-		log.Error(err, "Failed to create object", "endpoint", endpoint)
+		c.log.Error(err, "Failed to create object", "endpoint", endpoint)
 		return nil, err
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		log.Error(nil, "Error response from AWX API",
+		c.log.Error(nil, "Error response from AWX API",
 			"status", resp.Status,
 			"endpoint", endpoint,
-			"response", string(body))
-		return nil, fmt.Errorf("failed to create object: %s", resp.Status)
+			"response", c.bodyForLog(body))
+		return nil, fmt.Errorf("failed to create object: %w", &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Fields:     parseFieldErrors(resp.StatusCode, string(body)),
+		})
 	}
 
 	result := make(map[string]interface{})
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Error(err, "Failed to decode response", "endpoint", endpoint)
+		c.log.Error(err, "Failed to decode response", "endpoint", endpoint)
 		return nil, err
 	}
 
-	log.Info("Received response", "endpoint", endpoint, "status", resp.Status, "keys", getMapKeys(result))
+	c.log.Info("Received response", "endpoint", endpoint, "status", resp.Status, "keys", getMapKeys(result))
 
 	// Handle the case where the API returns a collection instead of a direct object
 	if results, ok := result["results"].([]interface{}); ok {
-		log.Info("API returned a collection", "endpoint", endpoint, "count", len(results))
+		c.log.Info("API returned a collection", "endpoint", endpoint, "count", len(results))
 
 		// Try to find our newly created object in the results
 		if name, ok := payload["name"].(string); ok {
 			for _, item := range results {
 				if obj, ok := item.(map[string]interface{}); ok {
 					if objName, ok := obj["name"].(string); ok && objName == name {
-						log.Info("Found newly created object in results", "endpoint", endpoint, "name", name)
+						c.log.Info("Found newly created object in results", "endpoint", endpoint, "name", name)
 						return obj, nil
 					}
 				}
 			}
-			log.Error(nil, "Failed to find newly created object in results",
+			c.log.Error(nil, "Failed to find newly created object in results",
 				"endpoint", endpoint,
 				"name", name,
 				"result_count", len(results))
@@ -457,7 +750,7 @@ func (c *Client) CreateObject(endpoint string, payload map[string]interface{}, e
 	// Check if the result has id, if not it's probably an error
 	if _, hasID := result["id"]; !hasID {
 		if name, ok := payload["name"].(string); ok {
-			log.Error(nil, "Failed to create object: response missing ID",
+			c.log.Error(nil, "Failed to create object: response missing ID",
 				"endpoint", endpoint,
 				"name", name,
 				"keys", getMapKeys(result))
@@ -471,7 +764,7 @@ func (c *Client) CreateObject(endpoint string, payload map[string]interface{}, e
 	if expectedObj != "" {
 		if typeStr, ok := result["type"].(string); ok {
 			if typeStr != expectedObj {
-				log.Error(nil, "Object created with unexpected type",
+				c.log.Error(nil, "Object created with unexpected type",
 					"endpoint", endpoint,
 					"expected", expectedObj,
 					"got", typeStr)
@@ -485,8 +778,23 @@ func (c *Client) CreateObject(endpoint string, payload map[string]interface{}, e
 
 // UpdateObject updates an object in the AWX API
 func (c *Client) UpdateObject(endpoint string, id int, data map[string]interface{}) (map[string]interface{}, error) {
+	return c.updateObject(endpoint, id, data, data)
+}
+
+// UpdateSensitiveObject updates an object like UpdateObject with data holding
+// secrets, e.g. the inputs of a credential. The data is never logged.
+func (c *Client) UpdateSensitiveObject(endpoint string, id int, data map[string]interface{}) (map[string]interface{}, error) {
+	return c.updateObject(endpoint, id, data, sensitiveBody{value: data})
+}
+
+// updateObject PATCHes body, which is data or data marked as sensitive, to
+// update an object
+func (c *Client) updateObject(endpoint string, id int, data map[string]interface{}, body interface{}) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/%d/", endpoint, id)
-	respBody, err := c.doRequest(http.MethodPatch, url, data)
+	if err := c.validatePayload(http.MethodPatch, url, data); err != nil {
+		return nil, err
+	}
+	respBody, err := c.doRequest(http.MethodPatch, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -499,13 +807,13 @@ func (c *Client) UpdateObject(endpoint string, id int, data map[string]interface
 
 	// Verify the updated object has an ID field
 	if _, ok := result["id"]; !ok {
-		log.Error(nil, "Updated object missing ID field",
+		c.log.Error(nil, "Updated object missing ID field",
 			"endpoint", endpoint,
 			"id", id,
 			"keys", getMapKeys(result))
 
 		// As a fallback, retrieve the object we just updated
-		log.Info("Fetching updated object as fallback",
+		c.log.Info("Fetching updated object as fallback",
 			"endpoint", endpoint,
 			"id", id)
 		return c.GetObject(endpoint, id)
@@ -514,65 +822,135 @@ func (c *Client) UpdateObject(endpoint string, id int, data map[string]interface
 	return result, nil
 }
 
-// DeleteObject deletes an object from the AWX API
+// Put replaces the resource at endpoint with data, e.g. a category of the
+// AWX settings that only accepts the full set of its keys. Unlike a PATCH,
+// fields missing from data are reset to their defaults.
+func (c *Client) Put(endpoint string, data map[string]interface{}) (map[string]interface{}, error) {
+	respBody, err := c.doRequest(http.MethodPut, endpoint, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteObject deletes an object from the AWX API. The outcome is taken from
+// the status of the DELETE alone: 204, or 202 for objects AWX deletes in the
+// background such as inventories, means deleted and 404 means already gone,
+// so neither an existence check before nor a verification after is needed.
 func (c *Client) DeleteObject(endpoint string, id int) error {
-	url := fmt.Sprintf("%s/%d/", endpoint, id)
+	return c.DeleteObjectWithParams(endpoint, id, nil)
+}
+
+// CopyObject creates a copy of an object under a new name using its copy endpoint
+func (c *Client) CopyObject(endpoint string, id int, name string) (map[string]interface{}, error) {
+	copyEndpoint := fmt.Sprintf("%s/%d/copy", endpoint, id)
+	c.log.Info("Copying object", "endpoint", endpoint, "id", id, "name", name)
 
-	// First verify the object exists
-	_, err := c.GetObject(endpoint, id)
+	respBody, err := c.doRequest(http.MethodPost, copyEndpoint, map[string]interface{}{
+		"name": name,
+	})
 	if err != nil {
-		// If the error indicates the object doesn't exist, treat as success
-		if strings.Contains(err.Error(), "404") {
-			log.Info("Object already deleted or doesn't exist",
-				"endpoint", endpoint,
-				"id", id)
-			return nil
+		return nil, fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if _, ok := result["id"]; !ok {
+		c.log.Error(nil, "Copied object missing ID field",
+			"endpoint", endpoint,
+			"id", id,
+			"keys", getMapKeys(result))
+		return nil, fmt.Errorf("copied object has no ID field")
+	}
+
+	return result, nil
+}
+
+// DeleteObjectWithParams deletes an object from the AWX API, passing the given
+// query parameters with the DELETE request
+func (c *Client) DeleteObjectWithParams(endpoint string, id int, params map[string]string) error {
+	requestEndpoint := fmt.Sprintf("%s/%d/", endpoint, id)
+	if len(params) > 0 {
+		query := url.Values{}
+		for key, value := range params {
+			query.Add(key, value)
 		}
-		return fmt.Errorf("failed to verify object before deletion: %w", err)
+		requestEndpoint = fmt.Sprintf("%s?%s", requestEndpoint, query.Encode())
 	}
 
-	// Object exists, attempt to delete it
-	respBody, err := c.doRequest(http.MethodDelete, url, nil)
-	if err != nil {
-		// Check if error is a 404 (already deleted), which can be treated as success
-		if strings.Contains(err.Error(), "404") {
-			log.Info("Object already deleted",
-				"endpoint", endpoint,
-				"id", id)
+	if _, err := c.doRequest(http.MethodDelete, requestEndpoint, nil); err != nil {
+		if protectedErr, ok := AsProtectedObjectError(err); ok {
+			c.log.Info("Leaving protected object in place", "endpoint", endpoint, "id", id, "name", protectedErr.Name)
+			return nil
+		}
+		if IsStatus(err, http.StatusNotFound) {
+			c.log.Info("Object already deleted", "endpoint", endpoint, "id", id)
 			return nil
 		}
 		return fmt.Errorf("failed to delete object: %w", err)
 	}
 
-	// Per AWX API docs, a successful delete typically returns an empty response
-	// But let's add extra handling for any response we might get
-	if len(respBody) > 0 {
-		log.Info("Delete operation returned non-empty response",
-			"endpoint", endpoint,
-			"id", id,
-			"responseLength", len(respBody))
+	c.log.Info("Successfully deleted object", "endpoint", endpoint, "id", id, "params", params)
+	return nil
+}
 
-		// Try to parse the response just in case it contains useful information
-		var result map[string]interface{}
-		if err := json.Unmarshal(respBody, &result); err == nil {
-			if len(result) > 0 {
-				log.Info("Delete operation returned structured data",
-					"endpoint", endpoint,
-					"id", id,
-					"keys", getMapKeys(result))
-			}
-		}
+// ListRelated lists the objects on a related endpoint of an object,
+// e.g. the credentials of a job template
+func (c *Client) ListRelated(endpoint string, id int, related string) ([]map[string]interface{}, error) {
+	return c.ListObjects(fmt.Sprintf("%s/%d/%s", endpoint, id, related), nil)
+}
+
+// AssociateRelated associates an existing object with an object through one of
+// its related endpoints, e.g. a credential with a job template
+func (c *Client) AssociateRelated(endpoint string, id int, related string, relatedID int) error {
+	relatedEndpoint := fmt.Sprintf("%s/%d/%s", endpoint, id, related)
+	c.log.Info("Associating related object", "endpoint", relatedEndpoint, "relatedID", relatedID)
+
+	_, err := c.doRequest(http.MethodPost, relatedEndpoint, map[string]interface{}{
+		"id": relatedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to associate %s %d with %s %d: %w", related, relatedID, endpoint, id, err)
 	}
+	return nil
+}
 
-	// Assume delete was successful if we reach this point
-	log.Info("Successfully deleted object",
-		"endpoint", endpoint,
-		"id", id)
+// DisassociateRelated removes the association between an object and a related
+// object without deleting either of them
+func (c *Client) DisassociateRelated(endpoint string, id int, related string, relatedID int) error {
+	relatedEndpoint := fmt.Sprintf("%s/%d/%s", endpoint, id, related)
+	c.log.Info("Disassociating related object", "endpoint", relatedEndpoint, "relatedID", relatedID)
+
+	_, err := c.doRequest(http.MethodPost, relatedEndpoint, map[string]interface{}{
+		"id":           relatedID,
+		"disassociate": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disassociate %s %d from %s %d: %w", related, relatedID, endpoint, id, err)
+	}
 	return nil
 }
 
-// FindObjectByName finds an object by name in the AWX API
+// FindObjectByName finds an object by name in the AWX API, reading it by ID
+// when the client was told its ID with SetObjectID. Objects found are kept in
+// the inventory of the client and served from it until the next inventory
+// resync or a write to their endpoint.
 func (c *Client) FindObjectByName(endpoint, name string) (map[string]interface{}, error) {
+	if object, ok := c.inventory.lookup(c.log, endpoint, name); ok {
+		return object, nil
+	}
+	if object := c.findObjectByID(endpoint, name); object != nil {
+		c.inventory.store(c.log, endpoint, name, object)
+		return object, nil
+	}
+
 	filters := map[string]string{"name": name}
 	objects, err := c.ListObjects(endpoint, filters)
 	if err != nil {
@@ -581,7 +959,7 @@ func (c *Client) FindObjectByName(endpoint, name string) (map[string]interface{}
 
 	if len(objects) == 0 {
 		// Object not found
-		log.Info("Object not found by name",
+		c.log.Info("Object not found by name",
 			"endpoint", endpoint,
 			"name", name)
 		return nil, nil
@@ -589,7 +967,7 @@ func (c *Client) FindObjectByName(endpoint, name string) (map[string]interface{}
 
 	// Per AWX docs, name should be unique, but let's log if we find multiple matches
 	if len(objects) > 1 {
-		log.Info("Found multiple objects with the same name (using first)",
+		c.log.Info("Found multiple objects with the same name (using first)",
 			"endpoint", endpoint,
 			"name", name,
 			"count", len(objects))
@@ -598,7 +976,7 @@ func (c *Client) FindObjectByName(endpoint, name string) (map[string]interface{}
 	// Verify the object has an ID field
 	result := objects[0]
 	if _, ok := result["id"]; !ok {
-		log.Error(nil, "Object returned by API missing ID field",
+		c.log.Error(nil, "Object returned by API missing ID field",
 			"endpoint", endpoint,
 			"name", name,
 			"keys", getMapKeys(result))
@@ -607,20 +985,21 @@ func (c *Client) FindObjectByName(endpoint, name string) (map[string]interface{}
 		// The calling code should handle objects without IDs
 	}
 
+	c.inventory.store(c.log, endpoint, name, result)
 	return result, nil
 }
 
 // TestConnection tests the connection to the AWX instance
 func (c *Client) TestConnection() error {
-	// Make a request to the API v2 endpoint to check if the connection works
+	// Make a request to the ping endpoint to check if the connection works
 	endpoint := "ping"
 
-	log.Info("Testing connection to AWX", "baseURL", c.baseURL)
+	c.log.Info("Testing connection to AWX", "baseURL", c.baseURL)
 
 	// Use the existing doRequest method to leverage our error handling
 	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
-		log.Error(err, "Failed to connect to AWX",
+		c.log.Error(err, "Failed to connect to AWX",
 			"baseURL", c.baseURL,
 			"username", c.username)
 		return fmt.Errorf("failed to connect to AWX: %w", err)
@@ -631,16 +1010,17 @@ func (c *Client) TestConnection() error {
 	if err := json.Unmarshal(respBody, &result); err == nil {
 		// Check for version or other information
 		if version, ok := result["version"]; ok {
-			log.Info("Successfully connected to AWX",
+			c.observeVersion(fmt.Sprint(version))
+			c.log.Info("Successfully connected to AWX",
 				"baseURL", c.baseURL,
 				"version", version)
 		} else {
-			log.Info("Successfully connected to AWX",
+			c.log.Info("Successfully connected to AWX",
 				"baseURL", c.baseURL,
 				"response", result)
 		}
 	} else {
-		log.Info("Successfully connected to AWX (could not parse response)",
+		c.log.Info("Successfully connected to AWX (could not parse response)",
 			"baseURL", c.baseURL)
 	}
 
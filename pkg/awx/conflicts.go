@@ -0,0 +1,86 @@
+package awx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// conflictAttempts is the number of times a write is tried while AWX
+	// answers 409 Conflict
+	conflictAttempts = 5
+	// conflictInitialBackoff is the delay before the first retry, doubled after each attempt
+	conflictInitialBackoff = time.Second
+	// conflictMaxBackoff caps the delay between two attempts
+	conflictMaxBackoff = 10 * time.Second
+)
+
+// ConflictError is returned when AWX kept rejecting a write with 409 Conflict,
+// usually because the object is locked by a running project sync or job
+type ConflictError struct {
+	Operation string
+	Attempts  int
+	Err       error
+}
+
+// Error implements the error interface
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s is locked by AWX after %d attempts: %v", e.Operation, e.Attempts, e.Err)
+}
+
+// Unwrap returns the last error returned by AWX
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// AsConflictError returns the ConflictError wrapped in err, if any
+func AsConflictError(err error) (*ConflictError, bool) {
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr, true
+	}
+	return nil, false
+}
+
+// retryOnConflict runs fn and retries it with exponential backoff while AWX
+// answers 409 Conflict. Other errors are returned immediately. The backoff
+// ends with the context of the client, returning ErrDeadlineExceeded at its
+// deadline, so a locked object doesn't hold a reconcile beyond it.
+func (c *Client) retryOnConflict(operation string, fn func() error) error {
+	ctx := c.Context()
+	backoff := conflictInitialBackoff
+	var err error
+	for attempt := 1; attempt <= conflictAttempts; attempt++ {
+		err = fn()
+		if err == nil || !IsStatus(err, http.StatusConflict) {
+			return err
+		}
+		if attempt == conflictAttempts {
+			break
+		}
+
+		c.log.Info("AWX object is locked, retrying",
+			"operation", operation,
+			"attempt", attempt,
+			"backoff", backoff.String())
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%s: %w", operation, ErrDeadlineExceeded)
+			}
+			return fmt.Errorf("%s: %w", operation, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > conflictMaxBackoff {
+			backoff = conflictMaxBackoff
+		}
+	}
+
+	return &ConflictError{Operation: operation, Attempts: conflictAttempts, Err: err}
+}
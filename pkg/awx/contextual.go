@@ -0,0 +1,51 @@
+package awx
+
+import "context"
+
+// The methods below are the context-first forms of the generic object API for
+// programs that pass a context per call, like database/sql. Each sends its
+// requests within ctx through a client derived with WithContext; the managers
+// take their context from the client they are created with.
+
+// PingContext reads the ping endpoint within ctx, see Ping
+func (c *Client) PingContext(ctx context.Context) (*PingInfo, error) {
+	return c.WithContext(ctx).Ping()
+}
+
+// TestConnectionContext tests the connection to AWX within ctx, see
+// TestConnection
+func (c *Client) TestConnectionContext(ctx context.Context) error {
+	return c.WithContext(ctx).TestConnection()
+}
+
+// GetObjectContext retrieves an object within ctx, see GetObject
+func (c *Client) GetObjectContext(ctx context.Context, endpoint string, id int) (map[string]interface{}, error) {
+	return c.WithContext(ctx).GetObject(endpoint, id)
+}
+
+// FindObjectByNameContext finds an object by its name within ctx, see
+// FindObjectByName
+func (c *Client) FindObjectByNameContext(ctx context.Context, endpoint, name string) (map[string]interface{}, error) {
+	return c.WithContext(ctx).FindObjectByName(endpoint, name)
+}
+
+// ListAllObjectsContext lists all objects matching the filters within ctx,
+// see ListAllObjects
+func (c *Client) ListAllObjectsContext(ctx context.Context, endpoint string, filters map[string]string) ([]map[string]interface{}, error) {
+	return c.WithContext(ctx).ListAllObjects(endpoint, filters)
+}
+
+// CreateObjectContext creates an object within ctx, see CreateObject
+func (c *Client) CreateObjectContext(ctx context.Context, endpoint string, payload map[string]interface{}, expectedObj string) (map[string]interface{}, error) {
+	return c.WithContext(ctx).CreateObject(endpoint, payload, expectedObj)
+}
+
+// UpdateObjectContext updates an object within ctx, see UpdateObject
+func (c *Client) UpdateObjectContext(ctx context.Context, endpoint string, id int, data map[string]interface{}) (map[string]interface{}, error) {
+	return c.WithContext(ctx).UpdateObject(endpoint, id, data)
+}
+
+// DeleteObjectContext deletes an object within ctx, see DeleteObject
+func (c *Client) DeleteObjectContext(ctx context.Context, endpoint string, id int) error {
+	return c.WithContext(ctx).DeleteObject(endpoint, id)
+}
@@ -0,0 +1,161 @@
+package awx
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx/awxtest"
+)
+
+// contractFixtures holds one fixture per supported AWX version. Recorded
+// fixtures carry the date of their recording. Fixtures named synthetic-* were
+// written by hand after the AWX API documentation, only pin the requests the
+// managers send and stand in until a recording against that version replaces
+// them.
+const contractFixtures = "testdata/contracts"
+
+// recordContract records a new fixture against the AWX at AWX_CONTRACT_URL
+// instead of replaying the existing ones:
+//
+//	AWX_CONTRACT_URL=https://awx.example.com AWX_CONTRACT_USER=admin AWX_CONTRACT_PASSWORD=... \
+//	  go test ./pkg/awx -run TestContracts -record-contract
+var recordContract = flag.Bool("record-contract", false,
+	"record a contract fixture against the AWX instance at AWX_CONTRACT_URL")
+
+// runContractScenario drives the managers through the requests covered by
+// the contract fixtures. The AWX instance must not contain the objects yet.
+func runContractScenario(awxClient *Client) error {
+	if err := awxClient.TestConnection(); err != nil {
+		return err
+	}
+
+	pm := NewProjectManager(awxClient)
+	projectSpec := awxv1alpha1.ProjectSpec{
+		Name:    "contract-project",
+		SCMType: "git",
+		SCMUrl:  "https://github.com/ansible/ansible-tower-samples.git",
+	}
+	if _, err := pm.EnsureProject(projectSpec); err != nil {
+		return fmt.Errorf("create project: %w", err)
+	}
+	projectSpec.Description = "updated"
+	if _, err := pm.EnsureProject(projectSpec); err != nil {
+		return fmt.Errorf("update project: %w", err)
+	}
+
+	im := NewInventoryManager(awxClient)
+	inventorySpec := awxv1alpha1.InventorySpec{
+		Name:  "contract-inventory",
+		Hosts: []awxv1alpha1.HostSpec{{Name: "contract-host"}},
+	}
+	if _, err := im.EnsureInventory(inventorySpec); err != nil {
+		return fmt.Errorf("create inventory: %w", err)
+	}
+
+	if err := im.DeleteInventory(inventorySpec.Name); err != nil {
+		return fmt.Errorf("delete inventory: %w", err)
+	}
+	if err := pm.DeleteProject(projectSpec.Name); err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+	return nil
+}
+
+// TestContracts replays the recorded fixtures of every supported AWX version
+// and checks that the managers still send exactly the recorded requests and
+// understand the responses
+func TestContracts(t *testing.T) {
+	if *recordContract {
+		recordContractFixture(t)
+		return
+	}
+
+	paths, err := filepath.Glob(filepath.Join(contractFixtures, "*.json"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, paths, "No contract fixtures found")
+
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			fixture, err := awxtest.LoadFixture(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			synthetic := strings.HasPrefix(filepath.Base(path), "synthetic-")
+			assert.Equal(t, synthetic, fixture.Recorded == "",
+				"Only fixtures named synthetic-* may lack the date of their recording")
+			if synthetic {
+				t.Logf("AWX %s is only covered by a synthetic fixture, record one with ./deploy.sh e2e-record", fixture.Version)
+			}
+			server := awxtest.NewReplayServer(fixture)
+			defer server.Close()
+
+			awxClient := NewClient(server.URL, "admin", "password")
+			if fixture.APIPath != "" {
+				awxClient.SetAPIPath(fixture.APIPath)
+			}
+			err = runContractScenario(awxClient)
+			assert.NoError(t, err, "AWX %s", fixture.Version)
+			assert.Empty(t, server.Mismatches(), "Requests deviate from the fixture of AWX %s", fixture.Version)
+			assert.Empty(t, server.Remaining(), "Recorded requests were not sent to AWX %s", fixture.Version)
+		})
+	}
+}
+
+// recordContractFixture runs the scenario against a real AWX instance and
+// writes the recorded interactions as a new fixture named after its version
+func recordContractFixture(t *testing.T) {
+	baseURL := os.Getenv("AWX_CONTRACT_URL")
+	if baseURL == "" {
+		t.Fatal("AWX_CONTRACT_URL must be set to record a contract fixture")
+	}
+
+	awxClient := NewClient(baseURL, os.Getenv("AWX_CONTRACT_USER"), os.Getenv("AWX_CONTRACT_PASSWORD"))
+	apiPath, err := awxClient.DetectAPIPath()
+	if err != nil {
+		t.Fatalf("Could not reach %s: %v", baseURL, err)
+	}
+	awxClient.StartRecording()
+	if err := runContractScenario(awxClient); err != nil {
+		t.Fatalf("Contract scenario failed against %s: %v", baseURL, err)
+	}
+
+	interactions := awxClient.RecordedInteractions()
+	var ping struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(interactions[0].Body, &ping); err != nil || ping.Version == "" {
+		t.Fatalf("Could not read the AWX version from the ping response: %v", err)
+	}
+
+	name := os.Getenv("AWX_CONTRACT_NAME")
+	if name == "" {
+		name = "awx-" + ping.Version
+	}
+	fixture := awxtest.Fixture{
+		Version:      ping.Version,
+		Recorded:     time.Now().UTC().Format(time.DateOnly),
+		Interactions: make([]awxtest.Interaction, 0, len(interactions)),
+	}
+	if apiPath != KnownAPIPaths[0] {
+		fixture.APIPath = apiPath
+	}
+	for _, interaction := range interactions {
+		fixture.Interactions = append(fixture.Interactions, awxtest.Interaction(interaction))
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	assert.NoError(t, err)
+
+	path := filepath.Join(contractFixtures, name+".json")
+	assert.NoError(t, os.MkdirAll(contractFixtures, 0o755))
+	assert.NoError(t, os.WriteFile(path, append(data, '\n'), 0o644))
+	t.Logf("Recorded %d interactions with AWX %s to %s", len(interactions), ping.Version, path)
+}
@@ -0,0 +1,158 @@
+package awx
+
+import (
+	"fmt"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// encryptedValue is returned by AWX in place of secret credential inputs
+const encryptedValue = "$encrypted$"
+
+// CredentialManager handles AWX Credential resources
+type CredentialManager struct {
+	client *Client
+}
+
+// NewCredentialManager creates a new CredentialManager
+func NewCredentialManager(client *Client) *CredentialManager {
+	return &CredentialManager{
+		client: client,
+	}
+}
+
+// GetCredential retrieves a credential by name
+func (cm *CredentialManager) GetCredential(name string) (map[string]interface{}, error) {
+	cm.client.log.Info("Fetching credential by name", "name", name)
+	return cm.client.FindObjectByName("credentials", name)
+}
+
+// IsCredentialInDesiredState checks if the credential matches the desired
+// specification. Secret inputs can't be read back from AWX and are not compared.
+func (cm *CredentialManager) IsCredentialInDesiredState(object map[string]interface{}, credentialSpec awxv1alpha1.CredentialSpec) bool {
+	credential, err := DecodeObject[Credential](object)
+	if err != nil {
+		return false
+	}
+
+	// Check name and description
+	if credential.Name != credentialSpec.Name || credential.Description != credentialSpec.Description {
+		return false
+	}
+
+	// Check credential type
+	credentialType, err := cm.client.CredentialType(credentialSpec.Kind)
+	if err != nil || credential.CredentialType != credentialType.ID {
+		return false
+	}
+
+	// Check inputs
+	for field, desired := range credentialSpec.Inputs {
+		actual, ok := credential.Inputs[field]
+		if !ok {
+			return false
+		}
+		if actual == encryptedValue {
+			continue
+		}
+		if fmt.Sprint(actual) != desired {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EnsureCredential ensures that a credential exists with the specified
+// configuration, validating its inputs against the credential type first
+func (cm *CredentialManager) EnsureCredential(credentialSpec awxv1alpha1.CredentialSpec) (map[string]interface{}, error) {
+	cm.client.log.Info("Ensuring credential exists with desired configuration", "name", credentialSpec.Name)
+
+	credentialType, err := cm.client.CredentialType(credentialSpec.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credential kind: %w", err)
+	}
+	if err := credentialType.ValidateInputs(credentialSpec.Kind, credentialSpec.Inputs); err != nil {
+		return nil, err
+	}
+
+	credential, err := cm.client.FindObjectByName("credentials", credentialSpec.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if credential exists: %w", err)
+	}
+
+	inputs := make(map[string]interface{}, len(credentialSpec.Inputs))
+	for field, value := range credentialSpec.Inputs {
+		inputs[field] = value
+	}
+
+	// Using default organization (ID 1) like projects do
+	credentialData := map[string]interface{}{
+		"name":            credentialSpec.Name,
+		"description":     credentialSpec.Description,
+		"organization":    1,
+		"credential_type": credentialType.ID,
+		"inputs":          inputs,
+	}
+
+	if credential == nil {
+		cm.client.log.Info("Creating AWX credential", "name", credentialSpec.Name, "kind", credentialSpec.Kind)
+		credential, err = cm.client.CreateSensitiveObject("credentials", credentialData, "credential")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create credential: %w", err)
+		}
+		if _, ok := credential["id"]; !ok {
+			return nil, fmt.Errorf("created credential '%s' has no ID field", credentialSpec.Name)
+		}
+
+		cm.client.log.Info("Successfully created credential", "name", credentialSpec.Name, "id", credential["id"])
+		return credential, nil
+	}
+
+	id, err := getObjectID(credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ID from existing credential '%s': %w", credentialSpec.Name, err)
+	}
+
+	cm.client.log.Info("Updating AWX credential", "name", credentialSpec.Name, "id", id)
+	err = cm.client.retryOnConflict("update credential "+credentialSpec.Name, func() error {
+		credential, err = cm.client.UpdateSensitiveObject("credentials", id, credentialData)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update credential: %w", err)
+	}
+
+	cm.client.log.Info("Successfully updated credential", "name", credentialSpec.Name, "id", id)
+	return credential, nil
+}
+
+// DeleteCredential deletes a credential by name
+func (cm *CredentialManager) DeleteCredential(name string) error {
+	cm.client.log.Info("Deleting credential", "name", name)
+
+	credential, err := cm.client.FindObjectByName("credentials", name)
+	if err != nil {
+		return fmt.Errorf("failed to check if credential exists: %w", err)
+	}
+	if credential == nil {
+		cm.client.log.Info("Credential already deleted", "name", name)
+		return nil
+	}
+
+	id, err := getObjectID(credential)
+	if err != nil {
+		return fmt.Errorf("failed to get credential ID: %w", err)
+	}
+
+	cm.client.log.Info("Deleting AWX credential", "name", name, "id", id)
+	err = cm.client.retryOnConflict("delete credential "+name, func() error {
+		return cm.client.DeleteObject("credentials", id)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete credential %s: %w", name, err)
+	}
+
+	cm.client.log.Info("Successfully deleted credential", "name", name)
+	return nil
+}
@@ -0,0 +1,153 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// credentialTypesTTL is how long the credential type catalog is cached
+const credentialTypesTTL = 10 * time.Minute
+
+// CredentialType describes an AWX credential type and the inputs it accepts
+type CredentialType struct {
+	ID        int
+	Name      string
+	Namespace string
+	Fields    []string
+	Required  []string
+}
+
+// credentialTypeResponse is a credential type as returned by the AWX API
+type credentialTypeResponse struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Inputs    struct {
+		Fields []struct {
+			ID string `json:"id"`
+		} `json:"fields"`
+		Required []string `json:"required"`
+	} `json:"inputs"`
+}
+
+// matches reports whether kind names the credential type, either by its
+// namespace (e.g. "ssh") or case-insensitively by its name (e.g. "machine")
+func (t *CredentialType) matches(kind string) bool {
+	return (t.Namespace != "" && t.Namespace == kind) || strings.EqualFold(t.Name, kind)
+}
+
+// ValidateInputs checks that the inputs provide every required field of the
+// credential type and no fields it doesn't define
+func (t *CredentialType) ValidateInputs(kind string, inputs map[string]string) error {
+	var problems []string
+	for _, field := range t.Required {
+		if inputs[field] == "" {
+			problems = append(problems, fmt.Sprintf("field '%s' required for kind %s", field, kind))
+		}
+	}
+
+	var unknown []string
+	for field := range inputs {
+		if !slices.Contains(t.Fields, field) {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	for _, field := range unknown {
+		problems = append(problems, fmt.Sprintf("field '%s' is not defined for kind %s", field, kind))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid credential inputs: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CredentialType returns the credential type named by kind from the cached
+// catalog, refreshing the catalog when it is stale or doesn't know the kind
+func (c *Client) CredentialType(kind string) (*CredentialType, error) {
+	c.credentialTypesMu.Lock()
+	defer c.credentialTypesMu.Unlock()
+
+	if time.Since(c.credentialTypesFetched) < credentialTypesTTL {
+		if credentialType := findCredentialType(c.credentialTypes, kind); credentialType != nil {
+			return credentialType, nil
+		}
+	}
+
+	credentialTypes, err := c.listCredentialTypes()
+	if err != nil {
+		return nil, err
+	}
+	c.credentialTypes = credentialTypes
+	c.credentialTypesFetched = time.Now()
+
+	if credentialType := findCredentialType(credentialTypes, kind); credentialType != nil {
+		return credentialType, nil
+	}
+	return nil, fmt.Errorf("unknown credential kind %s", kind)
+}
+
+// CachedCredentialType returns the credential type named by kind from the
+// cached catalog without contacting AWX. It reports false when no catalog was
+// fetched within credentialTypesTTL; a kind the cached catalog doesn't know
+// is returned as nil.
+func (c *Client) CachedCredentialType(kind string) (*CredentialType, bool) {
+	c.credentialTypesMu.Lock()
+	defer c.credentialTypesMu.Unlock()
+
+	if c.credentialTypesFetched.IsZero() || time.Since(c.credentialTypesFetched) >= credentialTypesTTL {
+		return nil, false
+	}
+	return findCredentialType(c.credentialTypes, kind), true
+}
+
+// listCredentialTypes fetches all credential types from AWX, following the
+// pages of the list
+func (c *Client) listCredentialTypes() ([]CredentialType, error) {
+	objects, err := c.ListAllObjects("credential_types", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credential types: %w", err)
+	}
+
+	credentialTypes := make([]CredentialType, 0, len(objects))
+	for _, obj := range objects {
+		// Round-trip through JSON to decode the nested inputs schema
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode credential type: %w", err)
+		}
+		var resp credentialTypeResponse
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse credential type: %w", err)
+		}
+
+		credentialType := CredentialType{
+			ID:        resp.ID,
+			Name:      resp.Name,
+			Namespace: resp.Namespace,
+			Required:  resp.Inputs.Required,
+		}
+		for _, field := range resp.Inputs.Fields {
+			credentialType.Fields = append(credentialType.Fields, field.ID)
+		}
+		credentialTypes = append(credentialTypes, credentialType)
+	}
+
+	c.log.Info("Loaded credential type catalog", "baseURL", c.baseURL, "count", len(credentialTypes))
+	return credentialTypes, nil
+}
+
+// findCredentialType returns the credential type named by kind, if any
+func findCredentialType(credentialTypes []CredentialType, kind string) *CredentialType {
+	for i := range credentialTypes {
+		if credentialTypes[i].matches(kind) {
+			return &credentialTypes[i]
+		}
+	}
+	return nil
+}
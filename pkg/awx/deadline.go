@@ -0,0 +1,61 @@
+package awx
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDeadlineExceeded is returned for requests refused or canceled because
+// the deadline of the context of the client passed
+var ErrDeadlineExceeded = errors.New("request deadline exceeded")
+
+// IsDeadlineExceeded reports whether err was caused by the deadline of the
+// client rather than by AWX
+func IsDeadlineExceeded(err error) bool {
+	return errors.Is(err, ErrDeadlineExceeded)
+}
+
+// WithContext returns a client that shares the connection, session token
+// and caches of c and sends its requests within ctx, e.g. bounded by the
+// deadline of a reconcile. Requests still running when ctx ends are canceled
+// and later ones are refused. c itself is left unchanged, so concurrent users
+// of a cached client don't see each other's deadlines.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{clientState: c.clientState, ctx: ctx}
+}
+
+// Shares reports whether c and other were derived from the same client and
+// share its state
+func (c *Client) Shares(other *Client) bool {
+	return other != nil && c.clientState == other.clientState
+}
+
+// Context returns the context the requests of the client are sent within
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Deadline returns the deadline of the context of the client, the zero time
+// if it has none
+func (c *Client) Deadline() time.Time {
+	deadline, _ := c.Context().Deadline()
+	return deadline
+}
+
+// requestContext returns the context of a request, canceled when the context
+// of the client ends
+func (c *Client) requestContext() (context.Context, context.CancelFunc, error) {
+	ctx := c.Context()
+	if err := ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, nil, ErrDeadlineExceeded
+		}
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, nil
+}
@@ -0,0 +1,115 @@
+package awx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dependentFilters maps the endpoints whose objects job templates depend on
+// to the job template field referencing them
+var dependentFilters = map[string]string{
+	"projects":    "project",
+	"inventories": "inventory",
+}
+
+// DependencyError is returned when AWX refuses to delete an object because
+// other AWX objects still use it
+type DependencyError struct {
+	// Kind is the kind of the object that can't be deleted, e.g. "project"
+	Kind string
+	Name string
+	// Blockers lists the objects using it, e.g. "job template deploy" or "job 42"
+	Blockers []string
+	Err      error
+}
+
+// Error implements the error interface
+func (e *DependencyError) Error() string {
+	if len(e.Blockers) == 0 {
+		return fmt.Sprintf("%s %s is still in use: %v", e.Kind, e.Name, e.Err)
+	}
+	return fmt.Sprintf("%s %s is still used by %s", e.Kind, e.Name, strings.Join(e.Blockers, ", "))
+}
+
+// Unwrap returns the error returned by AWX
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// AsDependencyError returns the DependencyError wrapped in err, if any
+func AsDependencyError(err error) (*DependencyError, bool) {
+	var dependencyErr *DependencyError
+	if errors.As(err, &dependencyErr) {
+		return dependencyErr, true
+	}
+	return nil, false
+}
+
+// DependentJobTemplates returns the names of the job templates using the
+// object with the given name, e.g. the job templates running a project
+func (c *Client) DependentJobTemplates(endpoint, name string) ([]string, error) {
+	field, ok := dependentFilters[endpoint]
+	if !ok {
+		return nil, nil
+	}
+
+	object, err := c.FindObjectByName(endpoint, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s %s: %w", endpoint, name, err)
+	}
+	if object == nil {
+		return nil, nil
+	}
+	id, err := getObjectID(object)
+	if err != nil {
+		return nil, err
+	}
+
+	jobTemplates, err := c.ListObjects("job_templates", map[string]string{field: strconv.Itoa(id)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job templates using %s %s: %w", endpoint, name, err)
+	}
+	names := make([]string, 0, len(jobTemplates))
+	for _, jobTemplate := range jobTemplates {
+		if jobTemplateName, ok := jobTemplate["name"].(string); ok {
+			names = append(names, jobTemplateName)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// activeJobsResponse is the body of AWX's answer to deleting an object that
+// is used by running jobs
+type activeJobsResponse struct {
+	ActiveJobs []struct {
+		Type string `json:"type"`
+		ID   int    `json:"id"`
+	} `json:"active_jobs"`
+}
+
+// asDependencyError turns a 409 or 400 answer to a delete request into a
+// DependencyError naming the active jobs AWX reported. Other errors are
+// returned unchanged.
+func asDependencyError(kind, name string, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) ||
+		(apiErr.StatusCode != http.StatusConflict && apiErr.StatusCode != http.StatusBadRequest) {
+		return err
+	}
+
+	dependencyErr := &DependencyError{Kind: kind, Name: name, Err: err}
+	var response activeJobsResponse
+	if json.Unmarshal([]byte(apiErr.Body), &response) == nil {
+		for _, job := range response.ActiveJobs {
+			jobType := strings.ReplaceAll(job.Type, "_", " ")
+			dependencyErr.Blockers = append(dependencyErr.Blockers, fmt.Sprintf("%s %d", jobType, job.ID))
+		}
+	}
+	return dependencyErr
+}
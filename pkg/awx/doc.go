@@ -0,0 +1,17 @@
+// Package awx is the client the operator uses for the AWX REST API:
+//
+//	client := awx.NewClient("https://awx.example.com", "admin", password)
+//	project, err := client.FindObjectByName("projects", "web")
+//
+// The logger and metrics are injectable. Logs go to the logr.Logger set with
+// SetLogger, or to the logger of a single client set with Client.SetLogger,
+// and the metrics returned by Collectors can be registered with any
+// Prometheus registry. Process wide settings such as transport options or
+// protected names are set with the Set functions.
+//
+// The package is a module of its own, versioned with pkg/awx/vX.Y.Z tags. It
+// requires the operator module only for the spec types of api/v1alpha1 that
+// the managers, e.g. ProjectManager, take, and not controller-runtime. The
+// generic methods have context-first forms, e.g. GetObjectContext. The client
+// reports its Version in the User-Agent.
+package awx
@@ -0,0 +1,126 @@
+package awx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultThrottleDelay is used when AWX throttles a request without saying
+// when to retry
+const defaultThrottleDelay = 30 * time.Second
+
+// APIError is returned when the AWX API answers with a non-2xx status code
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the Retry-After header, if any
+	RetryAfter time.Duration
+	// Fields are the validation errors of a 400 response, if any
+	Fields FieldErrors
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsStatus reports whether err is an APIError with the given status code
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// PlaybookNotFoundError is returned when a job template references a playbook
+// that is not available in its project
+type PlaybookNotFoundError struct {
+	Playbook string
+	Project  string
+}
+
+// Error implements the error interface
+func (e *PlaybookNotFoundError) Error() string {
+	return fmt.Sprintf("playbook %s not found in project %s", e.Playbook, e.Project)
+}
+
+// ReferenceNotFoundError is returned when a resource references an object by
+// name that doesn't exist, e.g. a job template naming a missing project
+type ReferenceNotFoundError struct {
+	// Kind is the kind of the missing object, e.g. "project" or "Secret"
+	Kind string
+	Name string
+}
+
+// Error implements the error interface
+func (e *ReferenceNotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Kind, e.Name)
+}
+
+// AsReferenceNotFoundError returns the ReferenceNotFoundError wrapped in err, if any
+func AsReferenceNotFoundError(err error) (*ReferenceNotFoundError, bool) {
+	var refErr *ReferenceNotFoundError
+	if errors.As(err, &refErr) {
+		return refErr, true
+	}
+	return nil, false
+}
+
+// RetryAfter reports whether err was caused by AWX throttling or being
+// temporarily unavailable, or by the open circuit breaker, and how long to
+// wait before retrying
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable) {
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter, true
+		}
+		return defaultThrottleDelay, true
+	}
+
+	var circuitErr *CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		return time.Until(circuitErr.RetryAt), true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// MassDeletionError is returned when reconciling an inventory would delete
+// more hosts than its deletion threshold allows
+type MassDeletionError struct {
+	Inventory     string
+	ExistingHosts int
+	HostsToDelete int
+	MaxPercent    int32
+}
+
+// Error implements the error interface
+func (e *MassDeletionError) Error() string {
+	return fmt.Sprintf("refusing to delete %d of %d hosts from inventory %s (limit %d%%); set allowMassDeletion to proceed",
+		e.HostsToDelete, e.ExistingHosts, e.Inventory, e.MaxPercent)
+}
+
+// AsMassDeletionError returns the MassDeletionError wrapped in err, if any
+func AsMassDeletionError(err error) (*MassDeletionError, bool) {
+	var massErr *MassDeletionError
+	if errors.As(err, &massErr) {
+		return massErr, true
+	}
+	return nil, false
+}
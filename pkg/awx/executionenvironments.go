@@ -0,0 +1,76 @@
+package awx
+
+import (
+	"fmt"
+)
+
+// Sources of the execution environment a job template runs in, from the most
+// to the least specific
+const (
+	ExecutionEnvironmentSourceJobTemplate  = "jobTemplate"
+	ExecutionEnvironmentSourceProject      = "project"
+	ExecutionEnvironmentSourceOrganization = "organization"
+	ExecutionEnvironmentSourceDefault      = "default"
+)
+
+// EffectiveExecutionEnvironment is the execution environment a job template
+// runs in and where it was configured. AWX falls back from the job template to
+// the default environment of its project and then of its organization.
+type EffectiveExecutionEnvironment struct {
+	// Name of the execution environment, empty when AWX picks its global default
+	Name string
+	// Source is one of the ExecutionEnvironmentSource constants
+	Source string
+}
+
+// String formats the execution environment for the status, e.g. "organization: AWX EE (latest)"
+func (e *EffectiveExecutionEnvironment) String() string {
+	if e.Source == ExecutionEnvironmentSourceDefault {
+		return e.Source
+	}
+	return fmt.Sprintf("%s: %s", e.Source, e.Name)
+}
+
+// EffectiveExecutionEnvironment resolves the execution environment the job
+// template runs in, following the inheritance of AWX
+func (jtm *JobTemplateManager) EffectiveExecutionEnvironment(jobTemplate map[string]interface{}) (*EffectiveExecutionEnvironment, error) {
+	if id, ok := jobTemplate["execution_environment"].(float64); ok {
+		return jtm.executionEnvironment(int(id), ExecutionEnvironmentSourceJobTemplate)
+	}
+
+	organizationID, hasOrganization := jobTemplate["organization"].(float64)
+	if projectID, ok := jobTemplate["project"].(float64); ok {
+		project, err := jtm.client.GetObject("projects", int(projectID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project %d: %w", int(projectID), err)
+		}
+		if id, ok := project["default_environment"].(float64); ok {
+			return jtm.executionEnvironment(int(id), ExecutionEnvironmentSourceProject)
+		}
+		if !hasOrganization {
+			organizationID, hasOrganization = project["organization"].(float64)
+		}
+	}
+
+	if hasOrganization {
+		organization, err := jtm.client.GetObject("organizations", int(organizationID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get organization %d: %w", int(organizationID), err)
+		}
+		if id, ok := organization["default_environment"].(float64); ok {
+			return jtm.executionEnvironment(int(id), ExecutionEnvironmentSourceOrganization)
+		}
+	}
+
+	return &EffectiveExecutionEnvironment{Source: ExecutionEnvironmentSourceDefault}, nil
+}
+
+// executionEnvironment looks up the name of an execution environment
+func (jtm *JobTemplateManager) executionEnvironment(id int, source string) (*EffectiveExecutionEnvironment, error) {
+	executionEnvironment, err := jtm.client.GetObject("execution_environments", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution environment %d: %w", id, err)
+	}
+	name, _ := executionEnvironment["name"].(string)
+	return &EffectiveExecutionEnvironment{Name: name, Source: source}, nil
+}
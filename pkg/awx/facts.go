@@ -0,0 +1,128 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostFacts are the facts of a host that are published in the instance
+// status, taken from the Ansible facts AWX gathered in the last job
+type HostFacts struct {
+	OS           string
+	Kernel       string
+	Architecture string
+	Addresses    []string
+	// Modified is when AWX last stored facts of the host
+	Modified time.Time
+}
+
+// factValue returns a fact as a string. AWX stores facts with the ansible_
+// prefix, older versions and custom fact caches without it.
+func factValue(facts map[string]interface{}, name string) string {
+	for _, key := range []string{"ansible_" + name, name} {
+		if value, ok := facts[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// factList returns a fact holding a list of strings
+func factList(facts map[string]interface{}, name string) []string {
+	for _, key := range []string{"ansible_" + name, name} {
+		if values, ok := facts[key].([]interface{}); ok {
+			result := make([]string, 0, len(values))
+			for _, value := range values {
+				if value, ok := value.(string); ok {
+					result = append(result, value)
+				}
+			}
+			return result
+		}
+	}
+	return nil
+}
+
+// parseHostFacts picks the published facts from the Ansible facts of a host
+func parseHostFacts(facts map[string]interface{}) HostFacts {
+	return HostFacts{
+		OS:           strings.TrimSpace(factValue(facts, "distribution") + " " + factValue(facts, "distribution_version")),
+		Kernel:       factValue(facts, "kernel"),
+		Architecture: factValue(facts, "architecture"),
+		Addresses:    append(factList(facts, "all_ipv4_addresses"), factList(facts, "all_ipv6_addresses")...),
+	}
+}
+
+// hostFacts reads the Ansible facts of a host
+func (im *InventoryManager) hostFacts(hostID int) (map[string]interface{}, error) {
+	respBody, err := im.client.doRequest(http.MethodGet, fmt.Sprintf("hosts/%d/ansible_facts", hostID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var facts map[string]interface{}
+	if err := json.Unmarshal(respBody, &facts); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return facts, nil
+}
+
+// HostFacts reads the facts of the named hosts of an inventory. Hosts that
+// don't exist or for which AWX never gathered facts are left out. The facts
+// are read with the configured host concurrency.
+func (im *InventoryManager) HostFacts(inventoryName string, hostNames []string) (map[string]HostFacts, error) {
+	inventory, err := im.GetInventory(inventoryName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+	if inventory == nil {
+		return nil, &ReferenceNotFoundError{Kind: "inventory", Name: inventoryName}
+	}
+	inventoryID, err := getObjectID(inventory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory ID: %w", err)
+	}
+
+	hosts, err := im.client.ListAllObjects(fmt.Sprintf("inventories/%d/hosts", inventoryID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	wanted := make(map[string]bool, len(hostNames))
+	for _, name := range hostNames {
+		wanted[name] = true
+	}
+
+	var mu sync.Mutex
+	result := make(map[string]HostFacts)
+	var tasks []func() error
+	for _, host := range hosts {
+		name, _ := host["name"].(string)
+		modified, _ := host["ansible_facts_modified"].(string)
+		if !wanted[name] || modified == "" {
+			continue
+		}
+		hostID, err := getObjectID(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ID of host %s: %w", name, err)
+		}
+		tasks = append(tasks, func() error {
+			facts, err := im.hostFacts(hostID)
+			if err != nil {
+				return fmt.Errorf("failed to read facts of host %s: %w", name, err)
+			}
+			hostFacts := parseHostFacts(facts)
+			hostFacts.Modified, _ = time.Parse(time.RFC3339, modified)
+			mu.Lock()
+			result[name] = hostFacts
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := runConcurrently(int(hostConcurrency.Load()), tasks); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
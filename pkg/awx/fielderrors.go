@@ -0,0 +1,128 @@
+package awx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldErrors are the validation errors AWX answers 400 Bad Request with,
+// keyed by field, e.g. {"playbook": ["Playbook not found for project."]}.
+// Errors of nested fields such as credential inputs are keyed by their path,
+// e.g. "inputs.username".
+type FieldErrors map[string][]string
+
+// nonFieldKeys hold errors that don't belong to a single field
+var nonFieldKeys = map[string]bool{
+	"__all__":          true,
+	"non_field_errors": true,
+	"detail":           true,
+	"error":            true,
+}
+
+// parseFieldErrors extracts the field errors of a 400 response. Other
+// responses and bodies that are not JSON objects return nil.
+func parseFieldErrors(statusCode int, body string) FieldErrors {
+	if statusCode != http.StatusBadRequest {
+		return nil
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return nil
+	}
+	fields := make(FieldErrors)
+	collectFieldErrors(fields, "", response)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// collectFieldErrors adds the messages of a decoded error response to fields,
+// descending into nested objects
+func collectFieldErrors(fields FieldErrors, prefix string, response map[string]interface{}) {
+	for key, value := range response {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case string:
+			fields[field] = append(fields[field], value)
+		case []interface{}:
+			for _, message := range value {
+				if message, ok := message.(string); ok {
+					fields[field] = append(fields[field], message)
+				}
+			}
+		case map[string]interface{}:
+			collectFieldErrors(fields, field, value)
+		}
+	}
+}
+
+// readableFieldError turns an AWX message into a status message, naming the
+// field unless the message already starts with it
+func readableFieldError(field, message string) string {
+	message = strings.TrimSuffix(strings.TrimSpace(message), ".")
+	if first, size := utf8.DecodeRuneInString(message); size > 0 {
+		// Acronyms such as "SCM" keep their case
+		if second, _ := utf8.DecodeRuneInString(message[size:]); !unicode.IsUpper(second) {
+			message = string(unicode.ToLower(first)) + message[size:]
+		}
+	}
+
+	leaf := field[strings.LastIndex(field, ".")+1:]
+	if nonFieldKeys[leaf] {
+		return message
+	}
+	if strings.HasPrefix(strings.ToLower(message), strings.ReplaceAll(leaf, "_", " ")) {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", field, message)
+}
+
+// String returns the errors as readable messages ordered by field
+func (f FieldErrors) String() string {
+	names := make([]string, 0, len(f))
+	for field := range f {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	var messages []string
+	for _, field := range names {
+		for _, message := range f[field] {
+			messages = append(messages, readableFieldError(field, message))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// AsFieldErrors returns the field errors of the APIError or ValidationError
+// wrapped in err, if any
+func AsFieldErrors(err error) (FieldErrors, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && len(apiErr.Fields) > 0 {
+		return apiErr.Fields, true
+	}
+	if validationErr, ok := AsValidationError(err); ok {
+		return validationErr.Fields, true
+	}
+	return nil, false
+}
+
+// StatusMessage describes why reconciling an object failed. Validation errors
+// of AWX are summarized per field, e.g. "job template 'deploy': playbook not
+// found for project", instead of repeating the raw response body.
+func StatusMessage(kind, name string, err error) string {
+	if fields, ok := AsFieldErrors(err); ok {
+		return fmt.Sprintf("%s '%s': %s", kind, name, fields)
+	}
+	return err.Error()
+}
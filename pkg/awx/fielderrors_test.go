@@ -0,0 +1,32 @@
+package awx
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFieldErrors verifies that AWX validation responses are turned into
+// readable messages per field
+func TestFieldErrors(t *testing.T) {
+	fields := parseFieldErrors(http.StatusBadRequest,
+		`{"playbook": ["Playbook not found for project."], "inputs": {"username": ["Required."]}, "__all__": ["SCM credential is required."]}`)
+	assert.Equal(t, FieldErrors{
+		"playbook":        {"Playbook not found for project."},
+		"inputs.username": {"Required."},
+		"__all__":         {"SCM credential is required."},
+	}, fields)
+	assert.Equal(t, "SCM credential is required; inputs.username: required; playbook not found for project", fields.String())
+
+	assert.Nil(t, parseFieldErrors(http.StatusBadRequest, "<html>Bad Request</html>"))
+	assert.Nil(t, parseFieldErrors(http.StatusConflict, `{"detail": "locked"}`))
+
+	err := fmt.Errorf("failed to create job template: %w", &APIError{StatusCode: http.StatusBadRequest, Fields: fields})
+	assert.Equal(t, "job template 'deploy': SCM credential is required; inputs.username: required; playbook not found for project",
+		StatusMessage("job template", "deploy", err))
+
+	plain := fmt.Errorf("failed to find project")
+	assert.Equal(t, plain.Error(), StatusMessage("job template", "deploy", plain))
+}
@@ -0,0 +1,140 @@
+package awx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// permissionMetadataTTL is how long the metadata of an endpoint is trusted
+// for the permission check, so granted permissions are picked up soon after.
+// Field validation keeps the metadata until AWX reports another version, as
+// the fields only change with an upgrade.
+const permissionMetadataTTL = 10 * time.Minute
+
+// fieldValidation controls whether payloads are validated against the
+// fields AWX advertises before they are sent
+var fieldValidation atomic.Bool
+
+func init() {
+	fieldValidation.Store(true)
+}
+
+// SetFieldValidation enables or disables validating payloads against the
+// OPTIONS metadata of AWX, typically once from operator flags at startup
+func SetFieldValidation(enabled bool) {
+	fieldValidation.Store(enabled)
+}
+
+// cachedMetadata is the field metadata of an endpoint and when it was read
+type cachedMetadata struct {
+	metadata *EndpointMetadata
+	fetched  time.Time
+}
+
+// ValidationError is returned when a payload doesn't match the fields AWX
+// advertises for the endpoint, e.g. a missing required field, a scm_type that
+// is not one of the choices or a field this AWX version doesn't know. The
+// request is not sent.
+type ValidationError struct {
+	Endpoint string
+	Method   string
+	Fields   FieldErrors
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s to %s: %s", e.Method, e.Endpoint, e.Fields)
+}
+
+// metadataKey returns the cache key of the metadata of an endpoint, with
+// object IDs replaced, e.g. "job_templates/*/schedules" for the schedules of
+// any job template
+func metadataKey(endpoint string) string {
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// endpointMetadata returns the OPTIONS metadata of an endpoint, cached per
+// endpoint kind. Metadata older than maxAge is read again, a maxAge of 0
+// keeps it until the AWX version changes.
+func (c *Client) endpointMetadata(endpoint string, maxAge time.Duration) (*EndpointMetadata, error) {
+	key := metadataKey(endpoint)
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	if cached, ok := c.metadata[key]; ok && (maxAge == 0 || time.Since(cached.fetched) < maxAge) {
+		return cached.metadata, nil
+	}
+
+	metadata, err := c.Options(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if c.metadata == nil {
+		c.metadata = make(map[string]cachedMetadata)
+	}
+	c.metadata[key] = cachedMetadata{metadata: metadata, fetched: time.Now()}
+	return metadata, nil
+}
+
+// validatePayload checks a POST or PATCH payload against the fields AWX
+// advertises. New objects are checked against the POST fields of the list
+// endpoint, updates against the PUT fields of the object without requiring
+// unchanged fields. Payloads are sent unchecked when the metadata can't be
+// read or doesn't list the action, e.g. for users without permission.
+func (c *Client) validatePayload(method, endpoint string, data map[string]interface{}) error {
+	if !fieldValidation.Load() {
+		return nil
+	}
+	metadata, err := c.endpointMetadata(endpoint, 0)
+	if err != nil {
+		c.log.Info("Sending payload without field validation", "endpoint", endpoint, "error", err.Error())
+		return nil
+	}
+
+	action := http.MethodPost
+	if method != http.MethodPost {
+		action = http.MethodPut
+	}
+	fields, ok := metadata.Actions[action]
+	if !ok {
+		return nil
+	}
+	if errs := validateFields(fields, data, method == http.MethodPost); errs != nil {
+		return &ValidationError{Endpoint: endpoint, Method: method, Fields: errs}
+	}
+	return nil
+}
+
+// observeVersion drops the cached metadata when AWX reports another version
+// than before, e.g. after an upgrade
+func (c *Client) observeVersion(version string) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	if version == c.version {
+		return
+	}
+	if c.version != "" {
+		c.log.Info("AWX version changed, reading field metadata again", "from", c.version, "to", version)
+		c.metadata = nil
+	}
+	c.version = version
+}
+
+// AsValidationError returns the ValidationError wrapped in err, if any
+func AsValidationError(err error) (*ValidationError, bool) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,225 @@
+// Command gen generates the typed AWX objects of package awx from the
+// OpenAPI schema AWX publishes at /api/swagger/?format=openapi. Only the
+// definitions listed with -definitions are generated, e.g. the objects the
+// operator manages:
+//
+//	go run ./gen -schema gen/schema.json -definitions Project,JobTemplate -out zz_generated.types.go
+//
+// The schema is read from a file or, to refresh the committed copy, from the
+// URL of a live AWX with the credentials in AWX_USERNAME and AWX_PASSWORD:
+//
+//	go run ./gen -schema 'https://awx.example.com/api/swagger/?format=openapi' -save gen/schema.json ...
+//
+// Both Swagger 2.0 definitions and OpenAPI 3 component schemas are read.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// schema is the part of a Swagger 2.0 or OpenAPI 3 document the generator reads
+type schema struct {
+	Definitions map[string]definition `json:"definitions"`
+	Components  struct {
+		Schemas map[string]definition `json:"schemas"`
+	} `json:"components"`
+}
+
+// definition is an object definition of the schema
+type definition struct {
+	Type       string              `json:"type"`
+	Properties map[string]property `json:"properties"`
+}
+
+// property is a field of a definition
+type property struct {
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ReadOnly    bool      `json:"readOnly"`
+	Nullable    bool      `json:"nullable"`
+	XNullable   bool      `json:"x-nullable"`
+	Items       *property `json:"items"`
+}
+
+// initialisms are the words of field names written in upper case in Go
+var initialisms = map[string]bool{
+	"api": true, "http": true, "https": true, "id": true, "json": true,
+	"scm": true, "ssh": true, "url": true, "uuid": true,
+}
+
+func main() {
+	schemaPath := flag.String("schema", "", "Path or URL of the AWX OpenAPI schema")
+	save := flag.String("save", "", "Path to save the schema read from a URL to")
+	definitions := flag.String("definitions", "", "Comma separated names of the definitions to generate")
+	packageName := flag.String("package", "awx", "Package of the generated file")
+	out := flag.String("out", "zz_generated.types.go", "Path of the generated file")
+	flag.Parse()
+
+	data, err := readSchema(*schemaPath, os.Getenv("AWX_USERNAME"), os.Getenv("AWX_PASSWORD"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *save != "" {
+		if err := os.WriteFile(*save, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	source, err := generate(data, *schemaPath, *packageName, strings.Split(*definitions, ","))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readSchema reads the schema from a file, or from the URL of an AWX with
+// basic authentication when the username is set
+func readSchema(location, username, password string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read schema from %s: %s", location, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// generate returns the formatted Go source of the named definitions of the
+// schema read from schemaPath
+func generate(data []byte, schemaPath, packageName string, names []string) ([]byte, error) {
+	var doc schema
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", schemaPath, err)
+	}
+	definitions := doc.Definitions
+	if len(definitions) == 0 {
+		definitions = doc.Components.Schemas
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by pkg/awx/gen from %s. DO NOT EDIT.\n\n", schemaPath)
+	fmt.Fprintf(&buf, "package %s\n", packageName)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		def, ok := definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("definition %s not found in schema %s", name, schemaPath)
+		}
+		writeStruct(&buf, name, def)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// writeStruct writes the struct of a definition, with its fields sorted by
+// their JSON name
+func writeStruct(buf *bytes.Buffer, name string, def definition) {
+	fields := make([]string, 0, len(def.Properties))
+	for field := range def.Properties {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	fmt.Fprintf(buf, "\n// %s is the %s object of the AWX API\n", name, name)
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	for i, field := range fields {
+		prop := def.Properties[field]
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if comment := fieldComment(prop); comment != "" {
+			fmt.Fprintf(buf, "// %s\n", comment)
+		}
+		tag := field
+		if prop.ReadOnly {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(buf, "%s %s `json:\"%s\"`\n", goName(field), goType(prop, true), tag)
+	}
+	buf.WriteString("}\n")
+}
+
+// fieldComment returns the doc comment of a field, its description or title
+// on a single line
+func fieldComment(prop property) string {
+	comment := prop.Description
+	if comment == "" {
+		comment = prop.Title
+	}
+	comment = strings.Join(strings.Fields(comment), " ")
+	if prop.ReadOnly {
+		if comment == "" {
+			return "Read-only."
+		}
+		comment = strings.TrimSuffix(comment, ".") + ". Read-only."
+	}
+	return comment
+}
+
+// goName converts a snake case field name to a Go field name, e.g.
+// scm_url to SCMURL
+func goName(field string) string {
+	var name strings.Builder
+	for _, word := range strings.Split(field, "_") {
+		if word == "" {
+			continue
+		}
+		if initialisms[word] {
+			name.WriteString(strings.ToUpper(word))
+			continue
+		}
+		name.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return name.String()
+}
+
+// goType returns the Go type of a property. Nullable scalars become
+// pointers when nullable is allowed, which it isn't for array items.
+func goType(prop property, nullable bool) string {
+	var typ string
+	switch prop.Type {
+	case "integer":
+		typ = "int"
+	case "number":
+		typ = "float64"
+	case "boolean":
+		typ = "bool"
+	case "string":
+		typ = "string"
+	case "array":
+		if prop.Items == nil {
+			return "[]interface{}"
+		}
+		return "[]" + goType(*prop.Items, false)
+	default:
+		return "map[string]interface{}"
+	}
+	if nullable && (prop.Nullable || prop.XNullable) {
+		return "*" + typ
+	}
+	return typ
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGeneratedTypesUpToDate verifies that the committed typed objects match
+// the schema and the definitions listed in the go:generate directive
+func TestGeneratedTypesUpToDate(t *testing.T) {
+	directive, err := os.ReadFile("../types.go")
+	assert.NoError(t, err)
+	match := regexp.MustCompile(`-definitions (\S+)`).FindSubmatch(directive)
+	assert.NotNil(t, match, "types.go should list the definitions to generate")
+
+	data, err := os.ReadFile("schema.json")
+	assert.NoError(t, err)
+	source, err := generate(data, "gen/schema.json", "awx", strings.Split(string(match[1]), ","))
+	assert.NoError(t, err)
+	committed, err := os.ReadFile("../zz_generated.types.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(committed), string(source), "Run go generate ./pkg/awx")
+
+	_, err = generate(data, "gen/schema.json", "awx", []string{"Team"})
+	assert.EqualError(t, err, "definition Team not found in schema gen/schema.json")
+}
+
+// TestReadSchemaFromAWX verifies that the schema is read from the URL of an
+// AWX with basic authentication
+func TestReadSchemaFromAWX(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeFile(w, r, "schema.json")
+	}))
+	defer server.Close()
+
+	data, err := readSchema(server.URL+"/api/swagger/?format=openapi", "admin", "secret")
+	assert.NoError(t, err)
+	local, err := readSchema("schema.json", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, local, data)
+
+	_, err = readSchema(server.URL+"/api/swagger/?format=openapi", "admin", "wrong")
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
+
+// TestGoNamesAndTypes verifies the Go names and types of schema properties
+func TestGoNamesAndTypes(t *testing.T) {
+	assert.Equal(t, "SCMURL", goName("scm_url"))
+	assert.Equal(t, "AskInventoryOnLaunch", goName("ask_inventory_on_launch"))
+	assert.Equal(t, "ID", goName("id"))
+
+	assert.Equal(t, "*int", goType(property{Type: "integer", XNullable: true}, true))
+	assert.Equal(t, "*string", goType(property{Type: "string", Nullable: true}, true))
+	assert.Equal(t, "[]string", goType(property{Type: "array", Items: &property{Type: "string", Nullable: true}}, true))
+	assert.Equal(t, "map[string]interface{}", goType(property{Type: "object"}, true))
+	assert.Equal(t, "Read-only.", fieldComment(property{ReadOnly: true}))
+	assert.Equal(t, "The last revision. Read-only.", fieldComment(property{Description: "The last\n   revision.", ReadOnly: true}))
+}
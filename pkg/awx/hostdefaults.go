@@ -0,0 +1,191 @@
+package awx
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// maxReportedHostDrift is the number of drifted hosts described in the
+// drift of an inventory, so large inventories keep a readable condition
+const maxReportedHostDrift = 5
+
+// hostDescriptionData is the data available to host description templates
+type hostDescriptionData struct {
+	Name      string
+	Inventory string
+}
+
+// parseHostDescription parses the description template of the host defaults
+func parseHostDescription(text string) (*template.Template, error) {
+	tmpl, err := template.New("host description").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host description template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ValidateHostDefaults checks that the description template of the host
+// defaults can be parsed. Variables are checked when they are merged, after
+// the spec templates were rendered.
+func ValidateHostDefaults(defaults awxv1alpha1.HostDefaults) error {
+	if defaults.Description == "" {
+		return nil
+	}
+	_, err := parseHostDescription(defaults.Description)
+	return err
+}
+
+// mergeHostVariables merges the variables of a host over the shared
+// variables of the inventory. Either side is used verbatim when the other is
+// empty; otherwise the merged variables are written as YAML with sorted keys,
+// so the result is the same on every reconcile.
+func mergeHostVariables(defaults, own string) (string, error) {
+	if strings.TrimSpace(defaults) == "" {
+		return own, nil
+	}
+	if strings.TrimSpace(own) == "" {
+		return defaults, nil
+	}
+
+	merged := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(defaults), &merged); err != nil {
+		return "", fmt.Errorf("invalid host default variables: %w", err)
+	}
+	var hostVariables map[string]interface{}
+	if err := yaml.Unmarshal([]byte(own), &hostVariables); err != nil {
+		return "", fmt.Errorf("invalid variables: %w", err)
+	}
+	for key, value := range hostVariables {
+		merged[key] = value
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("failed to write merged variables: %w", err)
+	}
+	return string(data), nil
+}
+
+// effectiveHosts merges the host defaults of the inventory into its hosts. It
+// returns the hosts as they are sent to AWX and, by host name, the fields
+// that were taken from the defaults.
+func effectiveHosts(inventorySpec awxv1alpha1.InventorySpec) ([]awxv1alpha1.HostSpec, map[string][]string, error) {
+	defaults := inventorySpec.HostDefaults
+	if defaults == nil {
+		return inventorySpec.Hosts, nil, nil
+	}
+
+	var description *template.Template
+	if defaults.Description != "" {
+		tmpl, err := parseHostDescription(defaults.Description)
+		if err != nil {
+			return nil, nil, err
+		}
+		description = tmpl
+	}
+
+	hosts := make([]awxv1alpha1.HostSpec, 0, len(inventorySpec.Hosts))
+	inherited := make(map[string][]string)
+	for _, hostSpec := range inventorySpec.Hosts {
+		if hostSpec.Description == "" && description != nil {
+			var buf bytes.Buffer
+			data := hostDescriptionData{Name: hostSpec.Name, Inventory: inventorySpec.Name}
+			if err := description.Execute(&buf, data); err != nil {
+				return nil, nil, fmt.Errorf("failed to render description of host %s: %w", hostSpec.Name, err)
+			}
+			hostSpec.Description = buf.String()
+			inherited[hostSpec.Name] = append(inherited[hostSpec.Name], "description")
+		}
+		if strings.TrimSpace(defaults.Variables) != "" {
+			variables, err := mergeHostVariables(defaults.Variables, hostSpec.Variables)
+			if err != nil {
+				return nil, nil, fmt.Errorf("host %s: %w", hostSpec.Name, err)
+			}
+			hostSpec.Variables = variables
+			inherited[hostSpec.Name] = append(inherited[hostSpec.Name], "variables")
+		}
+		hosts = append(hosts, hostSpec)
+	}
+	return hosts, inherited, nil
+}
+
+// describeHostChanges names the changed fields of a host, marking those
+// taken from the host defaults
+func describeHostChanges(name string, changes map[string]interface{}, inherited []string) string {
+	fields := getMapKeys(changes)
+	sort.Strings(fields)
+	for i, field := range fields {
+		for _, inheritedField := range inherited {
+			if field == inheritedField {
+				fields[i] = field + " from hostDefaults"
+			}
+		}
+	}
+	return fmt.Sprintf("host %s: %s", name, strings.Join(fields, ", "))
+}
+
+// HostDrift describes how the hosts of an inventory differ from the spec
+// with the host defaults merged in: missing and undeclared hosts and the
+// changed fields of the others, marking the fields that come from the host
+// defaults. At most maxReportedHostDrift hosts are described.
+func (im *InventoryManager) HostDrift(inventory map[string]interface{}, inventorySpec awxv1alpha1.InventorySpec) ([]string, error) {
+	if len(inventorySpec.Hosts) == 0 {
+		return nil, nil
+	}
+	inventoryID, err := getObjectID(inventory)
+	if err != nil {
+		return nil, err
+	}
+	hosts, inherited, err := effectiveHosts(inventorySpec)
+	if err != nil {
+		return nil, err
+	}
+	existingHosts, err := im.client.ListAllObjects(fmt.Sprintf("inventories/%d/hosts", inventoryID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+
+	existingHostMap := make(map[string]map[string]interface{}, len(existingHosts))
+	for _, host := range existingHosts {
+		if name, ok := host["name"].(string); ok {
+			existingHostMap[name] = host
+		}
+	}
+
+	var drift []string
+	declared := make(map[string]bool, len(hosts))
+	for _, hostSpec := range hosts {
+		declared[hostSpec.Name] = true
+		existingHost, exists := existingHostMap[hostSpec.Name]
+		if !exists {
+			drift = append(drift, fmt.Sprintf("host %s missing", hostSpec.Name))
+			continue
+		}
+		if changes := hostChanges(existingHost, hostSpec, inventorySpec.VariablesMergePolicy); len(changes) > 0 {
+			drift = append(drift, describeHostChanges(hostSpec.Name, changes, inherited[hostSpec.Name]))
+		}
+	}
+	var undeclared []string
+	for name := range existingHostMap {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		drift = append(drift, fmt.Sprintf("host %s not declared", name))
+	}
+
+	if len(drift) > maxReportedHostDrift {
+		more := len(drift) - maxReportedHostDrift
+		drift = append(drift[:maxReportedHostDrift], fmt.Sprintf("%d more hosts", more))
+	}
+	return drift, nil
+}
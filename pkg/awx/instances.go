@@ -0,0 +1,189 @@
+package awx
+
+import (
+	"fmt"
+	"slices"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// instancesEndpoint lists the nodes of the receptor mesh
+const instancesEndpoint = "instances"
+
+// Node states of mesh instances
+const (
+	nodeStateInstalled      = "installed"
+	nodeStateReady          = "ready"
+	nodeStateDeprovisioning = "deprovisioning"
+)
+
+// MeshInstanceManager handles the execution and hop nodes of the receptor mesh
+type MeshInstanceManager struct {
+	client *Client
+}
+
+// NewMeshInstanceManager creates a new MeshInstanceManager
+func NewMeshInstanceManager(client *Client) *MeshInstanceManager {
+	return &MeshInstanceManager{
+		client: client,
+	}
+}
+
+// GetInstance retrieves a mesh instance by hostname
+func (mm *MeshInstanceManager) GetInstance(hostname string) (map[string]interface{}, error) {
+	instances, err := mm.client.ListObjects(instancesEndpoint, map[string]string{"hostname": hostname})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+	return instances[0], nil
+}
+
+// instanceEnabled returns whether the instance should accept jobs, which it
+// does unless disabled explicitly
+func instanceEnabled(instanceSpec awxv1alpha1.MeshInstanceSpec) bool {
+	return instanceSpec.Enabled == nil || *instanceSpec.Enabled
+}
+
+// instanceNodeType returns the declared node type, defaulting to execution
+func instanceNodeType(instanceSpec awxv1alpha1.MeshInstanceSpec) string {
+	if instanceSpec.NodeType == "" {
+		return "execution"
+	}
+	return instanceSpec.NodeType
+}
+
+// instanceData returns the fields of a mesh instance that can be changed
+// after it is registered
+func instanceData(instanceSpec awxv1alpha1.MeshInstanceSpec) map[string]interface{} {
+	peers := instanceSpec.Peers
+	if peers == nil {
+		peers = []string{}
+	}
+	data := map[string]interface{}{
+		"peers_from_control_nodes": instanceSpec.PeersFromControlNodes,
+		"peers":                    peers,
+		"enabled":                  instanceEnabled(instanceSpec),
+	}
+	if instanceSpec.ListenerPort > 0 {
+		data["listener_port"] = instanceSpec.ListenerPort
+	}
+	return data
+}
+
+// instancePeers returns the hostnames an instance connects to. AWX lists
+// them as hostnames, but older versions return the instance IDs.
+func instancePeers(instance map[string]interface{}) []string {
+	values, _ := instance["peers"].([]interface{})
+	peers := make([]string, 0, len(values))
+	for _, value := range values {
+		peers = append(peers, fmt.Sprint(value))
+	}
+	slices.Sort(peers)
+	return peers
+}
+
+// IsInstanceInDesiredState checks if the mutable fields of a mesh instance
+// match the spec. The node type can't be changed and is not compared.
+func (mm *MeshInstanceManager) IsInstanceInDesiredState(instance map[string]interface{}, instanceSpec awxv1alpha1.MeshInstanceSpec) bool {
+	if instanceSpec.ListenerPort > 0 && fmt.Sprint(instance["listener_port"]) != fmt.Sprint(instanceSpec.ListenerPort) {
+		return false
+	}
+	if peersFromControlNodes, _ := instance["peers_from_control_nodes"].(bool); peersFromControlNodes != instanceSpec.PeersFromControlNodes {
+		return false
+	}
+	if enabled, _ := instance["enabled"].(bool); enabled != instanceEnabled(instanceSpec) {
+		return false
+	}
+
+	desiredPeers := slices.Clone(instanceSpec.Peers)
+	slices.Sort(desiredPeers)
+	return slices.Equal(instancePeers(instance), desiredPeers)
+}
+
+// EnsureInstance registers a mesh instance or updates it to match the spec
+func (mm *MeshInstanceManager) EnsureInstance(instanceSpec awxv1alpha1.MeshInstanceSpec) (map[string]interface{}, error) {
+	instance, err := mm.GetInstance(instanceSpec.Hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance == nil {
+		payload := instanceData(instanceSpec)
+		payload["hostname"] = instanceSpec.Hostname
+		payload["node_type"] = instanceNodeType(instanceSpec)
+		payload["node_state"] = nodeStateInstalled
+
+		mm.client.log.Info("Registering mesh instance", "hostname", instanceSpec.Hostname, "nodeType", payload["node_type"])
+		instance, err = mm.client.CreateObject(instancesEndpoint, payload, "instance")
+		if err != nil {
+			return nil, fmt.Errorf("failed to register instance %s: %w", instanceSpec.Hostname, err)
+		}
+		return instance, nil
+	}
+
+	if nodeType, _ := instance["node_type"].(string); nodeType != instanceNodeType(instanceSpec) {
+		return nil, fmt.Errorf("instance %s is registered as a %s node, the node type can't be changed to %s",
+			instanceSpec.Hostname, nodeType, instanceNodeType(instanceSpec))
+	}
+	if mm.IsInstanceInDesiredState(instance, instanceSpec) {
+		return instance, nil
+	}
+
+	id, err := getObjectID(instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance ID: %w", err)
+	}
+
+	mm.client.log.Info("Updating mesh instance", "hostname", instanceSpec.Hostname)
+	err = mm.client.retryOnConflict("update instance "+instanceSpec.Hostname, func() error {
+		instance, err = mm.client.UpdateObject(instancesEndpoint, id, instanceData(instanceSpec))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update instance %s: %w", instanceSpec.Hostname, err)
+	}
+	return instance, nil
+}
+
+// InstanceHealth describes the health of a mesh instance as reported by AWX,
+// e.g. "ready" or "unavailable: <errors>"
+func InstanceHealth(instance map[string]interface{}) string {
+	state, _ := instance["node_state"].(string)
+	if state == "" {
+		state = nodeStateInstalled
+	}
+	if instanceErrors, _ := instance["errors"].(string); instanceErrors != "" && state != nodeStateReady {
+		return fmt.Sprintf("%s: %s", state, instanceErrors)
+	}
+	return state
+}
+
+// DeprovisionInstance marks a mesh instance for removal. AWX removes it from
+// the mesh once the node is gone.
+func (mm *MeshInstanceManager) DeprovisionInstance(hostname string) error {
+	instance, err := mm.GetInstance(hostname)
+	if err != nil {
+		return err
+	}
+	if instance == nil {
+		return nil
+	}
+	if state, _ := instance["node_state"].(string); state == nodeStateDeprovisioning {
+		return nil
+	}
+
+	id, err := getObjectID(instance)
+	if err != nil {
+		return fmt.Errorf("failed to get instance ID: %w", err)
+	}
+
+	mm.client.log.Info("Deprovisioning mesh instance", "hostname", hostname)
+	_, err = mm.client.UpdateObject(instancesEndpoint, id, map[string]interface{}{"node_state": nodeStateDeprovisioning})
+	if err != nil {
+		return fmt.Errorf("failed to deprovision instance %s: %w", hostname, err)
+	}
+	return nil
+}
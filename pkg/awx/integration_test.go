@@ -0,0 +1,1956 @@
+package awx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx/awxtest"
+)
+
+// newTestClient returns a client for the fake AWX server
+func newTestClient(server *awxtest.Server) *Client {
+	return NewClient(server.URL, server.Username, server.Password)
+}
+
+// TestProjectLifecycle verifies that projects are created once, updated in
+// place and deleted
+func TestProjectLifecycle(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	pm := NewProjectManager(newTestClient(server))
+
+	spec := awxv1alpha1.ProjectSpec{
+		Name:    "test-project",
+		SCMType: "git",
+		SCMUrl:  "https://github.com/example/repo.git",
+	}
+	_, err := pm.EnsureProject(spec)
+	assert.NoError(t, err)
+
+	spec.Description = "updated"
+	_, err = pm.EnsureProject(spec)
+	assert.NoError(t, err)
+
+	projects := server.Objects("projects")
+	assert.Len(t, projects, 1, "Project should not be created twice")
+	assert.Equal(t, "updated", projects[0]["description"])
+
+	assert.NoError(t, pm.DeleteProject(spec.Name))
+	assert.Empty(t, server.Objects("projects"))
+}
+
+// TestUpdateRetriedOnConflict verifies that writes rejected with 409 Conflict
+// are retried until AWX accepts them
+func TestUpdateRetriedOnConflict(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "locked-project", "scm_type": "git"})
+	server.Inject(awxtest.Fault{Method: http.MethodPatch, Path: "projects/", Status: http.StatusConflict, Times: 1})
+
+	pm := NewProjectManager(newTestClient(server))
+	_, err := pm.EnsureProject(awxv1alpha1.ProjectSpec{
+		Name:        "locked-project",
+		Description: "updated",
+		SCMType:     "git",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", server.Object("projects", "locked-project")["description"])
+}
+
+// TestConflictBackoffEndsWithContext verifies that the backoff between two
+// attempts on a locked object ends with the context of the client
+func TestConflictBackoffEndsWithContext(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "locked-project", "scm_type": "git"})
+	server.Inject(awxtest.Fault{Method: http.MethodPatch, Path: "projects/", Status: http.StatusConflict})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	pm := NewProjectManager(newTestClient(server).WithContext(ctx))
+	started := time.Now()
+	_, err := pm.EnsureProject(awxv1alpha1.ProjectSpec{Name: "locked-project", Description: "updated", SCMType: "git"})
+	assert.True(t, IsDeadlineExceeded(err), "The backoff should end at the deadline: %v", err)
+	assert.Less(t, time.Since(started), conflictInitialBackoff, "The backoff should not outlast the deadline")
+}
+
+// TestCircuitBreakerOpensOnServerErrors verifies that repeated server errors
+// open the breaker so that further requests fail fast
+func TestCircuitBreakerOpensOnServerErrors(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Inject(awxtest.Fault{Path: "projects", Status: http.StatusInternalServerError})
+
+	pm := NewProjectManager(newTestClient(server))
+	for i := 0; i < breakerFailureThreshold; i++ {
+		_, err := pm.GetProject("test-project")
+		assert.True(t, IsStatus(err, http.StatusInternalServerError))
+	}
+
+	requests := len(server.Requests())
+	_, err := pm.GetProject("test-project")
+	assert.True(t, IsCircuitOpen(err), "Breaker should be open after %d failures", breakerFailureThreshold)
+	assert.Len(t, server.Requests(), requests, "Open breaker should not reach AWX")
+}
+
+// TestTokenAuthentication verifies that the client logs in once and sends the
+// session token, along with the attribution headers
+func TestTokenAuthentication(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+
+	awxClient := newTestClient(server)
+	awxClient.SetAuthMethod(AuthMethodToken)
+	awxClient.SetManagedBy("awxinstance/default/test-instance")
+	awxClient.SetCorrelationID("5f0c1d3e-reconcile")
+	pm := NewProjectManager(awxClient)
+
+	for i := 0; i < 2; i++ {
+		_, err := pm.GetProject("test-project")
+		assert.NoError(t, err)
+	}
+
+	requests := server.Requests()
+	assert.Len(t, requests, 3, "Token should be requested once")
+	assert.Equal(t, "/api/v2/tokens/", requests[0].Path)
+	for _, request := range requests[1:] {
+		assert.Contains(t, request.Header.Get("Authorization"), "Bearer ")
+		assert.Equal(t, "awxinstance/default/test-instance", request.Header.Get(managedByHeader))
+		assert.Equal(t, "5f0c1d3e-reconcile", request.Header.Get(correlationIDHeader))
+		assert.Contains(t, request.Header.Get("User-Agent"), userAgentProduct)
+	}
+}
+
+// TestAuthMethodSwitchedConcurrently verifies that requests authenticate with
+// either method while another user of the client switches between them
+func TestAuthMethodSwitchedConcurrently(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	awxClient := newTestClient(server)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_, err := awxClient.Ping()
+			assert.NoError(t, err)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			awxClient.SetAuthMethod(AuthMethodToken)
+		} else {
+			awxClient.SetAuthMethod(AuthMethodBasic)
+		}
+	}
+	wg.Wait()
+}
+
+// TestTokenCreationDisabled verifies that a client without token creation
+// authenticates with basic auth instead of requesting a session token
+func TestTokenCreationDisabled(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+
+	awxClient := newTestClient(server)
+	awxClient.SetAuthMethod(AuthMethodToken)
+	awxClient.SetTokenCreation(false)
+	_, err := NewProjectManager(awxClient).GetProject("test-project")
+	assert.NoError(t, err)
+
+	requests := server.Requests()
+	assert.Len(t, requests, 1)
+	assert.NotEqual(t, "/api/v2/tokens/", requests[0].Path, "No token should be requested")
+	assert.True(t, strings.HasPrefix(requests[0].Header.Get("Authorization"), "Basic "))
+	assert.Empty(t, server.Tokens())
+	assert.Zero(t, awxClient.SessionTokenID())
+}
+
+// TestAvailableCapacity verifies that the capacity of the instance groups of a
+// job template is summed up
+func TestAvailableCapacity(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	jobTemplate := server.Add("job_templates", map[string]interface{}{"name": "test-template"})
+	for _, capacity := range []int{10, 32} {
+		group := server.Add("instance_groups", map[string]interface{}{"capacity": capacity})
+		server.Associate("job_templates", jobTemplate["id"].(int), "instance_groups", group["id"].(int))
+	}
+
+	capacity, err := NewJobTemplateManager(newTestClient(server)).AvailableCapacity("test-template")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, capacity)
+}
+
+// TestSlowServer verifies that latency injected by the fake server doesn't
+// break requests within the client timeout
+func TestSlowServer(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.SetLatency(50 * time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, newTestClient(server).TestConnection())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+// TestJobTemplateCredentials verifies that the declared credentials are
+// attached to a job template and undeclared ones are detached
+func TestJobTemplateCredentials(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "test-project"})
+	server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+	server.Add("credentials", map[string]interface{}{"name": "become"})
+	old := server.Add("credentials", map[string]interface{}{"name": "old"})
+	jobTemplate := server.Add("job_templates", map[string]interface{}{"name": "test-template"})
+	server.Associate("job_templates", jobTemplate["id"].(int), "credentials", old["id"].(int))
+
+	jtm := NewJobTemplateManager(newTestClient(server))
+	spec := awxv1alpha1.JobTemplateSpec{
+		Name:          "test-template",
+		ProjectName:   "test-project",
+		InventoryName: "test-inventory",
+		Playbook:      "site.yml",
+		BecomeEnabled: true,
+		Credentials:   []string{"become"},
+	}
+	assert.False(t, jtm.credentialsInDesiredState(jobTemplate["id"].(int), spec))
+
+	_, err := jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	assert.True(t, jtm.credentialsInDesiredState(jobTemplate["id"].(int), spec))
+	assert.Equal(t, true, server.Object("job_templates", "test-template")["become_enabled"])
+}
+
+// TestProjectSignatureValidation verifies that the refspec and the signature
+// validation credential are set by name and compared
+func TestProjectSignatureValidation(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("credentials", map[string]interface{}{"name": "gpg-key"})
+	pm := NewProjectManager(newTestClient(server))
+
+	spec := awxv1alpha1.ProjectSpec{
+		Name:                          "signed-project",
+		SCMType:                       "git",
+		SCMUrl:                        "https://github.com/example/repo.git",
+		SCMBranch:                     "main",
+		SCMRefspec:                    "refs/pull/*:refs/remotes/origin/pull/*",
+		SignatureValidationCredential: "gpg-key",
+	}
+	project, err := pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	assert.True(t, pm.IsProjectInDesiredState(project, spec))
+
+	spec.SignatureValidationCredential = ""
+	assert.False(t, pm.IsProjectInDesiredState(project, spec), "Removing the credential should be drift")
+
+	spec.SignatureValidationCredential = "missing-key"
+	_, err = pm.EnsureProject(spec)
+	assert.ErrorContains(t, err, "signature validation credential missing-key not found")
+	_, isReference := AsReferenceNotFoundError(err)
+	assert.True(t, isReference)
+}
+
+// TestProjectBranchPolicy verifies that a branch changed in AWX is reverted
+// unless the project ignores branch changes
+func TestProjectBranchPolicy(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	pm := NewProjectManager(client)
+
+	spec := awxv1alpha1.ProjectSpec{
+		Name:            "hotfix-project",
+		SCMType:         "git",
+		SCMUrl:          "https://github.com/example/repo.git",
+		SCMBranch:       "main",
+		SCMBranchPolicy: awxv1alpha1.SCMBranchPolicyIgnore,
+	}
+	project, err := pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "main", server.Object("projects", spec.Name)["scm_branch"], "The branch should be set on creation")
+
+	// An AWX admin switches to a hotfix branch
+	id, err := ObjectID(project)
+	assert.NoError(t, err)
+	project, err = client.UpdateObject("projects", id, map[string]interface{}{"scm_branch": "hotfix-1"})
+	assert.NoError(t, err)
+	assert.True(t, pm.IsProjectInDesiredState(project, spec))
+
+	spec.Description = "updated"
+	_, err = pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "hotfix-1", server.Object("projects", spec.Name)["scm_branch"])
+
+	spec.SCMBranchPolicy = awxv1alpha1.SCMBranchPolicyEnforce
+	assert.False(t, pm.IsProjectInDesiredState(server.Object("projects", spec.Name), spec))
+	_, err = pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "main", server.Object("projects", spec.Name)["scm_branch"])
+}
+
+// TestBulkHostCreation verifies that new hosts are created in chunks with the
+// bulk API and one by one when AWX doesn't provide it
+func TestBulkHostCreation(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	im := NewInventoryManager(newTestClient(server))
+
+	spec := awxv1alpha1.InventorySpec{Name: "large-inventory"}
+	for i := 0; i < 2*bulkHostChunkSize+10; i++ {
+		spec.Hosts = append(spec.Hosts, awxv1alpha1.HostSpec{Name: fmt.Sprintf("host-%03d", i)})
+	}
+	_, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.Len(t, server.Objects("hosts"), len(spec.Hosts))
+
+	bulkRequests := 0
+	for _, request := range server.Requests() {
+		if request.Path == "/api/v2/bulk/host_create/" {
+			bulkRequests++
+		}
+	}
+	assert.Equal(t, 3, bulkRequests)
+
+	// Older AWX versions answer 404 on the bulk API
+	server.Inject(awxtest.Fault{Path: "bulk/", Status: http.StatusNotFound})
+	_, err = im.EnsureInventory(awxv1alpha1.InventorySpec{
+		Name:  "small-inventory",
+		Hosts: []awxv1alpha1.HostSpec{{Name: "extra-1"}, {Name: "extra-2"}},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, server.Object("hosts", "extra-2"))
+}
+
+// TestRenameObject verifies that an object is renamed in place and left alone
+// when it is gone or the new name is taken
+func TestRenameObject(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	project := server.Add("projects", map[string]interface{}{"name": "old-name"})
+	id, err := ObjectID(project)
+	assert.NoError(t, err)
+
+	renamed, err := client.RenameObject("projects", id, "new-name")
+	assert.NoError(t, err)
+	assert.True(t, renamed)
+	assert.Nil(t, server.Object("projects", "old-name"))
+	assert.Equal(t, project["id"], server.Object("projects", "new-name")["id"])
+
+	server.Add("projects", map[string]interface{}{"name": "taken"})
+	renamed, err = client.RenameObject("projects", id, "taken")
+	assert.NoError(t, err)
+	assert.False(t, renamed)
+	assert.NotNil(t, server.Object("projects", "new-name"))
+
+	renamed, err = client.RenameObject("projects", id+100, "other-name")
+	assert.NoError(t, err)
+	assert.False(t, renamed)
+}
+
+// TestProjectDependencies verifies that the job templates using a project are
+// found and that AWX refusing the deletion names the active jobs
+func TestProjectDependencies(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	pm := NewProjectManager(newTestClient(server))
+	project := server.Add("projects", map[string]interface{}{"name": "web"})
+	server.Add("job_templates", map[string]interface{}{"name": "deploy", "project": project["id"]})
+	server.Add("job_templates", map[string]interface{}{"name": "backup", "project": project["id"]})
+	server.Add("job_templates", map[string]interface{}{"name": "unrelated", "project": 999})
+
+	dependents, err := pm.client.DependentJobTemplates("projects", "web")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"backup", "deploy"}, dependents)
+
+	server.Inject(awxtest.Fault{
+		Method: http.MethodDelete,
+		Path:   "projects/",
+		Status: http.StatusBadRequest,
+		Body:   `{"error": "Resource is being used by running jobs.", "active_jobs": [{"type": "project_update", "id": 42}]}`,
+	})
+	err = pm.DeleteProject("web")
+	dependencyErr, ok := AsDependencyError(err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"project update 42"}, dependencyErr.Blockers)
+	assert.ErrorContains(t, err, "project web is still used by project update 42")
+}
+
+// TestHostUpdatesPatchOnlyChanges verifies that only changed hosts are
+// updated, with just the fields that differ
+func TestHostUpdatesPatchOnlyChanges(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	im := NewInventoryManager(newTestClient(server))
+
+	spec := awxv1alpha1.InventorySpec{Name: "web"}
+	for i := 0; i < 20; i++ {
+		spec.Hosts = append(spec.Hosts, awxv1alpha1.HostSpec{
+			Name:      fmt.Sprintf("web-%02d", i),
+			Variables: "http_port: 80",
+		})
+	}
+	_, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+
+	spec.Hosts[3].Variables = "http_port: 8080"
+	_, err = im.EnsureInventory(spec)
+	assert.NoError(t, err)
+
+	var patches []string
+	for _, request := range server.Requests() {
+		if request.Method == http.MethodPatch && strings.HasPrefix(request.Path, "/api/v2/hosts/") {
+			patches = append(patches, request.Path)
+		}
+	}
+	assert.Len(t, patches, 1)
+	assert.Equal(t, "http_port: 8080", server.Object("hosts", "web-03")["variables"])
+}
+
+// TestCopiedInventoryKeepsHosts verifies that the hosts an inventory was
+// copied with are neither pruned nor counted against the mass deletion guard,
+// while undeclared hosts of its own are still pruned
+func TestCopiedInventoryKeepsHosts(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	im := NewInventoryManager(newTestClient(server))
+
+	golden := awxv1alpha1.InventorySpec{Name: "golden"}
+	for i := 0; i < 2*unguardedHostDeletions; i++ {
+		golden.Hosts = append(golden.Hosts, awxv1alpha1.HostSpec{Name: fmt.Sprintf("golden-%02d", i)})
+	}
+	_, err := im.EnsureInventory(golden)
+	assert.NoError(t, err)
+
+	spec := awxv1alpha1.InventorySpec{
+		Name:     "staging",
+		CopyFrom: "golden",
+		Hosts:    []awxv1alpha1.HostSpec{{Name: "web-1"}},
+	}
+	inventory, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	inventory, err = im.GetInventory("staging")
+	assert.NoError(t, err)
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec), "Copied hosts should not be drift")
+
+	spec.Hosts = []awxv1alpha1.HostSpec{{Name: "web-2"}}
+	_, err = im.EnsureInventory(spec)
+	assert.NoError(t, err)
+
+	var hosts []string
+	for _, host := range server.Objects("hosts") {
+		if fmt.Sprint(host["inventory"]) == fmt.Sprint(inventory["id"]) {
+			hosts = append(hosts, host["name"].(string))
+		}
+	}
+	assert.Len(t, hosts, len(golden.Hosts)+1)
+	assert.Contains(t, hosts, "golden-00")
+	assert.Contains(t, hosts, "web-2")
+	assert.NotContains(t, hosts, "web-1", "Undeclared hosts of the copy itself should be pruned")
+}
+
+// TestInventoryHostsAcrossPages verifies that inventories with more hosts
+// than fit on a page are compared and reconciled in full, and that a
+// differing host count is detected without listing the hosts
+func TestInventoryHostsAcrossPages(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	im := NewInventoryManager(newTestClient(server))
+
+	spec := awxv1alpha1.InventorySpec{Name: "large-inventory"}
+	for i := 0; i < 3*awxtest.DefaultPageSize; i++ {
+		spec.Hosts = append(spec.Hosts, awxv1alpha1.HostSpec{Name: fmt.Sprintf("host-%03d", i)})
+	}
+	inventory, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec))
+
+	// Reconciling again must not recreate the hosts beyond the first page
+	_, err = im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.Len(t, server.Objects("hosts"), len(spec.Hosts))
+
+	before := len(server.Requests())
+	spec.Hosts = spec.Hosts[1:]
+	assert.False(t, im.IsInventoryInDesiredState(inventory, spec))
+	assert.Len(t, server.Requests(), before+1, "A differing count should need a single request")
+}
+
+// TestPingDuringMigrations verifies that running migrations are told apart
+// from other unavailability
+func TestPingDuringMigrations(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+
+	info, err := client.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, awxtest.Version, info.Version)
+
+	server.Inject(awxtest.Fault{
+		Path:   "ping/",
+		Status: http.StatusServiceUnavailable,
+		Body:   "<html><h1>AWX is currently upgrading.</h1><p>Database migrations are running.</p></html>",
+		Times:  1,
+	})
+	_, err = client.Ping()
+	assert.True(t, IsMigrating(err))
+
+	server.Inject(awxtest.Fault{Path: "ping/", Status: http.StatusServiceUnavailable, Times: 1})
+	_, err = client.Ping()
+	assert.Error(t, err)
+	assert.False(t, IsMigrating(err))
+}
+
+// TestMeshInstances verifies that mesh instances are registered, updated in
+// place and deprovisioned, and that their health is reported
+func TestMeshInstances(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	mm := NewMeshInstanceManager(newTestClient(server))
+
+	spec := awxv1alpha1.MeshInstanceSpec{Hostname: "hop-1", NodeType: "hop", ListenerPort: 27199}
+	instance, err := mm.EnsureInstance(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "hop", instance["node_type"])
+	assert.Equal(t, "installed", InstanceHealth(instance))
+	assert.True(t, mm.IsInstanceInDesiredState(instance, spec))
+
+	execution := awxv1alpha1.MeshInstanceSpec{Hostname: "exec-1", ListenerPort: 27199, Peers: []string{"hop-1"}}
+	instance, err = mm.EnsureInstance(execution)
+	assert.NoError(t, err)
+	assert.Equal(t, "execution", instance["node_type"])
+	assert.Len(t, server.Objects("instances"), 2)
+
+	// Disabling a node patches it instead of registering it again
+	disabled := false
+	execution.Enabled = &disabled
+	instance, err = mm.EnsureInstance(execution)
+	assert.NoError(t, err)
+	assert.Equal(t, false, instance["enabled"])
+	assert.Len(t, server.Objects("instances"), 2)
+
+	// The node type can't be changed once registered
+	spec.NodeType = "execution"
+	_, err = mm.EnsureInstance(spec)
+	assert.ErrorContains(t, err, "node type can't be changed")
+
+	instance["node_state"] = "unavailable"
+	instance["errors"] = "Receptor error: connection refused"
+	assert.Equal(t, "unavailable: Receptor error: connection refused", InstanceHealth(instance))
+
+	assert.NoError(t, mm.DeprovisionInstance("exec-1"))
+	instance, err = mm.GetInstance("exec-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "deprovisioning", instance["node_state"])
+}
+
+// TestJobTemplateSurvey verifies that a survey is written once, that its
+// password default is sent to AWX but compared only as being set, and that
+// password defaults echoed in errors are redacted
+func TestJobTemplateSurvey(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "test-project"})
+	server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+
+	jtm := NewJobTemplateManager(newTestClient(server))
+	spec := awxv1alpha1.JobTemplateSpec{
+		Name:          "test-template",
+		ProjectName:   "test-project",
+		InventoryName: "test-inventory",
+		Playbook:      "site.yml",
+		Survey: &awxv1alpha1.SurveySpec{
+			Questions: []awxv1alpha1.SurveyQuestionSpec{
+				{Variable: "environment", Question: "Environment", Type: "multiplechoice", Choices: []string{"dev", "prod"}, Default: "dev"},
+				{Variable: "vault_password", Question: "Vault password", Type: "password", Default: "s3cr3t", Required: true},
+			},
+		},
+	}
+	jobTemplate, err := jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, true, jobTemplate["survey_enabled"])
+
+	id, err := getObjectID(jobTemplate)
+	assert.NoError(t, err)
+	stored := server.Survey("job_templates", id)["spec"].([]interface{})
+	assert.Equal(t, "s3cr3t", stored[1].(map[string]interface{})["default"])
+	assert.True(t, jtm.surveyInDesiredState(id, spec.Survey))
+
+	// An unchanged survey is not written again
+	before := len(server.Requests())
+	_, err = jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	for _, request := range server.Requests()[before:] {
+		assert.False(t, request.Method == http.MethodPost && strings.HasSuffix(request.Path, "/survey_spec/"))
+	}
+
+	spec.Survey.Questions[0].Default = "prod"
+	assert.False(t, jtm.surveyInDesiredState(id, spec.Survey))
+
+	err = redactSurveyPasswords(fmt.Errorf("request failed with status 400: invalid default s3cr3t"), spec.Survey)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+}
+
+// TestValidationErrorFields verifies that the field errors of a rejected
+// write are kept on the returned error
+func TestValidationErrorFields(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "test-project"})
+	server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+	server.Inject(awxtest.Fault{
+		Method: http.MethodPost,
+		Path:   "job_templates/",
+		Status: http.StatusBadRequest,
+		Body:   `{"playbook": ["Playbook not found for project."]}`,
+	})
+
+	jtm := NewJobTemplateManager(newTestClient(server))
+	_, err := jtm.EnsureJobTemplate(awxv1alpha1.JobTemplateSpec{
+		Name:          "deploy",
+		ProjectName:   "test-project",
+		InventoryName: "test-inventory",
+		Playbook:      "missing.yml",
+	})
+	fields, ok := AsFieldErrors(err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Playbook not found for project."}, fields["playbook"])
+	assert.Equal(t, "job template 'deploy': playbook not found for project", StatusMessage("job template", "deploy", err))
+}
+
+// TestJobCleanup verifies that the cleanup schedule AWX installs is updated in
+// place, a missing one is created and both are left alone once they match
+func TestJobCleanup(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("system_job_templates", map[string]interface{}{"name": "Cleanup Job Details", "job_type": "cleanup_jobs"})
+	activityTemplate := server.Add("system_job_templates", map[string]interface{}{"name": "Cleanup Activity Stream", "job_type": "cleanup_activitystream"})
+	defaultSchedule := server.Add("schedules", map[string]interface{}{
+		"name":       "Cleanup Activity Schedule",
+		"rrule":      "DTSTART:20240101T000000Z RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=TU",
+		"enabled":    true,
+		"timezone":   "UTC",
+		"extra_data": map[string]interface{}{"days": "355"},
+	})
+	server.Associate("system_job_templates", activityTemplate["id"].(int), "schedules", defaultSchedule["id"].(int))
+
+	cleanup := &awxv1alpha1.JobCleanupSpec{
+		Jobs:           &awxv1alpha1.CleanupScheduleSpec{RetentionDays: 30, Recurrence: "FREQ=DAILY;INTERVAL=1", Start: "2024-01-01T03:00:00"},
+		ActivityStream: &awxv1alpha1.CleanupScheduleSpec{RetentionDays: 90, Recurrence: "FREQ=WEEKLY;INTERVAL=1;BYDAY=TU", Start: "2024-01-01T00:00:00"},
+	}
+	cm := NewCleanupManager(newTestClient(server))
+	assert.NoError(t, cm.EnsureJobCleanup(cleanup))
+
+	schedule := server.Object("schedules", "Cleanup Job Schedule")
+	assert.NotNil(t, schedule)
+	assert.Equal(t, map[string]interface{}{"days": float64(30)}, schedule["extra_data"])
+	assert.Len(t, server.Objects("schedules"), 2, "The installed activity stream schedule should be updated in place")
+	assert.Equal(t, map[string]interface{}{"days": float64(90)}, server.Object("schedules", "Cleanup Activity Schedule")["extra_data"])
+
+	writes := len(server.Requests())
+	assert.NoError(t, cm.EnsureJobCleanup(cleanup))
+	for _, request := range server.Requests()[writes:] {
+		assert.Equal(t, http.MethodGet, request.Method, "Schedules in the desired state should not be written")
+	}
+
+	emptyServer := awxtest.NewServer()
+	defer emptyServer.Close()
+	err := NewCleanupManager(newTestClient(emptyServer)).EnsureJobCleanup(&awxv1alpha1.JobCleanupSpec{Jobs: cleanup.Jobs})
+	assert.ErrorContains(t, err, "system job template cleanup_jobs not found")
+}
+
+// TestInventoryCache verifies that objects looked up by name are served from
+// the inventory until the client writes to their endpoint or a resync is due
+func TestInventoryCache(t *testing.T) {
+	SetInventoryResync(time.Hour)
+	defer SetInventoryResync(0)
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	project := server.Add("projects", map[string]interface{}{"name": "cached", "description": "initial"})
+	id, err := ObjectID(project)
+	assert.NoError(t, err)
+
+	_, err = client.FindObjectByName("projects", "cached")
+	assert.NoError(t, err)
+	requests := len(server.Requests())
+	found, err := client.FindObjectByName("projects", "cached")
+	assert.NoError(t, err)
+	assert.Equal(t, "initial", found["description"])
+	assert.Len(t, server.Requests(), requests, "A cached object should not be read from AWX")
+
+	found["description"] = "changed by the caller"
+	found, _ = client.FindObjectByName("projects", "cached")
+	assert.Equal(t, "initial", found["description"], "Lookups should return copies")
+
+	_, err = newTestClient(server).UpdateObject("projects", id, map[string]interface{}{"description": "changed in AWX"})
+	assert.NoError(t, err)
+	found, _ = client.FindObjectByName("projects", "cached")
+	assert.Equal(t, "initial", found["description"], "Changes in AWX should be seen after the resync")
+
+	_, err = client.UpdateObject("projects", id, map[string]interface{}{"description": "updated"})
+	assert.NoError(t, err)
+	found, _ = client.FindObjectByName("projects", "cached")
+	assert.Equal(t, "updated", found["description"], "Writes should invalidate the endpoint")
+
+	_, err = newTestClient(server).UpdateObject("projects", id, map[string]interface{}{"description": "changed again"})
+	assert.NoError(t, err)
+	SetInventoryResync(time.Nanosecond)
+	found, _ = client.FindObjectByName("projects", "cached")
+	assert.Equal(t, "changed again", found["description"], "A resync should read the object from AWX")
+}
+
+// TestSpecVerified verifies that a verified spec hash holds until the client
+// writes to AWX or the inventory is resynced
+func TestSpecVerified(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+
+	client.MarkSpecVerified("projects", "web", "abc")
+	assert.False(t, client.SpecVerified("projects", "web", "abc"), "Nothing should be verified while the cache is disabled")
+
+	SetInventoryResync(time.Hour)
+	defer SetInventoryResync(0)
+	client.MarkSpecVerified("projects", "web", "abc")
+	assert.True(t, client.SpecVerified("projects", "web", "abc"))
+	assert.False(t, client.SpecVerified("projects", "web", "def"), "A changed spec should not be verified")
+	assert.False(t, client.SpecVerified("projects", "api", "abc"))
+
+	_, err := client.CreateObject("credentials", map[string]interface{}{"name": "git"}, "credential")
+	assert.NoError(t, err)
+	assert.False(t, client.SpecVerified("projects", "web", "abc"), "Writes should reset verified specs")
+
+	client.MarkSpecVerified("projects", "web", "abc")
+	SetInventoryResync(time.Nanosecond)
+	assert.False(t, client.SpecVerified("projects", "web", "abc"), "A resync should reset verified specs")
+}
+
+// TestHostFacts verifies that the selected facts of the requested hosts are
+// read, leaving out hosts without gathered facts
+func TestHostFacts(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	im := NewInventoryManager(newTestClient(server))
+	_, err := im.EnsureInventory(awxv1alpha1.InventorySpec{
+		Name:  "fleet",
+		Hosts: []awxv1alpha1.HostSpec{{Name: "web-1"}, {Name: "web-2"}, {Name: "db-1"}},
+	})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"web-1", "db-1"} {
+		id, err := ObjectID(server.Object("hosts", name))
+		assert.NoError(t, err)
+		server.SetFacts(id, map[string]interface{}{
+			"ansible_distribution":         "Ubuntu",
+			"ansible_distribution_version": "22.04",
+			"ansible_kernel":               "5.15.0-91-generic",
+			"ansible_architecture":         "x86_64",
+			"ansible_all_ipv4_addresses":   []interface{}{"10.0.0." + name[len(name)-1:]},
+			"ansible_all_ipv6_addresses":   []interface{}{"fe80::1"},
+		})
+	}
+
+	facts, err := im.HostFacts("fleet", []string{"web-1", "web-2"})
+	assert.NoError(t, err)
+	assert.Len(t, facts, 1, "Hosts without facts and hosts not asked for should be left out")
+	assert.Equal(t, "Ubuntu 22.04", facts["web-1"].OS)
+	assert.Equal(t, "5.15.0-91-generic", facts["web-1"].Kernel)
+	assert.Equal(t, "x86_64", facts["web-1"].Architecture)
+	assert.Equal(t, []string{"10.0.0.1", "fe80::1"}, facts["web-1"].Addresses)
+	assert.False(t, facts["web-1"].Modified.IsZero())
+
+	_, err = im.HostFacts("missing", nil)
+	assert.ErrorContains(t, err, "missing")
+}
+
+// TestWorkflowSurveyAndWebhook verifies that workflow job templates get their
+// survey and webhook like job templates and that the webhook key is readable
+func TestWorkflowSurveyAndWebhook(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	credential := server.Add("credentials", map[string]interface{}{"name": "github-token"})
+	wm := NewWorkflowJobTemplateManager(newTestClient(server))
+
+	spec := awxv1alpha1.WorkflowJobTemplateSpec{
+		Name: "release",
+		Survey: &awxv1alpha1.SurveySpec{
+			Questions: []awxv1alpha1.SurveyQuestionSpec{{Variable: "version", Question: "Version", Required: true}},
+		},
+		Webhook: &awxv1alpha1.WebhookSpec{Service: "github", CredentialName: "github-token"},
+	}
+	workflow, err := wm.EnsureWorkflowJobTemplate(spec)
+	assert.NoError(t, err)
+	id, err := ObjectID(workflow)
+	assert.NoError(t, err)
+
+	stored := server.Object("workflow_job_templates", "release")
+	assert.Equal(t, "github", stored["webhook_service"])
+	assert.Equal(t, float64(credential["id"].(int)), stored["webhook_credential"])
+	assert.Equal(t, true, stored["survey_enabled"])
+	assert.Len(t, server.Survey("workflow_job_templates", id)["spec"], 1)
+	assert.True(t, wm.IsWorkflowJobTemplateInDesiredState(stored, spec))
+
+	receiver, err := wm.WebhookReceiver(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s/api/v2/workflow_job_templates/%d/github/", server.URL, id), receiver.URL)
+	assert.NotEmpty(t, receiver.Key)
+
+	spec.Webhook = nil
+	assert.False(t, wm.IsWorkflowJobTemplateInDesiredState(stored, spec), "A removed webhook should be drift")
+	_, err = wm.EnsureWorkflowJobTemplate(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "", server.Object("workflow_job_templates", "release")["webhook_service"])
+
+	spec.Webhook = &awxv1alpha1.WebhookSpec{Service: "gitlab", CredentialName: "missing"}
+	_, err = wm.EnsureWorkflowJobTemplate(spec)
+	_, ok := AsReferenceNotFoundError(err)
+	assert.True(t, ok, "A missing webhook credential should be a missing reference")
+}
+
+// TestProtectedNames verifies that objects with a protected name are neither
+// updated nor deleted, while other objects and copies are unaffected
+func TestProtectedNames(t *testing.T) {
+	SetProtectedNames([]string{"Demo Project", " "})
+	defer SetProtectedNames(nil)
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	demo := server.Add("projects", map[string]interface{}{"name": "Demo Project", "description": "demo"})
+	demoID, err := ObjectID(demo)
+	assert.NoError(t, err)
+	other := server.Add("projects", map[string]interface{}{"name": "web"})
+	otherID, err := ObjectID(other)
+	assert.NoError(t, err)
+
+	_, err = client.FindObjectByName("projects", "Demo Project")
+	assert.NoError(t, err)
+	_, err = client.UpdateObject("projects", demoID, map[string]interface{}{"description": "changed"})
+	protectedErr, ok := AsProtectedObjectError(err)
+	assert.True(t, ok, "Updating a protected object should fail: %v", err)
+	assert.Equal(t, "Demo Project", protectedErr.Name)
+	assert.Equal(t, "demo", server.Object("projects", "Demo Project")["description"])
+
+	assert.NoError(t, client.DeleteObject("projects", demoID), "Deleting a protected object should leave it in place")
+	assert.NotNil(t, server.Object("projects", "Demo Project"))
+
+	_, err = client.CopyObject("projects", demoID, "Demo Copy")
+	assert.NoError(t, err, "Copying a protected object should be allowed")
+
+	_, err = client.UpdateObject("projects", otherID, map[string]interface{}{"description": "changed"})
+	assert.NoError(t, err)
+	assert.NoError(t, client.DeleteObject("projects", otherID))
+	assert.Nil(t, server.Object("projects", "web"))
+}
+
+// TestJobTemplateRollback verifies that a job template update failing at its
+// survey restores the fields and credentials already changed
+func TestJobTemplateRollback(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "test-project"})
+	server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+	server.Add("credentials", map[string]interface{}{"name": "new"})
+	old := server.Add("credentials", map[string]interface{}{"name": "old"})
+	jobTemplate := server.Add("job_templates", map[string]interface{}{"name": "test-template", "description": "before"})
+	id := jobTemplate["id"].(int)
+	server.Associate("job_templates", id, "credentials", old["id"].(int))
+	server.Inject(awxtest.Fault{
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("job_templates/%d/survey_spec", id),
+		Status: http.StatusBadRequest,
+		Body:   `{"spec": ["Survey question 1 is invalid."]}`,
+	})
+
+	jtm := NewJobTemplateManager(newTestClient(server))
+	_, err := jtm.EnsureJobTemplate(awxv1alpha1.JobTemplateSpec{
+		Name:          "test-template",
+		Description:   "after",
+		ProjectName:   "test-project",
+		InventoryName: "test-inventory",
+		Playbook:      "site.yml",
+		Credentials:   []string{"new"},
+		Survey: &awxv1alpha1.SurveySpec{
+			Questions: []awxv1alpha1.SurveyQuestionSpec{{Variable: "environment", Question: "Environment"}},
+		},
+	})
+	rolledBack, ok := AsRolledBackError(err)
+	assert.True(t, ok, "A failed survey should roll back the update: %v", err)
+	assert.NoError(t, rolledBack.RollbackErr)
+	assert.Equal(t, 3, rolledBack.Reverted)
+	_, ok = AsFieldErrors(err)
+	assert.True(t, ok, "The cause should still be readable")
+
+	assert.Equal(t, "before", server.Object("job_templates", "test-template")["description"])
+	attached, err := jtm.attachedCredentials(id)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"old": old["id"].(int)}, attached)
+}
+
+// TestDependenciesReady verifies that a job template waits for the declared
+// syncs of its project and inventory sources
+func TestDependenciesReady(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	project := server.Add("projects", map[string]interface{}{"name": "test-project", "status": "running"})
+	inventory := server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+	source := server.Add("inventory_sources", map[string]interface{}{"name": "cloud", "status": "failed"})
+	server.Associate("inventories", inventory["id"].(int), "inventory_sources", source["id"].(int))
+
+	client := newTestClient(server)
+	jtm := NewJobTemplateManager(client)
+	spec := awxv1alpha1.JobTemplateSpec{
+		Name:          "test-template",
+		ProjectName:   "test-project",
+		InventoryName: "test-inventory",
+	}
+	assert.NoError(t, jtm.DependenciesReady(spec), "Job templates without waitFor should not wait")
+
+	spec.WaitFor = &awxv1alpha1.WaitForSpec{ProjectSync: true, InventorySources: true}
+	notReady, ok := AsDependencyNotReadyError(jtm.DependenciesReady(spec))
+	assert.True(t, ok)
+	assert.Equal(t, "project test-project has sync status running", notReady.Error())
+
+	projectID := project["id"].(int)
+	_, err := client.UpdateObject("projects", projectID, map[string]interface{}{"status": "successful"})
+	assert.NoError(t, err)
+	notReady, ok = AsDependencyNotReadyError(jtm.DependenciesReady(spec))
+	assert.True(t, ok)
+	assert.Equal(t, &DependencyNotReadyError{Kind: "inventory source", Name: "cloud", Status: "failed"}, notReady)
+
+	_, err = client.UpdateObject("inventory_sources", source["id"].(int), map[string]interface{}{"status": "successful"})
+	assert.NoError(t, err)
+	assert.NoError(t, jtm.DependenciesReady(spec))
+}
+
+// TestOptionsAndPut verifies that the fields listed by OPTIONS validate a
+// payload before it is sent, and that PUT replaces an object
+func TestOptionsAndPut(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.SetOptions("job_templates", map[string]interface{}{
+		"name": "Job Template List",
+		"actions": map[string]interface{}{
+			"POST": map[string]interface{}{
+				"name":     map[string]interface{}{"type": "string", "required": true, "max_length": 8},
+				"job_type": map[string]interface{}{"type": "choice", "choices": []interface{}{[]interface{}{"run", "Run"}, []interface{}{"check", "Check"}}},
+				"forks":    map[string]interface{}{"type": "integer", "min_value": 0},
+				"created":  map[string]interface{}{"type": "datetime", "read_only": true},
+			},
+		},
+	})
+	client := newTestClient(server)
+
+	metadata, err := client.Options("job_templates")
+	assert.NoError(t, err)
+	assert.True(t, metadata.Allows(http.MethodPost))
+	assert.False(t, metadata.Allows(http.MethodPut))
+	assert.Nil(t, metadata.ValidateFields(http.MethodPost, map[string]interface{}{"name": "deploy", "job_type": "check", "forks": 5}))
+	assert.Equal(t, FieldErrors{
+		"name":     {"This field is required."},
+		"job_type": {"\"debug\" is not a valid choice."},
+		"forks":    {"Ensure this value is greater than or equal to 0."},
+		"created":  {"Field is read-only."},
+		"unknown":  {"Unknown field."},
+	}, metadata.ValidateFields(http.MethodPost, map[string]interface{}{
+		"job_type": "debug", "forks": -1, "created": "now", "unknown": true,
+	}))
+	assert.Equal(t, FieldErrors{"name": {"Ensure this field has no more than 8 characters."}},
+		metadata.ValidateFields(http.MethodPost, map[string]interface{}{"name": "nightly-deploy"}))
+
+	object := server.Add("job_templates", map[string]interface{}{"name": "deploy"})
+	replaced, err := client.Put(fmt.Sprintf("job_templates/%d", object["id"].(int)), map[string]interface{}{"name": "deploy", "forks": 5})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(5), replaced["forks"])
+	assert.Equal(t, http.MethodPut, server.Requests()[len(server.Requests())-1].Method)
+}
+
+// TestFieldValidation verifies that payloads are checked against the fields
+// AWX advertises before they are sent, and sent unchecked when disabled
+func TestFieldValidation(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	scmTypes := []interface{}{[]interface{}{"", "Manual"}, []interface{}{"git", "Git"}}
+	server.SetOptions("projects", map[string]interface{}{
+		"actions": map[string]interface{}{
+			"POST": map[string]interface{}{
+				"name":     map[string]interface{}{"type": "string", "required": true},
+				"scm_type": map[string]interface{}{"type": "choice", "choices": scmTypes},
+			},
+		},
+	})
+	server.SetOptions("projects/*", map[string]interface{}{
+		"actions": map[string]interface{}{
+			"PUT": map[string]interface{}{
+				"name":     map[string]interface{}{"type": "string", "required": true},
+				"scm_type": map[string]interface{}{"type": "choice", "choices": scmTypes},
+			},
+		},
+	})
+	client := newTestClient(server)
+
+	_, err := client.CreateObject("projects", map[string]interface{}{"name": "web", "scm_type": "svn"}, "project")
+	validationErr, ok := AsValidationError(err)
+	assert.True(t, ok, "An invalid choice should be rejected before it is sent: %v", err)
+	assert.Equal(t, http.MethodPost, validationErr.Method)
+	assert.Equal(t, "project 'web': scm_type: \"svn\" is not a valid choice", StatusMessage("project", "web", err))
+	assert.Empty(t, server.Objects("projects"))
+
+	project, err := client.CreateObject("projects", map[string]interface{}{"name": "web", "scm_type": "git"}, "project")
+	assert.NoError(t, err)
+	id, err := ObjectID(project)
+	assert.NoError(t, err)
+
+	_, err = client.UpdateObject("projects", id, map[string]interface{}{"scm_type": ""})
+	assert.NoError(t, err, "Updates should not require unchanged fields")
+	_, err = client.UpdateObject("projects", id, map[string]interface{}{"scm_branch_typo": "main"})
+	fields, ok := AsFieldErrors(err)
+	assert.True(t, ok)
+	assert.Equal(t, FieldErrors{"scm_branch_typo": {"Unknown field."}}, fields)
+
+	// The metadata is read once per endpoint kind and AWX version
+	optionsRequests := func() int {
+		count := 0
+		for _, request := range server.Requests() {
+			if request.Method == http.MethodOptions {
+				count++
+			}
+		}
+		return count
+	}
+	assert.Equal(t, 2, optionsRequests())
+	assert.NoError(t, client.TestConnection())
+	_, err = client.UpdateObject("projects", id, map[string]interface{}{"scm_type": "git"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, optionsRequests(), "The metadata should be kept while the AWX version is unchanged")
+	client.observeVersion("24.0.0")
+	_, err = client.UpdateObject("projects", id, map[string]interface{}{"scm_type": "git"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, optionsRequests(), "The metadata should be read again after an upgrade")
+
+	SetFieldValidation(false)
+	defer SetFieldValidation(true)
+	_, err = client.UpdateObject("projects", id, map[string]interface{}{"scm_branch_typo": "main"})
+	assert.NoError(t, err)
+}
+
+// TestUnicodeNames verifies that names with spaces, reserved characters and
+// non-ASCII letters are found by filter and by named URL
+func TestUnicodeNames(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	name := "Déploiement 中文 + prod/eu"
+
+	_, err := NewProjectManager(client).EnsureProject(awxv1alpha1.ProjectSpec{Name: name, Description: "説明"})
+	assert.NoError(t, err)
+	found, err := client.FindObjectByName("projects", name)
+	assert.NoError(t, err)
+	assert.Equal(t, "説明", found["description"])
+
+	assert.Equal(t, "projects/D%C3%A9ploiement%20%E4%B8%AD%E6%96%87%20%5B+%5D%20prod%2Feu++Default/", namedURL("projects", name, "Default"))
+	byNamedURL, err := client.GetObjectByNamedURL("projects", name, "Default")
+	assert.NoError(t, err)
+	assert.Equal(t, found["id"], byNamedURL["id"])
+	missing, err := client.GetObjectByNamedURL("projects", "Déploiement")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	im := NewInventoryManager(client)
+	spec := awxv1alpha1.InventorySpec{Name: "インベントリ", Hosts: []awxv1alpha1.HostSpec{{Name: "主机 1"}, {Name: "host+2"}}}
+	inventory, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec))
+	assert.NotNil(t, server.Object("hosts", "主机 1"))
+}
+
+// TestBodyLogKeepsCharactersWhole verifies that truncated bodies are not cut
+// in the middle of a multi-byte character
+func TestBodyLogKeepsCharactersWhole(t *testing.T) {
+	SetMaxBodyLogSize(4)
+	defer SetMaxBodyLogSize(DefaultMaxBodyLogSize)
+	client := NewClient("https://awx.example.com", "admin", "secret")
+	assert.Equal(t, "ab...", client.bodyForLog([]byte("ab中文")))
+	assert.Equal(t, "a中...", client.bodyForLog([]byte("a中文")))
+	assert.Equal(t, "abcd", client.bodyForLog([]byte("abcd")))
+}
+
+// TestRedactedValues verifies that redacted values are masked in logged
+// bodies, also when JSON-escaped or cut in half by the body log limit
+func TestRedactedValues(t *testing.T) {
+	client := NewClient("https://awx.example.com", "admin", "secret")
+	client.SetRedactedValues([]string{"hunter2", `pa"ss`, ""})
+	assert.Equal(t, `{"variables": "token: [REDACTED]\nother: [REDACTED]"}`,
+		client.bodyForLog([]byte(`{"variables": "token: hunter2\nother: pa\"ss"}`)))
+
+	SetMaxBodyLogSize(10)
+	defer SetMaxBodyLogSize(DefaultMaxBodyLogSize)
+	assert.Equal(t, "token: [REDACTED]...", client.bodyForLog([]byte("token: hunter2 and more")),
+		"A value cut by the limit should be masked as a whole")
+	client.SetRedactedValues(nil)
+	assert.Equal(t, "token: hun...", client.bodyForLog([]byte("token: hunter2 and more")))
+}
+
+// TestRedactedValuesNotLogged verifies that Secret values rendered into the
+// variables of an inventory are masked in request and response bodies
+func TestRedactedValuesNotLogged(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("organizations", map[string]interface{}{"name": "Default"})
+
+	var lines []string
+	capture := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+	client := newTestClient(server)
+	client.SetLogger(capture)
+	client.SetRedactedValues([]string{"t0ps3cret"})
+
+	spec := awxv1alpha1.InventorySpec{Name: "fleet", Variables: "api_token: t0ps3cret"}
+	_, err := NewInventoryManager(client).EnsureInventory(spec)
+	assert.NoError(t, err)
+	_, err = NewInventoryManager(client).GetInventory("fleet")
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.NotContains(t, line, "t0ps3cret")
+	}
+}
+
+// TestHostDefaults verifies that the host defaults of an inventory are merged
+// into its hosts and that drift caused by them is named as such
+func TestHostDefaults(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	im := NewInventoryManager(newTestClient(server))
+
+	spec := awxv1alpha1.InventorySpec{
+		Name: "web",
+		HostDefaults: &awxv1alpha1.HostDefaults{
+			Description: "Web server {{ .Name }} in {{ .Inventory }}",
+			Variables:   "env: prod\nhttp_port: 80",
+		},
+		Hosts: []awxv1alpha1.HostSpec{
+			{Name: "web-1"},
+			{Name: "web-2", Description: "Primary", Variables: "http_port: 8080"},
+		},
+	}
+	inventory, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec))
+
+	assert.Equal(t, "Web server web-1 in web", server.Object("hosts", "web-1")["description"])
+	assert.Equal(t, "env: prod\nhttp_port: 80", server.Object("hosts", "web-1")["variables"])
+	assert.Equal(t, "Primary", server.Object("hosts", "web-2")["description"])
+	assert.Equal(t, "env: prod\nhttp_port: 8080\n", server.Object("hosts", "web-2")["variables"])
+
+	spec.HostDefaults.Variables = "env: staging\nhttp_port: 80"
+	assert.False(t, im.IsInventoryInDesiredState(inventory, spec))
+	drift, err := im.HostDrift(inventory, spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"host web-1: variables from hostDefaults",
+		"host web-2: variables from hostDefaults",
+	}, drift)
+
+	spec.Hosts[1].Variables = "http_port: [80"
+	_, err = im.EnsureInventory(spec)
+	assert.ErrorContains(t, err, "host web-2: invalid variables")
+}
+
+// TestMissingPermissions verifies that endpoints the user may not create
+// objects on or not even read are reported, and that endpoints without
+// listed actions are not judged
+func TestMissingPermissions(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.SetOptions("projects", map[string]interface{}{
+		"actions": map[string]interface{}{"GET": map[string]interface{}{}, "POST": map[string]interface{}{}},
+	})
+	server.SetOptions("job_templates", map[string]interface{}{
+		"actions": map[string]interface{}{"GET": map[string]interface{}{}},
+	})
+	server.Inject(awxtest.Fault{Method: http.MethodOptions, Path: "credentials", Status: http.StatusForbidden})
+	client := newTestClient(server)
+
+	missing, err := client.MissingPermissions("projects", "job_templates", "credentials", "inventories")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"create job_templates", "read credentials"}, missing)
+}
+
+// TestDeleteObjectUsesStatus verifies that deletions are judged by the
+// status of the DELETE alone, without reading the object before or after
+func TestDeleteObjectUsesStatus(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	project := server.Add("projects", map[string]interface{}{"name": "web"})
+	id := project["id"].(int)
+
+	assert.NoError(t, client.DeleteObject("projects", id))
+	assert.Nil(t, server.Object("projects", "web"))
+	assert.NoError(t, client.DeleteObject("projects", id), "An object that is already gone counts as deleted")
+
+	var methods []string
+	for _, request := range server.Requests() {
+		methods = append(methods, request.Method)
+	}
+	assert.Equal(t, []string{http.MethodDelete, http.MethodDelete}, methods)
+
+	server.Inject(awxtest.Fault{Method: http.MethodDelete, Path: "projects/", Status: http.StatusForbidden})
+	assert.True(t, IsStatus(client.DeleteObject("projects", id), http.StatusForbidden))
+}
+
+// TestClientLogger verifies that each client logs to its own logger, so the
+// logs of clients for different instances can be told apart
+func TestClientLogger(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "web"})
+
+	var lines []string
+	capture := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+	first := newTestClient(server)
+	first.SetLogger(capture.WithValues("instance", "first"))
+	second := newTestClient(server)
+	second.SetLogger(capture.WithValues("instance", "second"))
+
+	_, err := NewProjectManager(first).GetProject("web")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.Contains(t, line, `"instance"="first"`)
+	}
+
+	lines = nil
+	_, err = NewProjectManager(second).GetProject("web")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.Contains(t, line, `"instance"="second"`)
+	}
+}
+
+// TestCredentialTypeCatalog verifies that the catalog is read across all
+// pages of the credential types and that the cached lookup never contacts AWX
+func TestCredentialTypeCatalog(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	for i := 0; i < listPageSize+5; i++ {
+		server.Add("credential_types", map[string]interface{}{"name": fmt.Sprintf("Custom %d", i)})
+	}
+	server.Add("credential_types", map[string]interface{}{"name": "Machine", "namespace": "ssh"})
+
+	awxClient := newTestClient(server)
+	_, cached := awxClient.CachedCredentialType("ssh")
+	assert.False(t, cached)
+	assert.Empty(t, server.Requests(), "The cached lookup should not contact AWX")
+
+	credentialType, err := awxClient.CredentialType("ssh")
+	assert.NoError(t, err)
+	assert.Equal(t, "Machine", credentialType.Name, "Credential types past the first page should be found")
+	requests := len(server.Requests())
+
+	credentialType, cached = awxClient.CachedCredentialType("machine")
+	assert.True(t, cached)
+	assert.Equal(t, "Machine", credentialType.Name)
+	credentialType, cached = awxClient.CachedCredentialType("unknown")
+	assert.True(t, cached)
+	assert.Nil(t, credentialType)
+	assert.Len(t, server.Requests(), requests)
+}
+
+// TestCredentialInputsNotLogged verifies that the inputs of a credential are
+// never written to the request log when it is created or updated. Responses
+// are not checked, AWX returns secret inputs as $encrypted$ but the fake
+// server echoes them.
+func TestCredentialInputsNotLogged(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("credential_types", map[string]interface{}{
+		"name":      "Machine",
+		"namespace": "ssh",
+		"inputs": map[string]interface{}{
+			"fields": []interface{}{map[string]interface{}{"id": "username"}, map[string]interface{}{"id": "password"}},
+		},
+	})
+
+	var lines []string
+	capture := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+	client := newTestClient(server)
+	client.SetLogger(capture)
+	cm := NewCredentialManager(client)
+
+	spec := awxv1alpha1.CredentialSpec{Name: "deploy", Kind: "ssh", Inputs: map[string]string{"username": "deploy", "password": "s3cr3t"}}
+	_, err := cm.EnsureCredential(spec)
+	assert.NoError(t, err)
+	spec.Inputs["password"] = "r0tated"
+	_, err = cm.EnsureCredential(spec)
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		if strings.Contains(line, "REST API Response Body") {
+			continue
+		}
+		assert.NotContains(t, line, "s3cr3t")
+		assert.NotContains(t, line, "r0tated")
+	}
+}
+
+// TestAnalyticsPasswordNotLogged verifies that the Red Hat password is never
+// written to the request log when the analytics settings are updated
+func TestAnalyticsPasswordNotLogged(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("settings", map[string]interface{}{"name": "system"})
+
+	var lines []string
+	capture := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+	client := newTestClient(server)
+	client.SetLogger(capture)
+
+	desired := AnalyticsSettings{Enabled: true, Username: "rh-user", Password: "s3cr3t"}
+	assert.NoError(t, NewSettingsManager(client).EnsureAnalytics(desired))
+	settings := server.Object("settings", "system")
+	assert.Equal(t, "s3cr3t", settings[settingRedHatPassword])
+
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		if strings.Contains(line, "REST API Response Body") {
+			continue
+		}
+		assert.NotContains(t, line, "s3cr3t")
+	}
+}
+
+// TestProjectUpdate verifies that a project update is started for the named
+// project and read back with the end of its output
+func TestProjectUpdate(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "web"})
+	pm := NewProjectManager(newTestClient(server))
+
+	id, err := pm.StartProjectUpdate("web")
+	assert.NoError(t, err)
+	update, err := pm.GetProjectUpdate(id)
+	assert.NoError(t, err)
+	assert.True(t, update.Done())
+	assert.True(t, update.Succeeded())
+	assert.False(t, update.Finished.IsZero())
+
+	stdout, err := pm.ProjectUpdateStdout(id, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "PLAY RECAP\nlocalhost : ok=3 changed=1 failed=0", stdout)
+
+	server.Set("project_updates", id, map[string]interface{}{"status": "waiting"})
+	update, err = pm.GetProjectUpdate(id)
+	assert.NoError(t, err)
+	assert.False(t, update.Done())
+
+	_, err = pm.StartProjectUpdate("api")
+	_, notFound := AsReferenceNotFoundError(err)
+	assert.True(t, notFound)
+}
+
+// TestInventorySources verifies that the sources of an inventory and their
+// update schedules are created, compared and updated in place
+func TestInventorySources(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	project := server.Add("projects", map[string]interface{}{"name": "inventories"})
+	im := NewInventoryManager(newTestClient(server))
+
+	spec := awxv1alpha1.InventorySpec{
+		Name: "cloud",
+		Sources: []awxv1alpha1.InventorySourceSpec{{
+			Name:               "hosts-file",
+			Source:             "scm",
+			SourceProject:      "inventories",
+			SourcePath:         "hosts.yml",
+			OverwriteVars:      true,
+			UpdateCacheTimeout: 300,
+			UpdateSchedule: &awxv1alpha1.ScheduleSpec{
+				Name:       "hourly",
+				Recurrence: "FREQ=HOURLY;INTERVAL=1",
+				Start:      "2024-01-01T00:00:00",
+			},
+		}},
+	}
+	inventory, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	source := server.Object("inventory_sources", "hosts-file")
+	assert.NotNil(t, source)
+	assert.Equal(t, float64(project["id"].(int)), source["source_project"])
+	assert.Equal(t, true, source["overwrite_vars"])
+	assert.Equal(t, float64(300), source["update_cache_timeout"])
+	assert.NotNil(t, server.Object("schedules", "hourly"))
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec))
+
+	server.Set("inventory_sources", source["id"].(int), map[string]interface{}{"update_cache_timeout": float64(0)})
+	assert.False(t, im.IsInventoryInDesiredState(inventory, spec), "A changed cache timeout should be drift")
+	spec.Sources[0].UpdateSchedule.Recurrence = "FREQ=DAILY;INTERVAL=1"
+	_, err = im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec))
+	assert.Len(t, server.Objects("inventory_sources"), 1, "The source should be updated in place")
+	assert.Len(t, server.Objects("schedules"), 1, "The update schedule should be updated in place")
+
+	spec.Sources[0].UpdateSchedule = nil
+	assert.False(t, im.IsInventoryInDesiredState(inventory, spec), "An undeclared update schedule should be drift")
+	_, err = im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	assert.Empty(t, server.Objects("schedules"))
+	assert.True(t, im.IsInventoryInDesiredState(inventory, spec))
+}
+
+// TestProjectUpdateSchedule verifies that a project gets its declared update
+// schedule, launching project updates, and that schedules of projects without
+// one are left alone
+func TestProjectUpdateSchedule(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	pm := NewProjectManager(newTestClient(server))
+
+	spec := awxv1alpha1.ProjectSpec{
+		Name:    "playbooks",
+		SCMType: "git",
+		SCMUrl:  "https://git.example.com/playbooks.git",
+		UpdateSchedule: &awxv1alpha1.ScheduleSpec{
+			Name:       "nightly",
+			Recurrence: "FREQ=DAILY;INTERVAL=1",
+			Start:      "2024-01-01T02:00:00",
+		},
+	}
+	project, err := pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	schedule := server.Object("schedules", "nightly")
+	assert.NotNil(t, schedule)
+	projectID, err := getObjectID(project)
+	assert.NoError(t, err)
+	assert.Equal(t, projectID, schedule["unified_job_template"])
+	assert.True(t, pm.IsProjectInDesiredState(project, spec))
+
+	spec.UpdateSchedule.Recurrence = "FREQ=WEEKLY;INTERVAL=1"
+	assert.False(t, pm.IsProjectInDesiredState(project, spec), "A changed recurrence should be drift")
+	_, err = pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	assert.True(t, pm.IsProjectInDesiredState(project, spec))
+	assert.Len(t, server.Objects("schedules"), 1, "The update schedule should be updated in place")
+
+	spec.UpdateSchedule = nil
+	assert.True(t, pm.IsProjectInDesiredState(project, spec))
+	_, err = pm.EnsureProject(spec)
+	assert.NoError(t, err)
+	assert.Len(t, server.Objects("schedules"), 1, "Schedules of projects without an update schedule should be kept")
+
+	assert.Error(t, ValidateUpdateSchedule(awxv1alpha1.ScheduleSpec{
+		Name: "verbose", Recurrence: "FREQ=DAILY", Start: "2024-01-01T00:00:00", Verbosity: new(int32),
+	}))
+}
+
+// TestGalaxyCredentialOrder verifies that the Galaxy credentials of an
+// organization are put in the declared order, keeping those that already are
+func TestGalaxyCredentialOrder(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	organization := server.Add("organizations", map[string]interface{}{"name": "Default"})
+	for _, name := range []string{"hub", "galaxy", "mirror"} {
+		credential := server.Add("credentials", map[string]interface{}{"name": name})
+		server.Associate("organizations", organization["id"].(int), "galaxy_credentials", credential["id"].(int))
+	}
+	om := NewOrganizationManager(newTestClient(server))
+	attachedNames := func() []string {
+		attached, err := om.attachedGalaxyCredentials(organization["id"].(int))
+		assert.NoError(t, err)
+		names := make([]string, 0, len(attached))
+		for _, credential := range attached {
+			names = append(names, credential.name)
+		}
+		return names
+	}
+	associations := func() int {
+		count := 0
+		for _, request := range server.Requests() {
+			if request.Method == http.MethodPost && strings.HasSuffix(request.Path, "/galaxy_credentials/") {
+				count++
+			}
+		}
+		return count
+	}
+
+	spec := awxv1alpha1.OrganizationSpec{Name: "Default", GalaxyCredentials: []string{"hub", "mirror", "galaxy"}}
+	assert.NoError(t, om.EnsureOrganization(spec))
+	assert.Equal(t, []string{"hub", "mirror", "galaxy"}, attachedNames())
+	assert.Equal(t, 4, associations(), "Only the credentials after the first difference should be reattached")
+
+	assert.NoError(t, om.EnsureOrganization(spec))
+	assert.Equal(t, 4, associations(), "Credentials in order should be left alone")
+
+	spec.GalaxyCredentials = []string{"hub"}
+	assert.NoError(t, om.EnsureOrganization(spec))
+	assert.Equal(t, []string{"hub"}, attachedNames())
+
+	spec.GalaxyCredentials = []string{"missing"}
+	_, missing := AsReferenceNotFoundError(om.EnsureOrganization(spec))
+	assert.True(t, missing)
+	_, missing = AsReferenceNotFoundError(om.EnsureOrganization(awxv1alpha1.OrganizationSpec{Name: "Other"}))
+	assert.True(t, missing)
+}
+
+// TestClientDeadline verifies that requests running at the deadline of the
+// context of a client are canceled, that later requests are refused without
+// reaching AWX and that the client it was derived from is not affected
+func TestClientDeadline(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	shared := NewClient(server.URL, server.Username, server.Password)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := shared.WithContext(ctx)
+
+	server.SetLatency(200 * time.Millisecond)
+	err := client.TestConnection()
+	assert.True(t, IsDeadlineExceeded(err), "A request running at the deadline should be canceled: %v", err)
+
+	server.SetLatency(0)
+	sent := len(server.Requests())
+	err = client.TestConnection()
+	assert.True(t, IsDeadlineExceeded(err))
+	_, err = client.Post("job_templates/1/launch", map[string]interface{}{})
+	assert.True(t, IsDeadlineExceeded(err), "POSTs should be bound by the deadline as well: %v", err)
+	assert.Len(t, server.Requests(), sent, "Requests after the deadline should not be sent")
+
+	assert.True(t, shared.Deadline().IsZero())
+	assert.NoError(t, shared.TestConnection(), "The shared client should not see the deadline")
+}
+
+// TestContextFirstMethods verifies that the context-first methods send their
+// requests within the context passed and leave the client alone
+func TestContextFirstMethods(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := NewClient(server.URL, server.Username, server.Password)
+	ctx := context.Background()
+
+	created, err := client.CreateObjectContext(ctx, "projects", map[string]interface{}{"name": "web", "organization": 1}, "project")
+	assert.NoError(t, err)
+	id := int(created["id"].(float64))
+	_, err = client.UpdateObjectContext(ctx, "projects", id, map[string]interface{}{"description": "Web"})
+	assert.NoError(t, err)
+	project, err := client.GetObjectContext(ctx, "projects", id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Web", project["description"])
+	found, err := client.FindObjectByNameContext(ctx, "projects", "web")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(id), found["id"])
+	projects, err := client.ListAllObjectsContext(ctx, "projects", nil)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 1)
+	assert.NoError(t, client.DeleteObjectContext(ctx, "projects", id))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	sent := len(server.Requests())
+	_, err = client.GetObjectContext(canceled, "projects", id)
+	assert.Error(t, err)
+	assert.Error(t, client.TestConnectionContext(canceled))
+	assert.Len(t, server.Requests(), sent, "Requests within a canceled context should not be sent")
+	assert.NoError(t, client.TestConnection(), "The client should not keep the context of a call")
+}
+
+// TestGetTyped verifies that objects are read into the generated types
+func TestGetTyped(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := NewClient(server.URL, server.Username, server.Password)
+	created := server.Add("projects", map[string]interface{}{
+		"name":         "web",
+		"scm_type":     "git",
+		"scm_url":      "https://git.example.com/web.git",
+		"organization": 1,
+		"credential":   nil,
+	})
+
+	project, err := GetTyped[Project](client, "projects", created["id"].(int))
+	assert.NoError(t, err)
+	assert.Equal(t, created["id"], project.ID)
+	assert.Equal(t, "web", project.Name)
+	assert.Equal(t, "https://git.example.com/web.git", project.SCMURL)
+	assert.Equal(t, 1, *project.Organization)
+	assert.Nil(t, project.Credential)
+
+	_, err = DecodeObject[Project](map[string]interface{}{"name": 42})
+	assert.Error(t, err, "A name that is not a string should not decode")
+}
+
+// TestPromptedDefaults verifies that fields prompted on launch and not set in
+// the spec keep the default configured in AWX and are not reported as drift
+func TestPromptedDefaults(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "test-project"})
+	server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+
+	jtm := NewJobTemplateManager(newTestClient(server))
+	spec := awxv1alpha1.JobTemplateSpec{
+		Name:                 "test-template",
+		ProjectName:          "test-project",
+		InventoryName:        "test-inventory",
+		Playbook:             "site.yml",
+		SkipTags:             "slow",
+		AskTagsOnLaunch:      true,
+		AskVerbosityOnLaunch: true,
+	}
+	jobTemplate, err := jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	id, err := getObjectID(jobTemplate)
+	assert.NoError(t, err)
+
+	server.Set("job_templates", id, map[string]interface{}{"job_tags": "deploy", "verbosity": 2})
+	jobTemplate, err = jtm.GetJobTemplate(spec.Name)
+	assert.NoError(t, err)
+	assert.True(t, jtm.IsJobTemplateInDesiredState(jobTemplate, spec), "Prompt defaults should not be drift")
+
+	server.Set("job_templates", id, map[string]interface{}{"skip_tags": ""})
+	jobTemplate, err = jtm.GetJobTemplate(spec.Name)
+	assert.NoError(t, err)
+	assert.False(t, jtm.IsJobTemplateInDesiredState(jobTemplate, spec), "Declared skip tags should still be compared")
+
+	_, err = jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	jobTemplate = server.Object("job_templates", spec.Name)
+	assert.Equal(t, "deploy", jobTemplate["job_tags"])
+	assert.EqualValues(t, 2, jobTemplate["verbosity"])
+	assert.Equal(t, "slow", jobTemplate["skip_tags"])
+}
+
+// TestJobTemplateTags verifies that the job tags and skip tags of a job
+// template are created, updated and reported as drift when changed in AWX
+func TestJobTemplateTags(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	server.Add("projects", map[string]interface{}{"name": "test-project"})
+	server.Add("inventories", map[string]interface{}{"name": "test-inventory"})
+
+	jtm := NewJobTemplateManager(newTestClient(server))
+	spec := awxv1alpha1.JobTemplateSpec{
+		Name:          "test-template",
+		ProjectName:   "test-project",
+		InventoryName: "test-inventory",
+		Playbook:      "site.yml",
+		JobTags:       "deploy,migrate",
+		SkipTags:      "slow",
+	}
+	_, err := jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	jobTemplate := server.Object("job_templates", spec.Name)
+	assert.Equal(t, "deploy,migrate", jobTemplate["job_tags"])
+	assert.Equal(t, "slow", jobTemplate["skip_tags"])
+	id, err := getObjectID(jobTemplate)
+	assert.NoError(t, err)
+
+	spec.JobTags = "deploy"
+	spec.SkipTags = ""
+	jobTemplate, err = jtm.GetJobTemplate(spec.Name)
+	assert.NoError(t, err)
+	assert.False(t, jtm.IsJobTemplateInDesiredState(jobTemplate, spec), "Changed tags in the spec should be drift")
+	_, err = jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	jobTemplate = server.Object("job_templates", spec.Name)
+	assert.Equal(t, "deploy", jobTemplate["job_tags"])
+	assert.Equal(t, "", jobTemplate["skip_tags"])
+
+	server.Set("job_templates", id, map[string]interface{}{"job_tags": "debug"})
+	jobTemplate, err = jtm.GetJobTemplate(spec.Name)
+	assert.NoError(t, err)
+	assert.False(t, jtm.IsJobTemplateInDesiredState(jobTemplate, spec), "Job tags changed in AWX should be drift")
+	server.Set("job_templates", id, map[string]interface{}{"job_tags": "deploy", "skip_tags": "slow"})
+	jobTemplate, err = jtm.GetJobTemplate(spec.Name)
+	assert.NoError(t, err)
+	assert.False(t, jtm.IsJobTemplateInDesiredState(jobTemplate, spec), "Skip tags changed in AWX should be drift")
+
+	_, err = jtm.EnsureJobTemplate(spec)
+	assert.NoError(t, err)
+	jobTemplate, err = jtm.GetJobTemplate(spec.Name)
+	assert.NoError(t, err)
+	assert.True(t, jtm.IsJobTemplateInDesiredState(jobTemplate, spec), "The drift should be corrected")
+}
+
+// TestVariablesMergePolicy verifies that the Merge policy deep-merges the
+// declared variables over those in AWX and keeps the keys set elsewhere
+func TestVariablesMergePolicy(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	inventory := server.Add("inventories", map[string]interface{}{
+		"name":      "fleet",
+		"variables": "ntp:\n  servers: [pool.ntp.org]\n  iburst: true\nowner: cmdb\n",
+	})
+	host := server.Add("hosts", map[string]interface{}{
+		"name":      "web-1",
+		"inventory": inventory["id"],
+		"variables": `{"rack": "a1", "port": 80}`,
+	})
+	server.Associate("inventories", inventory["id"].(int), "hosts", host["id"].(int))
+
+	im := NewInventoryManager(newTestClient(server))
+	spec := awxv1alpha1.InventorySpec{
+		Name:                 "fleet",
+		Variables:            "ntp:\n  servers: [time.example.com]\n",
+		VariablesMergePolicy: awxv1alpha1.VariablesMergePolicyMerge,
+		Hosts:                []awxv1alpha1.HostSpec{{Name: "web-1", Variables: "port: 8080"}},
+	}
+	actual := server.Object("inventories", "fleet")
+	assert.False(t, im.IsInventoryInDesiredState(actual, spec), "A changed declared key should be drift")
+
+	_, err := im.EnsureInventory(spec)
+	assert.NoError(t, err)
+	variables, err := parseVariables(server.Object("inventories", "fleet")["variables"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ntp":   map[string]interface{}{"servers": []interface{}{"time.example.com"}, "iburst": true},
+		"owner": "cmdb",
+	}, variables)
+	hostVariables, err := parseVariables(server.Object("hosts", "web-1")["variables"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"rack": "a1", "port": float64(8080)}, hostVariables)
+
+	actual = server.Object("inventories", "fleet")
+	assert.True(t, im.IsInventoryInDesiredState(actual, spec), "Keys set elsewhere should not be drift")
+
+	spec.VariablesMergePolicy = awxv1alpha1.VariablesMergePolicyReplace
+	assert.False(t, im.IsInventoryInDesiredState(actual, spec))
+}
+
+// TestSessionTokenRevocation verifies that a replaced session token is
+// revoked and that the current one can be revoked on request
+func TestSessionTokenRevocation(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	awxClient := newTestClient(server)
+	awxClient.SetAuthMethod(AuthMethodToken)
+	pm := NewProjectManager(awxClient)
+
+	_, err := pm.GetProject("test-project")
+	assert.NoError(t, err)
+	first := awxClient.SessionTokenID()
+	assert.Equal(t, []int{first}, server.Tokens())
+
+	// A rejected token is replaced, which revokes it
+	awxClient.invalidateToken()
+	_, err = pm.GetProject("test-project")
+	assert.NoError(t, err)
+	second := awxClient.SessionTokenID()
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, []int{second}, server.Tokens())
+
+	assert.NoError(t, awxClient.RevokeSessionToken())
+	assert.Zero(t, awxClient.SessionTokenID())
+	assert.Empty(t, server.Tokens())
+	assert.NoError(t, awxClient.RevokeToken(second), "Revoking a revoked token should succeed")
+}
+
+// concurrencyProbe is a transport that records the most requests in flight
+// at the same time
+type concurrencyProbe struct {
+	next     http.RoundTripper
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (p *concurrencyProbe) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.mu.Lock()
+	p.inFlight++
+	p.peak = max(p.peak, p.inFlight)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	return p.next.RoundTrip(req)
+}
+
+// TestRequestLimiter verifies that a client keeps to the parallel requests
+// and the request rate of its limiter
+func TestRequestLimiter(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	probe := &concurrencyProbe{next: http.DefaultTransport}
+	client.SetTransport(probe)
+	client.SetRequestLimiter(NewRequestLimiter(RequestLimits{MaxParallel: 2}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.doRequest(http.MethodGet, "ping", nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, probe.peak)
+
+	client.SetRequestLimiter(NewRequestLimiter(RequestLimits{RequestsPerSecond: 20, Burst: 1}))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := client.doRequest(http.MethodGet, "ping", nil)
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond, "Requests should be spread at 20 per second")
+}
+
+// TestInProcessServer verifies that clients of an in-process fake AWX are
+// served without a listener, with the configured latency and failures
+func TestInProcessServer(t *testing.T) {
+	server := awxtest.NewInProcess()
+	defer server.Close()
+	awxClient := newTestClient(server)
+	awxClient.SetTransport(server)
+	pm := NewProjectManager(awxClient)
+
+	_, err := pm.EnsureProject(awxv1alpha1.ProjectSpec{Name: "web", SCMType: "git"})
+	assert.NoError(t, err)
+	assert.NotNil(t, server.Object("projects", "web"))
+
+	server.SetLatency(20 * time.Millisecond)
+	start := time.Now()
+	project, err := pm.GetProject("web")
+	assert.NoError(t, err)
+	assert.Equal(t, "web", project["name"])
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	server.SetLatency(0)
+
+	server.Inject(awxtest.Fault{Method: http.MethodDelete, Path: "projects/", Status: http.StatusForbidden, Times: 1})
+	assert.True(t, IsStatus(pm.DeleteProject("web"), http.StatusForbidden))
+	assert.NoError(t, pm.DeleteProject("web"))
+	assert.Nil(t, server.Object("projects", "web"))
+
+	server.SetUnreachable(true)
+	_, err = pm.GetProject("web")
+	assert.ErrorIs(t, err, awxtest.ErrUnreachable)
+}
+
+// decompressingTransport stands in for the proxy in front of AWX, recording
+// the Content-Encoding and decompressed body of each write
+type decompressingTransport struct {
+	mu        sync.Mutex
+	encodings []string
+	bodies    []string
+}
+
+func (d *decompressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	encoding := req.Header.Get("Content-Encoding")
+	reader := io.Reader(req.Body)
+	if encoding == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		reader = gz
+	}
+	data, err := io.ReadAll(reader)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.encodings = append(d.encodings, encoding)
+	d.bodies = append(d.bodies, string(data))
+	d.mu.Unlock()
+
+	req = req.Clone(req.Context())
+	req.Header.Del("Content-Encoding")
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestCompressedRequestBodies verifies that request bodies are sent gzip
+// compressed with a Content-Encoding header only when the option is set
+func TestCompressedRequestBodies(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+
+	for _, compress := range []bool{true, false} {
+		SetTransportOptions(TransportOptions{CompressRequests: compress})
+		client := newTestClient(server)
+		SetTransportOptions(TransportOptions{})
+		proxy := &decompressingTransport{}
+		client.SetTransport(proxy)
+
+		name := fmt.Sprintf("web-%t", compress)
+		_, err := client.CreateObject("projects", map[string]interface{}{"name": name}, "project")
+		assert.NoError(t, err)
+		assert.NotNil(t, server.Object("projects", name), "the body reaches AWX")
+
+		want := ""
+		if compress {
+			want = "gzip"
+		}
+		if assert.Len(t, proxy.bodies, 1) {
+			assert.Equal(t, want, proxy.encodings[0])
+			assert.Contains(t, proxy.bodies[0], `"name":"`+name+`"`)
+		}
+	}
+}
+
+// TestDetectAPIPathConcurrently verifies that probing the API path prefixes
+// doesn't redirect the requests other users of the client send meanwhile
+func TestDetectAPIPathConcurrently(t *testing.T) {
+	server := awxtest.NewServer()
+	defer server.Close()
+	client := newTestClient(server)
+	known := KnownAPIPaths
+	KnownAPIPaths = []string{"api/controller/v2", DefaultAPIPath}
+	defer func() { KnownAPIPaths = known }()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_, err := client.Ping()
+			assert.NoError(t, err, "Requests during the detection should keep the configured prefix")
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		apiPath, err := client.DetectAPIPath()
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultAPIPath, apiPath)
+	}
+	wg.Wait()
+	assert.Equal(t, DefaultAPIPath, client.APIPath())
+}
@@ -2,10 +2,22 @@ package awx
 
 import (
 	"fmt"
+	"net/http"
 
 	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
 )
 
+const (
+	// defaultMaxHostDeletionPercent is used when an InventorySpec doesn't set MaxHostDeletionPercent
+	defaultMaxHostDeletionPercent = 50
+	// unguardedHostDeletions is the number of hosts that may always be deleted,
+	// so that small inventories can still be edited freely
+	unguardedHostDeletions = 5
+	// bulkHostChunkSize is the number of hosts created per bulk request, the
+	// default limit of AWX
+	bulkHostChunkSize = 100
+)
+
 // InventoryManager handles AWX Inventory resources
 type InventoryManager struct {
 	client *Client
@@ -20,40 +32,44 @@ func NewInventoryManager(client *Client) *InventoryManager {
 
 // GetInventory retrieves an inventory by name
 func (im *InventoryManager) GetInventory(name string) (map[string]interface{}, error) {
-	log.Info("Fetching inventory by name", "name", name)
+	im.client.log.Info("Fetching inventory by name", "name", name)
 	return im.client.FindObjectByName("inventories", name)
 }
 
 // IsInventoryInDesiredState checks if the inventory matches the desired specification
-func (im *InventoryManager) IsInventoryInDesiredState(inventory map[string]interface{}, inventorySpec awxv1alpha1.InventorySpec) bool {
-	// Check name
-	if name, ok := inventory["name"].(string); !ok || name != inventorySpec.Name {
+func (im *InventoryManager) IsInventoryInDesiredState(object map[string]interface{}, inventorySpec awxv1alpha1.InventorySpec) bool {
+	inventory, err := DecodeObject[Inventory](object)
+	if err != nil {
 		return false
 	}
 
-	// Check description
-	if description, ok := inventory["description"].(string); !ok || description != inventorySpec.Description {
+	// Check name and description
+	if inventory.Name != inventorySpec.Name || inventory.Description != inventorySpec.Description {
 		return false
 	}
 
 	// Check variables
 	if inventorySpec.Variables != "" {
-		if variables, ok := inventory["variables"].(string); !ok || variables != inventorySpec.Variables {
+		_, changed, err := desiredVariables(inventory.Variables, inventorySpec.Variables, inventorySpec.VariablesMergePolicy)
+		if err != nil || changed {
 			return false
 		}
 	}
 
 	// Check hosts
 	if len(inventorySpec.Hosts) > 0 {
-		// Get inventory ID for host operations
-		inventoryID, err := getObjectID(inventory)
-		if err != nil {
+		// Compare the number of hosts first, which needs no listing of
+		// large inventories whose size already differs. Copies may hold the
+		// hosts of their copy source in addition.
+		hostsEndpoint := fmt.Sprintf("inventories/%d/hosts", inventory.ID)
+		count, err := im.client.CountObjects(hostsEndpoint, nil)
+		if err != nil || count < len(inventorySpec.Hosts) ||
+			(inventorySpec.CopyFrom == "" && count != len(inventorySpec.Hosts)) {
 			return false
 		}
 
 		// Get existing hosts
-		hostsEndpoint := fmt.Sprintf("inventories/%d/hosts", inventoryID)
-		existingHosts, err := im.client.ListObjects(hostsEndpoint, nil)
+		existingHosts, err := im.client.ListAllObjects(hostsEndpoint, nil)
 		if err != nil {
 			return false
 		}
@@ -67,8 +83,13 @@ func (im *InventoryManager) IsInventoryInDesiredState(inventory map[string]inter
 			}
 		}
 
-		// Check if all desired hosts exist with correct configuration
-		for _, hostSpec := range inventorySpec.Hosts {
+		// Check if all desired hosts exist with correct configuration, with
+		// the host defaults merged in
+		hosts, _, err := effectiveHosts(inventorySpec)
+		if err != nil {
+			return false
+		}
+		for _, hostSpec := range hosts {
 			existingHost, exists := existingHostMap[hostSpec.Name]
 			if !exists {
 				// Host doesn't exist
@@ -76,45 +97,75 @@ func (im *InventoryManager) IsInventoryInDesiredState(inventory map[string]inter
 			}
 
 			// Check host configuration
-			if !im.isHostInDesiredState(existingHost, hostSpec) {
+			if !im.isHostInDesiredState(existingHost, hostSpec, inventorySpec.VariablesMergePolicy) {
 				return false
 			}
 		}
 
-		// Check if there are extra hosts that are not in the desired state
+		// Check if there are extra hosts that are not in the desired state,
+		// other than the hosts the inventory was copied with
 		if len(existingHosts) != len(inventorySpec.Hosts) {
-			return false
+			keptHostNames, err := im.copiedHostNames(inventorySpec)
+			if err != nil {
+				return false
+			}
+			for _, hostSpec := range hosts {
+				keptHostNames[hostSpec.Name] = true
+			}
+			for name := range existingHostMap {
+				if !keptHostNames[name] {
+					return false
+				}
+			}
 		}
 	}
 
+	// Check the sources and their update schedules
+	if len(inventorySpec.Sources) > 0 && !im.sourcesInDesiredState(inventory.ID, inventorySpec) {
+		return false
+	}
+
 	return true
 }
 
 // isHostInDesiredState checks if a host matches the desired specification
-func (im *InventoryManager) isHostInDesiredState(host map[string]interface{}, hostSpec awxv1alpha1.HostSpec) bool {
+func (im *InventoryManager) isHostInDesiredState(host map[string]interface{}, hostSpec awxv1alpha1.HostSpec, policy string) bool {
 	// Check name
 	if name, ok := host["name"].(string); !ok || name != hostSpec.Name {
 		return false
 	}
 
-	// Check description
+	return len(hostChanges(host, hostSpec, policy)) == 0
+}
+
+// hostChanges returns the fields of an existing host that differ from the
+// desired specification, so only those are sent in the PATCH. Variables are
+// left alone when the spec doesn't set them, and written according to the
+// variables merge policy of the inventory otherwise.
+func hostChanges(host map[string]interface{}, hostSpec awxv1alpha1.HostSpec, policy string) map[string]interface{} {
+	changes := map[string]interface{}{}
+
 	if description, ok := host["description"].(string); !ok || description != hostSpec.Description {
-		return false
+		changes["description"] = hostSpec.Description
 	}
 
-	// Check variables
 	if hostSpec.Variables != "" {
-		if variables, ok := host["variables"].(string); !ok || variables != hostSpec.Variables {
-			return false
+		variables, changed, err := desiredVariables(host["variables"], hostSpec.Variables, policy)
+		if err != nil {
+			// Invalid variables are sent as declared, so AWX reports the error
+			variables, changed = hostSpec.Variables, true
+		}
+		if changed {
+			changes["variables"] = variables
 		}
 	}
 
-	return true
+	return changes
 }
 
 // EnsureInventory ensures that an inventory exists with the specified configuration
 func (im *InventoryManager) EnsureInventory(inventorySpec awxv1alpha1.InventorySpec) (map[string]interface{}, error) {
-	log.Info("Ensuring inventory exists with desired configuration", "name", inventorySpec.Name)
+	im.client.log.Info("Ensuring inventory exists with desired configuration", "name", inventorySpec.Name)
 
 	// First, check if inventory exists
 	inventory, err := im.client.FindObjectByName("inventories", inventorySpec.Name)
@@ -138,40 +189,61 @@ func (im *InventoryManager) EnsureInventory(inventorySpec awxv1alpha1.InventoryS
 	// Create or update inventory
 	if inventory == nil {
 		// Inventory doesn't exist, create it
-		log.Info("Creating AWX inventory", "name", inventorySpec.Name, "organization", orgID)
-		inventory, err = im.client.CreateObject("inventories", inventoryData, "inventory")
+		if inventorySpec.CopyFrom != "" {
+			im.client.log.Info("Creating AWX inventory as a copy", "name", inventorySpec.Name, "copyFrom", inventorySpec.CopyFrom)
+			inventory, err = createFromCopy(im.client, "inventories", inventorySpec.CopyFrom, inventoryData)
+		} else {
+			im.client.log.Info("Creating AWX inventory", "name", inventorySpec.Name, "organization", orgID)
+			inventory, err = im.client.CreateObject("inventories", inventoryData, "inventory")
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to create inventory: %w", err)
 		}
 
 		// Verify new inventory has an ID
 		if _, ok := inventory["id"]; !ok {
-			log.Error(nil, "Newly created inventory missing ID field",
+			im.client.log.Error(nil, "Newly created inventory missing ID field",
 				"name", inventorySpec.Name,
 				"keys", getMapKeys(inventory))
 			return nil, fmt.Errorf("created inventory '%s' has no ID field", inventorySpec.Name)
 		}
 
-		log.Info("Successfully created inventory",
+		im.client.log.Info("Successfully created inventory",
 			"name", inventorySpec.Name,
 			"id", inventory["id"])
 	} else {
 		// Inventory exists, update it
 		inventoryID, err = getObjectID(inventory)
 		if err != nil {
-			log.Error(err, "Cannot get ID from existing inventory",
+			im.client.log.Error(err, "Cannot get ID from existing inventory",
 				"name", inventorySpec.Name,
 				"keys", getMapKeys(inventory))
 			return nil, fmt.Errorf("failed to get ID from existing inventory '%s': %w", inventorySpec.Name, err)
 		}
 
-		log.Info("Updating AWX inventory", "name", inventorySpec.Name, "id", inventoryID)
-		inventory, err = im.client.UpdateObject("inventories", inventoryID, inventoryData)
+		// With the Merge policy the declared variables are merged over those
+		// in AWX, which are kept when none are declared
+		if inventorySpec.VariablesMergePolicy == awxv1alpha1.VariablesMergePolicyMerge {
+			delete(inventoryData, "variables")
+			if inventorySpec.Variables != "" {
+				variables, _, err := desiredVariables(inventory["variables"], inventorySpec.Variables, inventorySpec.VariablesMergePolicy)
+				if err != nil {
+					return nil, fmt.Errorf("inventory %s: %w", inventorySpec.Name, err)
+				}
+				inventoryData["variables"] = variables
+			}
+		}
+
+		im.client.log.Info("Updating AWX inventory", "name", inventorySpec.Name, "id", inventoryID)
+		err = im.client.retryOnConflict("update inventory "+inventorySpec.Name, func() error {
+			inventory, err = im.client.UpdateObject("inventories", inventoryID, inventoryData)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to update inventory: %w", err)
 		}
 
-		log.Info("Successfully updated inventory",
+		im.client.log.Info("Successfully updated inventory",
 			"name", inventorySpec.Name,
 			"id", inventoryID)
 	}
@@ -184,25 +256,37 @@ func (im *InventoryManager) EnsureInventory(inventorySpec awxv1alpha1.InventoryS
 
 	// Process hosts if defined
 	if len(inventorySpec.Hosts) > 0 {
-		log.Info("Reconciling inventory hosts",
+		im.client.log.Info("Reconciling inventory hosts",
 			"inventory", inventorySpec.Name,
 			"count", len(inventorySpec.Hosts))
-		err = im.reconcileHosts(inventoryID, inventorySpec.Hosts)
+		err = im.reconcileHosts(inventoryID, inventorySpec)
 		if err != nil {
 			return nil, fmt.Errorf("failed to reconcile hosts for inventory '%s': %w", inventorySpec.Name, err)
 		}
 	}
 
+	// Process sources if defined
+	if len(inventorySpec.Sources) > 0 {
+		if err := im.reconcileSources(inventoryID, inventorySpec); err != nil {
+			return nil, fmt.Errorf("failed to reconcile sources for inventory '%s': %w", inventorySpec.Name, err)
+		}
+	}
+
 	return inventory, nil
 }
 
 // reconcileHosts ensures that the hosts in the inventory match the desired state
-func (im *InventoryManager) reconcileHosts(inventoryID int, desiredHosts []awxv1alpha1.HostSpec) error {
+func (im *InventoryManager) reconcileHosts(inventoryID int, inventorySpec awxv1alpha1.InventorySpec) error {
+	desiredHosts, inherited, err := effectiveHosts(inventorySpec)
+	if err != nil {
+		return err
+	}
+
 	// Per AWX API: use the related hosts endpoint for an inventory
 	hostsEndpoint := fmt.Sprintf("inventories/%d/hosts", inventoryID)
-	log.Info("Fetching existing hosts", "endpoint", hostsEndpoint)
+	im.client.log.Info("Fetching existing hosts", "endpoint", hostsEndpoint)
 
-	existingHosts, err := im.client.ListObjects(hostsEndpoint, nil)
+	existingHosts, err := im.client.ListAllObjects(hostsEndpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to list existing hosts: %w", err)
 	}
@@ -216,74 +300,241 @@ func (im *InventoryManager) reconcileHosts(inventoryID int, desiredHosts []awxv1
 		}
 	}
 
-	// Track desired host names to identify hosts to remove
-	desiredHostNames := make(map[string]bool)
+	// Track desired host names to identify hosts to remove. The hosts an
+	// inventory was copied with aren't declared but are kept as well.
+	keptHostNames, err := im.copiedHostNames(inventorySpec)
+	if err != nil {
+		return err
+	}
+	for _, hostSpec := range desiredHosts {
+		keptHostNames[hostSpec.Name] = true
+	}
+
+	// Refuse to delete a large share of the inventory unless acknowledged,
+	// since that usually means the spec was trimmed by accident
+	if err := im.checkHostDeletionThreshold(inventorySpec, existingHostMap, keptHostNames); err != nil {
+		return err
+	}
 
-	// Create or update hosts according to AWX API docs
+	// Patch the changed fields of existing hosts and collect the new ones.
+	// Unchanged hosts cost no request, which matters for large inventories
+	// where a reconcile typically touches only a few hosts.
+	var newHosts []map[string]interface{}
+	var updates []func() error
+	unchanged := 0
 	for _, hostSpec := range desiredHosts {
-		desiredHostNames[hostSpec.Name] = true
-
-		// Map host spec to AWX API fields
-		hostData := map[string]interface{}{
-			"name":        hostSpec.Name,
-			"description": hostSpec.Description,
-			"inventory":   inventoryID,
-			"variables":   hostSpec.Variables,
+		existingHost, exists := existingHostMap[hostSpec.Name]
+		if !exists {
+			// Map host spec to AWX API fields
+			newHosts = append(newHosts, map[string]interface{}{
+				"name":        hostSpec.Name,
+				"description": hostSpec.Description,
+				"inventory":   inventoryID,
+				"variables":   hostSpec.Variables,
+			})
+			continue
 		}
 
-		if existingHost, exists := existingHostMap[hostSpec.Name]; exists {
-			// Update existing host
-			hostID, err := getObjectID(existingHost)
-			if err != nil {
-				return fmt.Errorf("failed to get host ID: %w", err)
-			}
+		changes := hostChanges(existingHost, hostSpec, inventorySpec.VariablesMergePolicy)
+		if len(changes) == 0 {
+			unchanged++
+			continue
+		}
+
+		hostID, err := getObjectID(existingHost)
+		if err != nil {
+			return fmt.Errorf("failed to get host ID: %w", err)
+		}
 
-			log.Info("Updating AWX host",
-				"name", hostSpec.Name,
+		name := hostSpec.Name
+		fromDefaults := inherited[name]
+		updates = append(updates, func() error {
+			im.client.log.Info("Updating AWX host",
+				"name", name,
 				"id", hostID,
-				"inventory", inventoryID)
-			_, err = im.client.UpdateObject("hosts", hostID, hostData)
+				"inventory", inventoryID,
+				"fields", getMapKeys(changes),
+				"fromHostDefaults", fromDefaults)
+			err := im.client.retryOnConflict("update host "+name, func() error {
+				_, err := im.client.UpdateObject("hosts", hostID, changes)
+				return err
+			})
 			if err != nil {
-				return fmt.Errorf("failed to update host %s: %w", hostSpec.Name, err)
+				return fmt.Errorf("failed to update host %s: %w", name, err)
 			}
-		} else {
-			// Create new host
-			log.Info("Creating AWX host",
-				"name", hostSpec.Name,
-				"inventory", inventoryID)
-			_, err := im.client.CreateObject("hosts", hostData, "host")
-			if err != nil {
-				return fmt.Errorf("failed to create host %s: %w", hostSpec.Name, err)
-			}
-		}
+			return nil
+		})
+	}
+	if unchanged > 0 {
+		im.client.log.Info("Skipped unchanged AWX hosts", "inventory", inventoryID, "count", unchanged)
+	}
+	if err := runConcurrently(int(hostConcurrency.Load()), updates); err != nil {
+		return err
+	}
+
+	// Create the new hosts in chunks
+	if err := im.createHosts(inventoryID, newHosts); err != nil {
+		return err
 	}
 
 	// Remove hosts that are not in the desired state
 	// According to AWX API docs, we should use the DELETE method on each host
+	var deletions []func() error
 	for name, host := range existingHostMap {
-		if !desiredHostNames[name] {
+		if !keptHostNames[name] {
 			hostID, err := getObjectID(host)
 			if err != nil {
 				return fmt.Errorf("failed to get host ID for deletion: %w", err)
 			}
 
-			log.Info("Deleting AWX host",
-				"name", name,
-				"id", hostID,
-				"inventory", inventoryID)
-			err = im.client.DeleteObject("hosts", hostID)
-			if err != nil {
-				return fmt.Errorf("failed to delete host %s: %w", name, err)
-			}
+			deletions = append(deletions, func() error {
+				im.client.log.Info("Deleting AWX host",
+					"name", name,
+					"id", hostID,
+					"inventory", inventoryID)
+				err := im.client.retryOnConflict("delete host "+name, func() error {
+					return im.client.DeleteObject("hosts", hostID)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to delete host %s: %w", name, err)
+				}
+				return nil
+			})
 		}
 	}
+	if err := runConcurrently(int(hostConcurrency.Load()), deletions); err != nil {
+		return err
+	}
 
-	log.Info("Host reconciliation complete",
+	im.client.log.Info("Host reconciliation complete",
 		"inventory", inventoryID,
 		"hostCount", len(desiredHosts))
 	return nil
 }
 
+// copiedHostNames returns the names of the hosts in the copy source of the
+// inventory, which AWX copied along with it. Once the source is gone, the
+// copied hosts are no longer known and only the mass deletion guard keeps them.
+func (im *InventoryManager) copiedHostNames(inventorySpec awxv1alpha1.InventorySpec) (map[string]bool, error) {
+	names := make(map[string]bool)
+	if inventorySpec.CopyFrom == "" {
+		return names, nil
+	}
+
+	source, err := im.client.FindObjectByName("inventories", inventorySpec.CopyFrom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find copy source %s: %w", inventorySpec.CopyFrom, err)
+	}
+	if source == nil {
+		im.client.log.Info("Copy source of inventory not found, its copied hosts are no longer kept",
+			"inventory", inventorySpec.Name,
+			"copyFrom", inventorySpec.CopyFrom)
+		return names, nil
+	}
+	sourceID, err := getObjectID(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get copy source ID: %w", err)
+	}
+
+	hosts, err := im.client.ListAllObjects(fmt.Sprintf("inventories/%d/hosts", sourceID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the hosts of copy source %s: %w", inventorySpec.CopyFrom, err)
+	}
+	for _, host := range hosts {
+		if name, ok := host["name"].(string); ok {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// createHosts creates hosts with the bulk API in chunks of bulkHostChunkSize,
+// so that large inventories neither exceed the AWX request limits nor need a
+// request per host. AWX versions without the bulk API get one request per host.
+func (im *InventoryManager) createHosts(inventoryID int, hosts []map[string]interface{}) error {
+	for start := 0; start < len(hosts); start += bulkHostChunkSize {
+		chunk := hosts[start:min(start+bulkHostChunkSize, len(hosts))]
+		im.client.log.Info("Creating AWX hosts in bulk",
+			"inventory", inventoryID,
+			"count", len(chunk),
+			"offset", start)
+
+		hostsData := make([]map[string]interface{}, 0, len(chunk))
+		for _, hostData := range chunk {
+			hostsData = append(hostsData, map[string]interface{}{
+				"name":        hostData["name"],
+				"description": hostData["description"],
+				"variables":   hostData["variables"],
+			})
+		}
+		_, err := im.client.doRequest(http.MethodPost, "bulk/host_create", map[string]interface{}{
+			"inventory": inventoryID,
+			"hosts":     hostsData,
+		})
+		if IsStatus(err, http.StatusNotFound) {
+			im.client.log.Info("Bulk API not available, creating hosts one by one", "inventory", inventoryID)
+			return im.createHostsOneByOne(hosts[start:])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create hosts %d to %d: %w", start+1, start+len(chunk), err)
+		}
+	}
+	return nil
+}
+
+// createHostsOneByOne creates hosts with a request per host, in parallel
+func (im *InventoryManager) createHostsOneByOne(hosts []map[string]interface{}) error {
+	creations := make([]func() error, 0, len(hosts))
+	for _, hostData := range hosts {
+		creations = append(creations, func() error {
+			im.client.log.Info("Creating AWX host",
+				"name", hostData["name"],
+				"inventory", hostData["inventory"])
+			if _, err := im.client.CreateObject("hosts", hostData, "host"); err != nil {
+				return fmt.Errorf("failed to create host %s: %w", hostData["name"], err)
+			}
+			return nil
+		})
+	}
+	return runConcurrently(int(hostConcurrency.Load()), creations)
+}
+
+// checkHostDeletionThreshold returns a MassDeletionError if removing the undesired
+// hosts would exceed the inventory's deletion threshold
+func (im *InventoryManager) checkHostDeletionThreshold(inventorySpec awxv1alpha1.InventorySpec,
+	existingHostMap map[string]map[string]interface{}, keptHostNames map[string]bool) error {
+	if inventorySpec.AllowMassDeletion || len(existingHostMap) == 0 {
+		return nil
+	}
+
+	toDelete := 0
+	for name := range existingHostMap {
+		if !keptHostNames[name] {
+			toDelete++
+		}
+	}
+
+	maxPercent := inventorySpec.MaxHostDeletionPercent
+	if maxPercent <= 0 {
+		maxPercent = defaultMaxHostDeletionPercent
+	}
+
+	if toDelete > unguardedHostDeletions && toDelete*100 > int(maxPercent)*len(existingHostMap) {
+		im.client.log.Info("Refusing mass deletion of hosts",
+			"inventory", inventorySpec.Name,
+			"existing", len(existingHostMap),
+			"toDelete", toDelete,
+			"maxPercent", maxPercent)
+		return &MassDeletionError{
+			Inventory:     inventorySpec.Name,
+			ExistingHosts: len(existingHostMap),
+			HostsToDelete: toDelete,
+			MaxPercent:    maxPercent,
+		}
+	}
+	return nil
+}
+
 // DeleteInventory deletes an inventory by name
 func (im *InventoryManager) DeleteInventory(name string) error {
 	inventory, err := im.client.FindObjectByName("inventories", name)
@@ -301,6 +552,12 @@ func (im *InventoryManager) DeleteInventory(name string) error {
 		return err
 	}
 
-	log.Info("Deleting AWX inventory", "name", name, "id", id)
-	return im.client.DeleteObject("inventories", id)
+	im.client.log.Info("Deleting AWX inventory", "name", name, "id", id)
+	err = im.client.retryOnConflict("delete inventory "+name, func() error {
+		return im.client.DeleteObject("inventories", id)
+	})
+	if err != nil {
+		return asDependencyError("inventory", name, err)
+	}
+	return nil
 }
@@ -0,0 +1,179 @@
+package awx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// inventoryResync is how long looked up objects are served from the inventory
+// cache before all of them are read from AWX again. Zero disables the cache.
+var inventoryResync atomic.Int64
+
+// SetInventoryResync sets how long objects looked up by name are served from
+// the in-memory inventory of each client before a full resync reads them from
+// AWX again, typically once from operator flags at startup. Zero or less
+// disables the cache, so every drift check reads from AWX.
+func SetInventoryResync(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	inventoryResync.Store(int64(interval))
+}
+
+// inventoryEntry is an object of the inventory snapshot, kept as the JSON it
+// was read as so that every lookup returns a copy the caller may change
+type inventoryEntry struct {
+	id         int
+	hash       string
+	data       []byte
+	generation int
+}
+
+// verifiedSpec records the spec an object was last reconciled from
+type verifiedSpec struct {
+	hash       string
+	generation int
+}
+
+// objectInventory is a read-through snapshot of the AWX objects a client
+// looked up by name, keyed by endpoint and name. Each resync starts a new
+// generation. Entries of older generations are no longer served, but their
+// hashes tell whether an object changed in AWX since it was last read.
+type objectInventory struct {
+	mu         sync.Mutex
+	objects    map[string]map[string]inventoryEntry
+	verified   map[string]verifiedSpec
+	generation int
+	syncedAt   time.Time
+}
+
+// advance starts a new generation when the resync interval has passed. The
+// caller holds mu.
+func (inv *objectInventory) advance(logger logr.Logger, resync time.Duration) {
+	if time.Since(inv.syncedAt) >= resync {
+		inv.generation++
+		inv.syncedAt = time.Now()
+		logger.V(1).Info("Resyncing object inventory", "generation", inv.generation)
+	}
+}
+
+// lookup returns a copy of the object of the current generation, starting a
+// new generation first when the resync interval has passed
+func (inv *objectInventory) lookup(logger logr.Logger, endpoint, name string) (map[string]interface{}, bool) {
+	resync := time.Duration(inventoryResync.Load())
+	if resync <= 0 {
+		return nil, false
+	}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.advance(logger, resync)
+
+	entry, ok := inv.objects[endpoint][name]
+	if !ok || entry.generation != inv.generation {
+		inventoryLookups.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(entry.data, &object); err != nil {
+		return nil, false
+	}
+	inventoryLookups.WithLabelValues("hit").Inc()
+	return object, true
+}
+
+// store adds an object read from AWX to the current generation, logging when
+// it differs from the snapshot of the previous generation
+func (inv *objectInventory) store(logger logr.Logger, endpoint, name string, object map[string]interface{}) {
+	if inventoryResync.Load() <= 0 {
+		return
+	}
+	id, err := getObjectID(object)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+	entry := inventoryEntry{id: id, hash: hex.EncodeToString(sum[:]), data: data}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if previous, ok := inv.objects[endpoint][name]; ok && previous.generation != inv.generation &&
+		(previous.id != entry.id || previous.hash != entry.hash) {
+		logger.Info("Object changed in AWX since the last resync", "endpoint", endpoint, "name", name, "id", entry.id)
+	}
+	if inv.objects == nil {
+		inv.objects = make(map[string]map[string]inventoryEntry)
+	}
+	if inv.objects[endpoint] == nil {
+		inv.objects[endpoint] = make(map[string]inventoryEntry)
+	}
+	entry.generation = inv.generation
+	inv.objects[endpoint][name] = entry
+}
+
+// invalidate drops the objects of every collection a write to endpoint may
+// change, e.g. both job_templates and credentials for
+// "job_templates/5/credentials"
+func (inv *objectInventory) invalidate(endpoint string) {
+	endpoint, _, _ = strings.Cut(endpoint, "?")
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	// Any write may change what other objects refer to, e.g. the ID of a
+	// recreated credential, so none of them counts as verified anymore
+	inv.verified = nil
+	if len(inv.objects) == 0 {
+		return
+	}
+	for _, segment := range strings.Split(strings.Trim(endpoint, "/"), "/") {
+		if _, err := strconv.Atoi(segment); err != nil {
+			delete(inv.objects, segment)
+		}
+	}
+}
+
+// SpecVerified returns whether the object was reconciled from a spec with the
+// given hash in the current inventory generation, and the client has not
+// written to AWX since. Reconciling it again can then be skipped without
+// reading it from AWX. It always returns false while the cache is disabled.
+func (c *Client) SpecVerified(endpoint, name, specHash string) bool {
+	resync := time.Duration(inventoryResync.Load())
+	if resync <= 0 {
+		return false
+	}
+
+	c.inventory.mu.Lock()
+	defer c.inventory.mu.Unlock()
+	c.inventory.advance(c.log, resync)
+	verified, ok := c.inventory.verified[endpoint+"/"+name]
+	return ok && verified.hash == specHash && verified.generation == c.inventory.generation
+}
+
+// MarkSpecVerified records that the object matches the spec with the given
+// hash, until the next inventory resync or write of the client
+func (c *Client) MarkSpecVerified(endpoint, name, specHash string) {
+	resync := time.Duration(inventoryResync.Load())
+	if resync <= 0 {
+		return
+	}
+
+	c.inventory.mu.Lock()
+	defer c.inventory.mu.Unlock()
+	c.inventory.advance(c.log, resync)
+	if c.inventory.verified == nil {
+		c.inventory.verified = make(map[string]verifiedSpec)
+	}
+	c.inventory.verified[endpoint+"/"+name] = verifiedSpec{hash: specHash, generation: c.inventory.generation}
+}
@@ -0,0 +1,235 @@
+package awx
+
+import (
+	"fmt"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// ValidateInventorySource checks the update schedule of an inventory source
+func ValidateInventorySource(sourceSpec awxv1alpha1.InventorySourceSpec) error {
+	if sourceSpec.UpdateSchedule == nil {
+		return nil
+	}
+	return ValidateUpdateSchedule(*sourceSpec.UpdateSchedule)
+}
+
+// inventorySourceData maps the spec of an inventory source to AWX API
+// fields, resolving its project and credential
+func (im *InventoryManager) inventorySourceData(sourceSpec awxv1alpha1.InventorySourceSpec) (map[string]interface{}, error) {
+	sourceData := map[string]interface{}{
+		"name":                 sourceSpec.Name,
+		"description":          sourceSpec.Description,
+		"source":               sourceSpec.Source,
+		"source_path":          sourceSpec.SourcePath,
+		"source_vars":          sourceSpec.SourceVars,
+		"overwrite":            sourceSpec.Overwrite,
+		"overwrite_vars":       sourceSpec.OverwriteVars,
+		"update_on_launch":     sourceSpec.UpdateOnLaunch,
+		"update_cache_timeout": sourceSpec.UpdateCacheTimeout,
+		"source_project":       nil,
+		"credential":           nil,
+	}
+
+	if sourceSpec.SourceProject != "" {
+		project, err := im.client.FindObjectByName("projects", sourceSpec.SourceProject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find source project: %w", err)
+		}
+		if project == nil {
+			return nil, fmt.Errorf("source %w", &ReferenceNotFoundError{Kind: "project", Name: sourceSpec.SourceProject})
+		}
+		projectID, err := getObjectID(project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source project ID: %w", err)
+		}
+		sourceData["source_project"] = projectID
+	}
+	if sourceSpec.Credential != "" {
+		credential, err := im.client.FindObjectByName("credentials", sourceSpec.Credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find source credential: %w", err)
+		}
+		if credential == nil {
+			return nil, fmt.Errorf("source %w", &ReferenceNotFoundError{Kind: "credential", Name: sourceSpec.Credential})
+		}
+		credentialID, err := getObjectID(credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source credential ID: %w", err)
+		}
+		sourceData["credential"] = credentialID
+	}
+	return sourceData, nil
+}
+
+// inventorySourceChanges returns the fields of an existing inventory source
+// that differ from the desired data. IDs and numbers are decoded from JSON as
+// float64, so they are compared as such.
+func inventorySourceChanges(source, sourceData map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for field, desired := range sourceData {
+		current := source[field]
+		switch value := desired.(type) {
+		case int:
+			if number, ok := current.(float64); !ok || int(number) != value {
+				changes[field] = desired
+			}
+		case int32:
+			if number, ok := current.(float64); !ok || int32(number) != value {
+				changes[field] = desired
+			}
+		case nil:
+			if current != nil {
+				changes[field] = desired
+			}
+		default:
+			if current != desired {
+				changes[field] = desired
+			}
+		}
+	}
+	return changes
+}
+
+// DeleteUpdateSchedules deletes the declared update schedules of the sources of
+// the inventory and leaves the sources in place
+func (im *InventoryManager) DeleteUpdateSchedules(inventorySpec awxv1alpha1.InventorySpec) error {
+	scheduled := map[string]string{}
+	for _, sourceSpec := range inventorySpec.Sources {
+		if sourceSpec.UpdateSchedule != nil {
+			scheduled[sourceSpec.Name] = sourceSpec.UpdateSchedule.Name
+		}
+	}
+	if len(scheduled) == 0 {
+		return nil
+	}
+	inventory, err := im.client.FindObjectByName("inventories", inventorySpec.Name)
+	if err != nil || inventory == nil {
+		return err
+	}
+	inventoryID, err := getObjectID(inventory)
+	if err != nil {
+		return fmt.Errorf("failed to get inventory ID: %w", err)
+	}
+
+	sources, err := im.client.ListRelated("inventories", inventoryID, "inventory_sources")
+	if err != nil {
+		return fmt.Errorf("failed to list inventory sources: %w", err)
+	}
+	for _, source := range sources {
+		name, _ := source["name"].(string)
+		schedule, ok := scheduled[name]
+		if !ok {
+			continue
+		}
+		sourceID, err := getObjectID(source)
+		if err != nil {
+			return fmt.Errorf("failed to get inventory source ID: %w", err)
+		}
+		if err := im.client.deleteNamedSchedules("inventory_sources", sourceID, []string{schedule}); err != nil {
+			return fmt.Errorf("inventory source %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// sourcesInDesiredState checks if exactly the declared sources exist on the
+// inventory as declared, with their update schedules
+func (im *InventoryManager) sourcesInDesiredState(inventoryID int, inventorySpec awxv1alpha1.InventorySpec) bool {
+	sources, err := im.client.ListRelated("inventories", inventoryID, "inventory_sources")
+	if err != nil || len(sources) != len(inventorySpec.Sources) {
+		return false
+	}
+	existing := make(map[string]map[string]interface{}, len(sources))
+	for _, source := range sources {
+		if name, ok := source["name"].(string); ok {
+			existing[name] = source
+		}
+	}
+
+	for _, sourceSpec := range inventorySpec.Sources {
+		source, ok := existing[sourceSpec.Name]
+		if !ok {
+			return false
+		}
+		sourceData, err := im.inventorySourceData(sourceSpec)
+		if err != nil || len(inventorySourceChanges(source, sourceData)) > 0 {
+			return false
+		}
+		sourceID, err := getObjectID(source)
+		if err != nil || !im.client.updateScheduleInDesiredState("inventory_sources", sourceID, sourceSpec.UpdateSchedule) {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileSources creates, updates and removes the sources of the inventory
+// and their update schedules
+func (im *InventoryManager) reconcileSources(inventoryID int, inventorySpec awxv1alpha1.InventorySpec) error {
+	sourcesEndpoint := fmt.Sprintf("inventories/%d/inventory_sources", inventoryID)
+	sources, err := im.client.ListRelated("inventories", inventoryID, "inventory_sources")
+	if err != nil {
+		return fmt.Errorf("failed to list inventory sources: %w", err)
+	}
+	existing := make(map[string]map[string]interface{}, len(sources))
+	for _, source := range sources {
+		if name, ok := source["name"].(string); ok {
+			existing[name] = source
+		}
+	}
+
+	desired := make(map[string]bool, len(inventorySpec.Sources))
+	for _, sourceSpec := range inventorySpec.Sources {
+		desired[sourceSpec.Name] = true
+		sourceData, err := im.inventorySourceData(sourceSpec)
+		if err != nil {
+			return fmt.Errorf("inventory source %s: %w", sourceSpec.Name, err)
+		}
+
+		source, exists := existing[sourceSpec.Name]
+		if !exists {
+			im.client.log.Info("Creating AWX inventory source", "name", sourceSpec.Name, "inventory", inventorySpec.Name, "source", sourceSpec.Source)
+			source, err = im.client.CreateObject(sourcesEndpoint, sourceData, "inventory_source")
+			if err != nil {
+				return fmt.Errorf("failed to create inventory source %s: %w", sourceSpec.Name, err)
+			}
+		} else if changes := inventorySourceChanges(source, sourceData); len(changes) > 0 {
+			sourceID, err := getObjectID(source)
+			if err != nil {
+				return fmt.Errorf("failed to get inventory source ID: %w", err)
+			}
+			im.client.log.Info("Updating AWX inventory source", "name", sourceSpec.Name, "id", sourceID, "fields", getMapKeys(changes))
+			err = im.client.retryOnConflict("update inventory source "+sourceSpec.Name, func() error {
+				_, err := im.client.UpdateObject("inventory_sources", sourceID, changes)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update inventory source %s: %w", sourceSpec.Name, err)
+			}
+		}
+
+		sourceID, err := getObjectID(source)
+		if err != nil {
+			return fmt.Errorf("failed to get inventory source ID: %w", err)
+		}
+		if err := im.client.reconcileUpdateSchedule("inventory_sources", sourceID, sourceSpec.UpdateSchedule); err != nil {
+			return fmt.Errorf("inventory source %s: %w", sourceSpec.Name, err)
+		}
+	}
+
+	for name, source := range existing {
+		if desired[name] {
+			continue
+		}
+		sourceID, err := getObjectID(source)
+		if err != nil {
+			return fmt.Errorf("failed to get inventory source ID for deletion: %w", err)
+		}
+		im.client.log.Info("Deleting AWX inventory source", "name", name, "id", sourceID, "inventory", inventorySpec.Name)
+		if err := im.client.DeleteObject("inventory_sources", sourceID); err != nil {
+			return fmt.Errorf("failed to delete inventory source %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job is a run of a job template
+type Job struct {
+	ID int
+	// Status is the job status, e.g. "pending", "running", "successful" or "failed"
+	Status   string
+	Started  time.Time
+	Finished time.Time
+	// Verbosity, DiffMode, JobTags and SkipTags are the launch parameters
+	// the job runs with
+	Verbosity int
+	DiffMode  bool
+	JobTags   string
+	SkipTags  string
+}
+
+// LaunchOptions are the parameters of a job template launch
+type LaunchOptions struct {
+	// CredentialPasswords answer the passwords the credentials of the job
+	// template prompt for on launch, keyed as in passwords_needed_to_start
+	CredentialPasswords map[string]string
+	// SurveyAnswers answer questions of the survey of the job template,
+	// keyed by their variable. The job template must have its survey enabled.
+	SurveyAnswers map[string]string
+	// Verbosity, DiffMode, JobTags and SkipTags override those of the job
+	// template when set. The job template must prompt for them on launch.
+	Verbosity *int32
+	DiffMode  *bool
+	JobTags   *string
+	SkipTags  *string
+}
+
+// launchPrompts maps the launch parameters that can be overridden to the
+// ask_*_on_launch field of the job template that allows it. AWX ignores the
+// parameters a job template doesn't prompt for. Survey answers are sent as
+// extra_vars, which AWX accepts for the questions of an enabled survey.
+var launchPrompts = map[string]string{
+	"verbosity":  "ask_verbosity_on_launch",
+	"diff_mode":  "ask_diff_mode_on_launch",
+	"job_tags":   "ask_tags_on_launch",
+	"skip_tags":  "ask_skip_tags_on_launch",
+	"extra_vars": "survey_enabled",
+}
+
+// launchData returns the launch payload for the options
+func (o LaunchOptions) launchData() map[string]interface{} {
+	launch := map[string]interface{}{}
+	if len(o.CredentialPasswords) > 0 {
+		launch["credential_passwords"] = o.CredentialPasswords
+	}
+	if len(o.SurveyAnswers) > 0 {
+		launch["extra_vars"] = o.SurveyAnswers
+	}
+	if o.Verbosity != nil {
+		launch["verbosity"] = *o.Verbosity
+	}
+	if o.DiffMode != nil {
+		launch["diff_mode"] = *o.DiffMode
+	}
+	if o.JobTags != nil {
+		launch["job_tags"] = *o.JobTags
+	}
+	if o.SkipTags != nil {
+		launch["skip_tags"] = *o.SkipTags
+	}
+	return launch
+}
+
+// validateLaunchPrompts checks that the job template prompts for every
+// launch parameter overridden in launch
+func validateLaunchPrompts(name string, jobTemplate, launch map[string]interface{}) error {
+	var problems []string
+	for field, prompt := range launchPrompts {
+		if _, ok := launch[field]; !ok {
+			continue
+		}
+		if asked, _ := jobTemplate[prompt].(bool); !asked {
+			problems = append(problems, fmt.Sprintf("%s override requires %s", field, prompt))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("job template %s: %s", name, strings.Join(problems, "; "))
+}
+
+// Done reports whether the job finished, successfully or not
+func (j *Job) Done() bool {
+	return !jobRunning[j.Status]
+}
+
+// Succeeded reports whether the job finished successfully
+func (j *Job) Succeeded() bool {
+	return j.Status == "successful"
+}
+
+// LaunchJobTemplate launches the named job template with the options and
+// returns the ID of the job. The credential passwords and survey answers are
+// only sent to AWX and never logged. Overrides the job template doesn't prompt for are refused
+// instead of being ignored by AWX. Every call launches a new job.
+func (jtm *JobTemplateManager) LaunchJobTemplate(name string, options LaunchOptions) (int, error) {
+	jobTemplate, jobTemplateID, err := jtm.findJobTemplate(name)
+	if err != nil {
+		return 0, err
+	}
+
+	launch := options.launchData()
+	if err := validateLaunchPrompts(name, jobTemplate, launch); err != nil {
+		return 0, err
+	}
+	jtm.client.log.Info("Launching job template", "jobTemplate", name, "id", jobTemplateID,
+		"credentialPasswords", len(options.CredentialPasswords), "surveyAnswers", len(options.SurveyAnswers), "verbosity", launch["verbosity"], "diffMode", launch["diff_mode"],
+		"jobTags", launch["job_tags"], "skipTags", launch["skip_tags"])
+	respBody, err := jtm.client.doRequest(http.MethodPost, fmt.Sprintf("job_templates/%d/launch", jobTemplateID),
+		sensitiveBody{value: launch})
+	if err != nil {
+		return 0, fmt.Errorf("failed to launch job template %s: %w", name, err)
+	}
+
+	var launched map[string]interface{}
+	if err := json.Unmarshal(respBody, &launched); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	// AWX returns the job with its ID in both fields
+	if id, ok := launched["job"].(float64); ok {
+		return int(id), nil
+	}
+	return getObjectID(launched)
+}
+
+// FindLaunchedJob returns the ID of the first job of the named job template
+// created at or after since, or 0 if there is none. It finds the job of a
+// launch whose result was never recorded, e.g. because the operator stopped
+// right after the launch.
+func (jtm *JobTemplateManager) FindLaunchedJob(name string, since time.Time) (int, error) {
+	_, jobTemplateID, err := jtm.findJobTemplate(name)
+	if err != nil {
+		return 0, err
+	}
+	jobs, err := jtm.client.ListObjects("jobs", map[string]string{
+		"job_template": strconv.Itoa(jobTemplateID),
+		"created__gte": since.UTC().Format(time.RFC3339),
+		"order_by":     "id",
+		"page_size":    "1",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list jobs of job template %s: %w", name, err)
+	}
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+	return getObjectID(jobs[0])
+}
+
+// findJobTemplate returns the named job template and its ID
+func (jtm *JobTemplateManager) findJobTemplate(name string) (map[string]interface{}, int, error) {
+	jobTemplate, err := jtm.client.FindObjectByName("job_templates", name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find job template: %w", err)
+	}
+	if jobTemplate == nil {
+		return nil, 0, &ReferenceNotFoundError{Kind: "job template", Name: name}
+	}
+	id, err := getObjectID(jobTemplate)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get job template ID: %w", err)
+	}
+	return jobTemplate, id, nil
+}
+
+// GetJob reads the current state of a job
+func (jtm *JobTemplateManager) GetJob(id int) (*Job, error) {
+	object, err := jtm.client.GetObject("jobs", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %d: %w", id, err)
+	}
+	job := &Job{ID: id}
+	job.Status, _ = object["status"].(string)
+	if verbosity, ok := object["verbosity"].(float64); ok {
+		job.Verbosity = int(verbosity)
+	}
+	job.DiffMode, _ = object["diff_mode"].(bool)
+	job.JobTags, _ = object["job_tags"].(string)
+	job.SkipTags, _ = object["skip_tags"].(string)
+	if started, ok := object["started"].(string); ok {
+		job.Started, _ = time.Parse(time.RFC3339, started)
+	}
+	if finished, ok := object["finished"].(string); ok {
+		job.Finished, _ = time.Parse(time.RFC3339, finished)
+	}
+	return job, nil
+}
+
+// JobOutput returns the complete output of a job
+func (jtm *JobTemplateManager) JobOutput(id int) (string, error) {
+	output, err := jtm.client.unifiedJobOutput("jobs", id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get output of job %d: %w", id, err)
+	}
+	return output, nil
+}
@@ -0,0 +1,92 @@
+package awx
+
+import (
+	"fmt"
+	"slices"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// attachedCredentials returns the credentials attached to a job template keyed by name
+func (jtm *JobTemplateManager) attachedCredentials(jobTemplateID int) (map[string]int, error) {
+	credentials, err := jtm.client.ListRelated("job_templates", jobTemplateID, "credentials")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials of job template: %w", err)
+	}
+
+	attached := make(map[string]int, len(credentials))
+	for _, credential := range credentials {
+		name, _ := credential["name"].(string)
+		id, err := getObjectID(credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ID of credential %s: %w", name, err)
+		}
+		attached[name] = id
+	}
+	return attached, nil
+}
+
+// credentialsInDesiredState checks if exactly the declared credentials are
+// attached to the job template
+func (jtm *JobTemplateManager) credentialsInDesiredState(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec) bool {
+	attached, err := jtm.attachedCredentials(jobTemplateID)
+	if err != nil || len(attached) != len(jobTemplateSpec.Credentials) {
+		return false
+	}
+	for _, name := range jobTemplateSpec.Credentials {
+		if _, ok := attached[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileCredentials attaches the declared credentials to the job template
+// and detaches the ones that are not declared. Undeclared credentials are
+// detached first, as AWX allows only one credential of most types. Each
+// change is recorded in changes so it can be rolled back.
+func (jtm *JobTemplateManager) reconcileCredentials(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec, changes *changeSet) error {
+	attached, err := jtm.attachedCredentials(jobTemplateID)
+	if err != nil {
+		return err
+	}
+
+	for name, credentialID := range attached {
+		if slices.Contains(jobTemplateSpec.Credentials, name) {
+			continue
+		}
+		jtm.client.log.Info("Detaching credential from job template", "jobTemplate", jobTemplateSpec.Name, "credential", name)
+		if err := jtm.client.DisassociateRelated("job_templates", jobTemplateID, "credentials", credentialID); err != nil {
+			return fmt.Errorf("failed to detach credential %s: %w", name, err)
+		}
+		changes.record("reattach credential "+name, func() error {
+			return jtm.client.AssociateRelated("job_templates", jobTemplateID, "credentials", credentialID)
+		})
+	}
+
+	for _, name := range jobTemplateSpec.Credentials {
+		if _, ok := attached[name]; ok {
+			continue
+		}
+		credential, err := jtm.client.FindObjectByName("credentials", name)
+		if err != nil {
+			return fmt.Errorf("failed to find credential %s: %w", name, err)
+		}
+		if credential == nil {
+			return &ReferenceNotFoundError{Kind: "credential", Name: name}
+		}
+		credentialID, err := getObjectID(credential)
+		if err != nil {
+			return fmt.Errorf("failed to get credential ID: %w", err)
+		}
+		jtm.client.log.Info("Attaching credential to job template", "jobTemplate", jobTemplateSpec.Name, "credential", name)
+		if err := jtm.client.AssociateRelated("job_templates", jobTemplateID, "credentials", credentialID); err != nil {
+			return fmt.Errorf("failed to attach credential %s: %w", name, err)
+		}
+		changes.record("detach credential "+name, func() error {
+			return jtm.client.DisassociateRelated("job_templates", jobTemplateID, "credentials", credentialID)
+		})
+	}
+
+	return nil
+}
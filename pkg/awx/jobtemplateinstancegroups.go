@@ -0,0 +1,110 @@
+package awx
+
+import (
+	"fmt"
+	"slices"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// instanceGroup is an instance group attached to a job template
+type instanceGroup struct {
+	name string
+	id   int
+}
+
+// attachedInstanceGroups returns the instance groups of a job template in the
+// order AWX tries them
+func (jtm *JobTemplateManager) attachedInstanceGroups(jobTemplateID int) ([]instanceGroup, error) {
+	groups, err := jtm.client.ListRelated("job_templates", jobTemplateID, "instance_groups")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instance groups of job template: %w", err)
+	}
+
+	attached := make([]instanceGroup, 0, len(groups))
+	for _, group := range groups {
+		name, _ := group["name"].(string)
+		id, err := getObjectID(group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ID of instance group %s: %w", name, err)
+		}
+		attached = append(attached, instanceGroup{name: name, id: id})
+	}
+	return attached, nil
+}
+
+// instanceGroupNames returns the names of the instance groups in order
+func instanceGroupNames(groups []instanceGroup) []string {
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.name)
+	}
+	return names
+}
+
+// instanceGroupsInDesiredState checks if exactly the declared instance groups
+// are attached to the job template, in the declared order
+func (jtm *JobTemplateManager) instanceGroupsInDesiredState(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec) bool {
+	attached, err := jtm.attachedInstanceGroups(jobTemplateID)
+	if err != nil {
+		return false
+	}
+	return slices.Equal(instanceGroupNames(attached), jobTemplateSpec.InstanceGroups)
+}
+
+// setInstanceGroups replaces the instance groups of the job template. AWX
+// keeps them in the order they were attached, so all groups are detached
+// before the wanted ones are attached in order.
+func (jtm *JobTemplateManager) setInstanceGroups(jobTemplateID int, current, wanted []instanceGroup) error {
+	for _, group := range current {
+		if err := jtm.client.DisassociateRelated("job_templates", jobTemplateID, "instance_groups", group.id); err != nil {
+			return fmt.Errorf("failed to detach instance group %s: %w", group.name, err)
+		}
+	}
+	for _, group := range wanted {
+		if err := jtm.client.AssociateRelated("job_templates", jobTemplateID, "instance_groups", group.id); err != nil {
+			return fmt.Errorf("failed to attach instance group %s: %w", group.name, err)
+		}
+	}
+	return nil
+}
+
+// reconcileInstanceGroups attaches the declared instance groups to the job
+// template in the declared order when they differ from the attached ones.
+// The change is recorded in changes so the previous groups can be restored.
+func (jtm *JobTemplateManager) reconcileInstanceGroups(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec, changes *changeSet) error {
+	attached, err := jtm.attachedInstanceGroups(jobTemplateID)
+	if err != nil {
+		return err
+	}
+	if slices.Equal(instanceGroupNames(attached), jobTemplateSpec.InstanceGroups) {
+		return nil
+	}
+
+	wanted := make([]instanceGroup, 0, len(jobTemplateSpec.InstanceGroups))
+	for _, name := range jobTemplateSpec.InstanceGroups {
+		group, err := jtm.client.FindObjectByName("instance_groups", name)
+		if err != nil {
+			return fmt.Errorf("failed to find instance group %s: %w", name, err)
+		}
+		if group == nil {
+			return &ReferenceNotFoundError{Kind: "instance group", Name: name}
+		}
+		groupID, err := getObjectID(group)
+		if err != nil {
+			return fmt.Errorf("failed to get instance group ID: %w", err)
+		}
+		wanted = append(wanted, instanceGroup{name: name, id: groupID})
+	}
+
+	jtm.client.log.Info("Setting instance groups of job template", "jobTemplate", jobTemplateSpec.Name, "instanceGroups", jobTemplateSpec.InstanceGroups)
+	// The previous groups are restored from scratch, whichever step failed
+	changes.record("restore instance groups", func() error {
+		current, err := jtm.attachedInstanceGroups(jobTemplateID)
+		if err != nil {
+			return err
+		}
+		return jtm.setInstanceGroups(jobTemplateID, current, attached)
+	})
+	return jtm.setInstanceGroups(jobTemplateID, attached, wanted)
+}
@@ -2,6 +2,7 @@ package awx
 
 import (
 	"fmt"
+	"slices"
 
 	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
 )
@@ -20,94 +21,105 @@ func NewJobTemplateManager(client *Client) *JobTemplateManager {
 
 // GetJobTemplate retrieves a job template by name
 func (jtm *JobTemplateManager) GetJobTemplate(name string) (map[string]interface{}, error) {
-	log.Info("Fetching job template by name", "name", name)
+	jtm.client.log.Info("Fetching job template by name", "name", name)
 	return jtm.client.FindObjectByName("job_templates", name)
 }
 
 // IsJobTemplateInDesiredState checks if the job template matches the desired specification
-func (jtm *JobTemplateManager) IsJobTemplateInDesiredState(jobTemplate map[string]interface{}, jobTemplateSpec awxv1alpha1.JobTemplateSpec) bool {
-	// Check name
-	if name, ok := jobTemplate["name"].(string); !ok || name != jobTemplateSpec.Name {
+func (jtm *JobTemplateManager) IsJobTemplateInDesiredState(object map[string]interface{}, jobTemplateSpec awxv1alpha1.JobTemplateSpec) bool {
+	jobTemplate, err := DecodeObject[JobTemplate](object)
+	if err != nil {
 		return false
 	}
 
-	// Check description
-	if description, ok := jobTemplate["description"].(string); !ok || description != jobTemplateSpec.Description {
+	// Check name, description and playbook
+	if jobTemplate.Name != jobTemplateSpec.Name || jobTemplate.Description != jobTemplateSpec.Description ||
+		jobTemplate.Playbook != jobTemplateSpec.Playbook {
 		return false
 	}
 
-	// Check playbook
-	if playbook, ok := jobTemplate["playbook"].(string); !ok || playbook != jobTemplateSpec.Playbook {
+	// Check project and inventory by name
+	if jobTemplate.Project == nil || jobTemplate.Inventory == nil {
+		return false
+	}
+	name, err := jtm.client.relatedName(jobTemplate.SummaryFields, "project", "projects", jobTemplate.Project)
+	if err != nil || name != jobTemplateSpec.ProjectName {
+		return false
+	}
+	name, err = jtm.client.relatedName(jobTemplate.SummaryFields, "inventory", "inventories", jobTemplate.Inventory)
+	if err != nil || name != jobTemplateSpec.InventoryName {
 		return false
 	}
 
-	// Check project
-	project, ok := jobTemplate["project"]
-	if !ok {
+	// Check extra vars if provided
+	if jobTemplateSpec.ExtraVars != "" && jobTemplate.ExtraVars != jobTemplateSpec.ExtraVars {
 		return false
 	}
 
-	// Project can be an object or just an ID field, handle both cases
-	projectObj, ok := project.(map[string]interface{})
-	if ok {
-		// Project is an object with a name field
-		projectName, ok := projectObj["name"].(string)
-		if !ok || projectName != jobTemplateSpec.ProjectName {
-			return false
-		}
-	} else {
-		// Project is an ID, we need to fetch the project to check its name
-		projectID, ok := project.(float64)
-		if !ok {
-			return false
-		}
+	// Check job and skip tags, unless they are left to the launch prompt
+	prompted := promptedDefaults(jobTemplateSpec)
+	if !prompted["job_tags"] && jobTemplate.JobTags != jobTemplateSpec.JobTags {
+		return false
+	}
+	if !prompted["skip_tags"] && jobTemplate.SkipTags != jobTemplateSpec.SkipTags {
+		return false
+	}
 
-		projectObj, err := jtm.client.GetObject("projects", int(projectID))
-		if err != nil {
+	// Check prompt on launch settings
+	actual := promptOnLaunch(jobTemplate)
+	for field, desired := range promptOnLaunchFields(jobTemplateSpec) {
+		if actual[field] != desired {
 			return false
 		}
+	}
 
-		projectName, ok := projectObj["name"].(string)
-		if !ok || projectName != jobTemplateSpec.ProjectName {
-			return false
-		}
+	// Check the verbosity and timeout if specified
+	if jobTemplateSpec.Verbosity != nil && int32(jobTemplate.Verbosity) != *jobTemplateSpec.Verbosity {
+		return false
+	}
+	if jobTemplateSpec.Timeout != nil && int32(jobTemplate.Timeout) != *jobTemplateSpec.Timeout {
+		return false
 	}
 
-	// Check inventory
-	inventory, ok := jobTemplate["inventory"]
-	if !ok {
+	// Check parallelism settings
+	if int32(jobTemplate.Forks) != jobTemplateSpec.Forks || int32(jobTemplate.JobSliceCount) != jobSliceCount(jobTemplateSpec) {
 		return false
 	}
 
-	// Inventory can be an object or just an ID field, handle both cases
-	inventoryObj, ok := inventory.(map[string]interface{})
-	if ok {
-		// Inventory is an object with a name field
-		inventoryName, ok := inventoryObj["name"].(string)
-		if !ok || inventoryName != jobTemplateSpec.InventoryName {
-			return false
-		}
-	} else {
-		// Inventory is an ID, we need to fetch the inventory to check its name
-		inventoryID, ok := inventory.(float64)
-		if !ok {
+	// Check the execution environment if specified. It is compared with the
+	// effective one, so a value inherited from the project or organization
+	// that matches the spec is not reported as drift.
+	if jobTemplateSpec.ExecutionEnvironment != "" {
+		effective, err := jtm.EffectiveExecutionEnvironment(object)
+		if err != nil || effective.Name != jobTemplateSpec.ExecutionEnvironment {
 			return false
 		}
+	}
 
-		inventoryObj, err := jtm.client.GetObject("inventories", int(inventoryID))
-		if err != nil {
-			return false
-		}
+	// Check privilege escalation
+	if jobTemplate.BecomeEnabled != jobTemplateSpec.BecomeEnabled {
+		return false
+	}
 
-		inventoryName, ok := inventoryObj["name"].(string)
-		if !ok || inventoryName != jobTemplateSpec.InventoryName {
-			return false
-		}
+	// Check the attached credentials if defined
+	if len(jobTemplateSpec.Credentials) > 0 && !jtm.credentialsInDesiredState(jobTemplate.ID, jobTemplateSpec) {
+		return false
 	}
 
-	// Check extra vars if provided
-	if jobTemplateSpec.ExtraVars != "" {
-		if extraVars, ok := jobTemplate["extra_vars"].(string); !ok || extraVars != jobTemplateSpec.ExtraVars {
+	// Check the instance groups if defined
+	if len(jobTemplateSpec.InstanceGroups) > 0 && !jtm.instanceGroupsInDesiredState(jobTemplate.ID, jobTemplateSpec) {
+		return false
+	}
+
+	// Check schedules if defined
+	if len(jobTemplateSpec.Schedules) > 0 && !jtm.schedulesInDesiredState(jobTemplate.ID, jobTemplateSpec) {
+		return false
+	}
+
+	// Check the survey if defined
+	if jobTemplateSpec.Survey != nil {
+		if jobTemplate.SurveyEnabled != surveyEnabled(jobTemplateSpec.Survey) ||
+			!jtm.surveyInDesiredState(jobTemplate.ID, jobTemplateSpec.Survey) {
 			return false
 		}
 	}
@@ -117,7 +129,7 @@ func (jtm *JobTemplateManager) IsJobTemplateInDesiredState(jobTemplate map[strin
 
 // EnsureJobTemplate ensures that a job template exists with the specified configuration
 func (jtm *JobTemplateManager) EnsureJobTemplate(jobTemplateSpec awxv1alpha1.JobTemplateSpec) (map[string]interface{}, error) {
-	log.Info("Ensuring job template exists with desired configuration", "name", jobTemplateSpec.Name)
+	jtm.client.log.Info("Ensuring job template exists with desired configuration", "name", jobTemplateSpec.Name)
 
 	// First, check if job template exists
 	jobTemplate, err := jtm.client.FindObjectByName("job_templates", jobTemplateSpec.Name)
@@ -126,27 +138,38 @@ func (jtm *JobTemplateManager) EnsureJobTemplate(jobTemplateSpec awxv1alpha1.Job
 	}
 
 	// Find the project by name - required for job templates per AWX API docs
-	log.Info("Finding associated project", "name", jobTemplateSpec.ProjectName)
+	jtm.client.log.Info("Finding associated project", "name", jobTemplateSpec.ProjectName)
 	project, err := jtm.client.FindObjectByName("projects", jobTemplateSpec.ProjectName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find project %s: %w", jobTemplateSpec.ProjectName, err)
 	}
 	if project == nil {
-		return nil, fmt.Errorf("project %s not found", jobTemplateSpec.ProjectName)
+		return nil, &ReferenceNotFoundError{Kind: "project", Name: jobTemplateSpec.ProjectName}
 	}
 	projectID, err := getObjectID(project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project ID: %w", err)
 	}
 
+	// Verify the playbook is available so AWX doesn't reject the job template
+	if jobTemplateSpec.ValidatePlaybook {
+		playbooks, err := NewProjectManager(jtm.client).ListPlaybooks(projectID)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(playbooks, jobTemplateSpec.Playbook) {
+			return nil, &PlaybookNotFoundError{Playbook: jobTemplateSpec.Playbook, Project: jobTemplateSpec.ProjectName}
+		}
+	}
+
 	// Find the inventory by name - required for job templates per AWX API docs
-	log.Info("Finding associated inventory", "name", jobTemplateSpec.InventoryName)
+	jtm.client.log.Info("Finding associated inventory", "name", jobTemplateSpec.InventoryName)
 	inventory, err := jtm.client.FindObjectByName("inventories", jobTemplateSpec.InventoryName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find inventory %s: %w", jobTemplateSpec.InventoryName, err)
 	}
 	if inventory == nil {
-		return nil, fmt.Errorf("inventory %s not found", jobTemplateSpec.InventoryName)
+		return nil, &ReferenceNotFoundError{Kind: "inventory", Name: jobTemplateSpec.InventoryName}
 	}
 	inventoryID, err := getObjectID(inventory)
 	if err != nil {
@@ -155,16 +178,27 @@ func (jtm *JobTemplateManager) EnsureJobTemplate(jobTemplateSpec awxv1alpha1.Job
 
 	// Map job template spec to AWX API fields according to AWX API docs
 	jobTemplateData := map[string]interface{}{
-		"name":                     jobTemplateSpec.Name,
-		"description":              jobTemplateSpec.Description,
-		"project":                  projectID,
-		"inventory":                inventoryID,
-		"playbook":                 jobTemplateSpec.Playbook,
-		"job_type":                 "run", // Default to 'run' if not specified
-		"verbosity":                0,     // Default verbosity
-		"ask_limit_on_launch":      false,
-		"ask_inventory_on_launch":  false,
-		"ask_credential_on_launch": false,
+		"name":            jobTemplateSpec.Name,
+		"description":     jobTemplateSpec.Description,
+		"project":         projectID,
+		"inventory":       inventoryID,
+		"playbook":        jobTemplateSpec.Playbook,
+		"job_type":        "run", // Default to 'run' if not specified
+		"verbosity":       jobTemplateVerbosity(jobTemplateSpec),
+		"job_tags":        jobTemplateSpec.JobTags,
+		"skip_tags":       jobTemplateSpec.SkipTags,
+		"forks":           jobTemplateSpec.Forks,
+		"job_slice_count": jobSliceCount(jobTemplateSpec),
+		"become_enabled":  jobTemplateSpec.BecomeEnabled,
+	}
+
+	// Set prompt on launch settings. Fields left to the prompt keep the
+	// default configured in AWX.
+	for field, value := range promptOnLaunchFields(jobTemplateSpec) {
+		jobTemplateData[field] = value
+	}
+	for field := range promptedDefaults(jobTemplateSpec) {
+		delete(jobTemplateData, field)
 	}
 
 	// Set extra vars if provided
@@ -172,24 +206,59 @@ func (jtm *JobTemplateManager) EnsureJobTemplate(jobTemplateSpec awxv1alpha1.Job
 		jobTemplateData["extra_vars"] = jobTemplateSpec.ExtraVars
 	}
 
-	// Create or update job template
+	// Set the timeout if provided, otherwise the one in AWX is kept
+	if jobTemplateSpec.Timeout != nil {
+		jobTemplateData["timeout"] = *jobTemplateSpec.Timeout
+	}
+
+	// Show the survey on launch if one is defined
+	if jobTemplateSpec.Survey != nil {
+		jobTemplateData["survey_enabled"] = surveyEnabled(jobTemplateSpec.Survey)
+	}
+
+	// Set the execution environment if provided, otherwise AWX inherits it
+	// from the project or organization
+	if jobTemplateSpec.ExecutionEnvironment != "" {
+		executionEnvironment, err := jtm.client.FindObjectByName("execution_environments", jobTemplateSpec.ExecutionEnvironment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find execution environment %s: %w", jobTemplateSpec.ExecutionEnvironment, err)
+		}
+		if executionEnvironment == nil {
+			return nil, &ReferenceNotFoundError{Kind: "execution environment", Name: jobTemplateSpec.ExecutionEnvironment}
+		}
+		executionEnvironmentID, err := getObjectID(executionEnvironment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get execution environment ID: %w", err)
+		}
+		jobTemplateData["execution_environment"] = executionEnvironmentID
+	}
+
+	// Create or update job template. Updates of an existing job template are
+	// recorded, so that a failure updating its credentials, schedules or
+	// survey restores the parts already changed.
+	var changes *changeSet
 	if jobTemplate == nil {
 		// Job template doesn't exist, create it
-		log.Info("Creating AWX job template", "name", jobTemplateSpec.Name)
-		jobTemplate, err = jtm.client.CreateObject("job_templates", jobTemplateData, "job_template")
+		if jobTemplateSpec.CopyFrom != "" {
+			jtm.client.log.Info("Creating AWX job template as a copy", "name", jobTemplateSpec.Name, "copyFrom", jobTemplateSpec.CopyFrom)
+			jobTemplate, err = createFromCopy(jtm.client, "job_templates", jobTemplateSpec.CopyFrom, jobTemplateData)
+		} else {
+			jtm.client.log.Info("Creating AWX job template", "name", jobTemplateSpec.Name)
+			jobTemplate, err = jtm.client.CreateObject("job_templates", jobTemplateData, "job_template")
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to create job template: %w", err)
 		}
 
 		// Verify new job template has an ID
 		if _, ok := jobTemplate["id"]; !ok {
-			log.Error(nil, "Newly created job template missing ID field",
+			jtm.client.log.Error(nil, "Newly created job template missing ID field",
 				"name", jobTemplateSpec.Name,
 				"keys", getMapKeys(jobTemplate))
 			return nil, fmt.Errorf("created job template '%s' has no ID field", jobTemplateSpec.Name)
 		}
 
-		log.Info("Successfully created job template",
+		jtm.client.log.Info("Successfully created job template",
 			"name", jobTemplateSpec.Name,
 			"id", jobTemplate["id"],
 			"project", jobTemplateSpec.ProjectName,
@@ -198,33 +267,135 @@ func (jtm *JobTemplateManager) EnsureJobTemplate(jobTemplateSpec awxv1alpha1.Job
 		// Job template exists, update it
 		id, err := getObjectID(jobTemplate)
 		if err != nil {
-			log.Error(err, "Cannot get ID from existing job template",
+			jtm.client.log.Error(err, "Cannot get ID from existing job template",
 				"name", jobTemplateSpec.Name,
 				"keys", getMapKeys(jobTemplate))
 			return nil, fmt.Errorf("failed to get ID from existing job template '%s': %w", jobTemplateSpec.Name, err)
 		}
 
-		log.Info("Updating AWX job template",
+		jtm.client.log.Info("Updating AWX job template",
 			"name", jobTemplateSpec.Name,
 			"id", id)
-		jobTemplate, err = jtm.client.UpdateObject("job_templates", id, jobTemplateData)
+		changes = newChangeSet(jtm.client.log, "job template", jobTemplateSpec.Name)
+		previous := jobTemplate
+		err = jtm.client.retryOnConflict("update job template "+jobTemplateSpec.Name, func() error {
+			jobTemplate, err = jtm.client.UpdateObject("job_templates", id, jobTemplateData)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to update job template: %w", err)
 		}
+		changes.recordUpdate(jtm.client, "job_templates", previous, jobTemplateData)
 
-		log.Info("Successfully updated job template",
+		jtm.client.log.Info("Successfully updated job template",
 			"name", jobTemplateSpec.Name,
 			"id", id,
 			"project", jobTemplateSpec.ProjectName,
 			"inventory", jobTemplateSpec.InventoryName)
 	}
 
+	// Attach credentials if defined
+	if len(jobTemplateSpec.Credentials) > 0 {
+		id, err := getObjectID(jobTemplate)
+		if err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to get job template ID for credentials of '%s': %w", jobTemplateSpec.Name, err))
+		}
+		if err := jtm.reconcileCredentials(id, jobTemplateSpec, changes); err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to reconcile credentials for job template '%s': %w", jobTemplateSpec.Name, err))
+		}
+	}
+
+	// Assign instance groups if defined
+	if len(jobTemplateSpec.InstanceGroups) > 0 {
+		id, err := getObjectID(jobTemplate)
+		if err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to get job template ID for instance groups of '%s': %w", jobTemplateSpec.Name, err))
+		}
+		if err := jtm.reconcileInstanceGroups(id, jobTemplateSpec, changes); err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to reconcile instance groups for job template '%s': %w", jobTemplateSpec.Name, err))
+		}
+	}
+
+	// Process schedules if defined
+	if len(jobTemplateSpec.Schedules) > 0 {
+		id, err := getObjectID(jobTemplate)
+		if err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to get job template ID for schedules of '%s': %w", jobTemplateSpec.Name, err))
+		}
+		if err := jtm.reconcileSchedules(id, jobTemplateSpec, changes); err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to reconcile schedules for job template '%s': %w", jobTemplateSpec.Name, err))
+		}
+	}
+
+	// Replace the survey if defined
+	if jobTemplateSpec.Survey != nil {
+		id, err := getObjectID(jobTemplate)
+		if err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to get job template ID for the survey of '%s': %w", jobTemplateSpec.Name, err))
+		}
+		if err := jtm.reconcileSurvey(id, jobTemplateSpec); err != nil {
+			return nil, changes.rollback(fmt.Errorf("failed to reconcile survey for job template '%s': %w", jobTemplateSpec.Name, err))
+		}
+	}
+
 	return jobTemplate, nil
 }
 
+// jobTemplateVerbosity returns the desired verbosity, defaulting to normal
+func jobTemplateVerbosity(jobTemplateSpec awxv1alpha1.JobTemplateSpec) int32 {
+	if jobTemplateSpec.Verbosity == nil {
+		return 0
+	}
+	return *jobTemplateSpec.Verbosity
+}
+
+// promptOnLaunchFields maps the AWX ask_*_on_launch fields to their desired values
+func promptOnLaunchFields(jobTemplateSpec awxv1alpha1.JobTemplateSpec) map[string]bool {
+	return map[string]bool{
+		"ask_limit_on_launch":      jobTemplateSpec.AskLimitOnLaunch,
+		"ask_inventory_on_launch":  jobTemplateSpec.AskInventoryOnLaunch,
+		"ask_credential_on_launch": jobTemplateSpec.AskCredentialOnLaunch,
+		"ask_variables_on_launch":  jobTemplateSpec.AskVariablesOnLaunch,
+		"ask_verbosity_on_launch":  jobTemplateSpec.AskVerbosityOnLaunch,
+		"ask_tags_on_launch":       jobTemplateSpec.AskTagsOnLaunch,
+		"ask_diff_mode_on_launch":  jobTemplateSpec.AskDiffModeOnLaunch,
+	}
+}
+
+// promptOnLaunch returns the prompt on launch settings of a job template in
+// AWX, keyed like promptOnLaunchFields
+func promptOnLaunch(jobTemplate *JobTemplate) map[string]bool {
+	return map[string]bool{
+		"ask_limit_on_launch":      jobTemplate.AskLimitOnLaunch,
+		"ask_inventory_on_launch":  jobTemplate.AskInventoryOnLaunch,
+		"ask_credential_on_launch": jobTemplate.AskCredentialOnLaunch,
+		"ask_variables_on_launch":  jobTemplate.AskVariablesOnLaunch,
+		"ask_verbosity_on_launch":  jobTemplate.AskVerbosityOnLaunch,
+		"ask_tags_on_launch":       jobTemplate.AskTagsOnLaunch,
+		"ask_diff_mode_on_launch":  jobTemplate.AskDiffModeOnLaunch,
+	}
+}
+
+// promptedDefaults returns the fields that are prompted on launch and not set
+// in the spec. The value in AWX is only the default of the prompt, so it is
+// neither compared nor overwritten.
+func promptedDefaults(jobTemplateSpec awxv1alpha1.JobTemplateSpec) map[string]bool {
+	prompted := map[string]bool{}
+	if jobTemplateSpec.AskTagsOnLaunch && jobTemplateSpec.JobTags == "" {
+		prompted["job_tags"] = true
+	}
+	if jobTemplateSpec.AskTagsOnLaunch && jobTemplateSpec.SkipTags == "" {
+		prompted["skip_tags"] = true
+	}
+	if jobTemplateSpec.AskVerbosityOnLaunch && jobTemplateSpec.Verbosity == nil {
+		prompted["verbosity"] = true
+	}
+	return prompted
+}
+
 // DeleteJobTemplate deletes a job template by name
 func (jtm *JobTemplateManager) DeleteJobTemplate(name string) error {
-	log.Info("Deleting job template", "name", name)
+	jtm.client.log.Info("Deleting job template", "name", name)
 
 	jobTemplate, err := jtm.client.FindObjectByName("job_templates", name)
 	if err != nil {
@@ -233,7 +404,7 @@ func (jtm *JobTemplateManager) DeleteJobTemplate(name string) error {
 
 	if jobTemplate == nil {
 		// Job template doesn't exist, nothing to do
-		log.Info("Job template already deleted", "name", name)
+		jtm.client.log.Info("Job template already deleted", "name", name)
 		return nil
 	}
 
@@ -242,12 +413,14 @@ func (jtm *JobTemplateManager) DeleteJobTemplate(name string) error {
 		return fmt.Errorf("failed to get job template ID: %w", err)
 	}
 
-	log.Info("Deleting AWX job template", "name", name, "id", id)
-	err = jtm.client.DeleteObject("job_templates", id)
+	jtm.client.log.Info("Deleting AWX job template", "name", name, "id", id)
+	err = jtm.client.retryOnConflict("delete job template "+name, func() error {
+		return jtm.client.DeleteObject("job_templates", id)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete job template %s: %w", name, err)
 	}
 
-	log.Info("Successfully deleted job template", "name", name)
+	jtm.client.log.Info("Successfully deleted job template", "name", name)
 	return nil
 }
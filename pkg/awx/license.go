@@ -0,0 +1,50 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// LicenseInfo is the subscription information reported by the config endpoint.
+// AWX reports an open license without expiry or host limit.
+type LicenseInfo struct {
+	LicenseType      string `json:"license_type"`
+	SubscriptionName string `json:"subscription_name"`
+	Compliant        *bool  `json:"compliant"`
+	DateExpired      bool   `json:"date_expired"`
+	LicenseDate      int64  `json:"license_date"`
+	InstanceCount    int    `json:"instance_count"`
+	CurrentInstances int    `json:"current_instances"`
+}
+
+// IsCompliant reports whether the managed hosts are within the subscription.
+// Licenses that don't report compliance are considered compliant.
+func (l *LicenseInfo) IsCompliant() bool {
+	return l.Compliant == nil || *l.Compliant
+}
+
+// ExpiresAt returns the expiry date of the license, if it has one
+func (l *LicenseInfo) ExpiresAt() (time.Time, bool) {
+	if l.LicenseDate <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(l.LicenseDate, 0), true
+}
+
+// GetLicenseInfo reads the subscription information of the AWX instance
+func (c *Client) GetLicenseInfo() (*LicenseInfo, error) {
+	respBody, err := c.doRequest(http.MethodGet, "config", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWX config: %w", err)
+	}
+
+	var config struct {
+		LicenseInfo LicenseInfo `json:"license_info"`
+	}
+	if err := json.Unmarshal(respBody, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &config.LicenseInfo, nil
+}
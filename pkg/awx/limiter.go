@@ -0,0 +1,98 @@
+package awx
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// RequestLimits bound the requests sent to an AWX. Zero values leave the
+// corresponding limit off.
+type RequestLimits struct {
+	// RequestsPerSecond is the sustained request rate
+	RequestsPerSecond float64
+	// Burst is the number of requests sent at once before the rate applies,
+	// at least one
+	Burst int
+	// MaxParallel is the number of requests in flight at the same time
+	MaxParallel int
+}
+
+// RequestLimiter enforces RequestLimits. One limiter is shared by all clients
+// that talk to an AWX on behalf of the same instance, e.g. the admin and the
+// tenant client.
+type RequestLimiter struct {
+	limits RequestLimits
+	rate   *rate.Limiter
+	slots  chan struct{}
+}
+
+// NewRequestLimiter creates a limiter for the limits
+func NewRequestLimiter(limits RequestLimits) *RequestLimiter {
+	l := &RequestLimiter{limits: limits}
+	if limits.RequestsPerSecond > 0 {
+		l.rate = rate.NewLimiter(rate.Limit(limits.RequestsPerSecond), max(limits.Burst, 1))
+	}
+	if limits.MaxParallel > 0 {
+		l.slots = make(chan struct{}, limits.MaxParallel)
+	}
+	return l
+}
+
+// Limits returns the limits the limiter enforces
+func (l *RequestLimiter) Limits() RequestLimits {
+	return l.limits
+}
+
+// acquire waits until a request may be sent and returns the function that
+// releases its slot once the response was consumed. A nil limiter lets every
+// request through.
+func (l *RequestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// SetRequestLimiter makes the client wait for the limiter before every
+// request. Nil removes the limits.
+func (c *Client) SetRequestLimiter(limiter *RequestLimiter) {
+	c.limiter.Store(limiter)
+}
+
+// releasingBody releases the limiter slot of a request once its response
+// body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	closed  bool
+}
+
+// Close closes the body and releases the slot once
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.release()
+	}
+	return err
+}
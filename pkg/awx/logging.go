@@ -0,0 +1,93 @@
+package awx
+
+import (
+	"slices"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+)
+
+// log is the logger of the package. It discards everything until a logger is
+// set with SetLogger, so programs importing the client only get its logs when
+// they ask for them.
+var log = logr.New(&logSink{})
+
+// rootLogger is the logger set with SetLogger
+var rootLogger atomic.Pointer[logr.Logger]
+
+// SetLogger sets the logger of the package, e.g. a controller-runtime or zap
+// logger. It also applies to loggers derived from the package logger before.
+func SetLogger(logger logr.Logger) {
+	rootLogger.Store(&logger)
+}
+
+// SetLogger sets the logger of the client, e.g. the package logger with the
+// name and namespace of the AWXInstance the client belongs to. Clients log to
+// the package logger until then. Shared parts such as the circuit breaker of
+// an AWX host keep logging to the package logger.
+func (c *Client) SetLogger(logger logr.Logger) {
+	c.log = logger
+}
+
+// Logger returns the logger of the client
+func (c *Client) Logger() logr.Logger {
+	return c.log
+}
+
+// logSink forwards to the logger set with SetLogger at the time of each call,
+// adding the names and values given to it
+type logSink struct {
+	names     []string
+	values    []interface{}
+	callDepth int
+}
+
+// logger returns the current root logger with the names and values of the sink
+func (s *logSink) logger() logr.Logger {
+	root := rootLogger.Load()
+	if root == nil {
+		return logr.Discard()
+	}
+	// Skip the frames of logr.Logger and of this sink to report the caller
+	logger := root.WithCallDepth(s.callDepth + 2)
+	for _, name := range s.names {
+		logger = logger.WithName(name)
+	}
+	if len(s.values) > 0 {
+		logger = logger.WithValues(s.values...)
+	}
+	return logger
+}
+
+// Init implements logr.LogSink
+func (s *logSink) Init(logr.RuntimeInfo) {}
+
+// Enabled implements logr.LogSink
+func (s *logSink) Enabled(level int) bool {
+	return s.logger().V(level).Enabled()
+}
+
+// Info implements logr.LogSink
+func (s *logSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.logger().V(level).Info(msg, keysAndValues...)
+}
+
+// Error implements logr.LogSink
+func (s *logSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.logger().Error(err, msg, keysAndValues...)
+}
+
+// WithValues implements logr.LogSink
+func (s *logSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logSink{names: s.names, values: append(slices.Clip(s.values), keysAndValues...), callDepth: s.callDepth}
+}
+
+// WithName implements logr.LogSink
+func (s *logSink) WithName(name string) logr.LogSink {
+	return &logSink{names: append(slices.Clip(s.names), name), values: s.values, callDepth: s.callDepth}
+}
+
+// WithCallDepth implements logr.CallDepthLogSink
+func (s *logSink) WithCallDepth(depth int) logr.LogSink {
+	return &logSink{names: s.names, values: s.values, callDepth: s.callDepth + depth}
+}
@@ -0,0 +1,28 @@
+package awx
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// breakerStateGauge exposes the circuit breaker state per AWX host
+	// (0 = closed, 1 = half-open, 2 = open)
+	breakerStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "awx_client_circuit_breaker_state",
+		Help: "Circuit breaker state per AWX host (0 = closed, 1 = half-open, 2 = open)",
+	}, []string{"host"})
+
+	// inventoryLookups counts the objects looked up by name that were served
+	// from the inventory cache (hit) or read from AWX (miss)
+	inventoryLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "awx_client_inventory_lookups_total",
+		Help: "Objects looked up by name, by whether they were served from the inventory cache",
+	}, []string{"result"})
+)
+
+// Collectors returns the metrics of the client. They are not registered by
+// the package, so programs choose the registry, e.g. the one of
+// controller-runtime.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{breakerStateGauge, inventoryLookups}
+}
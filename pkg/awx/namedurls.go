@@ -0,0 +1,76 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// namedURLDelimiter separates the identifiers of a named URL, e.g. the name
+// of a job template and the name of its organization in "deploy++Default"
+const namedURLDelimiter = "++"
+
+// escapeNamedURLIdentifier escapes a name for a named URL. AWX reads a
+// literal + as "[+]", so it is not taken for a delimiter, and characters
+// such as spaces, slashes and non-ASCII letters are percent-encoded.
+func escapeNamedURLIdentifier(identifier string) string {
+	return url.PathEscape(strings.ReplaceAll(identifier, "+", "[+]"))
+}
+
+// namedURL returns the escaped endpoint of an object addressed by its
+// identifiers instead of its ID, e.g. "projects/web++Default/"
+func namedURL(endpoint string, identifiers ...string) string {
+	escaped := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		escaped[i] = escapeNamedURLIdentifier(identifier)
+	}
+	return endpoint + "/" + strings.Join(escaped, namedURLDelimiter) + "/"
+}
+
+// setEscapedPath sets the path of u from an escaped path, keeping the
+// escaping so that reserved characters in names are sent as they were encoded
+func setEscapedPath(u *url.URL, escapedPath string) error {
+	unescaped, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return err
+	}
+	u.Path = unescaped
+	u.RawPath = escapedPath
+	return nil
+}
+
+// readableURL returns the URL with its path and query unescaped, so names
+// are logged as they are written rather than percent-encoded
+func readableURL(u *url.URL) string {
+	readable := u.Scheme + "://" + u.Host + u.Path
+	if u.RawQuery != "" {
+		query, err := url.QueryUnescape(u.RawQuery)
+		if err != nil {
+			query = u.RawQuery
+		}
+		readable += "?" + query
+	}
+	return readable
+}
+
+// GetObjectByNamedURL reads an object by its named URL, e.g. a job template
+// by its name and the name of its organization. It returns nil if no object
+// has these identifiers.
+func (c *Client) GetObjectByNamedURL(endpoint string, identifiers ...string) (map[string]interface{}, error) {
+	respBody, err := c.doRequest(http.MethodGet, namedURL(endpoint, identifiers...), nil)
+	if IsStatus(err, http.StatusNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	c.protected.note(result)
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package awx
+
+import "net/http"
+
+// objectIDKey returns the key of an object in the known IDs of a client
+func objectIDKey(endpoint, name string) string {
+	return endpoint + "/" + name
+}
+
+// SetObjectID tells the client the ID of an object created earlier, e.g. one
+// recorded on the AWXInstance before the operator restarted. FindObjectByName
+// reads the object by this ID instead of listing the endpoint by name.
+func (c *Client) SetObjectID(endpoint, name string, id int) {
+	c.objectIDsMu.Lock()
+	defer c.objectIDsMu.Unlock()
+	if c.objectIDs == nil {
+		c.objectIDs = make(map[string]int)
+	}
+	c.objectIDs[objectIDKey(endpoint, name)] = id
+}
+
+// findObjectByID reads an object by its known ID. The ID is forgotten when
+// the object is gone or was renamed in AWX, and the object is looked up by
+// name again.
+func (c *Client) findObjectByID(endpoint, name string) map[string]interface{} {
+	key := objectIDKey(endpoint, name)
+	c.objectIDsMu.Lock()
+	id, ok := c.objectIDs[key]
+	c.objectIDsMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	object, err := c.GetObject(endpoint, id)
+	if err == nil && object["name"] == name {
+		return object
+	}
+	if err != nil && !IsStatus(err, http.StatusNotFound) {
+		return nil
+	}
+
+	c.log.Info("Object no longer found by its recorded ID, looking it up by name", "endpoint", endpoint, "name", name, "id", id)
+	c.objectIDsMu.Lock()
+	delete(c.objectIDs, key)
+	c.objectIDsMu.Unlock()
+	return nil
+}
@@ -0,0 +1,140 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// FieldMetadata describes a field of an endpoint as listed by an OPTIONS
+// request, e.g. {"type": "string", "required": true, "max_length": 512}
+type FieldMetadata struct {
+	Type      string      `json:"type"`
+	Label     string      `json:"label"`
+	HelpText  string      `json:"help_text"`
+	Required  bool        `json:"required"`
+	ReadOnly  bool        `json:"read_only"`
+	MaxLength int         `json:"max_length"`
+	MinValue  *float64    `json:"min_value"`
+	MaxValue  *float64    `json:"max_value"`
+	Default   interface{} `json:"default"`
+	// Choices are listed by AWX as [value, label] pairs
+	Choices []interface{} `json:"choices"`
+}
+
+// choiceValues returns the values of the choices, without their labels
+func (f FieldMetadata) choiceValues() []string {
+	values := make([]string, 0, len(f.Choices))
+	for _, choice := range f.Choices {
+		if pair, ok := choice.([]interface{}); ok && len(pair) > 0 {
+			choice = pair[0]
+		}
+		values = append(values, fmt.Sprint(choice))
+	}
+	return values
+}
+
+// EndpointMetadata is the metadata AWX answers OPTIONS requests with. Actions
+// maps the methods the user may call, e.g. "POST" or "PUT", to their fields.
+type EndpointMetadata struct {
+	Name        string                              `json:"name"`
+	Description string                              `json:"description"`
+	Actions     map[string]map[string]FieldMetadata `json:"actions"`
+}
+
+// Options reads the metadata of an endpoint, e.g. the fields accepted when
+// creating a job template
+func (c *Client) Options(endpoint string) (*EndpointMetadata, error) {
+	respBody, err := c.doRequest(http.MethodOptions, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read options of %s: %w", endpoint, err)
+	}
+
+	var metadata EndpointMetadata
+	if err := json.Unmarshal(respBody, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &metadata, nil
+}
+
+// Allows reports whether the user may call method on the endpoint
+func (m *EndpointMetadata) Allows(method string) bool {
+	_, ok := m.Actions[method]
+	return ok
+}
+
+// ValidateFields checks data against the fields the endpoint accepts for
+// method, before it is sent: unknown and read-only fields, missing required
+// fields of a POST, strings longer than their maximum length, numbers out of
+// range and values that are not one of the choices. It returns nil when the
+// data is valid or AWX didn't list the fields of method.
+func (m *EndpointMetadata) ValidateFields(method string, data map[string]interface{}) FieldErrors {
+	fields, ok := m.Actions[method]
+	if !ok {
+		return nil
+	}
+	return validateFields(fields, data, method == http.MethodPost)
+}
+
+// validateFields checks data against the fields of an action, requiring the
+// required fields only when the whole object is sent
+func validateFields(fields map[string]FieldMetadata, data map[string]interface{}, complete bool) FieldErrors {
+	errs := make(FieldErrors)
+	for name, value := range data {
+		field, ok := fields[name]
+		switch {
+		case !ok:
+			errs[name] = append(errs[name], "Unknown field.")
+			continue
+		case field.ReadOnly:
+			errs[name] = append(errs[name], "Field is read-only.")
+			continue
+		case value == nil:
+			continue
+		}
+
+		if text, ok := value.(string); ok && field.MaxLength > 0 && len([]rune(text)) > field.MaxLength {
+			errs[name] = append(errs[name], fmt.Sprintf("Ensure this field has no more than %d characters.", field.MaxLength))
+		}
+		if number, ok := toFloat(value); ok {
+			if field.MinValue != nil && number < *field.MinValue {
+				errs[name] = append(errs[name], fmt.Sprintf("Ensure this value is greater than or equal to %v.", *field.MinValue))
+			}
+			if field.MaxValue != nil && number > *field.MaxValue {
+				errs[name] = append(errs[name], fmt.Sprintf("Ensure this value is less than or equal to %v.", *field.MaxValue))
+			}
+		}
+		if choices := field.choiceValues(); len(choices) > 0 && !slices.Contains(choices, fmt.Sprint(value)) {
+			errs[name] = append(errs[name], fmt.Sprintf("\"%v\" is not a valid choice.", value))
+		}
+	}
+
+	if complete {
+		for name, field := range fields {
+			if _, ok := data[name]; !ok && field.Required && !field.ReadOnly && field.Default == nil {
+				errs[name] = append(errs[name], "This field is required.")
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// toFloat returns a numeric value as a float64
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
@@ -0,0 +1,203 @@
+package awx
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// OrganizationHosts is the host usage of an AWX organization with max hosts
+type OrganizationHosts struct {
+	Name     string
+	MaxHosts int
+	Hosts    int
+}
+
+// UsedPercent returns the share of the max hosts in use
+func (o OrganizationHosts) UsedPercent() int {
+	if o.MaxHosts <= 0 {
+		return 0
+	}
+	return o.Hosts * 100 / o.MaxHosts
+}
+
+// OrganizationHostQuotas returns the host usage of the organizations that
+// limit their number of hosts. Organizations with a max hosts of 0 are
+// unlimited and left out.
+func (c *Client) OrganizationHostQuotas() ([]OrganizationHosts, error) {
+	organizations, err := c.ListAllObjects("organizations", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	var quotas []OrganizationHosts
+	for _, organization := range organizations {
+		maxHosts, _ := organization["max_hosts"].(float64)
+		if maxHosts <= 0 {
+			continue
+		}
+		id, err := getObjectID(organization)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := organization["name"].(string)
+
+		// AWX counts the hosts of all inventories of the organization
+		hosts, err := c.CountObjects("hosts", map[string]string{"inventory__organization": strconv.Itoa(id)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count hosts of organization %s: %w", name, err)
+		}
+		quotas = append(quotas, OrganizationHosts{Name: name, MaxHosts: int(maxHosts), Hosts: hosts})
+	}
+	return quotas, nil
+}
+
+// OrganizationManager handles the settings of existing AWX organizations
+type OrganizationManager struct {
+	client *Client
+}
+
+// NewOrganizationManager creates a new organization manager
+func NewOrganizationManager(client *Client) *OrganizationManager {
+	return &OrganizationManager{
+		client: client,
+	}
+}
+
+// galaxyCredential is a Galaxy credential attached to an organization
+type galaxyCredential struct {
+	name string
+	id   int
+}
+
+// attachedGalaxyCredentials returns the Galaxy credentials of the organization
+// in the order AWX tries them
+func (om *OrganizationManager) attachedGalaxyCredentials(organizationID int) ([]galaxyCredential, error) {
+	credentials, err := om.client.ListRelated("organizations", organizationID, "galaxy_credentials")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Galaxy credentials of organization: %w", err)
+	}
+
+	attached := make([]galaxyCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		name, _ := credential["name"].(string)
+		id, err := getObjectID(credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ID of Galaxy credential %s: %w", name, err)
+		}
+		attached = append(attached, galaxyCredential{name: name, id: id})
+	}
+	return attached, nil
+}
+
+// EnsureOrganizations applies the settings of the declared organizations
+func (om *OrganizationManager) EnsureOrganizations(organizationSpecs []awxv1alpha1.OrganizationSpec) error {
+	for _, organizationSpec := range organizationSpecs {
+		if err := om.EnsureOrganization(organizationSpec); err != nil {
+			return fmt.Errorf("organization %s: %w", organizationSpec.Name, err)
+		}
+	}
+	return nil
+}
+
+// EnsureOrganization gives the existing organization exactly the declared
+// Galaxy credentials in the declared order
+func (om *OrganizationManager) EnsureOrganization(organizationSpec awxv1alpha1.OrganizationSpec) error {
+	object, err := om.client.FindObjectByName("organizations", organizationSpec.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find organization: %w", err)
+	}
+	if object == nil {
+		return &ReferenceNotFoundError{Kind: "organization", Name: organizationSpec.Name}
+	}
+	organization, err := DecodeObject[Organization](object)
+	if err != nil {
+		return err
+	}
+	organizationID := organization.ID
+
+	wanted := make([]galaxyCredential, 0, len(organizationSpec.GalaxyCredentials))
+	for _, name := range organizationSpec.GalaxyCredentials {
+		credential, err := om.client.FindObjectByName("credentials", name)
+		if err != nil {
+			return fmt.Errorf("failed to find Galaxy credential %s: %w", name, err)
+		}
+		if credential == nil {
+			return fmt.Errorf("galaxy %w", &ReferenceNotFoundError{Kind: "credential", Name: name})
+		}
+		credentialID, err := getObjectID(credential)
+		if err != nil {
+			return fmt.Errorf("failed to get Galaxy credential ID: %w", err)
+		}
+		wanted = append(wanted, galaxyCredential{name: name, id: credentialID})
+	}
+
+	attached, err := om.attachedGalaxyCredentials(organizationID)
+	if err != nil {
+		return err
+	}
+	return om.reorderGalaxyCredentials(organizationID, attached, wanted)
+}
+
+// ResetOrganization detaches the declared Galaxy credentials from the
+// organization, which is not deleted since the operator didn't create it. An
+// organization that no longer exists has nothing left to detach.
+func (om *OrganizationManager) ResetOrganization(organizationSpec awxv1alpha1.OrganizationSpec) error {
+	if len(organizationSpec.GalaxyCredentials) == 0 {
+		return nil
+	}
+	organization, err := om.client.FindObjectByName("organizations", organizationSpec.Name)
+	if err != nil || organization == nil {
+		return err
+	}
+	organizationID, err := getObjectID(organization)
+	if err != nil {
+		return fmt.Errorf("failed to get organization ID: %w", err)
+	}
+
+	attached, err := om.attachedGalaxyCredentials(organizationID)
+	if err != nil {
+		return err
+	}
+	for _, credential := range attached {
+		if !slices.Contains(organizationSpec.GalaxyCredentials, credential.name) {
+			continue
+		}
+		om.client.log.Info("Detaching Galaxy credential from organization", "organization", organizationSpec.Name,
+			"credential", credential.name)
+		if err := om.client.DisassociateRelated("organizations", organizationID, "galaxy_credentials", credential.id); err != nil {
+			return fmt.Errorf("failed to detach Galaxy credential %s: %w", credential.name, err)
+		}
+	}
+	return nil
+}
+
+// reorderGalaxyCredentials turns the attached Galaxy credentials into the
+// wanted ones. AWX appends attached credentials to the end, so the credentials
+// up to the first difference are kept, and only those after it are detached
+// and attached again in order.
+func (om *OrganizationManager) reorderGalaxyCredentials(organizationID int, attached, wanted []galaxyCredential) error {
+	kept := 0
+	for kept < len(attached) && kept < len(wanted) && attached[kept].id == wanted[kept].id {
+		kept++
+	}
+	if kept == len(attached) && kept == len(wanted) {
+		return nil
+	}
+
+	om.client.log.Info("Reordering Galaxy credentials of organization", "id", organizationID,
+		"kept", kept, "detached", len(attached)-kept, "attached", len(wanted)-kept)
+	for _, credential := range attached[kept:] {
+		if err := om.client.DisassociateRelated("organizations", organizationID, "galaxy_credentials", credential.id); err != nil {
+			return fmt.Errorf("failed to detach Galaxy credential %s: %w", credential.name, err)
+		}
+	}
+	for _, credential := range wanted[kept:] {
+		if err := om.client.AssociateRelated("organizations", organizationID, "galaxy_credentials", credential.id); err != nil {
+			return fmt.Errorf("failed to attach Galaxy credential %s: %w", credential.name, err)
+		}
+	}
+	return nil
+}
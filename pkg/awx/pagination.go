@@ -0,0 +1,82 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// listPageSize is the page size requested when listing all objects, the
+// maximum AWX allows
+const listPageSize = 200
+
+// page is one page of a list response
+type page struct {
+	Count   int                      `json:"count"`
+	Next    *string                  `json:"next"`
+	Results []map[string]interface{} `json:"results"`
+}
+
+// listPage reads one page of an endpoint with the given query parameters
+func (c *Client) listPage(endpoint string, params url.Values) (*page, error) {
+	respBody, err := c.doRequest(http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result page
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	c.protected.note(result.Results...)
+	return &result, nil
+}
+
+// ListAllObjects lists all objects of an endpoint matching the filters,
+// following the pages of the response. The objects are ordered by ID unless
+// the filters order them otherwise, so that objects created while paging
+// neither shift others to a page already read nor appear twice.
+func (c *Client) ListAllObjects(endpoint string, filters map[string]string) ([]map[string]interface{}, error) {
+	params := url.Values{}
+	for key, value := range filters {
+		params.Set(key, value)
+	}
+	if params.Get("order_by") == "" {
+		params.Set("order_by", "id")
+	}
+	params.Set("page_size", strconv.Itoa(listPageSize))
+
+	var objects []map[string]interface{}
+	for number := 1; ; number++ {
+		params.Set("page", strconv.Itoa(number))
+		result, err := c.listPage(endpoint, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list page %d of %s: %w", number, endpoint, err)
+		}
+		objects = append(objects, result.Results...)
+		if result.Next == nil || len(result.Results) == 0 {
+			break
+		}
+	}
+
+	c.log.Info("Listed all objects", "endpoint", endpoint, "count", len(objects))
+	return objects, nil
+}
+
+// CountObjects returns the number of objects of an endpoint matching the
+// filters using the count of the list response, without listing them
+func (c *Client) CountObjects(endpoint string, filters map[string]string) (int, error) {
+	params := url.Values{}
+	for key, value := range filters {
+		params.Set(key, value)
+	}
+	params.Set("page_size", "1")
+
+	result, err := c.listPage(endpoint, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", endpoint, err)
+	}
+	return result.Count, nil
+}
@@ -0,0 +1,32 @@
+package awx
+
+import (
+	"errors"
+	"net/http"
+)
+
+// MissingPermissions checks whether the user may create objects on each of
+// the list endpoints and returns the missing permissions, e.g. "create
+// projects" or "read projects". AWX only lists the POST action in the
+// OPTIONS metadata of users allowed to create objects, which also excludes
+// OAuth2 tokens with read scope. The metadata is shared with field validation
+// and read again once older than permissionMetadataTTL. Endpoints whose metadata lists no actions at all
+// can't be judged and are not reported.
+func (c *Client) MissingPermissions(endpoints ...string) ([]string, error) {
+	var missing []string
+	for _, endpoint := range endpoints {
+		metadata, err := c.endpointMetadata(endpoint, permissionMetadataTTL)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+				missing = append(missing, "read "+endpoint)
+				continue
+			}
+			return nil, err
+		}
+		if len(metadata.Actions) > 0 && !metadata.Allows(http.MethodPost) {
+			missing = append(missing, "create "+endpoint)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,42 @@
+package awx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PingInfo is the state AWX reports on its unauthenticated ping endpoint
+type PingInfo struct {
+	Version     string `json:"version"`
+	ActiveNode  string `json:"active_node"`
+	InstallUUID string `json:"install_uuid"`
+}
+
+// Ping reads the ping endpoint, which reports a version once AWX is up and
+// its database is migrated
+func (c *Client) Ping() (*PingInfo, error) {
+	respBody, err := c.doRequest(http.MethodGet, "ping", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info PingInfo
+	if err := json.Unmarshal(respBody, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &info, nil
+}
+
+// IsMigrating reports whether err is AWX answering 503 Service Unavailable
+// while its database migrations are still running
+func IsMigrating(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	body := strings.ToLower(apiErr.Body)
+	return strings.Contains(body, "migrat") || strings.Contains(body, "upgrading")
+}
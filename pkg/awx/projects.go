@@ -1,7 +1,9 @@
 package awx
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 
 	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
 )
@@ -20,63 +22,72 @@ func NewProjectManager(client *Client) *ProjectManager {
 
 // GetProject retrieves a project by name
 func (pm *ProjectManager) GetProject(name string) (map[string]interface{}, error) {
-	log.Info("Fetching project by name", "name", name)
+	pm.client.log.Info("Fetching project by name", "name", name)
 	return pm.client.FindObjectByName("projects", name)
 }
 
-// IsProjectInDesiredState checks if the project matches the desired specification
-func (pm *ProjectManager) IsProjectInDesiredState(project map[string]interface{}, projectSpec awxv1alpha1.ProjectSpec) bool {
-	// Check name
-	if name, ok := project["name"].(string); !ok || name != projectSpec.Name {
-		return false
+// ListPlaybooks lists the playbooks AWX found in the project's last sync
+func (pm *ProjectManager) ListPlaybooks(id int) ([]string, error) {
+	respBody, err := pm.client.doRequest(http.MethodGet, fmt.Sprintf("projects/%d/playbooks", id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list playbooks of project %d: %w", id, err)
 	}
 
-	// Check description
-	if description, ok := project["description"].(string); !ok || description != projectSpec.Description {
+	var playbooks []string
+	if err := json.Unmarshal(respBody, &playbooks); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return playbooks, nil
+}
+
+// IsProjectInDesiredState checks if the project matches the desired specification
+func (pm *ProjectManager) IsProjectInDesiredState(object map[string]interface{}, projectSpec awxv1alpha1.ProjectSpec) bool {
+	project, err := DecodeObject[Project](object)
+	if err != nil {
 		return false
 	}
 
-	// Check SCM type
-	if scmType, ok := project["scm_type"].(string); !ok || scmType != projectSpec.SCMType {
+	// Check name, description and SCM type
+	if project.Name != projectSpec.Name || project.Description != projectSpec.Description ||
+		project.SCMType != projectSpec.SCMType {
 		return false
 	}
 
 	// Only check SCM URL if SCM type is not manual and URL is specified
-	if projectSpec.SCMType != "manual" && projectSpec.SCMUrl != "" {
-		if scmUrl, ok := project["scm_url"].(string); !ok || scmUrl != projectSpec.SCMUrl {
-			return false
-		}
+	if projectSpec.SCMType != "manual" && projectSpec.SCMUrl != "" && project.SCMURL != projectSpec.SCMUrl {
+		return false
 	}
 
-	// Check SCM branch if specified
-	if projectSpec.SCMBranch != "" {
-		if scmBranch, ok := project["scm_branch"].(string); !ok || scmBranch != projectSpec.SCMBranch {
-			return false
-		}
+	// Check SCM branch if specified, unless changes made in AWX are kept
+	if projectSpec.SCMBranch != "" && projectSpec.SCMBranchPolicy != awxv1alpha1.SCMBranchPolicyIgnore &&
+		project.SCMBranch != projectSpec.SCMBranch {
+		return false
 	}
 
-	// Check SCM credential if specified
+	// Check SCM credential, or the Insights credential of insights projects, if specified
 	if projectSpec.SCMCredential != "" {
-		// Check if the credential relation exists
-		credential, ok := project["credential"]
-		if !ok {
+		name, err := pm.client.relatedName(project.SummaryFields, "credential", "credentials", project.Credential)
+		if err != nil || name != projectSpec.SCMCredential {
 			return false
 		}
+	}
 
-		// Get the credential object to check its name
-		// This may require additional API calls, which could be optimized
-		credentialObj, ok := credential.(map[string]interface{})
-		if !ok {
-			// In some cases the credential might be just an ID, not a full object
-			// In that case, we'd need a separate API call to get the full object
-			// This would require additional implementation
-			return false
-		}
+	// Check SCM refspec
+	if project.SCMRefspec != projectSpec.SCMRefspec {
+		return false
+	}
 
-		credName, ok := credentialObj["name"].(string)
-		if !ok || credName != projectSpec.SCMCredential {
-			return false
-		}
+	// Check signature validation credential, which is unset when not specified
+	name, err := pm.client.relatedName(project.SummaryFields, "signature_validation_credential", "credentials",
+		project.SignatureValidationCredential)
+	if err != nil || name != projectSpec.SignatureValidationCredential {
+		return false
+	}
+
+	// Check the update schedule, if specified
+	if projectSpec.UpdateSchedule != nil &&
+		!pm.client.updateScheduleInDesiredState("projects", project.ID, projectSpec.UpdateSchedule) {
+		return false
 	}
 
 	return true
@@ -84,7 +95,7 @@ func (pm *ProjectManager) IsProjectInDesiredState(project map[string]interface{}
 
 // EnsureProject ensures that a project exists with the specified configuration
 func (pm *ProjectManager) EnsureProject(projectSpec awxv1alpha1.ProjectSpec) (map[string]interface{}, error) {
-	log.Info("Ensuring project exists with desired configuration", "name", projectSpec.Name)
+	pm.client.log.Info("Ensuring project exists with desired configuration", "name", projectSpec.Name)
 
 	// First, check if project exists
 	project, err := pm.client.FindObjectByName("projects", projectSpec.Name)
@@ -103,7 +114,7 @@ func (pm *ProjectManager) EnsureProject(projectSpec awxv1alpha1.ProjectSpec) (ma
 		"scm_type":                        projectSpec.SCMType,
 		"organization":                    orgID,
 		"local_path":                      "",
-		"scm_refspec":                     "",
+		"scm_refspec":                     projectSpec.SCMRefspec,
 		"scm_clean":                       false,
 		"scm_track_submodules":            false,
 		"scm_delete_on_update":            false,
@@ -124,34 +135,52 @@ func (pm *ProjectManager) EnsureProject(projectSpec awxv1alpha1.ProjectSpec) (ma
 	// Set SCM branch if provided
 	if projectSpec.SCMBranch != "" {
 		projectData["scm_branch"] = projectSpec.SCMBranch
-	} else if projectSpec.SCMType != "manual" {
-		// Use default branch if not specified but SCM is not manual
+	} else if projectSpec.SCMType != "manual" && projectSpec.SCMType != "insights" {
+		// Use default branch if not specified but the project is synced from SCM
 		projectData["scm_branch"] = "main"
 	}
 
 	// Set SCM credential if provided
 	if projectSpec.SCMCredential != "" {
-		log.Info("Finding SCM credential", "name", projectSpec.SCMCredential)
+		pm.client.log.Info("Finding SCM credential", "name", projectSpec.SCMCredential)
 		credential, err := pm.client.FindObjectByName("credentials", projectSpec.SCMCredential)
 		if err != nil {
 			return nil, fmt.Errorf("failed to find SCM credential: %w", err)
 		}
 
-		if credential != nil {
-			credentialID, ok := credential["id"]
-			if ok {
-				projectData["credential"] = credentialID
-				log.Info("Setting SCM credential",
-					"name", projectSpec.SCMCredential,
-					"id", credentialID)
-			}
+		if credential == nil {
+			return nil, fmt.Errorf("SCM %w", &ReferenceNotFoundError{Kind: "credential", Name: projectSpec.SCMCredential})
+		}
+		credentialID, ok := credential["id"]
+		if ok {
+			projectData["credential"] = credentialID
+			pm.client.log.Info("Setting SCM credential",
+				"name", projectSpec.SCMCredential,
+				"id", credentialID)
 		}
 	}
 
+	// Set the signature validation credential if provided
+	if projectSpec.SignatureValidationCredential != "" {
+		pm.client.log.Info("Finding signature validation credential", "name", projectSpec.SignatureValidationCredential)
+		credential, err := pm.client.FindObjectByName("credentials", projectSpec.SignatureValidationCredential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find signature validation credential: %w", err)
+		}
+		if credential == nil {
+			return nil, fmt.Errorf("signature validation %w", &ReferenceNotFoundError{Kind: "credential", Name: projectSpec.SignatureValidationCredential})
+		}
+		credentialID, err := getObjectID(credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signature validation credential ID: %w", err)
+		}
+		projectData["signature_validation_credential"] = credentialID
+	}
+
 	// Create or update project
 	if project == nil {
 		// Project doesn't exist, create it
-		log.Info("Creating AWX project",
+		pm.client.log.Info("Creating AWX project",
 			"name", projectSpec.Name,
 			"organization", orgID,
 			"scm_type", projectSpec.SCMType)
@@ -167,7 +196,7 @@ func (pm *ProjectManager) EnsureProject(projectSpec awxv1alpha1.ProjectSpec) (ma
 
 		// Verify the project has the expected name
 		if name, ok := project["name"].(string); !ok || name != projectSpec.Name {
-			log.Error(nil, "Created project has unexpected name",
+			pm.client.log.Error(nil, "Created project has unexpected name",
 				"expected", projectSpec.Name,
 				"actual", name,
 				"keys", getMapKeys(project))
@@ -175,7 +204,7 @@ func (pm *ProjectManager) EnsureProject(projectSpec awxv1alpha1.ProjectSpec) (ma
 
 		// Verify the project has an ID
 		if _, ok := project["id"]; !ok {
-			log.Error(nil, "Created project missing ID field",
+			pm.client.log.Error(nil, "Created project missing ID field",
 				"name", projectSpec.Name,
 				"keys", getMapKeys(project))
 			return nil, fmt.Errorf("created project has no ID field")
@@ -183,45 +212,81 @@ func (pm *ProjectManager) EnsureProject(projectSpec awxv1alpha1.ProjectSpec) (ma
 
 		// Log successful creation
 		id, _ := getObjectID(project)
-		log.Info("Successfully created AWX project", "name", projectSpec.Name, "id", id)
+		pm.client.log.Info("Successfully created AWX project", "name", projectSpec.Name, "id", id)
+
+		if projectSpec.UpdateSchedule != nil {
+			if err := pm.client.reconcileUpdateSchedule("projects", id, projectSpec.UpdateSchedule); err != nil {
+				return nil, fmt.Errorf("project %s: %w", projectSpec.Name, err)
+			}
+		}
 
 		// Per AWX API docs, new projects should be synced to make playbooks available
 		if projectSpec.SCMType != "manual" {
-			log.Info("Project created, consider syncing it to make playbooks available",
+			pm.client.log.Info("Project created, consider syncing it to make playbooks available",
 				"name", projectSpec.Name,
 				"id", id)
 		}
 
 		return project, nil
 	} else {
-		// Project exists, update it
+		// Project exists, update it, keeping its branch when changes made in
+		// AWX are ignored
+		if projectSpec.SCMBranchPolicy == awxv1alpha1.SCMBranchPolicyIgnore {
+			delete(projectData, "scm_branch")
+		}
 		id, err := getObjectID(project)
 		if err != nil {
-			log.Error(err, "Cannot get ID from existing project",
+			pm.client.log.Error(err, "Cannot get ID from existing project",
 				"name", projectSpec.Name,
 				"keys", getMapKeys(project))
 			return nil, fmt.Errorf("failed to get ID from existing project '%s': %w", projectSpec.Name, err)
 		}
 
-		log.Info("Updating AWX project",
+		pm.client.log.Info("Updating AWX project",
 			"name", projectSpec.Name,
 			"id", id,
 			"scm_type", projectSpec.SCMType)
-		project, err = pm.client.UpdateObject("projects", id, projectData)
+		err = pm.client.retryOnConflict("update project "+projectSpec.Name, func() error {
+			project, err = pm.client.UpdateObject("projects", id, projectData)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to update project: %w", err)
 		}
 
 		// Log successful update
-		log.Info("Successfully updated AWX project", "name", projectSpec.Name, "id", id)
+		pm.client.log.Info("Successfully updated AWX project", "name", projectSpec.Name, "id", id)
+
+		if projectSpec.UpdateSchedule != nil {
+			if err := pm.client.reconcileUpdateSchedule("projects", id, projectSpec.UpdateSchedule); err != nil {
+				return nil, fmt.Errorf("project %s: %w", projectSpec.Name, err)
+			}
+		}
 
 		return project, nil
 	}
 }
 
+// DeleteUpdateSchedule deletes the declared update schedule of the project and
+// leaves the project in place
+func (pm *ProjectManager) DeleteUpdateSchedule(projectSpec awxv1alpha1.ProjectSpec) error {
+	if projectSpec.UpdateSchedule == nil {
+		return nil
+	}
+	project, err := pm.client.FindObjectByName("projects", projectSpec.Name)
+	if err != nil || project == nil {
+		return err
+	}
+	id, err := getObjectID(project)
+	if err != nil {
+		return fmt.Errorf("failed to get project ID: %w", err)
+	}
+	return pm.client.deleteNamedSchedules("projects", id, []string{projectSpec.UpdateSchedule.Name})
+}
+
 // DeleteProject deletes a project by name
 func (pm *ProjectManager) DeleteProject(name string) error {
-	log.Info("Deleting project", "name", name)
+	pm.client.log.Info("Deleting project", "name", name)
 
 	project, err := pm.client.FindObjectByName("projects", name)
 	if err != nil {
@@ -230,7 +295,7 @@ func (pm *ProjectManager) DeleteProject(name string) error {
 
 	if project == nil {
 		// Project doesn't exist, nothing to do
-		log.Info("Project already deleted", "name", name)
+		pm.client.log.Info("Project already deleted", "name", name)
 		return nil
 	}
 
@@ -239,12 +304,14 @@ func (pm *ProjectManager) DeleteProject(name string) error {
 		return fmt.Errorf("failed to get project ID: %w", err)
 	}
 
-	log.Info("Deleting AWX project", "name", name, "id", id)
-	err = pm.client.DeleteObject("projects", id)
+	pm.client.log.Info("Deleting AWX project", "name", name, "id", id)
+	err = pm.client.retryOnConflict("delete project "+name, func() error {
+		return pm.client.DeleteObject("projects", id)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete project %s: %w", name, err)
+		return fmt.Errorf("failed to delete project %s: %w", name, asDependencyError("project", name, err))
 	}
 
-	log.Info("Successfully deleted project", "name", name)
+	pm.client.log.Info("Successfully deleted project", "name", name)
 	return nil
 }
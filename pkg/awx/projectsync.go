@@ -0,0 +1,129 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ProjectUpdate is a sync of a project from its source control
+type ProjectUpdate struct {
+	ID int
+	// Status is the job status, e.g. "pending", "running", "successful" or "failed"
+	Status   string
+	Started  time.Time
+	Finished time.Time
+}
+
+// jobRunning are the states of a project update or job that hasn't finished
+var jobRunning = map[string]bool{
+	"new":     true,
+	"pending": true,
+	"waiting": true,
+	"running": true,
+}
+
+// Done reports whether the project update finished, successfully or not
+func (u *ProjectUpdate) Done() bool {
+	return !jobRunning[u.Status]
+}
+
+// Succeeded reports whether the project update finished successfully
+func (u *ProjectUpdate) Succeeded() bool {
+	return u.Status == "successful"
+}
+
+// StartProjectUpdate starts a sync of the named project and returns the ID of
+// the project update. Every call starts a new sync.
+func (pm *ProjectManager) StartProjectUpdate(name string) (int, error) {
+	project, err := pm.client.FindObjectByName("projects", name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find project: %w", err)
+	}
+	if project == nil {
+		return 0, &ReferenceNotFoundError{Kind: "project", Name: name}
+	}
+	projectID, err := getObjectID(project)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get project ID: %w", err)
+	}
+
+	pm.client.log.Info("Starting project update", "project", name, "id", projectID)
+	respBody, err := pm.client.doRequest(http.MethodPost, fmt.Sprintf("projects/%d/update", projectID), map[string]interface{}{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to start update of project %s: %w", name, err)
+	}
+
+	var started map[string]interface{}
+	if err := json.Unmarshal(respBody, &started); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	// AWX returns the project update with its ID in both fields
+	if id, ok := started["project_update"].(float64); ok {
+		return int(id), nil
+	}
+	return getObjectID(started)
+}
+
+// GetProjectUpdate reads the current state of a project update
+func (pm *ProjectManager) GetProjectUpdate(id int) (*ProjectUpdate, error) {
+	object, err := pm.client.GetObject("project_updates", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project update %d: %w", id, err)
+	}
+	update := &ProjectUpdate{ID: id}
+	update.Status, _ = object["status"].(string)
+	if started, ok := object["started"].(string); ok {
+		update.Started, _ = time.Parse(time.RFC3339, started)
+	}
+	if finished, ok := object["finished"].(string); ok {
+		update.Finished, _ = time.Parse(time.RFC3339, finished)
+	}
+	return update, nil
+}
+
+// ProjectUpdateStdout returns the last maxLines lines of the output of a
+// project update
+func (pm *ProjectManager) ProjectUpdateStdout(id, maxLines int) (string, error) {
+	output, err := pm.ProjectUpdateOutput(id)
+	if err != nil {
+		return "", err
+	}
+	return LastLines(output, maxLines), nil
+}
+
+// ProjectUpdateOutput returns the complete output of a project update
+func (pm *ProjectManager) ProjectUpdateOutput(id int) (string, error) {
+	output, err := pm.client.unifiedJobOutput("project_updates", id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get output of project update %d: %w", id, err)
+	}
+	return output, nil
+}
+
+// unifiedJobOutput returns the complete output of a job, project update or
+// other unified job of endpoint
+func (c *Client) unifiedJobOutput(endpoint string, id int) (string, error) {
+	respBody, err := c.doRequest(http.MethodGet, fmt.Sprintf("%s/%d/stdout?format=json", endpoint, id), nil)
+	if err != nil {
+		return "", err
+	}
+	var stdout struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(respBody, &stdout); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return stdout.Content, nil
+}
+
+// LastLines returns the last n lines of text, without trailing blank lines
+func LastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,127 @@
+package awx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// protectedNames holds the names of AWX objects the operator never updates
+// or deletes
+var protectedNames atomic.Pointer[map[string]bool]
+
+// SetProtectedNames sets the names of AWX objects that are never updated or
+// deleted, e.g. "Demo Project" or "Default", typically once from operator
+// flags at startup. The names apply to objects of every kind.
+func SetProtectedNames(names []string) {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	protectedNames.Store(&set)
+}
+
+// isProtectedName reports whether name is protected
+func isProtectedName(name string) bool {
+	names := protectedNames.Load()
+	return names != nil && (*names)[name]
+}
+
+// protectedActions are object endpoints that don't change the object, such
+// as copying a protected template
+var protectedActions = map[string]bool{
+	"copy":   true,
+	"launch": true,
+	"update": true,
+}
+
+// ProtectedObjectError is returned when a request would update or delete an
+// object with a protected name
+type ProtectedObjectError struct {
+	Endpoint string
+	ID       int
+	Name     string
+}
+
+// Error implements the error interface
+func (e *ProtectedObjectError) Error() string {
+	return fmt.Sprintf("%s %d (%s) is protected and is not changed by the operator", e.Endpoint, e.ID, e.Name)
+}
+
+// AsProtectedObjectError returns the ProtectedObjectError wrapped in err, if any
+func AsProtectedObjectError(err error) (*ProtectedObjectError, bool) {
+	var protectedErr *ProtectedObjectError
+	if errors.As(err, &protectedErr) {
+		return protectedErr, true
+	}
+	return nil, false
+}
+
+// protectedObjects are the objects with a protected name a client has read,
+// keyed by "<endpoint>/<id>". Objects are read before they are changed, as
+// their ID is only known from AWX.
+type protectedObjects struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+// objectPath returns the endpoint and ID of an object from its URL, e.g.
+// "hosts" and 12 for "/api/v2/hosts/12/"
+func objectPath(object map[string]interface{}) (string, int, bool) {
+	objectURL, _ := object["url"].(string)
+	segments := strings.Split(strings.Trim(objectURL, "/"), "/")
+	if len(segments) < 2 {
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(segments[len(segments)-1])
+	if err != nil {
+		return "", 0, false
+	}
+	return segments[len(segments)-2], id, true
+}
+
+// note remembers the objects that have a protected name
+func (p *protectedObjects) note(objects ...map[string]interface{}) {
+	for _, object := range objects {
+		name, _ := object["name"].(string)
+		if name == "" || !isProtectedName(name) {
+			continue
+		}
+		endpoint, id, ok := objectPath(object)
+		if !ok {
+			continue
+		}
+		p.mu.Lock()
+		if p.objects == nil {
+			p.objects = make(map[string]string)
+		}
+		p.objects[fmt.Sprintf("%s/%d", endpoint, id)] = name
+		p.mu.Unlock()
+	}
+}
+
+// check returns a ProtectedObjectError when a write to endpoint would change
+// a protected object, e.g. "projects/6/" or "job_templates/7/credentials"
+func (p *protectedObjects) check(endpoint string) error {
+	endpoint, _, _ = strings.Cut(endpoint, "?")
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) < 2 {
+		return nil
+	}
+	id, err := strconv.Atoi(segments[1])
+	if err != nil || (len(segments) > 2 && protectedActions[segments[2]]) {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if name, ok := p.objects[segments[0]+"/"+segments[1]]; ok {
+		return &ProtectedObjectError{Endpoint: segments[0], ID: id, Name: name}
+	}
+	return nil
+}
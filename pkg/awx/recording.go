@@ -0,0 +1,71 @@
+package awx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Interaction is a request sent to AWX together with the response it got.
+// Recorded interactions are stored as fixtures for the contract tests, which
+// replay them to catch incompatibilities with the recorded AWX versions.
+type Interaction struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"`
+	Status int    `json:"status"`
+	// Body is the response body. Bodies that aren't JSON are stored as a string.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// StartRecording makes the client record every request and response from now on
+func (c *Client) StartRecording() {
+	c.recordingMu.Lock()
+	defer c.recordingMu.Unlock()
+	c.recording = true
+	c.interactions = nil
+}
+
+// RecordedInteractions returns the interactions recorded since StartRecording
+func (c *Client) RecordedInteractions() []Interaction {
+	c.recordingMu.Lock()
+	defer c.recordingMu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// recordInteraction stores the request and response while recording. The
+// response body is read and replaced so callers can still consume it.
+func (c *Client) recordInteraction(req *http.Request, resp *http.Response) error {
+	c.recordingMu.Lock()
+	defer c.recordingMu.Unlock()
+	if !c.recording {
+		return nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Method: req.Method,
+		URI:    req.URL.RequestURI(),
+		Status: resp.StatusCode,
+	}
+	switch {
+	case len(respBody) == 0:
+	case json.Valid(respBody):
+		interaction.Body = json.RawMessage(respBody)
+	default:
+		quoted, err := json.Marshal(string(respBody))
+		if err != nil {
+			return fmt.Errorf("failed to encode response body for recording: %w", err)
+		}
+		interaction.Body = quoted
+	}
+	c.interactions = append(c.interactions, interaction)
+	return nil
+}
@@ -0,0 +1,103 @@
+package awx
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// redactedMarker replaces the values of a redactor in logged bodies
+const redactedMarker = "[REDACTED]"
+
+// maskByte stands in for the bytes of a masked value until the masked runs
+// are collapsed into redactedMarker. JSON bodies never hold a raw NUL byte.
+const maskByte = 0
+
+// redactor masks secret values in logged bodies, e.g. values of Secrets
+// rendered into variables that are sent to AWX as plain payload fields
+type redactor struct {
+	// values holds the values as they are and JSON-escaped, longest first
+	values [][]byte
+}
+
+// newRedactor returns a redactor for the values, or nil for none
+func newRedactor(values []string) *redactor {
+	seen := make(map[string]bool)
+	r := &redactor{}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		quoted, _ := json.Marshal(value)
+		for _, form := range []string{value, string(quoted[1 : len(quoted)-1])} {
+			if !seen[form] {
+				seen[form] = true
+				r.values = append(r.values, []byte(form))
+			}
+		}
+	}
+	if len(r.values) == 0 {
+		return nil
+	}
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+	return r
+}
+
+// longest returns the length of the longest value
+func (r *redactor) longest() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.values[0])
+}
+
+// mask returns a copy of data with every value overwritten by maskByte,
+// keeping the length of data so it can be truncated afterwards
+func (r *redactor) mask(data []byte) []byte {
+	if r == nil {
+		return data
+	}
+	masked := bytes.Clone(data)
+	for _, value := range r.values {
+		for offset := 0; ; {
+			i := bytes.Index(masked[offset:], value)
+			if i < 0 {
+				break
+			}
+			start := offset + i
+			for j := start; j < start+len(value); j++ {
+				masked[j] = maskByte
+			}
+			offset = start + len(value)
+		}
+	}
+	return masked
+}
+
+// collapse replaces the masked runs of data by redactedMarker, so the logs
+// don't reveal the length of the values
+func (r *redactor) collapse(data []byte) string {
+	if r == nil || bytes.IndexByte(data, maskByte) < 0 {
+		return string(data)
+	}
+	var out bytes.Buffer
+	for i := 0; i < len(data); i++ {
+		if data[i] != maskByte {
+			out.WriteByte(data[i])
+			continue
+		}
+		out.WriteString(redactedMarker)
+		for i+1 < len(data) && data[i+1] == maskByte {
+			i++
+		}
+	}
+	return out.String()
+}
+
+// SetRedactedValues sets the values masked wherever they appear in logged
+// request and response bodies of the client, e.g. the values of Secrets
+// rendered into variables and extra vars. The values replace those of an
+// earlier call, an empty list masks nothing.
+func (c *Client) SetRedactedValues(values []string) {
+	c.redactor.Store(newRedactor(values))
+}
@@ -0,0 +1,42 @@
+package awx
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ObjectID returns the ID of an object returned by the AWX API
+func ObjectID(obj map[string]interface{}) (int, error) {
+	return getObjectID(obj)
+}
+
+// RenameObject renames the object with the given ID in place, which keeps its
+// ID, related objects and job history. It returns false without changing
+// anything when the object no longer exists or another object already has
+// the new name.
+func (c *Client) RenameObject(endpoint string, id int, name string) (bool, error) {
+	if _, err := c.GetObject(endpoint, id); err != nil {
+		if IsStatus(err, http.StatusNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get %s %d: %w", endpoint, id, err)
+	}
+
+	existing, err := c.FindObjectByName(endpoint, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s %s: %w", endpoint, name, err)
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	c.log.Info("Renaming AWX object", "endpoint", endpoint, "id", id, "name", name)
+	err = c.retryOnConflict("rename "+endpoint, func() error {
+		_, err := c.UpdateObject(endpoint, id, map[string]interface{}{"name": name})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to rename %s %d to %s: %w", endpoint, id, name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package awx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// undoStep reverts one write of a multi-object change
+type undoStep struct {
+	description string
+	undo        func() error
+}
+
+// changeSet records how to revert the writes of a change that spans several
+// AWX objects, e.g. a job template with its credentials and schedules. Each
+// write records its inverse, built from the state read before the write, so
+// a failure part way through can restore the objects already changed. A nil
+// changeSet records nothing.
+type changeSet struct {
+	log   logr.Logger
+	kind  string
+	name  string
+	steps []undoStep
+}
+
+// newChangeSet starts recording the writes of a change to the named object,
+// logging its rollback to logger
+func newChangeSet(logger logr.Logger, kind, name string) *changeSet {
+	return &changeSet{log: logger, kind: kind, name: name}
+}
+
+// record adds the inverse of a write that succeeded
+func (cs *changeSet) record(description string, undo func() error) {
+	if cs == nil {
+		return
+	}
+	cs.steps = append(cs.steps, undoStep{description: description, undo: undo})
+}
+
+// recordUpdate records the inverse of PATCHing data onto object, which
+// restores the fields it had before. Fields the object didn't return are
+// left as they are.
+func (cs *changeSet) recordUpdate(client *Client, endpoint string, object, data map[string]interface{}) {
+	if cs == nil {
+		return
+	}
+	id, err := getObjectID(object)
+	if err != nil {
+		return
+	}
+	snapshot := make(map[string]interface{}, len(data))
+	for field := range data {
+		if value, ok := object[field]; ok {
+			snapshot[field] = value
+		}
+	}
+	if len(snapshot) == 0 {
+		return
+	}
+	cs.record(fmt.Sprintf("restore %s %d", endpoint, id), func() error {
+		_, err := client.UpdateObject(endpoint, id, snapshot)
+		return err
+	})
+}
+
+// rollback reverts the recorded writes in reverse order after cause made the
+// change fail. It returns a RolledBackError wrapping cause, or cause itself
+// when nothing was changed yet.
+func (cs *changeSet) rollback(cause error) error {
+	if cs == nil || len(cs.steps) == 0 {
+		return cause
+	}
+
+	cs.log.Info("Rolling back partial change", "kind", cs.kind, "name", cs.name, "steps", len(cs.steps), "cause", cause.Error())
+	rolledBack := &RolledBackError{Kind: cs.kind, Name: cs.name, Err: cause}
+	var failures []error
+	for i := len(cs.steps) - 1; i >= 0; i-- {
+		step := cs.steps[i]
+		if err := step.undo(); err != nil {
+			cs.log.Error(err, "Failed to roll back change", "kind", cs.kind, "name", cs.name, "step", step.description)
+			failures = append(failures, fmt.Errorf("%s: %w", step.description, err))
+			continue
+		}
+		rolledBack.Reverted++
+	}
+	rolledBack.RollbackErr = errors.Join(failures...)
+	cs.steps = nil
+	return rolledBack
+}
+
+// RolledBackError is returned when a change spanning several AWX objects
+// failed part way and the objects already changed were restored, so AWX is
+// left as it was before the change rather than half updated
+type RolledBackError struct {
+	Kind string
+	Name string
+	// Err is the error that made the change fail
+	Err error
+	// Reverted is the number of writes that were reverted
+	Reverted int
+	// RollbackErr holds the writes that could not be reverted, if any
+	RollbackErr error
+}
+
+// Error implements the error interface
+func (e *RolledBackError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("%v (rollback of %s %s incomplete: %v)", e.Err, e.Kind, e.Name, e.RollbackErr)
+	}
+	return fmt.Sprintf("%v (rolled back %d changes)", e.Err, e.Reverted)
+}
+
+// Unwrap returns the error that made the change fail
+func (e *RolledBackError) Unwrap() error {
+	return e.Err
+}
+
+// AsRolledBackError returns the RolledBackError wrapped in err, if any
+func AsRolledBackError(err error) (*RolledBackError, bool) {
+	var rolledBack *RolledBackError
+	if errors.As(err, &rolledBack) {
+		return rolledBack, true
+	}
+	return nil, false
+}
@@ -0,0 +1,488 @@
+package awx
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// scheduleTimeLayout is the layout of the start and end times in the spec
+	scheduleTimeLayout = "2006-01-02T15:04:05"
+	// rruleTimeLayout is the iCalendar date-time layout used in rrules
+	rruleTimeLayout = "20060102T150405"
+	// defaultScheduleTimezone is used for schedules without a timezone
+	defaultScheduleTimezone = "UTC"
+)
+
+// scheduleTimezone returns the time zone of the schedule, defaulting to UTC
+func scheduleTimezone(scheduleSpec awxv1alpha1.ScheduleSpec) string {
+	if scheduleSpec.Timezone == "" {
+		return defaultScheduleTimezone
+	}
+	return scheduleSpec.Timezone
+}
+
+// scheduleEnabled returns whether the schedule should launch jobs, defaulting to true
+func scheduleEnabled(scheduleSpec awxv1alpha1.ScheduleSpec) bool {
+	return scheduleSpec.Enabled == nil || *scheduleSpec.Enabled
+}
+
+// scheduleLaunchOverrides returns the launch parameters the schedule
+// overrides, with nil for those left to the job template
+func scheduleLaunchOverrides(scheduleSpec awxv1alpha1.ScheduleSpec) map[string]interface{} {
+	overrides := map[string]interface{}{"verbosity": nil, "diff_mode": nil}
+	if scheduleSpec.Verbosity != nil {
+		overrides["verbosity"] = *scheduleSpec.Verbosity
+	}
+	if scheduleSpec.DiffMode != nil {
+		overrides["diff_mode"] = *scheduleSpec.DiffMode
+	}
+	return overrides
+}
+
+// ValidateScheduleOverrides checks that the job template prompts for the
+// launch parameters its schedule overrides, as AWX rejects them otherwise
+func ValidateScheduleOverrides(jobTemplateSpec awxv1alpha1.JobTemplateSpec, scheduleSpec awxv1alpha1.ScheduleSpec) error {
+	if scheduleSpec.Verbosity != nil && !jobTemplateSpec.AskVerbosityOnLaunch {
+		return fmt.Errorf("verbosity override requires askVerbosityOnLaunch")
+	}
+	if scheduleSpec.DiffMode != nil && !jobTemplateSpec.AskDiffModeOnLaunch {
+		return fmt.Errorf("diffMode override requires askDiffModeOnLaunch")
+	}
+	return nil
+}
+
+// BuildRRule builds the rrule AWX expects for a schedule, with DTSTART in the
+// declared time zone and UNTIL in UTC
+func BuildRRule(scheduleSpec awxv1alpha1.ScheduleSpec) (string, error) {
+	timezone := scheduleTimezone(scheduleSpec)
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return "", fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	start, err := time.ParseInLocation(scheduleTimeLayout, scheduleSpec.Start, location)
+	if err != nil {
+		return "", fmt.Errorf("invalid start %q: %w", scheduleSpec.Start, err)
+	}
+
+	recurrence := strings.TrimPrefix(strings.TrimSpace(scheduleSpec.Recurrence), "RRULE:")
+	if recurrence == "" {
+		return "", fmt.Errorf("recurrence is required")
+	}
+	if scheduleSpec.End != "" {
+		end, err := time.ParseInLocation(scheduleTimeLayout, scheduleSpec.End, location)
+		if err != nil {
+			return "", fmt.Errorf("invalid end %q: %w", scheduleSpec.End, err)
+		}
+		if !end.After(start) {
+			return "", fmt.Errorf("end %s is not after start %s", scheduleSpec.End, scheduleSpec.Start)
+		}
+		recurrence += ";UNTIL=" + end.UTC().Format(rruleTimeLayout) + "Z"
+	}
+
+	return fmt.Sprintf("DTSTART;TZID=%s:%s RRULE:%s", timezone, start.Format(rruleTimeLayout), recurrence), nil
+}
+
+// normalizedRRule is an rrule reduced to the instant it starts and its
+// recurrence parameters, so that equivalent rrules compare equal
+type normalizedRRule struct {
+	start  time.Time
+	params map[string]string
+}
+
+// parseRRuleTime parses an iCalendar date-time, which is UTC with a Z suffix
+// and in the given location otherwise
+func parseRRuleTime(value string, location *time.Location) (time.Time, error) {
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		return time.ParseInLocation(rruleTimeLayout, utc, time.UTC)
+	}
+	return time.ParseInLocation(rruleTimeLayout, value, location)
+}
+
+// normalizeRRule parses an rrule as written by AWX or BuildRRule
+func normalizeRRule(rrule string) (*normalizedRRule, error) {
+	normalized := &normalizedRRule{params: make(map[string]string)}
+	location := time.UTC
+	hasStart := false
+
+	for _, field := range strings.Fields(rrule) {
+		name, value, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule line %q", field)
+		}
+
+		switch {
+		case strings.HasPrefix(strings.ToUpper(name), "DTSTART"):
+			if _, timezone, ok := strings.Cut(name, ";TZID="); ok {
+				var err error
+				if location, err = time.LoadLocation(timezone); err != nil {
+					return nil, fmt.Errorf("invalid DTSTART timezone %q: %w", timezone, err)
+				}
+			}
+			start, err := parseRRuleTime(value, location)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTSTART %q: %w", value, err)
+			}
+			normalized.start = start.UTC()
+			hasStart = true
+		case strings.EqualFold(name, "RRULE"):
+			for _, param := range strings.Split(value, ";") {
+				key, paramValue, _ := strings.Cut(param, "=")
+				normalized.params[strings.ToUpper(key)] = strings.ToUpper(paramValue)
+			}
+		}
+	}
+
+	if !hasStart {
+		return nil, fmt.Errorf("rrule %q has no DTSTART", rrule)
+	}
+	if until, ok := normalized.params["UNTIL"]; ok {
+		end, err := parseRRuleTime(until, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNTIL %q: %w", until, err)
+		}
+		normalized.params["UNTIL"] = end.UTC().Format(time.RFC3339)
+	}
+	if _, ok := normalized.params["INTERVAL"]; !ok {
+		normalized.params["INTERVAL"] = "1"
+	}
+	return normalized, nil
+}
+
+// equivalentRRules reports whether two rrules start at the same instant and
+// have the same recurrence, regardless of how DTSTART is written
+func equivalentRRules(a, b string) bool {
+	normalizedA, err := normalizeRRule(a)
+	if err != nil {
+		return false
+	}
+	normalizedB, err := normalizeRRule(b)
+	if err != nil {
+		return false
+	}
+	if !normalizedA.start.Equal(normalizedB.start) || len(normalizedA.params) != len(normalizedB.params) {
+		return false
+	}
+	for key, value := range normalizedA.params {
+		if normalizedB.params[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// isScheduleInDesiredState checks if the schedule matches the desired specification
+func isScheduleInDesiredState(schedule map[string]interface{}, scheduleSpec awxv1alpha1.ScheduleSpec) bool {
+	if description, ok := schedule["description"].(string); !ok || description != scheduleSpec.Description {
+		return false
+	}
+	if !scheduleOverridesInDesiredState(schedule, scheduleSpec) {
+		return false
+	}
+	return isScheduleTimingInDesiredState(schedule, scheduleSpec)
+}
+
+// scheduleOverridesInDesiredState checks if the schedule overrides exactly
+// the declared launch parameters
+func scheduleOverridesInDesiredState(schedule map[string]interface{}, scheduleSpec awxv1alpha1.ScheduleSpec) bool {
+	verbosity, ok := schedule["verbosity"].(float64)
+	if ok != (scheduleSpec.Verbosity != nil) || (ok && int32(verbosity) != *scheduleSpec.Verbosity) {
+		return false
+	}
+	diffMode, ok := schedule["diff_mode"].(bool)
+	return ok == (scheduleSpec.DiffMode != nil) && (!ok || diffMode == *scheduleSpec.DiffMode)
+}
+
+// isScheduleTimingInDesiredState checks if the schedule is enabled and runs
+// as declared
+func isScheduleTimingInDesiredState(schedule map[string]interface{}, scheduleSpec awxv1alpha1.ScheduleSpec) bool {
+	if enabled, ok := schedule["enabled"].(bool); !ok || enabled != scheduleEnabled(scheduleSpec) {
+		return false
+	}
+
+	// The same instant in another time zone moves with daylight saving time
+	if timezone, ok := schedule["timezone"].(string); ok && timezone != scheduleTimezone(scheduleSpec) {
+		return false
+	}
+
+	desired, err := BuildRRule(scheduleSpec)
+	if err != nil {
+		return false
+	}
+	rrule, ok := schedule["rrule"].(string)
+	return ok && equivalentRRules(rrule, desired)
+}
+
+// scheduleFields returns the fields of the schedule sent to AWX, without the
+// launch overrides that only job template schedules have
+func scheduleFields(scheduleSpec awxv1alpha1.ScheduleSpec) (map[string]interface{}, error) {
+	rrule, err := BuildRRule(scheduleSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %s: %w", scheduleSpec.Name, err)
+	}
+	return map[string]interface{}{
+		"name":        scheduleSpec.Name,
+		"description": scheduleSpec.Description,
+		"rrule":       rrule,
+		"enabled":     scheduleEnabled(scheduleSpec),
+	}, nil
+}
+
+// schedulesInDesiredState checks if the schedules of the job template match the spec
+func (jtm *JobTemplateManager) schedulesInDesiredState(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec) bool {
+	schedules, err := jtm.client.ListRelated("job_templates", jobTemplateID, "schedules")
+	if err != nil || len(schedules) != len(jobTemplateSpec.Schedules) {
+		return false
+	}
+
+	existing := make(map[string]map[string]interface{}, len(schedules))
+	for _, schedule := range schedules {
+		if name, ok := schedule["name"].(string); ok {
+			existing[name] = schedule
+		}
+	}
+	for _, scheduleSpec := range jobTemplateSpec.Schedules {
+		schedule, ok := existing[scheduleSpec.Name]
+		if !ok || !isScheduleInDesiredState(schedule, scheduleSpec) {
+			return false
+		}
+	}
+	return true
+}
+
+// removedScheduleFields are the fields a removed schedule is restored with
+// when its removal is rolled back
+var removedScheduleFields = []string{"name", "description", "rrule", "enabled", "extra_data", "verbosity", "diff_mode"}
+
+// reconcileSchedules creates, updates and removes the schedules of the job
+// template. Each change is recorded in changes so it can be rolled back;
+// removed schedules are restored as new schedules with the same fields.
+func (jtm *JobTemplateManager) reconcileSchedules(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec, changes *changeSet) error {
+	schedules, err := jtm.client.ListRelated("job_templates", jobTemplateID, "schedules")
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	existing := make(map[string]map[string]interface{}, len(schedules))
+	for _, schedule := range schedules {
+		if name, ok := schedule["name"].(string); ok {
+			existing[name] = schedule
+		}
+	}
+
+	desired := make(map[string]bool, len(jobTemplateSpec.Schedules))
+	for _, scheduleSpec := range jobTemplateSpec.Schedules {
+		desired[scheduleSpec.Name] = true
+
+		scheduleData, err := scheduleFields(scheduleSpec)
+		if err != nil {
+			return err
+		}
+		rrule := scheduleData["rrule"]
+		for field, value := range scheduleLaunchOverrides(scheduleSpec) {
+			scheduleData[field] = value
+		}
+
+		schedule, exists := existing[scheduleSpec.Name]
+		if !exists {
+			jtm.client.log.Info("Creating AWX schedule", "name", scheduleSpec.Name, "jobTemplate", jobTemplateSpec.Name, "rrule", rrule,
+				"verbosity", scheduleData["verbosity"], "diffMode", scheduleData["diff_mode"])
+			endpoint := fmt.Sprintf("job_templates/%d/schedules", jobTemplateID)
+			created, err := jtm.client.CreateObject(endpoint, scheduleData, "schedule")
+			if err != nil {
+				return fmt.Errorf("failed to create schedule %s: %w", scheduleSpec.Name, err)
+			}
+			if createdID, err := getObjectID(created); err == nil {
+				changes.record("remove schedule "+scheduleSpec.Name, func() error {
+					return jtm.client.DeleteObject("schedules", createdID)
+				})
+			}
+			continue
+		}
+		if isScheduleInDesiredState(schedule, scheduleSpec) {
+			continue
+		}
+
+		scheduleID, err := getObjectID(schedule)
+		if err != nil {
+			return fmt.Errorf("failed to get schedule ID: %w", err)
+		}
+		jtm.client.log.Info("Updating AWX schedule", "name", scheduleSpec.Name, "id", scheduleID, "rrule", rrule,
+			"verbosity", scheduleData["verbosity"], "diffMode", scheduleData["diff_mode"])
+		err = jtm.client.retryOnConflict("update schedule "+scheduleSpec.Name, func() error {
+			_, err := jtm.client.UpdateObject("schedules", scheduleID, scheduleData)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update schedule %s: %w", scheduleSpec.Name, err)
+		}
+		changes.recordUpdate(jtm.client, "schedules", schedule, scheduleData)
+	}
+
+	for name, schedule := range existing {
+		if desired[name] {
+			continue
+		}
+		scheduleID, err := getObjectID(schedule)
+		if err != nil {
+			return fmt.Errorf("failed to get schedule ID for deletion: %w", err)
+		}
+		jtm.client.log.Info("Deleting AWX schedule", "name", name, "id", scheduleID, "jobTemplate", jobTemplateSpec.Name)
+		err = jtm.client.retryOnConflict("delete schedule "+name, func() error {
+			return jtm.client.DeleteObject("schedules", scheduleID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete schedule %s: %w", name, err)
+		}
+		restored := make(map[string]interface{}, len(removedScheduleFields))
+		for _, field := range removedScheduleFields {
+			if value, ok := schedule[field]; ok {
+				restored[field] = value
+			}
+		}
+		changes.record("restore schedule "+name, func() error {
+			_, err := jtm.client.CreateObject(fmt.Sprintf("job_templates/%d/schedules", jobTemplateID), restored, "schedule")
+			return err
+		})
+	}
+	return nil
+}
+
+// deleteNamedSchedules deletes the schedules with the names from the object
+// behind endpoint, leaving its other schedules alone
+func (c *Client) deleteNamedSchedules(endpoint string, id int, names []string) error {
+	schedules, err := c.ListRelated(endpoint, id, "schedules")
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+	for _, schedule := range schedules {
+		name, _ := schedule["name"].(string)
+		if !slices.Contains(names, name) {
+			continue
+		}
+		scheduleID, err := getObjectID(schedule)
+		if err != nil {
+			return fmt.Errorf("failed to get schedule ID for deletion: %w", err)
+		}
+		c.log.Info("Deleting AWX schedule", "name", name, "id", scheduleID, "endpoint", endpoint, "objectID", id)
+		err = c.retryOnConflict("delete schedule "+name, func() error {
+			return c.DeleteObject("schedules", scheduleID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete schedule %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteSchedules deletes the declared schedules of the job template and
+// leaves the job template in place. A job template that no longer exists has
+// no schedules left to delete.
+func (jtm *JobTemplateManager) DeleteSchedules(jobTemplateSpec awxv1alpha1.JobTemplateSpec) error {
+	if len(jobTemplateSpec.Schedules) == 0 {
+		return nil
+	}
+	jobTemplate, err := jtm.client.FindObjectByName("job_templates", jobTemplateSpec.Name)
+	if err != nil || jobTemplate == nil {
+		return err
+	}
+	id, err := getObjectID(jobTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to get job template ID: %w", err)
+	}
+
+	names := make([]string, 0, len(jobTemplateSpec.Schedules))
+	for _, scheduleSpec := range jobTemplateSpec.Schedules {
+		names = append(names, scheduleSpec.Name)
+	}
+	return jtm.client.deleteNamedSchedules("job_templates", id, names)
+}
+
+// ValidateUpdateSchedule checks the update schedule of a project or an
+// inventory source. Updates take no launch overrides, so AWX would reject them.
+func ValidateUpdateSchedule(scheduleSpec awxv1alpha1.ScheduleSpec) error {
+	if _, err := BuildRRule(scheduleSpec); err != nil {
+		return fmt.Errorf("update schedule: %w", err)
+	}
+	if scheduleSpec.Verbosity != nil || scheduleSpec.DiffMode != nil {
+		return fmt.Errorf("update schedule: verbosity and diffMode only apply to job template schedules")
+	}
+	return nil
+}
+
+// updateScheduleInDesiredState checks if the project or inventory source
+// behind endpoint has exactly the declared update schedule, or none
+func (c *Client) updateScheduleInDesiredState(endpoint string, id int, scheduleSpec *awxv1alpha1.ScheduleSpec) bool {
+	schedules, err := c.ListRelated(endpoint, id, "schedules")
+	if err != nil {
+		return false
+	}
+	if scheduleSpec == nil {
+		return len(schedules) == 0
+	}
+	if len(schedules) != 1 {
+		return false
+	}
+	name, _ := schedules[0]["name"].(string)
+	return name == scheduleSpec.Name && isScheduleInDesiredState(schedules[0], *scheduleSpec)
+}
+
+// reconcileUpdateSchedule gives the project or inventory source behind
+// endpoint exactly the declared update schedule, removing any other schedule.
+// Schedules are created below the object, so AWX makes it their unified job
+// template and they launch project or inventory updates accordingly.
+func (c *Client) reconcileUpdateSchedule(endpoint string, id int, scheduleSpec *awxv1alpha1.ScheduleSpec) error {
+	schedules, err := c.ListRelated(endpoint, id, "schedules")
+	if err != nil {
+		return fmt.Errorf("failed to list update schedules: %w", err)
+	}
+
+	var kept bool
+	for _, schedule := range schedules {
+		scheduleID, err := getObjectID(schedule)
+		if err != nil {
+			return fmt.Errorf("failed to get schedule ID: %w", err)
+		}
+		name, _ := schedule["name"].(string)
+		if scheduleSpec == nil || kept || name != scheduleSpec.Name {
+			c.log.Info("Deleting AWX update schedule", "name", name, "id", scheduleID, "endpoint", endpoint, "objectID", id)
+			if err := c.DeleteObject("schedules", scheduleID); err != nil {
+				return fmt.Errorf("failed to delete update schedule %s: %w", name, err)
+			}
+			continue
+		}
+		kept = true
+		if isScheduleInDesiredState(schedule, *scheduleSpec) {
+			continue
+		}
+		scheduleData, err := scheduleFields(*scheduleSpec)
+		if err != nil {
+			return err
+		}
+		c.log.Info("Updating AWX update schedule", "name", name, "id", scheduleID, "rrule", scheduleData["rrule"])
+		err = c.retryOnConflict("update schedule "+name, func() error {
+			_, err := c.UpdateObject("schedules", scheduleID, scheduleData)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update schedule %s: %w", name, err)
+		}
+	}
+
+	if scheduleSpec == nil || kept {
+		return nil
+	}
+	scheduleData, err := scheduleFields(*scheduleSpec)
+	if err != nil {
+		return err
+	}
+	c.log.Info("Creating AWX update schedule", "name", scheduleSpec.Name, "endpoint", endpoint, "objectID", id, "rrule", scheduleData["rrule"])
+	if _, err := c.CreateObject(fmt.Sprintf("%s/%d/schedules", endpoint, id), scheduleData, "schedule"); err != nil {
+		return fmt.Errorf("failed to create update schedule %s: %w", scheduleSpec.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package awx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// TestScheduleRRuleNormalization verifies that the rrule of a schedule is
+// compared by the instant it starts, not by how AWX writes DTSTART
+func TestScheduleRRuleNormalization(t *testing.T) {
+	scheduleSpec := awxv1alpha1.ScheduleSpec{
+		Name:       "nightly",
+		Recurrence: "FREQ=DAILY;INTERVAL=1",
+		Start:      "2024-01-01T09:00:00",
+		End:        "2024-06-01T09:00:00",
+		Timezone:   "Europe/Berlin",
+	}
+	rrule, err := BuildRRule(scheduleSpec)
+	assert.NoError(t, err)
+	assert.Equal(t, "DTSTART;TZID=Europe/Berlin:20240101T090000 RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20240601T070000Z", rrule)
+
+	// The same start written in UTC, with reordered parameters and the default interval
+	assert.True(t, equivalentRRules(rrule, "DTSTART:20240101T080000Z RRULE:UNTIL=20240601T070000Z;FREQ=DAILY"))
+	// A start one hour later is a different schedule
+	assert.False(t, equivalentRRules(rrule, "DTSTART:20240101T090000Z RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20240601T070000Z"))
+
+	schedule := map[string]interface{}{
+		"name":        "nightly",
+		"description": "",
+		"enabled":     true,
+		"timezone":    "Europe/Berlin",
+		"rrule":       "DTSTART:20240101T080000Z RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20240601T070000Z",
+	}
+	assert.True(t, isScheduleInDesiredState(schedule, scheduleSpec))
+
+	schedule["timezone"] = "UTC"
+	assert.False(t, isScheduleInDesiredState(schedule, scheduleSpec), "Timezone change should be drift")
+
+	disabled := false
+	scheduleSpec.Enabled = &disabled
+	schedule["timezone"] = "Europe/Berlin"
+	assert.False(t, isScheduleInDesiredState(schedule, scheduleSpec), "Enabled change should be drift")
+
+	_, err = BuildRRule(awxv1alpha1.ScheduleSpec{Recurrence: "FREQ=DAILY", Start: "2024-01-01T09:00:00", Timezone: "Mars/Base"})
+	assert.Error(t, err)
+}
+
+// TestScheduleLaunchOverrides verifies that the verbosity and diff mode a
+// schedule launches jobs with are compared and validated against the prompts
+// of the job template
+func TestScheduleLaunchOverrides(t *testing.T) {
+	verbosity := int32(3)
+	diffMode := true
+	scheduleSpec := awxv1alpha1.ScheduleSpec{
+		Name:       "nightly",
+		Recurrence: "FREQ=DAILY;INTERVAL=1",
+		Start:      "2024-01-01T09:00:00",
+		Verbosity:  &verbosity,
+		DiffMode:   &diffMode,
+	}
+	schedule := map[string]interface{}{
+		"name":        "nightly",
+		"description": "",
+		"enabled":     true,
+		"timezone":    "UTC",
+		"rrule":       "DTSTART;TZID=UTC:20240101T090000 RRULE:FREQ=DAILY;INTERVAL=1",
+		"verbosity":   float64(3),
+		"diff_mode":   true,
+	}
+	assert.True(t, isScheduleInDesiredState(schedule, scheduleSpec))
+
+	schedule["verbosity"] = float64(1)
+	assert.False(t, isScheduleInDesiredState(schedule, scheduleSpec), "Verbosity change should be drift")
+
+	scheduleSpec.Verbosity = nil
+	assert.False(t, isScheduleInDesiredState(schedule, scheduleSpec), "An undeclared override should be drift")
+	schedule["verbosity"] = nil
+	assert.True(t, isScheduleInDesiredState(schedule, scheduleSpec))
+	assert.Equal(t, map[string]interface{}{"verbosity": nil, "diff_mode": true}, scheduleLaunchOverrides(scheduleSpec))
+
+	jobTemplateSpec := awxv1alpha1.JobTemplateSpec{Name: "deploy", AskVerbosityOnLaunch: true}
+	assert.EqualError(t, ValidateScheduleOverrides(jobTemplateSpec, scheduleSpec), "diffMode override requires askDiffModeOnLaunch")
+	jobTemplateSpec.AskDiffModeOnLaunch = true
+	assert.NoError(t, ValidateScheduleOverrides(jobTemplateSpec, scheduleSpec))
+}
@@ -0,0 +1,26 @@
+package awx
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// GetMetrics reads the Prometheus metrics exposed by the AWX instance. The
+// text format is requested explicitly since the endpoint answers JSON by default.
+func (c *Client) GetMetrics() (map[string]*dto.MetricFamily, error) {
+	respBody, err := c.doRequest(http.MethodGet, "metrics?format=txt", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWX metrics: %w", err)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(respBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AWX metrics: %w", err)
+	}
+	return families, nil
+}
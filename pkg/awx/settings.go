@@ -0,0 +1,118 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// systemSettingsEndpoint holds the system settings, including the analytics keys
+const systemSettingsEndpoint = "settings/system"
+
+// Automation Analytics keys of the AWX system settings
+const (
+	settingInsightsTrackingState = "INSIGHTS_TRACKING_STATE"
+	settingAnalyticsURL          = "AUTOMATION_ANALYTICS_URL"
+	settingAnalyticsInterval     = "AUTOMATION_ANALYTICS_GATHER_INTERVAL"
+	settingRedHatUsername        = "REDHAT_USERNAME"
+	settingRedHatPassword        = "REDHAT_PASSWORD"
+)
+
+// AnalyticsSettings are the desired Automation Analytics settings, with the
+// Red Hat account read from its Secret
+type AnalyticsSettings struct {
+	Enabled               bool
+	URL                   string
+	GatherIntervalSeconds int32
+	Username              string
+	Password              string
+}
+
+// SettingsManager handles the AWX system settings
+type SettingsManager struct {
+	client *Client
+}
+
+// NewSettingsManager creates a new SettingsManager
+func NewSettingsManager(client *Client) *SettingsManager {
+	return &SettingsManager{
+		client: client,
+	}
+}
+
+// GetSystemSettings retrieves the system settings
+func (sm *SettingsManager) GetSystemSettings() (map[string]interface{}, error) {
+	respBody, err := sm.client.doRequest(http.MethodGet, systemSettingsEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system settings: %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal(respBody, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return settings, nil
+}
+
+// analyticsSettingsData maps the analytics settings to the keys AWX expects.
+// Optional settings are only included when they are set.
+func analyticsSettingsData(desired AnalyticsSettings) map[string]interface{} {
+	data := map[string]interface{}{
+		settingInsightsTrackingState: desired.Enabled,
+	}
+	if desired.URL != "" {
+		data[settingAnalyticsURL] = desired.URL
+	}
+	if desired.GatherIntervalSeconds > 0 {
+		data[settingAnalyticsInterval] = desired.GatherIntervalSeconds
+	}
+	if desired.Username != "" {
+		data[settingRedHatUsername] = desired.Username
+	}
+	if desired.Password != "" {
+		data[settingRedHatPassword] = desired.Password
+	}
+	return data
+}
+
+// IsAnalyticsInDesiredState checks if the system settings match the desired
+// analytics settings. The password can't be read back from AWX, so it is only
+// checked to be set.
+func IsAnalyticsInDesiredState(settings map[string]interface{}, desired AnalyticsSettings) bool {
+	for key, value := range analyticsSettingsData(desired) {
+		if key == settingRedHatPassword {
+			if password, _ := settings[key].(string); password == "" {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(settings[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// EnsureAnalytics updates the analytics keys of the system settings when they
+// differ from the desired settings
+func (sm *SettingsManager) EnsureAnalytics(desired AnalyticsSettings) error {
+	settings, err := sm.GetSystemSettings()
+	if err != nil {
+		return err
+	}
+	if IsAnalyticsInDesiredState(settings, desired) {
+		return nil
+	}
+
+	sm.client.log.Info("Updating Automation Analytics settings", "enabled", desired.Enabled)
+	err = sm.client.retryOnConflict("update analytics settings", func() error {
+		// The settings carry the Red Hat password, so they are never logged
+		_, err := sm.client.doRequest(http.MethodPatch, systemSettingsEndpoint,
+			sensitiveBody{value: analyticsSettingsData(desired)})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update analytics settings: %w", err)
+	}
+	return nil
+}
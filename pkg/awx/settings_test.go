@@ -0,0 +1,33 @@
+package awx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIsAnalyticsInDesiredState verifies that the analytics settings are
+// compared without reading back the password
+func TestIsAnalyticsInDesiredState(t *testing.T) {
+	settings := map[string]interface{}{
+		settingInsightsTrackingState: true,
+		settingAnalyticsURL:          "https://example.com/api/ingress/v1/upload",
+		settingAnalyticsInterval:     float64(14400),
+		settingRedHatUsername:        "customer",
+		settingRedHatPassword:        encryptedValue,
+	}
+	desired := AnalyticsSettings{
+		Enabled:               true,
+		GatherIntervalSeconds: 14400,
+		Username:              "customer",
+		Password:              "secret",
+	}
+	assert.True(t, IsAnalyticsInDesiredState(settings, desired), "Unset URL and encrypted password should not be drift")
+
+	settings[settingRedHatPassword] = ""
+	assert.False(t, IsAnalyticsInDesiredState(settings, desired), "Missing password should be drift")
+
+	settings[settingRedHatPassword] = encryptedValue
+	settings[settingInsightsTrackingState] = false
+	assert.False(t, IsAnalyticsInDesiredState(settings, desired), "Disabled tracking should be drift")
+}
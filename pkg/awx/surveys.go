@@ -0,0 +1,209 @@
+package awx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// surveyQuestionPassword is the question type whose answers AWX encrypts
+const surveyQuestionPassword = "password"
+
+// surveyEnabled returns whether the survey is shown on launch, which it is
+// unless disabled explicitly
+func surveyEnabled(survey *awxv1alpha1.SurveySpec) bool {
+	return survey.Enabled == nil || *survey.Enabled
+}
+
+// surveyQuestionType returns the declared question type, defaulting to text
+func surveyQuestionType(question awxv1alpha1.SurveyQuestionSpec) string {
+	if question.Type == "" {
+		return "text"
+	}
+	return question.Type
+}
+
+// surveySpecData maps the survey to the AWX survey_spec format
+func surveySpecData(survey *awxv1alpha1.SurveySpec) map[string]interface{} {
+	questions := make([]interface{}, 0, len(survey.Questions))
+	for _, question := range survey.Questions {
+		data := map[string]interface{}{
+			"variable":             question.Variable,
+			"question_name":        question.Question,
+			"question_description": question.Description,
+			"type":                 surveyQuestionType(question),
+			"required":             question.Required,
+			"default":              question.Default,
+		}
+		if len(question.Choices) > 0 {
+			data["choices"] = strings.Join(question.Choices, "\n")
+		}
+		if question.Min != nil {
+			data["min"] = *question.Min
+		}
+		if question.Max != nil {
+			data["max"] = *question.Max
+		}
+		questions = append(questions, data)
+	}
+	return map[string]interface{}{
+		"name":        survey.Name,
+		"description": survey.Description,
+		"spec":        questions,
+	}
+}
+
+// surveyChoices returns the choices of a survey question, which AWX stores
+// either as a list or as a newline separated string
+func surveyChoices(value interface{}) []string {
+	switch choices := value.(type) {
+	case string:
+		if choices == "" {
+			return nil
+		}
+		return strings.Split(choices, "\n")
+	case []interface{}:
+		result := make([]string, 0, len(choices))
+		for _, choice := range choices {
+			result = append(result, fmt.Sprint(choice))
+		}
+		return result
+	}
+	return nil
+}
+
+// surveyField returns a field of the survey spec as a string, treating
+// missing and null fields as empty
+func surveyField(object map[string]interface{}, key string) string {
+	if object[key] == nil {
+		return ""
+	}
+	return fmt.Sprint(object[key])
+}
+
+// isSurveyQuestionInDesiredState checks if a question of the AWX survey
+// matches the spec. Password defaults can't be read back from AWX, so they
+// are only checked to be set.
+func isSurveyQuestionInDesiredState(actual map[string]interface{}, question awxv1alpha1.SurveyQuestionSpec) bool {
+	if surveyField(actual, "variable") != question.Variable ||
+		surveyField(actual, "question_name") != question.Question ||
+		surveyField(actual, "question_description") != question.Description ||
+		surveyField(actual, "type") != surveyQuestionType(question) {
+		return false
+	}
+	if required, _ := actual["required"].(bool); required != question.Required {
+		return false
+	}
+
+	defaultValue := surveyField(actual, "default")
+	if question.Type == surveyQuestionPassword {
+		if (defaultValue != "") != (question.Default != "") {
+			return false
+		}
+	} else if defaultValue != question.Default {
+		return false
+	}
+
+	if choices := surveyChoices(actual["choices"]); len(choices)+len(question.Choices) > 0 && !slices.Equal(choices, question.Choices) {
+		return false
+	}
+	if question.Min != nil && fmt.Sprint(actual["min"]) != fmt.Sprint(*question.Min) {
+		return false
+	}
+	if question.Max != nil && fmt.Sprint(actual["max"]) != fmt.Sprint(*question.Max) {
+		return false
+	}
+	return true
+}
+
+// getSurvey reads the survey of a job template or workflow job template.
+// Templates without a survey return an empty survey.
+func (c *Client) getSurvey(endpoint string, templateID int) (map[string]interface{}, error) {
+	respBody, err := c.doRequest(http.MethodGet, fmt.Sprintf("%s/%d/survey_spec", endpoint, templateID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read survey: %w", err)
+	}
+
+	var survey map[string]interface{}
+	if err := json.Unmarshal(respBody, &survey); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return survey, nil
+}
+
+// surveyInDesiredState checks if the survey of the job template matches the spec
+func (jtm *JobTemplateManager) surveyInDesiredState(jobTemplateID int, survey *awxv1alpha1.SurveySpec) bool {
+	return jtm.client.surveyInDesiredState("job_templates", jobTemplateID, survey)
+}
+
+// surveyInDesiredState checks if the survey of a job template or workflow job
+// template matches the spec
+func (c *Client) surveyInDesiredState(endpoint string, templateID int, survey *awxv1alpha1.SurveySpec) bool {
+	actual, err := c.getSurvey(endpoint, templateID)
+	if err != nil {
+		return false
+	}
+	if surveyField(actual, "name") != survey.Name || surveyField(actual, "description") != survey.Description {
+		return false
+	}
+
+	questions, _ := actual["spec"].([]interface{})
+	if len(questions) != len(survey.Questions) {
+		return false
+	}
+	for i, question := range survey.Questions {
+		actualQuestion, _ := questions[i].(map[string]interface{})
+		if !isSurveyQuestionInDesiredState(actualQuestion, question) {
+			return false
+		}
+	}
+	return true
+}
+
+// redactSurveyPasswords replaces the password defaults of the survey in an
+// error message, in case AWX echoed them in a validation error. The error is
+// returned unchanged when it contains none of them, so its type is kept.
+func redactSurveyPasswords(err error, survey *awxv1alpha1.SurveySpec) error {
+	message := err.Error()
+	redacted := message
+	for _, question := range survey.Questions {
+		if question.Type == surveyQuestionPassword && question.Default != "" {
+			redacted = strings.ReplaceAll(redacted, question.Default, encryptedValue)
+		}
+	}
+	if redacted == message {
+		return err
+	}
+	return errors.New(redacted)
+}
+
+// reconcileSurvey replaces the survey of the job template when it differs
+// from the spec
+func (jtm *JobTemplateManager) reconcileSurvey(jobTemplateID int, jobTemplateSpec awxv1alpha1.JobTemplateSpec) error {
+	return jtm.client.reconcileSurvey("job_templates", jobTemplateID, jobTemplateSpec.Name, jobTemplateSpec.Survey)
+}
+
+// reconcileSurvey replaces the survey of a job template or workflow job
+// template when it differs from the spec. The survey is sent as a sensitive
+// body, so password defaults never appear in the request log.
+func (c *Client) reconcileSurvey(endpoint string, templateID int, name string, survey *awxv1alpha1.SurveySpec) error {
+	if c.surveyInDesiredState(endpoint, templateID, survey) {
+		return nil
+	}
+
+	c.log.Info("Updating survey", "endpoint", endpoint, "template", name, "questions", len(survey.Questions))
+	err := c.retryOnConflict("update survey of "+name, func() error {
+		_, err := c.doRequest(http.MethodPost, fmt.Sprintf("%s/%d/survey_spec", endpoint, templateID),
+			sensitiveBody{value: surveySpecData(survey)})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update survey: %w", redactSurveyPasswords(err, survey))
+	}
+	return nil
+}
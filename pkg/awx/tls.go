@@ -0,0 +1,140 @@
+package awx
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CipherSuitesFIPS selects the TLS 1.2 cipher suites approved for FIPS 140
+const CipherSuitesFIPS = "FIPS"
+
+// fipsCipherSuites are the TLS 1.2 cipher suites approved for FIPS 140: ECDHE
+// key exchange with AES-GCM. TLS 1.3 only offers approved AES-GCM suites when
+// Go runs in FIPS mode.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// ParseTLSVersion parses a minimum TLS version such as "1.2" or "1.3". An
+// empty version returns 0, which keeps the Go default. Versions before 1.2
+// are rejected, as they are no stricter than the default.
+func ParseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.TrimSpace(version), "TLS") {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q, expected 1.2 or 1.3", version)
+}
+
+// ParseCipherSuites parses a comma separated list of IANA cipher suite names,
+// e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", or "FIPS" for the suites
+// approved for FIPS 140. Insecure suites and TLS 1.3 suites, which can't be
+// configured, are rejected.
+func ParseCipherSuites(names string) ([]uint16, error) {
+	if strings.TrimSpace(names) == "" {
+		return nil, nil
+	}
+	if strings.EqualFold(strings.TrimSpace(names), CipherSuitesFIPS) {
+		return fipsCipherSuites, nil
+	}
+
+	known := make(map[string]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite
+	}
+	insecure := make(map[string]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+
+	var suites []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		suite, ok := known[name]
+		switch {
+		case insecure[name]:
+			return nil, fmt.Errorf("cipher suite %s is insecure", name)
+		case !ok:
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		case !supportsTLS12(suite):
+			return nil, fmt.Errorf("cipher suite %s is a TLS 1.3 suite, which can't be configured", name)
+		}
+		suites = append(suites, suite.ID)
+	}
+	return suites, nil
+}
+
+// supportsTLS12 reports whether a cipher suite can be negotiated with TLS 1.2
+func supportsTLS12(suite *tls.CipherSuite) bool {
+	for _, version := range suite.SupportedVersions {
+		if version == tls.VersionTLS12 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTLSOptions checks that the TLS restrictions of the options can be
+// combined
+func ValidateTLSOptions(opts TransportOptions) error {
+	if opts.TLSMinVersion == tls.VersionTLS13 && len(opts.TLSCipherSuites) > 0 {
+		return fmt.Errorf("cipher suites only apply to TLS 1.2 and can't be combined with TLS 1.3 as the minimum version")
+	}
+	return nil
+}
+
+// restrictsTLS reports whether the options restrict TLS beyond the Go defaults
+func (opts TransportOptions) restrictsTLS() bool {
+	return opts.TLSMinVersion != 0 || len(opts.TLSCipherSuites) > 0
+}
+
+// TLSState describes the TLS connection last negotiated with AWX
+type TLSState struct {
+	// Version is the negotiated TLS version, e.g. "TLS 1.3"
+	Version string
+	// CipherSuite is the negotiated cipher suite
+	CipherSuite string
+	// Restricted reports whether the minimum version or cipher suites were
+	// restricted by the transport options
+	Restricted bool
+	// FIPSApproved reports whether the negotiated cipher suite is approved for
+	// FIPS 140
+	FIPSApproved bool
+}
+
+// fipsApproved reports whether a negotiated cipher suite is approved for FIPS
+// 140, which are the AES-GCM suites of TLS 1.3 and the FIPS suites of TLS 1.2
+func fipsApproved(state *tls.ConnectionState) bool {
+	if state.Version == tls.VersionTLS13 {
+		return state.CipherSuite == tls.TLS_AES_128_GCM_SHA256 || state.CipherSuite == tls.TLS_AES_256_GCM_SHA384
+	}
+	return state.Version == tls.VersionTLS12 && slices.Contains(fipsCipherSuites, state.CipherSuite)
+}
+
+// recordTLSState remembers the TLS parameters of a response
+func (c *Client) recordTLSState(state *tls.ConnectionState) {
+	if state == nil {
+		return
+	}
+	c.tlsState.Store(&TLSState{
+		Version:      tls.VersionName(state.Version),
+		CipherSuite:  tls.CipherSuiteName(state.CipherSuite),
+		Restricted:   c.restrictedTLS,
+		FIPSApproved: fipsApproved(state),
+	})
+}
+
+// TLSState returns the TLS connection last negotiated with AWX, or nil when
+// the client has not connected with TLS yet
+func (c *Client) TLSState() *TLSState {
+	return c.tlsState.Load()
+}
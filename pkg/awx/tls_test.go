@@ -0,0 +1,69 @@
+package awx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseTLSOptions verifies that TLS restrictions are parsed and
+// contradicting or insecure settings are rejected
+func TestParseTLSOptions(t *testing.T) {
+	version, err := ParseTLSVersion("1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), version)
+	version, err = ParseTLSVersion("")
+	assert.NoError(t, err)
+	assert.Zero(t, version)
+	_, err = ParseTLSVersion("1.0")
+	assert.ErrorContains(t, err, "unsupported TLS version")
+
+	suites, err := ParseCipherSuites("fips")
+	assert.NoError(t, err)
+	assert.Equal(t, fipsCipherSuites, suites)
+	suites, err = ParseCipherSuites("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}, suites)
+
+	_, err = ParseCipherSuites("TLS_RSA_WITH_RC4_128_SHA")
+	assert.ErrorContains(t, err, "is insecure")
+	_, err = ParseCipherSuites("TLS_AES_128_GCM_SHA256")
+	assert.ErrorContains(t, err, "can't be configured")
+	_, err = ParseCipherSuites("TLS_MADE_UP")
+	assert.ErrorContains(t, err, "unknown cipher suite")
+
+	assert.Error(t, ValidateTLSOptions(TransportOptions{TLSMinVersion: tls.VersionTLS13, TLSCipherSuites: fipsCipherSuites}))
+	assert.NoError(t, ValidateTLSOptions(TransportOptions{TLSMinVersion: tls.VersionTLS12, TLSCipherSuites: fipsCipherSuites}))
+}
+
+// TestRestrictedTLS verifies that the client only negotiates the allowed
+// version and cipher suites and reports the connection
+func TestRestrictedTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version": "24.0.0"}`))
+	}))
+	defer server.Close()
+
+	opts := TransportOptions{TLSMinVersion: tls.VersionTLS12, TLSCipherSuites: fipsCipherSuites}
+	client := NewClient(server.URL, "admin", "password")
+	transport := newTransport(opts)
+	transport.TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	// Cipher suites only apply to TLS 1.2
+	transport.TLSClientConfig.MaxVersion = tls.VersionTLS12
+	client.httpClient.Transport = transport
+	client.restrictedTLS = opts.restrictsTLS()
+
+	assert.Nil(t, client.TLSState())
+	_, err := client.doRequest(http.MethodGet, "ping", nil)
+	assert.NoError(t, err)
+
+	state := client.TLSState()
+	assert.Equal(t, "TLS 1.2", state.Version)
+	assert.Contains(t, state.CipherSuite, "_GCM_")
+	assert.True(t, state.Restricted)
+	assert.True(t, state.FIPSApproved)
+}
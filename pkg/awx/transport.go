@@ -0,0 +1,126 @@
+package awx
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unixSocketPrefix marks a dial address as a Unix domain socket path
+const unixSocketPrefix = "unix://"
+
+// TransportOptions configures the HTTP transport of new AWX clients
+type TransportOptions struct {
+	// DisableHTTP2 restricts connections to HTTP/1.1
+	DisableHTTP2 bool
+	// DisableCompression stops requesting gzip compressed responses
+	DisableCompression bool
+	// CompressRequests gzip compresses request bodies. AWX itself doesn't
+	// decompress them, so it needs a proxy in front of AWX that does.
+	CompressRequests bool
+	// DialAddress routes all connections to this address instead of the AWX
+	// host, e.g. "localhost:8043" for a port-forward or "unix:///tmp/awx.sock".
+	// The AWX host is still used for the Host header and TLS verification.
+	DialAddress string
+	// TLSMinVersion is the minimum TLS version, e.g. tls.VersionTLS12. Zero
+	// keeps the Go default.
+	TLSMinVersion uint16
+	// TLSCipherSuites restricts the TLS 1.2 cipher suites, e.g. to the ones
+	// approved for FIPS 140. Empty keeps the Go defaults.
+	TLSCipherSuites []uint16
+}
+
+var (
+	transportOptionsMu sync.Mutex
+	transportOptions   TransportOptions
+)
+
+// SetTransportOptions sets the transport options used by clients created
+// afterwards, typically once from operator flags at startup
+func SetTransportOptions(opts TransportOptions) {
+	transportOptionsMu.Lock()
+	defer transportOptionsMu.Unlock()
+	transportOptions = opts
+}
+
+// currentTransportOptions returns the options set by SetTransportOptions
+func currentTransportOptions() TransportOptions {
+	transportOptionsMu.Lock()
+	defer transportOptionsMu.Unlock()
+	return transportOptions
+}
+
+// newTransport returns an HTTP transport that negotiates HTTP/2 via ALPN and
+// transparently requests and decompresses gzip responses, which shrinks the
+// large paginated list responses considerably, unless disabled by the options
+func newTransport(opts TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = opts.DisableCompression
+	if opts.restrictsTLS() {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion:   opts.TLSMinVersion,
+			CipherSuites: opts.TLSCipherSuites,
+		}
+	}
+	if opts.DialAddress != "" {
+		transport.DialContext = dialAddress(opts.DialAddress)
+	}
+	if opts.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto map disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	} else {
+		transport.ForceAttemptHTTP2 = true
+	}
+	return transport
+}
+
+// gzipBody compresses body into a pipe while it is sent. Closing the returned
+// reader closes body, which stops a streaming encoder behind it.
+func gzipBody(body io.ReadCloser) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		defer body.Close()
+		compressor := gzip.NewWriter(writer)
+		_, err := io.Copy(compressor, body)
+		if closeErr := compressor.Close(); err == nil {
+			err = closeErr
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
+
+// compressRequestBody replaces the body of the request with its gzip
+// compressed form
+func compressRequestBody(req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	req.Body = gzipBody(req.Body)
+	req.GetBody = nil
+	req.ContentLength = -1
+	req.Header.Set("Content-Encoding", "gzip")
+}
+
+// dialAddress returns a dial function that connects to the given address
+// regardless of the address requested by the transport
+func dialAddress(address string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if path, ok := strings.CutPrefix(address, unixSocketPrefix); ok {
+		return func(ctx context.Context, _, addr string) (net.Conn, error) {
+			log.Info("Dialing AWX through Unix socket", "addr", addr, "socket", path)
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		log.Info("Dialing AWX through tunnel", "addr", addr, "tunnel", address)
+		return dialer.DialContext(ctx, network, address)
+	}
+}
@@ -0,0 +1,57 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The typed objects are generated from the AWX API schema in gen/schema.json.
+// To adopt a field AWX added, update the schema and run go generate.
+//go:generate go run ./gen -schema gen/schema.json -definitions Credential,Inventory,JobTemplate,Organization,Project,WorkflowJobTemplate -out zz_generated.types.go
+
+// DecodeObject converts an object read with the untyped methods of the
+// client, e.g. FindObjectByName, into one of the typed objects, e.g. Project
+func DecodeObject[T any](object map[string]interface{}) (*T, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode object: %w", err)
+	}
+	typed := new(T)
+	if err := json.Unmarshal(data, typed); err != nil {
+		return nil, fmt.Errorf("failed to decode object as %T: %w", *typed, err)
+	}
+	return typed, nil
+}
+
+// relatedName returns the name of the object a field of a typed object refers
+// to, or an empty string when the field is unset. The name is read from the
+// summary fields and only fetched from the endpoint when AWX didn't include
+// them.
+func (c *Client) relatedName(summaryFields map[string]interface{}, field, endpoint string, id *int) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	if related, ok := summaryFields[field].(map[string]interface{}); ok {
+		if name, ok := related["name"].(string); ok {
+			return name, nil
+		}
+	}
+
+	object, err := c.GetObject(endpoint, *id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %d: %w", field, *id, err)
+	}
+	name, _ := object["name"].(string)
+	return name, nil
+}
+
+// GetTyped reads the object with the ID from the endpoint as a typed object:
+//
+//	project, err := awx.GetTyped[awx.Project](client, "projects", id)
+func GetTyped[T any](c *Client, endpoint string, id int) (*T, error) {
+	object, err := c.GetObject(endpoint, id)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeObject[T](object)
+}
@@ -0,0 +1,60 @@
+package awx
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Version is the operator version reported in the User-Agent, set at build
+// time with -ldflags "-X github.com/derzufall/awx-k8s-operator/pkg/awx.Version=<version>"
+var Version = "dev"
+
+const (
+	// userAgentProduct identifies the operator in the User-Agent
+	userAgentProduct = "awx-k8s-operator"
+	// managedByHeader carries the Kubernetes object a request is made for
+	managedByHeader = "X-Managed-By"
+	// correlationIDHeader carries the correlation ID of the reconcile a
+	// request is made in
+	correlationIDHeader = "X-Correlation-ID"
+)
+
+// SetManagedBy attributes the requests of the client to a Kubernetes object,
+// e.g. "awxinstance/default/my-awx", so that AWX activity can be traced back to it
+func (c *Client) SetManagedBy(object string) {
+	c.managedBy = object
+}
+
+// SetCorrelationID attributes the following requests of the client to a
+// reconcile. The ID is sent in the X-Correlation-ID header and logged with
+// every request.
+func (c *Client) SetCorrelationID(correlationID string) {
+	c.correlationID.Store(&correlationID)
+}
+
+// CorrelationID returns the correlation ID requests are attributed to
+func (c *Client) CorrelationID() string {
+	if correlationID := c.correlationID.Load(); correlationID != nil {
+		return *correlationID
+	}
+	return ""
+}
+
+// userAgent returns the User-Agent sent with every request
+func (c *Client) userAgent() string {
+	if c.managedBy == "" {
+		return fmt.Sprintf("%s/%s", userAgentProduct, Version)
+	}
+	return fmt.Sprintf("%s/%s (%s)", userAgentProduct, Version, c.managedBy)
+}
+
+// setAttributionHeaders identifies the operator and the Kubernetes object on a request
+func (c *Client) setAttributionHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.userAgent())
+	if c.managedBy != "" {
+		req.Header.Set(managedByHeader, c.managedBy)
+	}
+	if correlationID := c.CorrelationID(); correlationID != "" {
+		req.Header.Set(correlationIDHeader, correlationID)
+	}
+}
@@ -35,3 +35,31 @@ func getMapKeys(m map[string]interface{}) []string {
 	}
 	return keys
 }
+
+// createFromCopy creates an object as a copy of the named source object and
+// applies the desired fields on top of the copy
+func createFromCopy(client *Client, endpoint, sourceName string, data map[string]interface{}) (map[string]interface{}, error) {
+	source, err := client.FindObjectByName(endpoint, sourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find copy source %s: %w", sourceName, err)
+	}
+	if source == nil {
+		return nil, &ReferenceNotFoundError{Kind: "copy source", Name: sourceName}
+	}
+	sourceID, err := getObjectID(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get copy source ID: %w", err)
+	}
+
+	name, _ := data["name"].(string)
+	copied, err := client.CopyObject(endpoint, sourceID, name)
+	if err != nil {
+		return nil, err
+	}
+	copiedID, err := getObjectID(copied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get copied object ID: %w", err)
+	}
+
+	return client.UpdateObject(endpoint, copiedID, data)
+}
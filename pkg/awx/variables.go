@@ -0,0 +1,79 @@
+package awx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// parseVariables parses variables in YAML or JSON format. Empty variables
+// are an empty map.
+func parseVariables(variables string) (map[string]interface{}, error) {
+	parsed := map[string]interface{}{}
+	if strings.TrimSpace(variables) == "" {
+		return parsed, nil
+	}
+	if err := yaml.Unmarshal([]byte(variables), &parsed); err != nil {
+		return nil, err
+	}
+	if parsed == nil {
+		parsed = map[string]interface{}{}
+	}
+	return parsed, nil
+}
+
+// deepMerge merges src into dst. Nested maps are merged key by key, any
+// other value of src replaces the one in dst.
+func deepMerge(dst, src map[string]interface{}) map[string]interface{} {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[key] = deepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+	return dst
+}
+
+// desiredVariables returns the variables to write over the existing ones in
+// AWX under the merge policy, and whether they differ from the existing ones.
+// With the Replace policy the declared variables are compared verbatim. With
+// the Merge policy they are deep-merged over the existing variables, which
+// are only changed when a declared key differs; variables in AWX that can't
+// be parsed are replaced.
+func desiredVariables(existing interface{}, declared, policy string) (string, bool, error) {
+	current, _ := existing.(string)
+	if policy != awxv1alpha1.VariablesMergePolicyMerge {
+		return declared, current != declared, nil
+	}
+
+	declaredVariables, err := parseVariables(declared)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid variables: %w", err)
+	}
+	currentVariables, err := parseVariables(current)
+	if err != nil {
+		return declared, true, nil
+	}
+	// Compare with a copy, since merging changes nested maps in place
+	original, err := parseVariables(current)
+	if err != nil {
+		return "", false, err
+	}
+	merged := deepMerge(currentVariables, declaredVariables)
+	if reflect.DeepEqual(merged, original) {
+		return current, false, nil
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to write merged variables: %w", err)
+	}
+	return string(data), true, nil
+}
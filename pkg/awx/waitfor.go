@@ -0,0 +1,116 @@
+package awx
+
+import (
+	"errors"
+	"fmt"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// syncSucceeded are the states of a project or inventory source whose last
+// update succeeded. Manual projects have nothing to sync and report "ok".
+var syncSucceeded = map[string]bool{
+	"successful": true,
+	"ok":         true,
+}
+
+// DependencyNotReadyError is returned when a job template waits for the sync
+// of its project or of an inventory source that hasn't succeeded (yet)
+type DependencyNotReadyError struct {
+	// Kind is the kind of the dependency, "project" or "inventory source"
+	Kind string
+	Name string
+	// Status is the sync state reported by AWX, e.g. "running" or "failed"
+	Status string
+}
+
+// Error implements the error interface
+func (e *DependencyNotReadyError) Error() string {
+	return fmt.Sprintf("%s %s has sync status %s", e.Kind, e.Name, e.Status)
+}
+
+// AsDependencyNotReadyError returns the DependencyNotReadyError wrapped in err, if any
+func AsDependencyNotReadyError(err error) (*DependencyNotReadyError, bool) {
+	var notReady *DependencyNotReadyError
+	if errors.As(err, &notReady) {
+		return notReady, true
+	}
+	return nil, false
+}
+
+// syncStatus reads the current sync state of a dependency. The object is read
+// by ID rather than served from the inventory, as its state changes without
+// the operator writing to it.
+func (c *Client) syncStatus(endpoint string, object map[string]interface{}) (string, error) {
+	id, err := getObjectID(object)
+	if err != nil {
+		return "", err
+	}
+	current, err := c.GetObject(endpoint, id)
+	if err != nil {
+		return "", err
+	}
+	status, _ := current["status"].(string)
+	if status == "" {
+		status = "never updated"
+	}
+	return status, nil
+}
+
+// DependenciesReady checks the syncs the job template waits for. It returns a
+// DependencyNotReadyError for the first project or inventory source whose
+// last sync did not succeed, and nil when the job template declares no
+// waitFor or all syncs succeeded.
+func (jtm *JobTemplateManager) DependenciesReady(jobTemplateSpec awxv1alpha1.JobTemplateSpec) error {
+	waitFor := jobTemplateSpec.WaitFor
+	if waitFor == nil {
+		return nil
+	}
+
+	if waitFor.ProjectSync {
+		project, err := jtm.client.FindObjectByName("projects", jobTemplateSpec.ProjectName)
+		if err != nil {
+			return fmt.Errorf("failed to find project %s: %w", jobTemplateSpec.ProjectName, err)
+		}
+		if project == nil {
+			return &ReferenceNotFoundError{Kind: "project", Name: jobTemplateSpec.ProjectName}
+		}
+		status, err := jtm.client.syncStatus("projects", project)
+		if err != nil {
+			return fmt.Errorf("failed to read sync status of project %s: %w", jobTemplateSpec.ProjectName, err)
+		}
+		if !syncSucceeded[status] {
+			return &DependencyNotReadyError{Kind: "project", Name: jobTemplateSpec.ProjectName, Status: status}
+		}
+	}
+
+	if waitFor.InventorySources {
+		inventory, err := jtm.client.FindObjectByName("inventories", jobTemplateSpec.InventoryName)
+		if err != nil {
+			return fmt.Errorf("failed to find inventory %s: %w", jobTemplateSpec.InventoryName, err)
+		}
+		if inventory == nil {
+			return &ReferenceNotFoundError{Kind: "inventory", Name: jobTemplateSpec.InventoryName}
+		}
+		inventoryID, err := getObjectID(inventory)
+		if err != nil {
+			return fmt.Errorf("failed to get inventory ID: %w", err)
+		}
+		sources, err := jtm.client.ListRelated("inventories", inventoryID, "inventory_sources")
+		if err != nil {
+			return fmt.Errorf("failed to list sources of inventory %s: %w", jobTemplateSpec.InventoryName, err)
+		}
+		for _, source := range sources {
+			name, _ := source["name"].(string)
+			status, _ := source["status"].(string)
+			if !syncSucceeded[status] {
+				if status == "" {
+					status = "never updated"
+				}
+				return &DependencyNotReadyError{Kind: "inventory source", Name: name, Status: status}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package awx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// WebhookReceiver is where GitHub or GitLab send the webhooks that launch a
+// template, together with the key the webhooks are signed with
+type WebhookReceiver struct {
+	URL string
+	Key string
+}
+
+// webhookData returns the webhook fields of a template. Templates without a
+// webhook have an empty service and no credential.
+func (c *Client) webhookData(webhook *awxv1alpha1.WebhookSpec) (map[string]interface{}, error) {
+	data := map[string]interface{}{
+		"webhook_service":    "",
+		"webhook_credential": nil,
+	}
+	if webhook == nil {
+		return data, nil
+	}
+	data["webhook_service"] = webhook.Service
+	if webhook.CredentialName != "" {
+		credential, err := c.FindObjectByName("credentials", webhook.CredentialName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find webhook credential %s: %w", webhook.CredentialName, err)
+		}
+		if credential == nil {
+			return nil, &ReferenceNotFoundError{Kind: "credential", Name: webhook.CredentialName}
+		}
+		credentialID, err := getObjectID(credential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get webhook credential ID: %w", err)
+		}
+		data["webhook_credential"] = credentialID
+	}
+	return data, nil
+}
+
+// isWebhookInDesiredState checks if the webhook service and credential of a
+// template match the spec
+func (c *Client) isWebhookInDesiredState(template map[string]interface{}, webhook *awxv1alpha1.WebhookSpec) bool {
+	desired, err := c.webhookData(webhook)
+	if err != nil {
+		return false
+	}
+	if service, _ := template["webhook_service"].(string); service != desired["webhook_service"] {
+		return false
+	}
+	credentialID, hasCredential := template["webhook_credential"].(float64)
+	desiredID, wantsCredential := desired["webhook_credential"].(int)
+	return hasCredential == wantsCredential && int(credentialID) == desiredID
+}
+
+// webhookReceiver reads the receiver URL and the key of the webhook of a
+// template. AWX generates the key when the webhook service is set.
+func (c *Client) webhookReceiver(endpoint string, template map[string]interface{}) (*WebhookReceiver, error) {
+	templateID, err := getObjectID(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template ID: %w", err)
+	}
+
+	respBody, err := c.doRequest(http.MethodGet, fmt.Sprintf("%s/%d/webhook_key", endpoint, templateID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook key: %w", err)
+	}
+	var response struct {
+		WebhookKey string `json:"webhook_key"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// AWX links the receiver of the configured service, e.g.
+	// /api/v2/workflow_job_templates/7/github/
+	related, _ := template["related"].(map[string]interface{})
+	receiverPath, _ := related["webhook_receiver"].(string)
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if receiverPath != "" {
+		u.Path = receiverPath
+	} else {
+		service, _ := template["webhook_service"].(string)
+		u.Path = c.apiURLPath(u.Path, path.Join(endpoint, fmt.Sprint(templateID), service))
+	}
+	return &WebhookReceiver{URL: u.String(), Key: response.WebhookKey}, nil
+}
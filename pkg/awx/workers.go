@@ -0,0 +1,48 @@
+package awx
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultHostConcurrency is the number of host requests sent to AWX in
+// parallel by default
+const DefaultHostConcurrency = 5
+
+// hostConcurrency limits the parallel host requests of an inventory
+var hostConcurrency atomic.Int64
+
+func init() {
+	hostConcurrency.Store(DefaultHostConcurrency)
+}
+
+// SetHostConcurrency sets how many host create, update and delete requests of
+// an inventory are sent to AWX in parallel, typically once from operator flags
+// at startup. Values below 1 process hosts one at a time.
+func SetHostConcurrency(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	hostConcurrency.Store(int64(workers))
+}
+
+// runConcurrently runs the tasks with at most workers of them at once. All
+// tasks are run even when some fail, and their errors are returned joined in
+// the order of the tasks.
+func runConcurrently(workers int, tasks []func() error) error {
+	errs := make([]error, len(tasks))
+	semaphore := make(chan struct{}, max(workers, 1))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = task()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,37 @@
+package awx
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRunConcurrently verifies that tasks are bounded by the number of workers
+// and that all errors are reported
+func TestRunConcurrently(t *testing.T) {
+	var running, peak atomic.Int32
+	tasks := make([]func() error, 0, 20)
+	for i := 0; i < 20; i++ {
+		tasks = append(tasks, func() error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := peak.Load()
+				if current <= previous || peak.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			if i%7 == 0 {
+				return fmt.Errorf("task %d failed", i)
+			}
+			return nil
+		})
+	}
+
+	err := runConcurrently(3, tasks)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.EqualError(t, err, "task 0 failed\ntask 7 failed\ntask 14 failed")
+	assert.NoError(t, runConcurrently(3, nil))
+}
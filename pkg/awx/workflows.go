@@ -0,0 +1,366 @@
+package awx
+
+import (
+	"fmt"
+	"slices"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+)
+
+// workflowNodePageSize is large enough to list all nodes of a workflow at once
+const workflowNodePageSize = "200"
+
+// workflowNodeRelation maps an AWX node relation to the child identifiers of a node spec
+type workflowNodeRelation struct {
+	field    string
+	children func(awxv1alpha1.WorkflowNodeSpec) []string
+}
+
+// workflowNodeRelations are the edges a workflow node can have
+var workflowNodeRelations = []workflowNodeRelation{
+	{field: "success_nodes", children: func(node awxv1alpha1.WorkflowNodeSpec) []string { return node.SuccessNodes }},
+	{field: "failure_nodes", children: func(node awxv1alpha1.WorkflowNodeSpec) []string { return node.FailureNodes }},
+	{field: "always_nodes", children: func(node awxv1alpha1.WorkflowNodeSpec) []string { return node.AlwaysNodes }},
+}
+
+// WorkflowJobTemplateManager handles AWX Workflow Job Template resources
+type WorkflowJobTemplateManager struct {
+	client *Client
+}
+
+// NewWorkflowJobTemplateManager creates a new WorkflowJobTemplateManager
+func NewWorkflowJobTemplateManager(client *Client) *WorkflowJobTemplateManager {
+	return &WorkflowJobTemplateManager{
+		client: client,
+	}
+}
+
+// GetWorkflowJobTemplate retrieves a workflow job template by name
+func (wm *WorkflowJobTemplateManager) GetWorkflowJobTemplate(name string) (map[string]interface{}, error) {
+	wm.client.log.Info("Fetching workflow job template by name", "name", name)
+	return wm.client.FindObjectByName("workflow_job_templates", name)
+}
+
+// listNodes lists the nodes of a workflow job template
+func (wm *WorkflowJobTemplateManager) listNodes(workflowID int) ([]map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("workflow_job_templates/%d/workflow_nodes", workflowID)
+	return wm.client.ListObjects(endpoint, map[string]string{"page_size": workflowNodePageSize})
+}
+
+// nodeIDs returns the node IDs of an AWX node relation such as success_nodes
+func nodeIDs(node map[string]interface{}, field string) []int {
+	values, _ := node[field].([]interface{})
+	ids := make([]int, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(float64); ok {
+			ids = append(ids, int(id))
+		}
+	}
+	return ids
+}
+
+// nodeJobTemplateName returns the name of the job template an AWX node runs
+func nodeJobTemplateName(node map[string]interface{}) string {
+	summaryFields, _ := node["summary_fields"].(map[string]interface{})
+	unifiedJobTemplate, _ := summaryFields["unified_job_template"].(map[string]interface{})
+	name, _ := unifiedJobTemplate["name"].(string)
+	return name
+}
+
+// IsWorkflowJobTemplateInDesiredState checks if the workflow job template and
+// its node graph match the desired specification. Nodes are compared by
+// identifier, so their order in AWX and in the spec doesn't matter.
+func (wm *WorkflowJobTemplateManager) IsWorkflowJobTemplateInDesiredState(object map[string]interface{},
+	workflowSpec awxv1alpha1.WorkflowJobTemplateSpec) bool {
+
+	workflow, err := DecodeObject[WorkflowJobTemplate](object)
+	if err != nil || workflow.Description != workflowSpec.Description {
+		return false
+	}
+	if !wm.client.isWebhookInDesiredState(object, workflowSpec.Webhook) {
+		return false
+	}
+
+	// Check the survey if defined
+	if workflowSpec.Survey != nil {
+		if workflow.SurveyEnabled != surveyEnabled(workflowSpec.Survey) ||
+			!wm.client.surveyInDesiredState("workflow_job_templates", workflow.ID, workflowSpec.Survey) {
+			return false
+		}
+	}
+	nodes, err := wm.listNodes(workflow.ID)
+	if err != nil || len(nodes) != len(workflowSpec.Nodes) {
+		return false
+	}
+
+	identifiers := make(map[int]string, len(nodes))
+	existing := make(map[string]map[string]interface{}, len(nodes))
+	for _, node := range nodes {
+		id, err := getObjectID(node)
+		if err != nil {
+			return false
+		}
+		identifier, _ := node["identifier"].(string)
+		identifiers[id] = identifier
+		existing[identifier] = node
+	}
+
+	for _, nodeSpec := range workflowSpec.Nodes {
+		node, ok := existing[nodeSpec.Identifier]
+		if !ok || nodeJobTemplateName(node) != nodeSpec.JobTemplateName {
+			return false
+		}
+		if converge, _ := node["all_parents_must_converge"].(bool); converge != nodeSpec.AllParentsMustConverge {
+			return false
+		}
+		for _, relation := range workflowNodeRelations {
+			var actual []string
+			for _, id := range nodeIDs(node, relation.field) {
+				actual = append(actual, identifiers[id])
+			}
+			desired := slices.Clone(relation.children(nodeSpec))
+			slices.Sort(actual)
+			slices.Sort(desired)
+			if !slices.Equal(actual, desired) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// EnsureWorkflowJobTemplate ensures that a workflow job template exists with
+// the specified configuration and node graph
+func (wm *WorkflowJobTemplateManager) EnsureWorkflowJobTemplate(workflowSpec awxv1alpha1.WorkflowJobTemplateSpec) (map[string]interface{}, error) {
+	wm.client.log.Info("Ensuring workflow job template exists with desired configuration", "name", workflowSpec.Name)
+
+	workflow, err := wm.client.FindObjectByName("workflow_job_templates", workflowSpec.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if workflow job template exists: %w", err)
+	}
+
+	// Using default organization (ID 1) like the other resources
+	workflowData := map[string]interface{}{
+		"name":         workflowSpec.Name,
+		"description":  workflowSpec.Description,
+		"organization": 1,
+	}
+
+	// Let GitHub or GitLab launch the workflow if a webhook is defined
+	webhookData, err := wm.client.webhookData(workflowSpec.Webhook)
+	if err != nil {
+		return nil, err
+	}
+	for field, value := range webhookData {
+		workflowData[field] = value
+	}
+
+	// Show the survey on launch if one is defined
+	if workflowSpec.Survey != nil {
+		workflowData["survey_enabled"] = surveyEnabled(workflowSpec.Survey)
+	}
+
+	if workflow == nil {
+		wm.client.log.Info("Creating AWX workflow job template", "name", workflowSpec.Name)
+		workflow, err = wm.client.CreateObject("workflow_job_templates", workflowData, "workflow_job_template")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workflow job template: %w", err)
+		}
+	} else {
+		id, err := getObjectID(workflow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ID from existing workflow job template '%s': %w", workflowSpec.Name, err)
+		}
+
+		wm.client.log.Info("Updating AWX workflow job template", "name", workflowSpec.Name, "id", id)
+		err = wm.client.retryOnConflict("update workflow job template "+workflowSpec.Name, func() error {
+			workflow, err = wm.client.UpdateObject("workflow_job_templates", id, workflowData)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update workflow job template: %w", err)
+		}
+	}
+
+	workflowID, err := getObjectID(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow job template ID for nodes of '%s': %w", workflowSpec.Name, err)
+	}
+	if err := wm.reconcileNodes(workflowID, workflowSpec); err != nil {
+		return nil, fmt.Errorf("failed to reconcile nodes for workflow job template '%s': %w", workflowSpec.Name, err)
+	}
+
+	// Replace the survey if defined
+	if workflowSpec.Survey != nil {
+		if err := wm.client.reconcileSurvey("workflow_job_templates", workflowID, workflowSpec.Name, workflowSpec.Survey); err != nil {
+			return nil, fmt.Errorf("failed to reconcile survey for workflow job template '%s': %w", workflowSpec.Name, err)
+		}
+	}
+
+	wm.client.log.Info("Successfully reconciled workflow job template", "name", workflowSpec.Name, "id", workflowID)
+	return workflow, nil
+}
+
+// reconcileNodes creates, updates and removes the nodes of a workflow keyed by
+// identifier and then links them with the declared edges
+func (wm *WorkflowJobTemplateManager) reconcileNodes(workflowID int, workflowSpec awxv1alpha1.WorkflowJobTemplateSpec) error {
+	nodes, err := wm.listNodes(workflowID)
+	if err != nil {
+		return fmt.Errorf("failed to list workflow nodes: %w", err)
+	}
+	existing := make(map[string]map[string]interface{}, len(nodes))
+	for _, node := range nodes {
+		if identifier, ok := node["identifier"].(string); ok {
+			existing[identifier] = node
+		}
+	}
+
+	// Create or update the nodes, remembering the ID of each identifier
+	nodeIDsByIdentifier := make(map[string]int, len(workflowSpec.Nodes))
+	jobTemplateIDs := make(map[string]int)
+	for _, nodeSpec := range workflowSpec.Nodes {
+		jobTemplateID, ok := jobTemplateIDs[nodeSpec.JobTemplateName]
+		if !ok {
+			jobTemplate, err := wm.client.FindObjectByName("job_templates", nodeSpec.JobTemplateName)
+			if err != nil {
+				return fmt.Errorf("failed to find job template %s: %w", nodeSpec.JobTemplateName, err)
+			}
+			if jobTemplate == nil {
+				return fmt.Errorf("node %s: %w", nodeSpec.Identifier, &ReferenceNotFoundError{Kind: "job template", Name: nodeSpec.JobTemplateName})
+			}
+			if jobTemplateID, err = getObjectID(jobTemplate); err != nil {
+				return fmt.Errorf("failed to get job template ID: %w", err)
+			}
+			jobTemplateIDs[nodeSpec.JobTemplateName] = jobTemplateID
+		}
+
+		nodeData := map[string]interface{}{
+			"identifier":                nodeSpec.Identifier,
+			"unified_job_template":      jobTemplateID,
+			"all_parents_must_converge": nodeSpec.AllParentsMustConverge,
+		}
+
+		node, exists := existing[nodeSpec.Identifier]
+		if !exists {
+			wm.client.log.Info("Creating workflow node", "workflow", workflowSpec.Name, "identifier", nodeSpec.Identifier)
+			endpoint := fmt.Sprintf("workflow_job_templates/%d/workflow_nodes", workflowID)
+			node, err = wm.client.CreateObject(endpoint, nodeData, "workflow_job_template_node")
+			if err != nil {
+				return fmt.Errorf("failed to create node %s: %w", nodeSpec.Identifier, err)
+			}
+		}
+		nodeID, err := getObjectID(node)
+		if err != nil {
+			return fmt.Errorf("failed to get ID of node %s: %w", nodeSpec.Identifier, err)
+		}
+		nodeIDsByIdentifier[nodeSpec.Identifier] = nodeID
+
+		if exists {
+			currentTemplate, _ := node["unified_job_template"].(float64)
+			currentConverge, _ := node["all_parents_must_converge"].(bool)
+			if int(currentTemplate) != jobTemplateID || currentConverge != nodeSpec.AllParentsMustConverge {
+				wm.client.log.Info("Updating workflow node", "workflow", workflowSpec.Name, "identifier", nodeSpec.Identifier, "id", nodeID)
+				err = wm.client.retryOnConflict("update workflow node "+nodeSpec.Identifier, func() error {
+					_, err := wm.client.UpdateObject("workflow_job_template_nodes", nodeID, nodeData)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("failed to update node %s: %w", nodeSpec.Identifier, err)
+				}
+			}
+		}
+	}
+
+	// Remove undeclared nodes, which also removes their edges
+	for identifier, node := range existing {
+		if _, declared := nodeIDsByIdentifier[identifier]; declared {
+			continue
+		}
+		nodeID, err := getObjectID(node)
+		if err != nil {
+			return fmt.Errorf("failed to get node ID for deletion: %w", err)
+		}
+		wm.client.log.Info("Deleting workflow node", "workflow", workflowSpec.Name, "identifier", identifier, "id", nodeID)
+		err = wm.client.retryOnConflict("delete workflow node "+identifier, func() error {
+			return wm.client.DeleteObject("workflow_job_template_nodes", nodeID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete node %s: %w", identifier, err)
+		}
+	}
+
+	// Link the nodes, adding missing edges and removing undeclared ones
+	declaredNodeIDs := make(map[int]bool, len(nodeIDsByIdentifier))
+	for _, nodeID := range nodeIDsByIdentifier {
+		declaredNodeIDs[nodeID] = true
+	}
+	for _, nodeSpec := range workflowSpec.Nodes {
+		nodeID := nodeIDsByIdentifier[nodeSpec.Identifier]
+		for _, relation := range workflowNodeRelations {
+			var current []int
+			if node, exists := existing[nodeSpec.Identifier]; exists {
+				current = nodeIDs(node, relation.field)
+			}
+
+			desired := make([]int, 0, len(relation.children(nodeSpec)))
+			for _, child := range relation.children(nodeSpec) {
+				childID, ok := nodeIDsByIdentifier[child]
+				if !ok {
+					return fmt.Errorf("node %s references unknown node %s", nodeSpec.Identifier, child)
+				}
+				desired = append(desired, childID)
+				if !slices.Contains(current, childID) {
+					if err := wm.client.AssociateRelated("workflow_job_template_nodes", nodeID, relation.field, childID); err != nil {
+						return err
+					}
+				}
+			}
+			for _, childID := range current {
+				// Edges to deleted nodes are already gone
+				if slices.Contains(desired, childID) || !declaredNodeIDs[childID] {
+					continue
+				}
+				if err := wm.client.DisassociateRelated("workflow_job_template_nodes", nodeID, relation.field, childID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeleteWorkflowJobTemplate deletes a workflow job template by name, together with its nodes
+func (wm *WorkflowJobTemplateManager) DeleteWorkflowJobTemplate(name string) error {
+	wm.client.log.Info("Deleting workflow job template", "name", name)
+
+	workflow, err := wm.client.FindObjectByName("workflow_job_templates", name)
+	if err != nil {
+		return fmt.Errorf("failed to check if workflow job template exists: %w", err)
+	}
+	if workflow == nil {
+		wm.client.log.Info("Workflow job template already deleted", "name", name)
+		return nil
+	}
+
+	id, err := getObjectID(workflow)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow job template ID: %w", err)
+	}
+
+	wm.client.log.Info("Deleting AWX workflow job template", "name", name, "id", id)
+	err = wm.client.retryOnConflict("delete workflow job template "+name, func() error {
+		return wm.client.DeleteObject("workflow_job_templates", id)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete workflow job template %s: %w", name, err)
+	}
+	return nil
+}
+
+// WebhookReceiver reads the URL and key GitHub or GitLab need to launch the
+// workflow job template with a webhook
+func (wm *WorkflowJobTemplateManager) WebhookReceiver(workflow map[string]interface{}) (*WebhookReceiver, error) {
+	return wm.client.webhookReceiver("workflow_job_templates", workflow)
+}
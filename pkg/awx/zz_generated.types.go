@@ -0,0 +1,414 @@
+// Code generated by pkg/awx/gen from gen/schema.json. DO NOT EDIT.
+
+package awx
+
+// Credential is the Credential object of the AWX API
+type Credential struct {
+	// Cloud. Read-only.
+	Cloud bool `json:"cloud,omitempty"`
+
+	// Created. Read-only.
+	Created string `json:"created,omitempty"`
+
+	// Specify the type of credential you want to create. Refer to the documentation for details on each type.
+	CredentialType int `json:"credential_type"`
+
+	// Description
+	Description string `json:"description"`
+
+	// ID. Read-only.
+	ID int `json:"id,omitempty"`
+
+	// Enter inputs using either JSON or YAML syntax. Refer to the documentation for example syntax.
+	Inputs map[string]interface{} `json:"inputs"`
+
+	// Kind. Read-only.
+	Kind string `json:"kind,omitempty"`
+
+	// Kubernetes. Read-only.
+	Kubernetes bool `json:"kubernetes,omitempty"`
+
+	// Managed. Read-only.
+	Managed bool `json:"managed,omitempty"`
+
+	// Modified. Read-only.
+	Modified string `json:"modified,omitempty"`
+
+	// Name
+	Name string `json:"name"`
+
+	// Inherit permissions from organization roles. If provided on creation, do not give either user or team.
+	Organization *int `json:"organization"`
+
+	// Related. Read-only.
+	Related map[string]interface{} `json:"related,omitempty"`
+
+	// Summary fields. Read-only.
+	SummaryFields map[string]interface{} `json:"summary_fields,omitempty"`
+
+	// Type. Read-only.
+	Type string `json:"type,omitempty"`
+
+	// Url. Read-only.
+	URL string `json:"url,omitempty"`
+}
+
+// Inventory is the Inventory object of the AWX API
+type Inventory struct {
+	// Created. Read-only.
+	Created string `json:"created,omitempty"`
+
+	// Description
+	Description string `json:"description"`
+
+	// This field is deprecated and will be removed in a future release. Flag indicating whether any hosts in this inventory have failed. Read-only.
+	HasActiveFailures bool `json:"has_active_failures,omitempty"`
+
+	// Filter that will be applied to the hosts of this inventory.
+	HostFilter *string `json:"host_filter"`
+
+	// ID. Read-only.
+	ID int `json:"id,omitempty"`
+
+	// Kind of inventory being represented.
+	Kind string `json:"kind"`
+
+	// Modified. Read-only.
+	Modified string `json:"modified,omitempty"`
+
+	// Name
+	Name string `json:"name"`
+
+	// Organization containing this inventory.
+	Organization int `json:"organization"`
+
+	// Flag indicating the inventory is being deleted. Read-only.
+	PendingDeletion bool `json:"pending_deletion,omitempty"`
+
+	// If enabled, the inventory will prevent adding any organization instance groups to the list of preferred instances groups to run associated job templates on.
+	PreventInstanceGroupFallback bool `json:"prevent_instance_group_fallback"`
+
+	// Related. Read-only.
+	Related map[string]interface{} `json:"related,omitempty"`
+
+	// Summary fields. Read-only.
+	SummaryFields map[string]interface{} `json:"summary_fields,omitempty"`
+
+	// This field is deprecated and will be removed in a future release. Total number of hosts in this inventory. Read-only.
+	TotalHosts int `json:"total_hosts,omitempty"`
+
+	// Type. Read-only.
+	Type string `json:"type,omitempty"`
+
+	// Url. Read-only.
+	URL string `json:"url,omitempty"`
+
+	// Inventory variables in JSON or YAML format.
+	Variables string `json:"variables"`
+}
+
+// JobTemplate is the JobTemplate object of the AWX API
+type JobTemplate struct {
+	// Allow simultaneous
+	AllowSimultaneous bool `json:"allow_simultaneous"`
+
+	// Ask credential on launch
+	AskCredentialOnLaunch bool `json:"ask_credential_on_launch"`
+
+	// Ask diff mode on launch
+	AskDiffModeOnLaunch bool `json:"ask_diff_mode_on_launch"`
+
+	// Ask inventory on launch
+	AskInventoryOnLaunch bool `json:"ask_inventory_on_launch"`
+
+	// Ask limit on launch
+	AskLimitOnLaunch bool `json:"ask_limit_on_launch"`
+
+	// Ask skip tags on launch
+	AskSkipTagsOnLaunch bool `json:"ask_skip_tags_on_launch"`
+
+	// Ask tags on launch
+	AskTagsOnLaunch bool `json:"ask_tags_on_launch"`
+
+	// Ask variables on launch
+	AskVariablesOnLaunch bool `json:"ask_variables_on_launch"`
+
+	// Ask verbosity on launch
+	AskVerbosityOnLaunch bool `json:"ask_verbosity_on_launch"`
+
+	// Become enabled
+	BecomeEnabled bool `json:"become_enabled"`
+
+	// Created. Read-only.
+	Created string `json:"created,omitempty"`
+
+	// Description
+	Description string `json:"description"`
+
+	// If enabled, textual changes made to any templated files on the host are shown in the standard output
+	DiffMode bool `json:"diff_mode"`
+
+	// The container image to be used for execution.
+	ExecutionEnvironment *int `json:"execution_environment"`
+
+	// Extra vars
+	ExtraVars string `json:"extra_vars"`
+
+	// Forks
+	Forks int `json:"forks"`
+
+	// ID. Read-only.
+	ID int `json:"id,omitempty"`
+
+	// Inventory
+	Inventory *int `json:"inventory"`
+
+	// The number of jobs to slice into at runtime. Will cause the Job Template to launch a workflow if value is greater than 1.
+	JobSliceCount int `json:"job_slice_count"`
+
+	// Job tags
+	JobTags string `json:"job_tags"`
+
+	// Job type
+	JobType string `json:"job_type"`
+
+	// Limit
+	Limit string `json:"limit"`
+
+	// Modified. Read-only.
+	Modified string `json:"modified,omitempty"`
+
+	// Name
+	Name string `json:"name"`
+
+	// Playbook
+	Playbook string `json:"playbook"`
+
+	// If enabled, the job template will prevent adding any inventory or organization instance groups to the list of preferred instances groups to run on.
+	PreventInstanceGroupFallback bool `json:"prevent_instance_group_fallback"`
+
+	// Project
+	Project *int `json:"project"`
+
+	// Related. Read-only.
+	Related map[string]interface{} `json:"related,omitempty"`
+
+	// Branch to use in job run. Project default used if blank. Only allowed if project allow_override field is set to true.
+	SCMBranch string `json:"scm_branch"`
+
+	// Skip tags
+	SkipTags string `json:"skip_tags"`
+
+	// Status. Read-only.
+	Status string `json:"status,omitempty"`
+
+	// Summary fields. Read-only.
+	SummaryFields map[string]interface{} `json:"summary_fields,omitempty"`
+
+	// Survey enabled
+	SurveyEnabled bool `json:"survey_enabled"`
+
+	// The amount of time (in seconds) to run before the task is canceled.
+	Timeout int `json:"timeout"`
+
+	// Type. Read-only.
+	Type string `json:"type,omitempty"`
+
+	// Url. Read-only.
+	URL string `json:"url,omitempty"`
+
+	// Verbosity
+	Verbosity int `json:"verbosity"`
+
+	// Personal Access Token for posting back the status to the service API
+	WebhookCredential *int `json:"webhook_credential"`
+
+	// Service that webhook requests will be accepted from
+	WebhookService string `json:"webhook_service"`
+}
+
+// Organization is the Organization object of the AWX API
+type Organization struct {
+	// Created. Read-only.
+	Created string `json:"created,omitempty"`
+
+	// Local absolute file path containing a custom Python virtualenv to use. Read-only.
+	CustomVirtualenv *string `json:"custom_virtualenv,omitempty"`
+
+	// The default execution environment for jobs run by this organization.
+	DefaultEnvironment *int `json:"default_environment"`
+
+	// Description
+	Description string `json:"description"`
+
+	// ID. Read-only.
+	ID int `json:"id,omitempty"`
+
+	// Maximum number of hosts allowed to be managed by this organization.
+	MaxHosts int `json:"max_hosts"`
+
+	// Modified. Read-only.
+	Modified string `json:"modified,omitempty"`
+
+	// Name
+	Name string `json:"name"`
+
+	// Related. Read-only.
+	Related map[string]interface{} `json:"related,omitempty"`
+
+	// Summary fields. Read-only.
+	SummaryFields map[string]interface{} `json:"summary_fields,omitempty"`
+
+	// Type. Read-only.
+	Type string `json:"type,omitempty"`
+
+	// Url. Read-only.
+	URL string `json:"url,omitempty"`
+}
+
+// Project is the Project object of the AWX API
+type Project struct {
+	// Allow changing the SCM branch or revision in a job template that uses this project.
+	AllowOverride bool `json:"allow_override"`
+
+	// Created. Read-only.
+	Created string `json:"created,omitempty"`
+
+	// Credential
+	Credential *int `json:"credential"`
+
+	// The default execution environment for jobs run using this project.
+	DefaultEnvironment *int `json:"default_environment"`
+
+	// Description
+	Description string `json:"description"`
+
+	// ID. Read-only.
+	ID int `json:"id,omitempty"`
+
+	// Local path (relative to PROJECTS_ROOT) containing playbooks and related files for this project.
+	LocalPath string `json:"local_path"`
+
+	// Modified. Read-only.
+	Modified string `json:"modified,omitempty"`
+
+	// Name
+	Name string `json:"name"`
+
+	// The organization used to determine access to this template.
+	Organization *int `json:"organization"`
+
+	// Related. Read-only.
+	Related map[string]interface{} `json:"related,omitempty"`
+
+	// Specific branch, tag or commit to checkout.
+	SCMBranch string `json:"scm_branch"`
+
+	// Discard any local changes before syncing the project.
+	SCMClean bool `json:"scm_clean"`
+
+	// Delete the project before syncing.
+	SCMDeleteOnUpdate bool `json:"scm_delete_on_update"`
+
+	// For git projects, an additional refspec to fetch.
+	SCMRefspec string `json:"scm_refspec"`
+
+	// The last revision fetched by a project update. Read-only.
+	SCMRevision string `json:"scm_revision,omitempty"`
+
+	// Track submodules latest commits on defined branch.
+	SCMTrackSubmodules bool `json:"scm_track_submodules"`
+
+	// Specifies the source control system used to store the project.
+	SCMType string `json:"scm_type"`
+
+	// The number of seconds after the last project update ran that a new project update will be launched as a job dependency.
+	SCMUpdateCacheTimeout int `json:"scm_update_cache_timeout"`
+
+	// Update the project when a job is launched that uses the project.
+	SCMUpdateOnLaunch bool `json:"scm_update_on_launch"`
+
+	// The location where the project is stored.
+	SCMURL string `json:"scm_url"`
+
+	// An optional credential used for validating files in the project against unexpected changes.
+	SignatureValidationCredential *int `json:"signature_validation_credential"`
+
+	// Status. Read-only.
+	Status string `json:"status,omitempty"`
+
+	// Summary fields. Read-only.
+	SummaryFields map[string]interface{} `json:"summary_fields,omitempty"`
+
+	// The amount of time (in seconds) to run before the task is canceled.
+	Timeout int `json:"timeout"`
+
+	// Type. Read-only.
+	Type string `json:"type,omitempty"`
+
+	// Url. Read-only.
+	URL string `json:"url,omitempty"`
+}
+
+// WorkflowJobTemplate is the WorkflowJobTemplate object of the AWX API
+type WorkflowJobTemplate struct {
+	// Allow simultaneous
+	AllowSimultaneous bool `json:"allow_simultaneous"`
+
+	// Ask variables on launch
+	AskVariablesOnLaunch bool `json:"ask_variables_on_launch"`
+
+	// Created. Read-only.
+	Created string `json:"created,omitempty"`
+
+	// Description
+	Description string `json:"description"`
+
+	// Extra vars
+	ExtraVars string `json:"extra_vars"`
+
+	// ID. Read-only.
+	ID int `json:"id,omitempty"`
+
+	// Inventory applied as a prompt, assuming job template prompts for inventory
+	Inventory *int `json:"inventory"`
+
+	// Limit
+	Limit *string `json:"limit"`
+
+	// Modified. Read-only.
+	Modified string `json:"modified,omitempty"`
+
+	// Name
+	Name string `json:"name"`
+
+	// The organization used to determine access to this template.
+	Organization *int `json:"organization"`
+
+	// Related. Read-only.
+	Related map[string]interface{} `json:"related,omitempty"`
+
+	// Scm branch
+	SCMBranch *string `json:"scm_branch"`
+
+	// Status. Read-only.
+	Status string `json:"status,omitempty"`
+
+	// Summary fields. Read-only.
+	SummaryFields map[string]interface{} `json:"summary_fields,omitempty"`
+
+	// Survey enabled
+	SurveyEnabled bool `json:"survey_enabled"`
+
+	// Type. Read-only.
+	Type string `json:"type,omitempty"`
+
+	// Url. Read-only.
+	URL string `json:"url,omitempty"`
+
+	// Personal Access Token for posting back the status to the service API
+	WebhookCredential *int `json:"webhook_credential"`
+
+	// Service that webhook requests will be accepted from
+	WebhookService string `json:"webhook_service"`
+}
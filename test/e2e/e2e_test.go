@@ -0,0 +1,382 @@
+//go:build e2e
+
+// Package e2e tests the operator deployed to a kind cluster against a real
+// AWX deployed by the upstream awx-operator. The suites need the cluster set
+// up by ./deploy.sh e2e, which also runs them.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awxv1alpha1 "github.com/derzufall/awx-k8s-operator/api/v1alpha1"
+	"github.com/derzufall/awx-k8s-operator/pkg/awx"
+)
+
+const (
+	// namespace holds the AWX of the upstream awx-operator, which the
+	// AWXInstance discovers
+	namespace = "awx"
+	// upstreamAWX is the name of the AWX resource of the upstream awx-operator
+	upstreamAWX = "awx"
+
+	// timeout bounds each wait for the operator, which includes project
+	// syncs and the periodic drift check
+	timeout      = 5 * time.Minute
+	pollInterval = 5 * time.Second
+)
+
+var (
+	k8sClient client.Client
+	awxClient *awx.Client
+)
+
+// TestMain connects to the kind cluster of the current kubeconfig and to AWX
+// at AWX_E2E_URL, e.g. a port-forward of the AWX Service
+func TestMain(m *testing.M) {
+	url, password := os.Getenv("AWX_E2E_URL"), os.Getenv("AWX_E2E_PASSWORD")
+	if url == "" || password == "" {
+		fmt.Fprintln(os.Stderr, "AWX_E2E_URL and AWX_E2E_PASSWORD must be set, run the suites with ./deploy.sh e2e")
+		os.Exit(1)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := awxv1alpha1.AddToScheme(scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	k8sClient, err = client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	awxClient = awx.NewClient(url, "admin", password)
+	os.Exit(m.Run())
+}
+
+// managedObject is an object of one of the kinds reconciled by the managers
+// of pkg/awx that has a description, declared by desiredSpec. Names are unique
+// across the AWX, so objects below others, e.g. schedules, are found by name.
+type managedObject struct {
+	endpoint string
+	name     string
+}
+
+// managedObjects are the objects with a description declared by desiredSpec,
+// covering every manager that reconciles one. Organizations, mesh instances
+// and cleanup schedules have none and are checked by revisionApplied.
+var managedObjects = []managedObject{
+	{"credentials", "e2e-credential"},
+	{"credentials", "e2e-galaxy"},
+	{"projects", "e2e-project"},
+	{"schedules", "e2e-project-update"},
+	{"inventories", "e2e-inventory"},
+	{"hosts", "e2e-host"},
+	{"inventory_sources", "e2e-source"},
+	{"schedules", "e2e-source-update"},
+	{"job_templates", "e2e-job-template"},
+	{"schedules", "e2e-job-schedule"},
+	{"workflow_job_templates", "e2e-workflow"},
+}
+
+const (
+	// organization is the organization AWX installs, which gets the Galaxy
+	// credential declared by desiredSpec
+	organization = "Default"
+	// meshInstance is the hop node declared by desiredSpec
+	meshInstance = "e2e-hop.example.com"
+	// cleanupSchedule is the schedule of the job cleanup AWX installs
+	cleanupSchedule = "Cleanup Job Schedule"
+)
+
+// revision is a version of desiredSpec. The objects with a description get
+// the description, the others the values of the other fields.
+type revision struct {
+	description   string
+	listenerPort  int32
+	retentionDays int32
+}
+
+var (
+	created = revision{description: "created by e2e", listenerPort: 27199, retentionDays: 30}
+	updated = revision{description: "updated by e2e", listenerPort: 27200, retentionDays: 60}
+)
+
+// desiredSpec declares objects of every kind the managers reconcile
+func desiredSpec(rev revision) awxv1alpha1.AWXInstanceSpec {
+	schedule := func(name string) *awxv1alpha1.ScheduleSpec {
+		return &awxv1alpha1.ScheduleSpec{
+			Name:        name,
+			Description: rev.description,
+			Recurrence:  "FREQ=DAILY;INTERVAL=1",
+			Start:       "2030-01-01T03:00:00",
+			Enabled:     ptr(false),
+		}
+	}
+	return awxv1alpha1.AWXInstanceSpec{
+		AdminEmail:       "admin@example.com",
+		ExternalInstance: true,
+		DiscoverFrom:     &awxv1alpha1.DiscoverySpec{Name: upstreamAWX},
+		Credentials: []awxv1alpha1.CredentialSpec{{
+			Name:        "e2e-credential",
+			Description: rev.description,
+			Kind:        "Machine",
+			Inputs:      map[string]string{"username": "e2e"},
+		}, {
+			Name:        "e2e-galaxy",
+			Description: rev.description,
+			Kind:        "galaxy_api_token",
+			Inputs:      map[string]string{"url": "https://galaxy.ansible.com/"},
+		}},
+		Organizations: []awxv1alpha1.OrganizationSpec{{
+			Name:              organization,
+			GalaxyCredentials: []string{"e2e-galaxy"},
+		}},
+		Projects: []awxv1alpha1.ProjectSpec{{
+			Name:           "e2e-project",
+			Description:    rev.description,
+			SCMType:        "git",
+			SCMUrl:         "https://github.com/ansible/ansible-tower-samples.git",
+			UpdateSchedule: schedule("e2e-project-update"),
+		}},
+		Inventories: []awxv1alpha1.InventorySpec{{
+			Name:        "e2e-inventory",
+			Description: rev.description,
+			Hosts:       []awxv1alpha1.HostSpec{{Name: "e2e-host", Description: rev.description}},
+			Sources: []awxv1alpha1.InventorySourceSpec{{
+				Name:           "e2e-source",
+				Description:    rev.description,
+				Source:         "scm",
+				SourceProject:  "e2e-project",
+				UpdateSchedule: schedule("e2e-source-update"),
+			}},
+		}},
+		JobTemplates: []awxv1alpha1.JobTemplateSpec{{
+			Name:          "e2e-job-template",
+			Description:   rev.description,
+			ProjectName:   "e2e-project",
+			InventoryName: "e2e-inventory",
+			Playbook:      "hello_world.yml",
+			Schedules:     []awxv1alpha1.ScheduleSpec{*schedule("e2e-job-schedule")},
+		}},
+		WorkflowJobTemplates: []awxv1alpha1.WorkflowJobTemplateSpec{{
+			Name:        "e2e-workflow",
+			Description: rev.description,
+			Nodes:       []awxv1alpha1.WorkflowNodeSpec{{Identifier: "hello", JobTemplateName: "e2e-job-template"}},
+		}},
+		MeshInstances: []awxv1alpha1.MeshInstanceSpec{{
+			Hostname:     meshInstance,
+			NodeType:     "hop",
+			ListenerPort: rev.listenerPort,
+		}},
+		JobCleanup: &awxv1alpha1.JobCleanupSpec{
+			Jobs: &awxv1alpha1.CleanupScheduleSpec{
+				RetentionDays: rev.retentionDays,
+				Recurrence:    "FREQ=WEEKLY;BYDAY=SU;INTERVAL=1",
+				Start:         "2030-01-01T04:00:00",
+			},
+		},
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// findObject returns the object from AWX, or an error when AWX doesn't have it
+func findObject(endpoint, name string) (map[string]interface{}, error) {
+	found, err := awxClient.FindObjectByName(endpoint, name)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s %s not found", endpoint, name)
+	}
+	return found, nil
+}
+
+// findMeshInstance returns the hop node from AWX, or nil when it isn't
+// registered
+func findMeshInstance() (map[string]interface{}, error) {
+	instances, err := awxClient.ListObjects("instances", map[string]string{"hostname": meshInstance})
+	if err != nil || len(instances) == 0 {
+		return nil, err
+	}
+	return instances[0], nil
+}
+
+// galaxyCredentialAttached returns whether the declared Galaxy credential is
+// attached to the organization
+func galaxyCredentialAttached() (bool, error) {
+	found, err := findObject("organizations", organization)
+	if err != nil {
+		return false, err
+	}
+	credentials, err := awxClient.ListRelated("organizations", int(found["id"].(float64)), "galaxy_credentials")
+	if err != nil {
+		return false, err
+	}
+	for _, credential := range credentials {
+		if credential["name"] == "e2e-galaxy" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// revisionApplied returns nil when the objects without a description match
+// the revision: the organization has the Galaxy credential, the hop node the
+// listener port and the cleanup schedule the retention days
+func revisionApplied(rev revision) error {
+	if attached, err := galaxyCredentialAttached(); err != nil || !attached {
+		return fmt.Errorf("galaxy credential not attached to organization %s: %v", organization, err)
+	}
+	instance, err := findMeshInstance()
+	if err != nil || instance == nil {
+		return fmt.Errorf("mesh instance %s not registered: %v", meshInstance, err)
+	}
+	if port, _ := instance["listener_port"].(float64); int32(port) != rev.listenerPort {
+		return fmt.Errorf("mesh instance %s listens on %v", meshInstance, instance["listener_port"])
+	}
+	schedule, err := findObject("schedules", cleanupSchedule)
+	if err != nil {
+		return err
+	}
+	extraData, _ := schedule["extra_data"].(map[string]interface{})
+	if days := fmt.Sprint(extraData["days"]); days != fmt.Sprint(rev.retentionDays) {
+		return fmt.Errorf("%s keeps %s days", cleanupSchedule, days)
+	}
+	return nil
+}
+
+// waitForRevision waits until every managed object has the description of
+// the revision in AWX and the other objects match it
+func waitForRevision(t *testing.T, rev revision) {
+	t.Helper()
+	for _, object := range managedObjects {
+		assert.Eventually(t, func() bool {
+			found, err := findObject(object.endpoint, object.name)
+			return err == nil && found["description"] == rev.description
+		}, timeout, pollInterval, "%s %s should have the description %q", object.endpoint, object.name, rev.description)
+	}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, revisionApplied(rev))
+	}, timeout, pollInterval, "Objects without a description should match %q", rev.description)
+}
+
+// TestManagers drives every manager through the create, update, drift and
+// delete paths with a single AWXInstance
+func TestManagers(t *testing.T) {
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: namespace, Name: "e2e"}
+	instance := &awxv1alpha1.AWXInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Spec:       desiredSpec(created),
+	}
+	if !assert.NoError(t, k8sClient.Create(ctx, instance)) {
+		return
+	}
+	t.Cleanup(func() {
+		_ = client.IgnoreNotFound(k8sClient.Delete(ctx, instance))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		waitForRevision(t, created)
+		assert.Eventually(t, func() bool {
+			current := &awxv1alpha1.AWXInstance{}
+			if err := k8sClient.Get(ctx, key, current); err != nil {
+				return false
+			}
+			return current.Status.ObservedGeneration == current.Generation &&
+				current.Status.JobTemplateStatuses["e2e-job-template"] == "Reconciled"
+		}, timeout, pollInterval, "The AWXInstance should be reconciled")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current := &awxv1alpha1.AWXInstance{}
+			if err := k8sClient.Get(ctx, key, current); err != nil {
+				return err
+			}
+			current.Spec = desiredSpec(updated)
+			return k8sClient.Update(ctx, current)
+		})
+		assert.NoError(t, err)
+		waitForRevision(t, updated)
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		for _, object := range managedObjects {
+			found, err := findObject(object.endpoint, object.name)
+			if !assert.NoError(t, err) {
+				continue
+			}
+			id := int(found["id"].(float64))
+			_, err = awxClient.UpdateObject(object.endpoint, id, map[string]interface{}{"description": "changed in AWX"})
+			assert.NoError(t, err)
+		}
+
+		if found, err := findObject("organizations", organization); assert.NoError(t, err) {
+			galaxy, err := findObject("credentials", "e2e-galaxy")
+			if assert.NoError(t, err) {
+				assert.NoError(t, awxClient.DisassociateRelated("organizations", int(found["id"].(float64)),
+					"galaxy_credentials", int(galaxy["id"].(float64))))
+			}
+		}
+		if found, err := findMeshInstance(); assert.NoError(t, err) && assert.NotNil(t, found) {
+			_, err = awxClient.UpdateObject("instances", int(found["id"].(float64)),
+				map[string]interface{}{"listener_port": created.listenerPort})
+			assert.NoError(t, err)
+		}
+		if found, err := findObject("schedules", cleanupSchedule); assert.NoError(t, err) {
+			_, err = awxClient.UpdateObject("schedules", int(found["id"].(float64)),
+				map[string]interface{}{"extra_data": map[string]interface{}{"days": created.retentionDays}})
+			assert.NoError(t, err)
+		}
+
+		waitForRevision(t, updated)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, k8sClient.Delete(ctx, instance))
+		assert.Eventually(t, func() bool {
+			return apierrors.IsNotFound(k8sClient.Get(ctx, key, &awxv1alpha1.AWXInstance{}))
+		}, timeout, pollInterval, "The finalizer should be removed once AWX was cleaned up")
+		for _, object := range managedObjects {
+			found, err := awxClient.FindObjectByName(object.endpoint, object.name)
+			assert.NoError(t, err)
+			assert.Nil(t, found, "%s %s should be deleted from AWX", object.endpoint, object.name)
+		}
+
+		found, err := findObject("organizations", organization)
+		assert.NoError(t, err)
+		assert.NotNil(t, found, "The organization should be left in AWX")
+		found, err = findMeshInstance()
+		assert.NoError(t, err)
+		if found != nil {
+			assert.Equal(t, "deprovisioning", found["node_state"], "The hop node should be deprovisioned")
+		}
+	})
+}